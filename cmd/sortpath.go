@@ -1,16 +1,21 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/internal/app"
 	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
 	"github.com/kacperkwapisz/sortpath/internal/fs"
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
+	"github.com/kacperkwapisz/sortpath/internal/ui"
 	"github.com/kacperkwapisz/sortpath/internal/updater"
 	"github.com/kacperkwapisz/sortpath/pkg/api"
 	"github.com/kacperkwapisz/sortpath/pkg/cli"
@@ -19,7 +24,15 @@ import (
 var Version = "dev"
 
 func main() {
-    args := os.Args[1:]
+    httpx.SetVersion(Version)
+
+    // Until the output flags are parsed, print with the defaults
+    out := ui.Std(ui.Options{})
+    args, err := cli.TakeConfigFlag(os.Args[1:])
+    if err != nil {
+        out.Error("❌ %v\n", err)
+        os.Exit(1)
+    }
     if len(args) == 0 || (len(args) == 1 && (args[0] == "-h" || args[0] == "--help")) {
         cli.PrintHelp(Version)
         return
@@ -27,10 +40,28 @@ func main() {
 
     // Version flag
     if len(args) == 1 && (args[0] == "-v" || args[0] == "--version") {
-        fmt.Printf("🔍 sortpath version %s\n", Version)
+        out.Result("🔍 sortpath version %s\n", Version)
+        return
+    }
+
+    if len(args) == 1 && args[0] == "--reset-install-prompt" {
+        if err := cli.ResetInstallPrompt(); err != nil {
+            out.Error("❌ %v\n", err)
+            os.Exit(1)
+        }
+        out.Result("✅ The install prompt will be shown again\n")
         return
     }
 
+    // A --config file must exist, except for the config subcommand, which
+    // can create it
+    if args[0] != "config" {
+        if err := config.CheckConfigPath(); err != nil {
+            out.Error("❌ Config error: %v\n", err)
+            os.Exit(apperrors.ExitConfig)
+        }
+    }
+
     // Install subcommand
     if args[0] == "install" {
         cli.HandleInstallCommand(args[1:])
@@ -43,6 +74,24 @@ func main() {
         return
     }
 
+    // Cache maintenance subcommand
+    if args[0] == "cache" {
+        cli.HandleCacheCommand(args[1:])
+        return
+    }
+
+    // Prompt template test subcommand
+    if args[0] == "prompt-test" {
+        cli.HandlePromptTestCommand(args[1:])
+        return
+    }
+
+    // Connectivity and environment check
+    if args[0] == "doctor" {
+        cli.HandleDoctorCommand(args[1:])
+        return
+    }
+
     // Update subcommand
     if args[0] == "update" {
         cli.HandleUpdateCommand(args[1:], Version)
@@ -51,50 +100,418 @@ func main() {
 
     // If the first argument is not "config" and not a quoted description, print help
     if len(args) == 1 && (args[0] == "list" || args[0] == "set" || args[0] == "get" || args[0] == "remove") {
-        fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+        out.Error("Unknown command: %s\n", args[0])
         cli.PrintHelp(Version)
         os.Exit(1)
     }
 
+    // Parse CLI flags and positional
+    opts, desc := cli.ParseArgs(args)
+    uiOpts, err := cli.OutputOptions(opts)
+    if err != nil {
+        out.Error("❌ %v\n", err)
+        os.Exit(1)
+    }
+    out = ui.Std(uiOpts)
+    cli.SetOutput(out)
+    if opts.ConfigPath != "" {
+        config.SetConfigPath(opts.ConfigPath)
+        if err := config.CheckConfigPath(); err != nil {
+            out.Fail("❌ Config error: %v\n", err)
+            os.Exit(apperrors.ExitConfig)
+        }
+    }
+
+    if opts.JSONSchema {
+        if err := cli.WriteResultSchema(out.Results()); err != nil {
+            out.Fail("❌ %v\n", err)
+            os.Exit(1)
+        }
+        return
+    }
+
+    // CI and container profiles turn off the install prompt and update check
+    unvalidated := config.ResolveConfigUnvalidated(opts)
+    skipPrompts := unvalidated.SkipPrompts
+
     // First-run install prompt (non-blocking in non-interactive environments)
-    maybePromptInstall()
+    if !skipPrompts {
+        cli.MaybePromptInstall()
+    }
 
     // Check for updates (non-blocking)
-    if Version != "dev" {
-        go checkForUpdates()
+    if Version != "dev" && !skipPrompts {
+        go checkForUpdates(out, unvalidated.UpdateChannel)
     }
 
-    // Parse CLI flags and positional
-    opts, desc := cli.ParseArgs(args)
-    if desc == "" {
-        fmt.Fprintf(os.Stderr, "Missing file description.\n")
-        cli.PrintHelp(Version)
+    var roots []cli.TreeRoot
+    if len(opts.Trees) > 1 {
+        if opts.LargeTree || opts.NoTree || opts.ContextWindow > 0 || opts.ExplainTree {
+            out.Fail("❌ Several --tree flags cannot be combined with --large-tree, --no-tree, --context-window or --explain-tree\n")
+            os.Exit(1)
+        }
+        var err error
+        if roots, err = cli.ParseTreeRoots(opts.Trees); err != nil {
+            out.Fail("❌ %v\n", err)
+            os.Exit(1)
+        }
+    }
+    if opts.ExplainTree {
+        conf := config.ResolveConfigUnvalidated(opts)
+        treeOpts := append(cli.TreeOptions(conf), fs.WithExplain(fs.ExplainTo(out.Errors())))
+        if out.JSON() {
+            treeOpts = append(treeOpts, fs.WithFormatter(fs.JSONFormatter{}))
+        }
+        tree, err := fs.Tree(conf.TreePath, treeOpts...)
+        if err != nil {
+            out.Fail("❌ Folder tree error: %v\n", err)
+            os.Exit(apperrors.ExitFS)
+        }
+        out.Result("%s", tree)
+        return
+    }
+    if opts.ContextWindow < 0 {
+        out.Fail("❌ --context-window must not be negative\n")
         os.Exit(1)
     }
-    conf, err := config.ResolveConfig(opts)
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "❌ Config error: %v\n", err)
+    if opts.Count < 1 {
+        out.Fail("❌ --count must be at least 1\n")
         os.Exit(1)
     }
-
-    tree, err := fs.Tree(conf.TreePath)
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "❌ Folder tree error: %v\n", err)
+    if opts.Count > 1 && (len(roots) > 1 || opts.LargeTree) {
+        out.Fail("❌ --count cannot be combined with several --tree flags or --large-tree\n")
         os.Exit(1)
     }
-
-    prompt := ai.BuildPrompt(tree, desc)
-    resp, err := api.QueryLLM(conf, prompt)
-    if err != nil {
-        fmt.Fprintf(os.Stderr, "❌ API error: %v\n", err)
+    if opts.MaxReasonLength < 0 {
+        out.Fail("❌ --max-reason-length must not be negative\n")
+        os.Exit(1)
+    }
+    if opts.LargeTree && (opts.NoTree || opts.ContextWindow > 0) {
+        out.Fail("❌ --large-tree cannot be combined with --no-tree or --context-window\n")
+        os.Exit(1)
+    }
+    if opts.PriceInput < 0 || opts.PriceOutput < 0 {
+        out.Fail("❌ --price-input and --price-output must not be negative\n")
+        os.Exit(1)
+    }
+    if err := cli.UseStdin(&opts, desc, config.DefaultEnvironmentDetector.StdinIsPiped()); err != nil {
+        out.Fail("❌ %v\n", err)
+        os.Exit(1)
+    }
+    if opts.Concurrency < 1 {
+        out.Fail("❌ --concurrency must be at least 1\n")
         os.Exit(1)
     }
+    if opts.Concurrency > 1 && !opts.Batch {
+        out.Fail("❌ --concurrency requires --batch\n")
+        os.Exit(1)
+    }
+    if opts.Batch && (opts.Move != "" || opts.Copy != "" || opts.Pick || opts.FromFile != "") {
+        out.Fail("❌ --batch cannot be combined with --move, --copy, --pick or --from-file\n")
+        os.Exit(1)
+    }
+    if opts.Budget < 0 {
+        out.Fail("❌ --budget must not be negative\n")
+        os.Exit(1)
+    }
+    if opts.Budget > 0 && !opts.Batch {
+        out.Fail("❌ --budget requires --batch\n")
+        os.Exit(1)
+    }
+    if opts.PreviewBytes < 0 {
+        out.Fail("❌ --preview-bytes must not be negative\n")
+        os.Exit(1)
+    }
+    if opts.DatasetTreeRef && opts.RecordDataset == "" {
+        out.Fail("❌ --dataset-tree-ref requires --record-dataset\n")
+        os.Exit(1)
+    }
+    if opts.Pick && (len(roots) > 1 || opts.NoTree) {
+        out.Fail("❌ --pick cannot be combined with several --tree flags or --no-tree\n")
+        os.Exit(1)
+    }
+    if opts.Pick {
+        if err := cli.CheckPick(); err != nil {
+            out.Fail("❌ %v\n", err)
+            os.Exit(1)
+        }
+    }
+    if opts.Move != "" && opts.Copy != "" {
+        out.Fail("❌ --move and --copy cannot be combined\n")
+        os.Exit(1)
+    }
+    // placeSrc is the file --move or --copy puts into the recommended folder
+    placeSrc, keepSource := opts.Move, false
+    if opts.Copy != "" {
+        placeSrc, keepSource = opts.Copy, true
+    }
+    if opts.DryRun && placeSrc == "" {
+        out.Fail("❌ --dry-run requires --move or --copy\n")
+        os.Exit(1)
+    }
+    if placeSrc != "" {
+        if err := cli.CheckPlaceSource(placeSrc); err != nil {
+            out.Fail("❌ %v\n", err)
+            os.Exit(apperrors.ExitFS)
+        }
+    }
+    if opts.PreviewBytes > 0 && opts.FromFile == "" && !opts.Batch {
+        out.Fail("❌ --preview-bytes requires --from-file or --batch\n")
+        os.Exit(1)
+    }
+    if opts.FromFile != "" {
+        fileDesc, err := fs.DescribeFile(opts.FromFile, opts.PreviewBytes)
+        if err != nil {
+            out.Fail("❌ Cannot describe file: %v\n", err)
+            os.Exit(apperrors.ExitFS)
+        }
+        // A typed description adds context to the generated one
+        desc = strings.TrimSpace(desc + "\n" + fileDesc)
+    }
+    if desc == "" && opts.Pick {
+        // Picking without a description needs neither the model nor an API key
+        conf := config.ResolveConfigUnvalidated(opts)
+        picked, err := cli.PickFromTree(conf.TreePath, "", cli.TreeOptions(conf)...)
+        if err != nil {
+            out.Fail("❌ %v\n", err)
+            os.Exit(1)
+        }
+        if err := cli.WriteResult("", &api.LLMResponse{Path: picked, Reason: cli.PickedReason}); err != nil {
+            out.Error("❌ Cannot write result: %v\n", err)
+        }
+        return
+    }
+    // items are what the run sorts: the one description, or each --batch input
+    var items []string
+    if opts.Batch {
+        var err error
+        if items, err = cli.ReadBatchInputs(opts.BatchInputs, os.Stdin); err != nil {
+            out.Fail("❌ %v\n", err)
+            os.Exit(1)
+        }
+    } else if desc != "" {
+        items = []string{desc}
+    }
+    if len(items) == 0 {
+        out.Fail("Missing file description.\n")
+        if !out.JSON() {
+            cli.PrintHelp(Version)
+        }
+        os.Exit(1)
+    }
+    conf, sources, err := cli.ResolveConfigWithRepair(opts)
+    if err != nil {
+        out.Fail("❌ Config error: %v\n", err)
+        os.Exit(apperrors.ExitConfig)
+    }
 
-    fmt.Println(resp.Path)
-    fmt.Printf("Reason: %s\n", resp.Reason)
+    // Correlate this run's request and log lines, generating an ID if none was given
+    if conf.TraceID == "" {
+        conf.TraceID = app.NewCorrelationID()
+    }
+    var logFile io.Writer
+    if conf.LogFile != "" {
+        f, err := config.DefaultSecureFileOps.OpenSecureAppend(conf.LogFile)
+        if err != nil {
+            out.Fail("❌ Log file error: %v\n", err)
+            os.Exit(apperrors.ExitFS)
+        }
+        defer f.Close()
+        logFile = f
+    }
+    console := out.Errors()
+    if cli.LogColor(opts) {
+        console = app.ColorLines(console)
+    }
+    logOutput := app.LogOutput(console, logFile, conf.LogFileOnly)
+    logger := app.NewRunLogger(app.ParseLogLevel(conf.LogLevel), app.ParseLogFormat(conf.LogFormat), conf.TraceID, logOutput)
+    config.LogSources(logger, sources)
+    cli.SetLogger(logger)
+    api.SetLogger(logger)
+
+    requestURL := api.RedactURL(api.CompletionsURL(conf))
+    send := func(prompt string) (*api.LLMResponse, error) {
+        if opts.ShowURL {
+            out.Diagnostic("🔗 POST %s\n", requestURL)
+        }
+        logger.Debug("querying model %s at %s", conf.RequestModel(), requestURL)
+
+        // Ctrl-C aborts the request in flight instead of waiting for the timeout
+        ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+        defer stop()
+        resp, err := api.QueryLLMContext(ctx, conf, prompt)
+        if err != nil {
+            if apperrors.IsType(err, "API_ERROR") {
+                return nil, err
+            }
+            return nil, fmt.Errorf("API error: %w", err)
+        }
+        return resp, nil
+    }
+    if opts.Batch {
+        // Every item is sorted into the same tree
+        cli.CacheTrees()
+    }
+    // queryTree recommends a folder for desc, also returning the tree sent
+    // with the query for --record-dataset
+    queryTree := func(desc string) (*api.LLMResponse, string, error) {
+        if len(roots) > 1 {
+            logger.Debug("building prompt across %d trees", len(roots))
+            prompt, err := cli.BuildMultiTreePrompt(roots, desc, cli.QueryPromptOptions(opts, conf), cli.TreeOptions(conf)...)
+            if err != nil {
+                return nil, "", err
+            }
+            resp, err := send(prompt)
+            if err != nil {
+                return nil, "", err
+            }
+            if _, err := cli.ResolveRoot(roots, resp); err != nil {
+                return nil, "", err
+            }
+            return resp, "", nil
+        }
+        if opts.LargeTree {
+            logger.Debug("large tree: choosing a branch of %s", conf.TreePath)
+            resp, err := cli.RecommendLargeTree(conf.TreePath, desc, cli.QueryPromptOptions(opts, conf), send, cli.TreeOptions(conf)...)
+            return resp, "", err
+        }
+        logger.Debug("building prompt (tree: %s, no-tree: %v)", conf.TreePath, opts.NoTree)
+        prompt, tree, err := cli.BuildQueryPromptWithTree(opts, conf, desc)
+        if err != nil {
+            return nil, "", err
+        }
+        resp, err := send(prompt)
+        return resp, tree, err
+    }
+
+    // sent records the description and tree each item was queried with;
+    // batch items can be in flight at the same time
+    type sentItem struct{ desc, tree string }
+    var sentMu sync.Mutex
+    sent := map[string]sentItem{}
+    query := func(item string) (*api.LLMResponse, error) {
+        desc := item
+        if opts.Batch {
+            var err error
+            if desc, err = cli.DescribeBatchInput(item, opts.PreviewBytes); err != nil {
+                return nil, fmt.Errorf("Cannot describe %s: %w", item, err)
+            }
+        }
+        resp, tree, err := queryTree(desc)
+        if err == nil && !opts.NoTree {
+            root := conf.TreePath
+            if len(roots) > 1 {
+                var chosen cli.TreeRoot
+                chosen, err = cli.ResolveRoot(roots, resp)
+                root = chosen.Path
+            }
+            if err == nil {
+                err = cli.VerifyResponse(root, resp, opts.Strict)
+            }
+        }
+        sentMu.Lock()
+        sent[item] = sentItem{desc: desc, tree: tree}
+        sentMu.Unlock()
+        return resp, err
+    }
+    var dataset *cli.DatasetWriter
+    if opts.RecordDataset != "" {
+        dataset = &cli.DatasetWriter{Path: opts.RecordDataset, TreeByRef: opts.DatasetTreeRef}
+    }
+    prices := cli.Prices{Input: opts.PriceInput, Output: opts.PriceOutput}
+    emit := func(item string, resp *api.LLMResponse) {
+        sentMu.Lock()
+        desc, tree := sent[item].desc, sent[item].tree
+        sentMu.Unlock()
+        resp.TruncateReason(opts.MaxReasonLength)
+        usage := cli.FormatUsage(resp.Usage, prices)
+        logger.Debug("recommendation received: %s (usage: %s)", resp.Path, usage)
+        if opts.ShowUsage {
+            out.Diagnostic("📊 Usage: %s\n", usage)
+        }
+
+        if opts.Pick {
+            picked, err := cli.PickFromTree(conf.TreePath, resp.Path, cli.TreeOptions(conf)...)
+            if err != nil {
+                out.Fail("❌ %v\n", err)
+                os.Exit(1)
+            }
+            if picked != resp.Path {
+                resp.Path, resp.Reason, resp.Unverified = picked, cli.PickedReason, false
+            }
+        }
+        if resp.Unverified {
+            out.Diagnostic("⚠️ %s is not in the folder tree; the model may have made it up (--strict makes this an error)\n", resp.Path)
+        }
+
+        var placed *cli.Placement
+        var placeErr error
+        if placeSrc != "" {
+            root := conf.TreePath
+            if len(roots) > 1 {
+                var chosen cli.TreeRoot
+                chosen, placeErr = cli.ResolveRoot(roots, resp)
+                root = chosen.Path
+            }
+            // Without a matched root the file would land relative to the
+            // working directory, so it isn't placed at all
+            if placeErr == nil && opts.DryRun {
+                placed, placeErr = cli.PlanPlacement(placeSrc, root, resp.Path, keepSource)
+                if placed != nil {
+                    placed.DryRun = true
+                }
+            } else if placeErr == nil {
+                placed, placeErr = cli.PlaceFile(placeSrc, root, resp.Path, keepSource)
+            }
+        }
+
+        var writeErr error
+        if opts.Batch {
+            writeErr = cli.WriteBatchResult(item, resp)
+        } else {
+            writeErr = cli.WritePlacedResult(desc, resp, placed)
+        }
+        if writeErr != nil {
+            out.Error("❌ Cannot write result: %v\n", writeErr)
+        }
+
+        if dataset != nil {
+            if err := dataset.Record(desc, tree, conf.RequestModel(), resp); err != nil {
+                out.Diagnostic("⚠️ Could not record dataset entry: %v\n", err)
+            }
+        }
+
+        if placeErr != nil {
+            out.Fail("❌ Cannot place %s: %v\n", placeSrc, placeErr)
+            os.Exit(apperrors.ExitFS)
+        }
+    }
+
+    // In a batch a failed item is reported and the rest still run; the exit
+    // code then comes from the first failure
+    var fail func(item string, err error)
+    if opts.Batch {
+        fail = func(item string, err error) {
+            out.Error("❌ %s: %s\n", item, strings.TrimPrefix(apperrors.FormatUserError(err), "❌ "))
+        }
+    }
+    summary, err := cli.RunBatchConcurrent(items, opts.Budget, opts.Concurrency, query, emit, fail)
+    if summary.BudgetExceeded {
+        out.Fail("⚠️ Token budget of %d exceeded (%d used); stopped after %d of %d items\n",
+            opts.Budget, summary.Tokens, summary.Completed+summary.Failed, summary.Total)
+        os.Exit(cli.ExitBudgetExceeded)
+    }
+    if err != nil && fail != nil {
+        out.Fail("❌ %d of %d items failed\n", summary.Failed, summary.Total)
+        os.Exit(apperrors.ExitCode(err))
+    }
+    if err != nil {
+        out.Fail("%s\n", apperrors.FormatUserError(err))
+        os.Exit(apperrors.ExitCode(err))
+    }
 }
 
-func checkForUpdates() {
+func checkForUpdates(out *ui.Output, channel string) {
     if Version == "dev" {
         return
     }
@@ -113,7 +530,7 @@ func checkForUpdates() {
         return // Already checked within last minute
     }
 
-    release, err := updater.CheckLatestRelease()
+    release, err := cli.LatestRelease(channel)
     if err != nil {
         // Silently fail, but update last check time to prevent rapid retries
         _ = updater.SetLastUpdateCheck(now)
@@ -125,51 +542,11 @@ func checkForUpdates() {
 
     if release.Version != Version {
         header, instruction := updater.FormatUpdateNotification(release.Version, Version, true)
-        fmt.Fprintf(os.Stderr, "\n%s\n", header)
-        fmt.Fprintf(os.Stderr, "%s\n\n", instruction)
+        out.Diagnostic("\n%s\n", header)
+        out.Diagnostic("%s\n\n", instruction)
     }
 }
 
 // Add version info to help output
 func init() {
 }
-
-func maybePromptInstall() {
-    // Check if we should prompt based on environment
-    if !config.DefaultEnvironmentDetector.ShouldPromptUser() {
-        return
-    }
-
-    // If executable is already in PATH dir, skip
-    execPath, err := os.Executable()
-    if err != nil {
-        return
-    }
-    execDir := filepath.Dir(execPath)
-    if cliIsDirInPATH(execDir) {
-        return
-    }
-
-    reader := bufio.NewReader(os.Stdin)
-    fmt.Print("📦 Install sortpath to /usr/local/bin so you can run it from anywhere? [Y/n]: ")
-    answer, _ := reader.ReadString('\n')
-    answer = strings.TrimSpace(strings.ToLower(answer))
-    if answer == "" || answer == "y" || answer == "yes" {
-        // Attempt install
-        os.Args = append([]string{os.Args[0], "install"}, os.Args[1:]...)
-        cli.HandleInstallCommand([]string{})
-        return
-    }
-    // User declined installation - no need to track this anymore
-}
-
-func cliIsDirInPATH(dir string) bool {
-    // mirror of pathContainsDir in cli package, but unexported there; simple recheck here
-    pathEnv := os.Getenv("PATH")
-    for _, p := range strings.Split(pathEnv, ":") {
-        if p == dir {
-            return true
-        }
-    }
-    return false
-}
\ No newline at end of file
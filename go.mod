@@ -3,3 +3,8 @@ module github.com/kacperkwapisz/sortpath
 go 1.23.2
 
 require gopkg.in/yaml.v3 v3.0.1
+
+require (
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sys v0.28.0 // indirect
+)
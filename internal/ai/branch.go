@@ -0,0 +1,34 @@
+package ai
+
+import "fmt"
+
+// BuildBranchPrompt builds the cheap first stage of the large-tree flow: the
+// model sees only the top-level folders (with a peek at their contents) and
+// picks the one desc belongs under. The folder name is returned as the path
+// so the response parses like a folder recommendation; only opts.JSON is used.
+func BuildBranchPrompt(overview, desc string, opts PromptOptions) string {
+	return fmt.Sprintf(
+`<role>
+You are a highly organized archival AI assistant. The user's storage is too large to show at once, so you first choose which top-level folder a file belongs under.
+</role>
+
+<context>
+Top-level folders and a sample of their contents:
+%s
+</context>
+
+<instructions>
+Given a file description or name, provide ONLY:
+- The name of the single best top-level folder from the list above, spelled exactly as shown.
+- A very brief justification (1 sentence).
+
+%s
+</instructions>
+
+<format>
+%s
+</format>
+
+<input>Description: %s</input>
+`, overview, opts.formatRule(), opts.formatBlock("path", "reason"), desc)
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Taxonomy is the generic category list used when no folder tree is given
+var Taxonomy = []string{
+	"Documents",
+	"Spreadsheets",
+	"Presentations",
+	"Images",
+	"Design",
+	"Audio",
+	"Video",
+	"Code",
+	"Archives",
+	"Software",
+	"Fonts",
+	"Other",
+}
+
+// BuildDescribePrompt builds a tree-less prompt that asks the model to pick a
+// category from Taxonomy. The category is returned in the <path> tag so the
+// response parses the same way as a folder recommendation.
+func BuildDescribePrompt(desc string, opts PromptOptions) string {
+	return fmt.Sprintf(
+`<role>
+You are a file triage assistant. Your job is to classify any file, asset, or resource into a single generic category.
+</role>
+
+<categories>
+%s
+</categories>
+
+<instructions>
+Given a file description or name, provide ONLY:
+- The single best category from the list above, spelled exactly as listed.
+- A very brief justification (1–2 sentences) based on the description.
+
+Rules:
+- Use "Other" only when no category fits.
+- %s
+%s</instructions>
+
+<format>
+%s
+</format>
+
+<input>Description: %s</input>
+`, "- "+strings.Join(Taxonomy, "\n- "), opts.formatRule(), opts.extraRules(), opts.formatBlock("path", "reason"), desc)
+}
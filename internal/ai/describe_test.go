@@ -0,0 +1,22 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildDescribePrompt(t *testing.T) {
+	prompt := BuildDescribePrompt("Quarterly revenue spreadsheet", PromptOptions{})
+
+	if strings.Contains(prompt, "<context>") {
+		t.Error("describe prompt should not contain a <context> section")
+	}
+	if !strings.Contains(prompt, "<input>Description: Quarterly revenue spreadsheet</input>") {
+		t.Error("describe prompt should contain the description")
+	}
+	for _, category := range Taxonomy {
+		if !strings.Contains(prompt, "- "+category+"\n") {
+			t.Errorf("describe prompt missing category %q", category)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// formatRule is the instruction naming the answer format
+func (o PromptOptions) formatRule() string {
+	if o.JSON {
+		return "Always output a single JSON object in the format below, with no other text."
+	}
+	return "Always output in the XML format below."
+}
+
+// formatBlock renders the empty answer shown in <format>, one entry per
+// field. Several JSON answers go in a "recommendations" array.
+func (o PromptOptions) formatBlock(fields ...string) string {
+	var b strings.Builder
+	if o.JSON && o.Count > 1 {
+		entries := make([]string, len(fields))
+		for i, f := range fields {
+			entries[i] = fmt.Sprintf("%q: \"\"", f)
+		}
+		fmt.Fprintf(&b, "{\n  \"recommendations\": [\n    {%s},\n    ...\n  ]\n}", strings.Join(entries, ", "))
+		return b.String()
+	}
+	if o.JSON {
+		b.WriteString("{\n")
+		for i, f := range fields {
+			sep := ","
+			if i == len(fields)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&b, "  %q: \"\"%s\n", f, sep)
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+	b.WriteString("<recommendation>\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "  <%s></%s>\n", f, f)
+	}
+	b.WriteString("</recommendation>")
+	return b.String()
+}
+
+// exampleAnswer renders a filled-in answer for <examples>, each line
+// prefixed with indent
+func (o PromptOptions) exampleAnswer(indent, path, reason string) string {
+	if o.JSON {
+		return fmt.Sprintf("%s{\"path\": %s, \"reason\": %s}\n", indent, jsonString(path), jsonString(reason))
+	}
+	return fmt.Sprintf("%s<recommendation>\n%s  <path>%s</path>\n%s  <reason>%s</reason>\n%s</recommendation>\n",
+		indent, indent, path, indent, reason, indent)
+}
+
+// jsonString quotes s as a JSON string, leaving <, > and & readable
+func jsonString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
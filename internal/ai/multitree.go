@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabeledTree is one archive root shown in a multi-tree prompt
+type LabeledTree struct {
+	Label string
+	Tree  string
+}
+
+// BuildMultiTreePrompt builds a prompt presenting several labeled archives.
+// The model names the archive in root and the folder within it in path.
+func BuildMultiTreePrompt(trees []LabeledTree, desc string, opts PromptOptions) string {
+	var b strings.Builder
+	labels := make([]string, 0, len(trees))
+	for _, t := range trees {
+		fmt.Fprintf(&b, "<tree root=%q>\n%s\n</tree>\n", t.Label, strings.TrimRight(t.Tree, "\n"))
+		labels = append(labels, t.Label)
+	}
+
+	return fmt.Sprintf(
+`<role>
+You are a highly organized archival AI assistant.
+The user keeps several separate archives. Your job is to choose the archive a file belongs in and the best folder within it.
+</role>
+
+<context>
+The user's archives, each labeled with its root name:
+%s</context>
+
+<instructions>
+Given a file description or name, provide ONLY:
+- The root name of the single best archive, spelled exactly as one of: %s.
+- The recommended full folder path within that archive, starting at its top level.
+- A very brief justification (1–2 sentences).
+
+Rules:
+- Never mix folders from different archives in one path.
+- Suggest new subfolders under existing categories if it improves clarity.
+- %s
+%s</instructions>
+
+<format>
+%s
+</format>
+
+<input>Description: %s</input>
+`, b.String(), strings.Join(labels, ", "), opts.formatRule(), opts.extraRules(), opts.formatBlock("root", "path", "reason"), desc)
+}
@@ -2,12 +2,60 @@ package ai
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
+// PromptOptions tweaks the generated prompt
+type PromptOptions struct {
+	// MaxReasonLength, when positive, asks the model to keep the reason under this many characters
+	MaxReasonLength int
+
+	// Minimal drops the role, rules and examples, leaving only the tree, the
+	// description and a one-line instruction. Meant for fine-tuned models.
+	Minimal bool
+
+	// JSON asks for the answer as a JSON object instead of the XML
+	// <recommendation> block, for providers with a JSON output mode
+	JSON bool
+
+	// Count, when above 1, asks for that many ranked recommendations
+	Count int
+
+	// Rules are the user's own rules, added verbatim as bullets after the
+	// built-in ones
+	Rules []string
+}
+
+// extraRules renders option-driven rules as bullet lines for <instructions>
+func (o PromptOptions) extraRules() string {
+	rules := ""
+	if o.MaxReasonLength > 0 {
+		rules += fmt.Sprintf("- Keep the reason under %d characters; one short sentence is best.\n", o.MaxReasonLength)
+	}
+	if o.Count > 1 && o.JSON {
+		rules += fmt.Sprintf("- Give exactly %d different recommendations, ranked best first, in the \"recommendations\" array.\n", o.Count)
+	} else if o.Count > 1 {
+		rules += fmt.Sprintf("- Give exactly %d different recommendations, ranked best first, each in its own <recommendation> block.\n", o.Count)
+	}
+	for _, rule := range o.Rules {
+		rules += "- " + rule + "\n"
+	}
+	return rules
+}
+
 func BuildPrompt(tree, desc string) string {
+	return BuildPromptWithOptions(tree, desc, PromptOptions{})
+}
+
+// BuildPromptWithOptions builds the prompt with optional extra constraints
+func BuildPromptWithOptions(tree, desc string, opts PromptOptions) string {
+	if opts.Minimal {
+		return buildMinimalPrompt(tree, desc, opts)
+	}
 	date := time.Now().Format("2006-01-02")
 	time := time.Now().Format("15:04:05")
+	extraRules := opts.extraRules()
 	return fmt.Sprintf(
 `<role>
 You are a highly organized archival AI assistant.
@@ -32,68 +80,73 @@ Rules:
 - Never place files in more than one top-level folder.
 - If a file relates to a specific project/client/year, recommend inside 01_PROJECTS (with YYYY/ProjectName subfolders).
 - If a user input contains a date and/or time, take it into account when recommending a folder path.
-- Always output in the XML format below.
-</instructions>
+- %s
+%s</instructions>
 
 <format>
-<recommendation>
-  <path></path>
-  <reason></reason>
-</recommendation>
+%s
 </format>
 
 <examples>
-<example>
-  <input>Description: Photoshop cracked installer for Mac</input>
-  <output>
-    <recommendation>
-      <path>/07_RESOURCES/Software/Mac/Unofficial_Cracked</path>
-      <reason>It's an unofficial Mac app installer; software belongs in the dedicated resources/software folder for clarity and safety.</reason>
-    </recommendation>
-  </output>
-</example>
-<example>
-  <input>Description: Clothing mockup, PSD file</input>
-  <output>
-    <recommendation>
-      <path>/07_RESOURCES/Mockups/Clothing</path>
-      <reason>Mockups are reusable assets, and 'Clothing' is the dedicated subcategory under mockups for this type.</reason>
-    </recommendation>
-  </output>
-</example>
-<example>
-  <input>Description: Berlin trip photos, 2025</input>
-  <output>
-    <recommendation>
-      <path>/03_PHOTOS/2025/Berlin_Trip</path>
-      <reason>Photos by year and event name keep memories organized and easy to find chronologically.</reason>
-    </recommendation>
-  </output>
-</example>
-<example>
-  <input>Description: All files for 2025 'BrandX' web design project</input>
-  <output>
-    <recommendation>
-      <path>/01_PROJECTS/2025/BrandX</path>
-      <reason>Project-specific work is stored in year-based subfolders under Projects.</reason>
-    </recommendation>
-  </output>
-</example>
-<example>
-  <input>Description: Custom coding boilerplate template</input>
-  <output>
-    <recommendation>
-      <path>/05_CODE/Templates</path>
-      <reason>Generic code templates are best grouped with other reusable code resources in the Templates subfolder.</reason>
-    </recommendation>
-  </output>
-</example>
-</examples>
+%s</examples>
 
 <output_instruction>
-Always wrap your single recommended folder path and brief reason with <recommendation>, <path>, and <reason> tags.
+%s
 </output_instruction>
 
 <input>Description: %s</input>
-`, date, time, tree, desc)
+`, date, time, tree, opts.formatRule(), extraRules, opts.formatBlock("path", "reason"), opts.examples(), opts.outputInstruction(), desc)
+}
+
+// buildMinimalPrompt keeps only what a model fine-tuned for this task needs
+func buildMinimalPrompt(tree, desc string, opts PromptOptions) string {
+	return fmt.Sprintf(
+`<context>
+%s
+</context>
+
+Recommend the best folder for the file below. Answer with %s.
+%s
+<input>Description: %s</input>
+`, tree, opts.minimalAnswer(), opts.extraRules(), desc)
+}
+
+// promptExamples are the worked examples shown in the rich prompt
+var promptExamples = []struct{ desc, path, reason string }{
+	{"Photoshop cracked installer for Mac", "/07_RESOURCES/Software/Mac/Unofficial_Cracked", "It's an unofficial Mac app installer; software belongs in the dedicated resources/software folder for clarity and safety."},
+	{"Clothing mockup, PSD file", "/07_RESOURCES/Mockups/Clothing", "Mockups are reusable assets, and 'Clothing' is the dedicated subcategory under mockups for this type."},
+	{"Berlin trip photos, 2025", "/03_PHOTOS/2025/Berlin_Trip", "Photos by year and event name keep memories organized and easy to find chronologically."},
+	{"All files for 2025 'BrandX' web design project", "/01_PROJECTS/2025/BrandX", "Project-specific work is stored in year-based subfolders under Projects."},
+	{"Custom coding boilerplate template", "/05_CODE/Templates", "Generic code templates are best grouped with other reusable code resources in the Templates subfolder."},
+}
+
+// examples renders promptExamples in the requested answer format
+func (o PromptOptions) examples() string {
+	var b strings.Builder
+	for _, ex := range promptExamples {
+		fmt.Fprintf(&b, "<example>\n  <input>Description: %s</input>\n  <output>\n%s  </output>\n</example>\n",
+			ex.desc, o.exampleAnswer("    ", ex.path, ex.reason))
+	}
+	return b.String()
+}
+
+// outputInstruction repeats the answer format at the end of the rich prompt
+func (o PromptOptions) outputInstruction() string {
+	switch {
+	case o.JSON && o.Count > 1:
+		return fmt.Sprintf(`Always answer with a single JSON object whose "recommendations" array holds your %d ranked folder paths in "path", each with a brief reason in "reason".`, o.Count)
+	case o.JSON:
+		return `Always answer with a single JSON object holding your recommended folder path in "path" and a brief reason in "reason".`
+	case o.Count > 1:
+		return fmt.Sprintf("Always wrap each of your %d ranked folder paths and brief reasons with <recommendation>, <path>, and <reason> tags, best first.", o.Count)
+	}
+	return "Always wrap your single recommended folder path and brief reason with <recommendation>, <path>, and <reason> tags."
+}
+
+// minimalAnswer is the inline answer template of the minimal prompt
+func (o PromptOptions) minimalAnswer() string {
+	if o.JSON {
+		return `a JSON object: {"path": "", "reason": ""}`
+	}
+	return "<recommendation><path></path><reason></reason></recommendation>"
 }
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPromptWithOptions_Minimal(t *testing.T) {
+	tree := "├── 01_PROJECTS\n└── 07_RESOURCES\n"
+	desc := "Clothing mockup, PSD file"
+
+	rich := BuildPromptWithOptions(tree, desc, PromptOptions{})
+	minimal := BuildPromptWithOptions(tree, desc, PromptOptions{Minimal: true})
+
+	for _, section := range []string{"<role>", "<examples>", "<instructions>"} {
+		if !strings.Contains(rich, section) {
+			t.Errorf("rich prompt missing %s", section)
+		}
+		if strings.Contains(minimal, section) {
+			t.Errorf("minimal prompt should omit %s", section)
+		}
+	}
+	if !strings.Contains(minimal, tree) {
+		t.Error("minimal prompt should keep the tree")
+	}
+	if !strings.Contains(minimal, "<input>Description: "+desc+"</input>") {
+		t.Error("minimal prompt should keep the description")
+	}
+	if len(minimal) >= len(rich)/4 {
+		t.Errorf("minimal prompt is %d bytes, want well under the rich prompt's %d", len(minimal), len(rich))
+	}
+}
+
+func TestBuildPrompts_JSON(t *testing.T) {
+	tree := "├── 01_PROJECTS\n└── 07_RESOURCES\n"
+	desc := "Clothing mockup, PSD file"
+	opts := PromptOptions{JSON: true}
+
+	prompts := map[string]string{
+		"rich":       BuildPromptWithOptions(tree, desc, opts),
+		"minimal":    BuildPromptWithOptions(tree, desc, PromptOptions{Minimal: true, JSON: true}),
+		"describe":   BuildDescribePrompt(desc, opts),
+		"branch":     BuildBranchPrompt(tree, desc, opts),
+		"multi-tree": BuildMultiTreePrompt([]LabeledTree{{Label: "work", Tree: tree}}, desc, opts),
+	}
+	for name, prompt := range prompts {
+		if !strings.Contains(prompt, "JSON") {
+			t.Errorf("%s prompt doesn't ask for JSON", name)
+		}
+		if strings.Contains(prompt, "<recommendation>") || strings.Contains(prompt, "<path>") {
+			t.Errorf("%s prompt still shows the XML answer format", name)
+		}
+	}
+	if !strings.Contains(prompts["multi-tree"], `"root": ""`) {
+		t.Error("multi-tree prompt should ask for the root")
+	}
+	if !strings.Contains(prompts["rich"], `{"path": "/07_RESOURCES/Mockups/Clothing", "reason": `) {
+		t.Error("rich prompt examples should be JSON answers")
+	}
+}
+
+func TestBuildPromptWithOptions_Count(t *testing.T) {
+	tree := "├── 01_PROJECTS\n└── 07_RESOURCES\n"
+
+	prompt := BuildPromptWithOptions(tree, "Tax return", PromptOptions{Count: 3})
+	if !strings.Contains(prompt, "Give exactly 3 different recommendations, ranked best first, each in its own <recommendation> block.") {
+		t.Error("prompt should ask for 3 ranked <recommendation> blocks")
+	}
+	if strings.Contains(prompt, "your single recommended") {
+		t.Error("prompt still asks for a single recommendation")
+	}
+
+	prompt = BuildPromptWithOptions(tree, "Tax return", PromptOptions{Count: 3, JSON: true})
+	if !strings.Contains(prompt, `"recommendations": [`) {
+		t.Error("JSON prompt should show the recommendations array")
+	}
+
+	if prompt := BuildPromptWithOptions(tree, "Tax return", PromptOptions{Count: 1}); strings.Contains(prompt, "ranked") {
+		t.Error("a count of 1 should leave the prompt unchanged")
+	}
+}
+
+func TestBuildPromptWithOptions_Rules(t *testing.T) {
+	tree := "├── 01_PROJECTS\n└── 06_DOCS\n"
+	desc := "Scanned lease agreement"
+
+	rules := []string{"All PDFs go under 06_DOCS", `Never create folders with spaces; use "_" instead`}
+	prompt := BuildPromptWithOptions(tree, desc, PromptOptions{Rules: rules})
+	start, end := strings.Index(prompt, "<instructions>"), strings.Index(prompt, "</instructions>")
+	if start < 0 || end < start {
+		t.Fatal("prompt has no <instructions> block")
+	}
+	instructions := prompt[start:end]
+	for _, rule := range rules {
+		if !strings.Contains(instructions, "\n- "+rule+"\n") {
+			t.Errorf("instructions missing rule %q:\n%s", rule, instructions)
+		}
+	}
+
+	// Without rules the prompt is unchanged
+	if got, want := BuildPromptWithOptions(tree, desc, PromptOptions{Rules: []string{}}), BuildPrompt(tree, desc); stripClock(got) != stripClock(want) {
+		t.Error("empty rules changed the prompt")
+	}
+}
+
+// stripClock drops the current time line, which can differ between two builds
+func stripClock(prompt string) string {
+	var kept []string
+	for _, line := range strings.Split(prompt, "\n") {
+		if !strings.HasPrefix(line, "Current time:") && !strings.HasPrefix(line, "Current date:") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
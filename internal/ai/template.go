@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// PromptData holds the values available to custom prompt templates
+type PromptData struct {
+	Tree        string
+	Description string
+	Date        string
+	Time        string
+}
+
+// NewPromptData fills in PromptData with the current date and time
+func NewPromptData(tree, desc string) PromptData {
+	now := time.Now()
+	return PromptData{
+		Tree:        tree,
+		Description: desc,
+		Date:        now.Format("2006-01-02"),
+		Time:        now.Format("15:04:05"),
+	}
+}
+
+// ParseTemplate parses a prompt template. Syntax errors carry the template
+// name and line number from text/template.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template syntax error: %w", err)
+	}
+	return tmpl, nil
+}
+
+// LoadTemplate reads and parses a prompt template file
+func LoadTemplate(path string) (*template.Template, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	return ParseTemplate(path, string(b))
+}
+
+// CheckTemplate reports a template that never uses {{.Description}}, which
+// would send the model a prompt without the file to sort
+func CheckTemplate(tmpl *template.Template) error {
+	if tmpl.Tree == nil || !usesField(tmpl.Tree.Root, "Description") {
+		return fmt.Errorf("prompt template %s never uses {{.Description}}", tmpl.Name())
+	}
+	return nil
+}
+
+// usesField reports whether node or anything below it reads .name
+func usesField(node parse.Node, name string) bool {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == name
+	case *parse.VariableNode:
+		// $.name reads the field from the top-level data
+		return len(n.Ident) > 1 && n.Ident[0] == "$" && n.Ident[1] == name
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if usesField(child, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return usesField(n.Pipe, name)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if usesField(cmd, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if usesField(arg, name) {
+				return true
+			}
+		}
+	case *parse.IfNode:
+		return usesField(n.Pipe, name) || usesField(n.List, name) || usesField(n.ElseList, name)
+	case *parse.RangeNode:
+		return usesField(n.Pipe, name) || usesField(n.List, name) || usesField(n.ElseList, name)
+	case *parse.WithNode:
+		return usesField(n.Pipe, name) || usesField(n.List, name) || usesField(n.ElseList, name)
+	case *parse.TemplateNode:
+		return usesField(n.Pipe, name)
+	}
+	return false
+}
+
+// RenderTemplate executes tmpl with data. References to fields that
+// PromptData doesn't have are reported as execution errors.
+func RenderTemplate(tmpl *template.Template, data PromptData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("template execution error: %w", err)
+	}
+	return b.String(), nil
+}
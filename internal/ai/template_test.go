@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTemplate_Outcomes(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		wantOut   string
+		wantErr   string
+		wantPhase string
+	}{
+		{
+			name:     "valid template",
+			template: "Folders:\n{{.Tree}}\nFile: {{.Description}}\n",
+			wantOut:  "Folders:\n├── Docs\n\nFile: tax return 2024\n",
+		},
+		{
+			name:      "undefined field",
+			template:  "File: {{.Descripton}}\n",
+			wantPhase: "template execution error",
+			wantErr:   "can't evaluate field Descripton",
+		},
+		{
+			name:      "syntax error",
+			template:  "line one\nFile: {{.Description}\n",
+			wantPhase: "template syntax error",
+			wantErr:   ":2:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTemplate(t, tt.template)
+			data := PromptData{Tree: "├── Docs\n", Description: "tax return 2024"}
+
+			tmpl, err := LoadTemplate(path)
+			var out string
+			if err == nil {
+				out, err = RenderTemplate(tmpl, data)
+			}
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error = %v", err)
+				}
+				if out != tt.wantOut {
+					t.Errorf("rendered = %q, want %q", out, tt.wantOut)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got output %q", tt.wantErr, out)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantPhase) {
+				t.Errorf("error = %v, want prefix %q", err, tt.wantPhase)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "plain use", template: "File: {{.Description}}"},
+		{name: "inside if", template: "{{if .Tree}}{{.Tree}}{{end}}{{with .Date}}{{$.Description}}{{end}}"},
+		{name: "in a pipeline", template: "{{.Description | printf \"%q\"}}"},
+		{name: "missing", template: "Folders:\n{{.Tree}}\n", wantErr: true},
+		{name: "only a similar name", template: "{{.Date}} Descriptions", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate("prompt.tmpl", tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = CheckTemplate(tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package ai
+
+// ResponseTokenBudget is reserved out of a context window for the model's answer
+const ResponseTokenBudget = 256
+
+// EstimateTokens gives a conservative token count for s using the common
+// four-bytes-per-token heuristic. Multi-byte tree-drawing characters make it
+// overestimate slightly, which is the safe direction when fitting a prompt.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
@@ -1,11 +1,20 @@
 package app
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -55,6 +64,24 @@ func ParseLogLevel(level string) LogLevel {
 	}
 }
 
+// LogFormat selects how log lines are written
+type LogFormat int
+
+const (
+	// LogFormatText writes "[INFO]  2009/11/10 23:00:00 message" lines
+	LogFormatText LogFormat = iota
+	// LogFormatJSON writes one JSON object per line, for log aggregators
+	LogFormatJSON
+)
+
+// ParseLogFormat parses a string into a LogFormat, defaulting to text
+func ParseLogFormat(format string) LogFormat {
+	if strings.EqualFold(format, "json") {
+		return LogFormatJSON
+	}
+	return LogFormatText
+}
+
 // Logger interface defines the logging contract
 type Logger interface {
 	Debug(msg string, args ...interface{})
@@ -64,13 +91,101 @@ type Logger interface {
 	GetLevel() LogLevel
 }
 
+// NoopLogger is a Logger that discards everything without formatting it, for
+// embedders and tests that want no logging at all
+type NoopLogger struct{}
+
+func (NoopLogger) Debug(msg string, args ...interface{}) {}
+func (NoopLogger) Info(msg string, args ...interface{})  {}
+func (NoopLogger) Error(msg string, args ...interface{}) {}
+func (NoopLogger) SetLevel(level LogLevel)               {}
+
+// GetLevel always reports LogLevelSilent
+func (NoopLogger) GetLevel() LogLevel { return LogLevelSilent }
+
 // StandardLogger implements Logger using Go's standard log package
 type StandardLogger struct {
 	level      LogLevel
+	format     LogFormat
+	jsonMu     sync.Mutex // serializes JSON lines, which bypass log.Logger
 	debugLog   *log.Logger
 	infoLog    *log.Logger
 	errorLog   *log.Logger
 	sensitiveKeys []string
+	redactor      *regexp.Regexp
+	caller        bool
+}
+
+// WithCaller adds the file:line of the code that logged to each line while
+// the level is debug. It is off by default; SORTPATH_LOG_CALLER=1 turns it
+// on for NewLoggerFromEnv and NewRunLogger.
+func (l *StandardLogger) WithCaller(on bool) *StandardLogger {
+	l.caller = on
+	return l
+}
+
+// callerFromEnv reports whether SORTPATH_LOG_CALLER asks for callers
+func callerFromEnv() bool {
+	on, _ := strconv.ParseBool(os.Getenv("SORTPATH_LOG_CALLER"))
+	return on
+}
+
+// loggerFile is this file; callerOf skips its frames
+var loggerFile = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return file
+}()
+
+// callerOf returns "file.go:line" of the first frame outside this file, so
+// wrappers such as contextLogger and TimedOperation report the code that
+// logged rather than themselves
+func callerOf() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if frame.File != loggerFile {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// defaultSensitiveKeys name the values every logger redacts
+var defaultSensitiveKeys = []string{
+	"api_key", "apikey", "api-key",
+	"token", "password", "secret",
+	"authorization", "auth",
+}
+
+var defaultRedactor = sensitivePattern(defaultSensitiveKeys)
+
+// sensitivePattern matches a key from keys and its value. The key is the
+// last part of a field name (client_secret matches secret; max_tokens
+// doesn't match token), optionally quoted as in JSON, and directly followed
+// by = or :, so "key = value" prose such as the config source listing is
+// left alone. The value is a quoted string, which may be JSON-escaped inside
+// another JSON string, or runs up to a space or a separator such as , & or
+// }. An auth scheme such as Bearer before it is kept. Group 1 is everything
+// before the value, group 2 the value.
+func sensitivePattern(keys []string) *regexp.Regexp {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	return regexp.MustCompile(`(?i)((?:^|[^a-z0-9_-])\\?["']?(?:[a-z0-9]+[_-])*(?:` + strings.Join(quoted, "|") + `)\\?["']?[:=]\s*(?:(?:bearer|basic|token)\s+)?)` +
+		`(\\"(?:[^"\\]|\\[^"])*\\"|"(?:[^"\\]|\\.)*"|'[^']*'|[^\s,;&"'}\]\\]+)`)
+}
+
+// WithSensitiveKeys adds keys whose values are redacted, such as
+// client_secret or refresh_token, to the built-in ones. Call it before the
+// logger is shared.
+func (l *StandardLogger) WithSensitiveKeys(keys []string) *StandardLogger {
+	l.sensitiveKeys = append(append([]string{}, l.sensitiveKeys...), keys...)
+	l.redactor = sensitivePattern(l.sensitiveKeys)
+	return l
 }
 
 // NewLogger creates a new StandardLogger with the specified level
@@ -80,11 +195,8 @@ func NewLogger(level LogLevel) *StandardLogger {
 		debugLog: log.New(os.Stdout, "[DEBUG] ", log.LstdFlags),
 		infoLog:  log.New(os.Stdout, "[INFO]  ", log.LstdFlags),
 		errorLog: log.New(os.Stderr, "[ERROR] ", log.LstdFlags),
-		sensitiveKeys: []string{
-			"api_key", "apikey", "api-key",
-			"token", "password", "secret",
-			"authorization", "auth",
-		},
+		sensitiveKeys: defaultSensitiveKeys,
+		redactor:      defaultRedactor,
 	}
 }
 
@@ -95,39 +207,84 @@ func NewLoggerWithOutput(level LogLevel, stdout, stderr io.Writer) *StandardLogg
 		debugLog: log.New(stdout, "[DEBUG] ", log.LstdFlags),
 		infoLog:  log.New(stdout, "[INFO]  ", log.LstdFlags),
 		errorLog: log.New(stderr, "[ERROR] ", log.LstdFlags),
-		sensitiveKeys: []string{
-			"api_key", "apikey", "api-key",
-			"token", "password", "secret",
-			"authorization", "auth",
-		},
+		sensitiveKeys: defaultSensitiveKeys,
+		redactor:      defaultRedactor,
 	}
 }
 
+// NewLoggerWithFormat creates a new StandardLogger with custom output
+// writers that writes lines in format
+func NewLoggerWithFormat(level LogLevel, format LogFormat, stdout, stderr io.Writer) *StandardLogger {
+	l := NewLoggerWithOutput(level, stdout, stderr)
+	l.format = format
+	return l
+}
+
 // Debug logs a debug message if the level allows it
 func (l *StandardLogger) Debug(msg string, args ...interface{}) {
-	if l.level <= LogLevelDebug {
-		formatted := l.formatMessage(msg, args...)
-		redacted := l.redactSensitiveData(formatted)
-		l.debugLog.Print(redacted)
-	}
+	l.write(LogLevelDebug, "", l.formatMessage(msg, args...))
 }
 
 // Info logs an info message if the level allows it
 func (l *StandardLogger) Info(msg string, args ...interface{}) {
-	if l.level <= LogLevelInfo {
-		formatted := l.formatMessage(msg, args...)
-		redacted := l.redactSensitiveData(formatted)
-		l.infoLog.Print(redacted)
-	}
+	l.write(LogLevelInfo, "", l.formatMessage(msg, args...))
 }
 
 // Error logs an error message if the level allows it
 func (l *StandardLogger) Error(msg string, args ...interface{}) {
-	if l.level <= LogLevelError {
-		formatted := l.formatMessage(msg, args...)
-		redacted := l.redactSensitiveData(formatted)
-		l.errorLog.Print(redacted)
+	l.write(LogLevelError, "", l.formatMessage(msg, args...))
+}
+
+// jsonLine is one line of LogFormatJSON output
+type jsonLine struct {
+	TS     string `json:"ts"`
+	Level  string `json:"level"`
+	Msg    string `json:"msg"`
+	Ctx    string `json:"ctx,omitempty"`
+	Caller string `json:"caller,omitempty"`
+}
+
+// write logs msg at level with an optional context. Sensitive values are
+// redacted before the line is formatted.
+func (l *StandardLogger) write(level LogLevel, ctx, msg string) {
+	if l.level > level {
+		return
+	}
+	out := l.infoLog
+	switch level {
+	case LogLevelDebug:
+		out = l.debugLog
+	case LogLevelError:
+		out = l.errorLog
+	}
+	caller := ""
+	if l.caller && l.level == LogLevelDebug {
+		caller = callerOf()
+	}
+
+	if l.format != LogFormatJSON {
+		if ctx != "" {
+			msg = fmt.Sprintf("[%s] %s", ctx, msg)
+		}
+		if caller != "" {
+			msg = caller + ": " + msg
+		}
+		out.Print(l.redactSensitiveData(msg))
+		return
+	}
+	line, err := json.Marshal(jsonLine{
+		TS:     time.Now().UTC().Format(time.RFC3339Nano),
+		Level:  level.String(),
+		Msg:    l.redactSensitiveData(msg),
+		Ctx:    l.redactSensitiveData(ctx),
+		Caller: caller,
+	})
+	if err != nil {
+		return
 	}
+	l.jsonMu.Lock()
+	defer l.jsonMu.Unlock()
+	out.Writer().Write(append(line, '\n'))
 }
 
 // SetLevel sets the logging level
@@ -148,77 +305,29 @@ func (l *StandardLogger) formatMessage(msg string, args ...interface{}) string {
 	return fmt.Sprintf(msg, args...)
 }
 
-// redactSensitiveData removes or masks sensitive information from log messages
+// redactSensitiveData replaces the value of every sensitive key in message
+// with [REDACTED], keeping any quotes around it
 func (l *StandardLogger) redactSensitiveData(message string) string {
-	result := message
-	
-	for _, key := range l.sensitiveKeys {
-		// Look for patterns like "api_key=value" or "api_key: value"
-		patterns := []string{
-			fmt.Sprintf("%s=", key),
-			fmt.Sprintf("%s:", key),
-			fmt.Sprintf(`"%s"`, key),
-			fmt.Sprintf("'%s'", key),
-		}
-		
-		for _, pattern := range patterns {
-			if strings.Contains(strings.ToLower(result), strings.ToLower(pattern)) {
-				// Replace the value part with [REDACTED]
-				result = l.maskSensitiveValue(result, key)
+	redactor := l.redactor
+	if redactor == nil {
+		redactor = defaultRedactor
+	}
+	return redactor.ReplaceAllStringFunc(message, func(match string) string {
+		m := redactor.FindStringSubmatch(match)
+		prefix, value := m[1], m[2]
+		quote := ""
+		for _, q := range []string{`\"`, `"`, `'`} {
+			if len(value) >= 2*len(q) && strings.HasPrefix(value, q) && strings.HasSuffix(value, q) {
+				quote = q
+				break
 			}
 		}
-	}
-	
-	return result
-}
-
-// maskSensitiveValue masks sensitive values in the message
-func (l *StandardLogger) maskSensitiveValue(message, key string) string {
-	lower := strings.ToLower(message)
-	lowerKey := strings.ToLower(key)
-	
-	// Find the key in the message
-	keyIndex := strings.Index(lower, lowerKey)
-	if keyIndex == -1 {
-		return message
-	}
-	
-	// Find the start of the value (after = or :)
-	valueStart := keyIndex + len(key)
-	for valueStart < len(message) && (message[valueStart] == '=' || message[valueStart] == ':' || message[valueStart] == ' ' || message[valueStart] == '"' || message[valueStart] == '\'') {
-		valueStart++
-	}
-	
-	if valueStart >= len(message) {
-		return message
-	}
-	
-	// Find the end of the value (space, comma, quote, or end of string)
-	valueEnd := valueStart
-	inQuotes := false
-	quoteChar := byte(0)
-	
-	for valueEnd < len(message) {
-		char := message[valueEnd]
-		
-		if !inQuotes && (char == '"' || char == '\'') {
-			inQuotes = true
-			quoteChar = char
-		} else if inQuotes && char == quoteChar {
-			valueEnd++
-			break
-		} else if !inQuotes && (char == ' ' || char == ',' || char == '\n' || char == '\t') {
-			break
+		if len(value) == 2*len(quote) {
+			// Nothing to hide in an empty quoted value
+			return match
 		}
-		valueEnd++
-	}
-	
-	// Replace the value with [REDACTED]
-	if valueEnd > valueStart {
-		return message[:valueStart] + "[REDACTED]" + message[valueEnd:]
-	}
-	
-	return message
+		return prefix + quote + "[REDACTED]" + quote
+	})
 }
 
 // NewLoggerFromEnv creates a logger with level from environment variable
@@ -232,7 +341,71 @@ func NewLoggerFromEnv() *StandardLogger {
 	}
 	
 	level := ParseLogLevel(levelStr)
-	return NewLogger(level)
+	return NewLogger(level).WithCaller(callerFromEnv())
+}
+
+// NewCorrelationID returns a random 32-character hex ID that is also a valid
+// W3C trace ID, used to correlate one run's API request and log lines
+func NewCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewRunLogger returns a logger writing to w in format that tags every line
+// with the run's trace ID
+func NewRunLogger(level LogLevel, format LogFormat, traceID string, w io.Writer) Logger {
+	return NewLoggerWithFormat(level, format, w, w).WithCaller(callerFromEnv()).WithContext("trace=" + traceID)
+}
+
+// LogOutput returns the writer a run logs to: console and file both, or
+// the file alone when fileOnly is set. A nil file logs to the console only.
+func LogOutput(console, file io.Writer, fileOnly bool) io.Writer {
+	switch {
+	case file == nil:
+		return console
+	case fileOnly:
+		return file
+	}
+	return io.MultiWriter(console, file)
+}
+
+// ANSI colors for text log lines on a terminal
+const (
+	colorRed   = "\x1b[31m"
+	colorDim   = "\x1b[2m"
+	colorReset = "\x1b[0m"
+)
+
+// ColorLines returns a writer that colors each text log line written to w
+// by its level: errors red, debug lines dim. Info and JSON lines pass
+// through unchanged. Wrap only the console writer, never a log file.
+func ColorLines(w io.Writer) io.Writer {
+	return colorWriter{w}
+}
+
+// colorWriter relies on log.Logger writing each line in a single Write
+type colorWriter struct {
+	w io.Writer
+}
+
+func (c colorWriter) Write(p []byte) (int, error) {
+	color := ""
+	switch {
+	case bytes.HasPrefix(p, []byte("[ERROR] ")):
+		color = colorRed
+	case bytes.HasPrefix(p, []byte("[DEBUG] ")):
+		color = colorDim
+	default:
+		return c.w.Write(p)
+	}
+	line := bytes.TrimSuffix(p, []byte("\n"))
+	if _, err := fmt.Fprintf(c.w, "%s%s%s\n", color, line, colorReset); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 // TimedOperation logs the duration of an operation
@@ -267,15 +440,32 @@ type contextLogger struct {
 }
 
 func (c *contextLogger) Debug(msg string, args ...interface{}) {
-	c.logger.Debug("[%s] %s", c.context, fmt.Sprintf(msg, args...))
+	c.write(LogLevelDebug, fmt.Sprintf(msg, args...))
 }
 
 func (c *contextLogger) Info(msg string, args ...interface{}) {
-	c.logger.Info("[%s] %s", c.context, fmt.Sprintf(msg, args...))
+	c.write(LogLevelInfo, fmt.Sprintf(msg, args...))
 }
 
 func (c *contextLogger) Error(msg string, args ...interface{}) {
-	c.logger.Error("[%s] %s", c.context, fmt.Sprintf(msg, args...))
+	c.write(LogLevelError, fmt.Sprintf(msg, args...))
+}
+
+// write hands the context to a StandardLogger separately, so JSON lines
+// carry it in "ctx"; other loggers get it as a "[context]" prefix
+func (c *contextLogger) write(level LogLevel, msg string) {
+	if l, ok := c.logger.(*StandardLogger); ok {
+		l.write(level, c.context, msg)
+		return
+	}
+	switch level {
+	case LogLevelDebug:
+		c.logger.Debug("[%s] %s", c.context, msg)
+	case LogLevelInfo:
+		c.logger.Info("[%s] %s", c.context, msg)
+	default:
+		c.logger.Error("[%s] %s", c.context, msg)
+	}
 }
 
 func (c *contextLogger) SetLevel(level LogLevel) {
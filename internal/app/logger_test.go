@@ -2,11 +2,17 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
 )
 
 func TestLogLevel_String(t *testing.T) {
@@ -214,6 +220,90 @@ func TestStandardLogger_RedactSensitiveData(t *testing.T) {
 	}
 }
 
+func TestStandardLogger_RedactPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "json object",
+			message: `body {"model":"gpt-4","api_key":"sk-abc123","n":1}`,
+			want:    `body {"model":"gpt-4","api_key":"[REDACTED]","n":1}`,
+		},
+		{
+			name:    "json with spaces and escaped quote",
+			message: `{"password": "pa\"ss", "user": "bob"}`,
+			want:    `{"password": "[REDACTED]", "user": "bob"}`,
+		},
+		{
+			name:    "json escaped inside a json string",
+			message: `raw_response="{\"token\":\"abc\",\"ok\":true}"`,
+			want:    `raw_response="{\"token\":\"[REDACTED]\",\"ok\":true}"`,
+		},
+		{
+			name:    "url-encoded value",
+			message: "GET /v1?api-key=sk%2Dabc%3D%3D&api-version=2024",
+			want:    "GET /v1?api-key=[REDACTED]&api-version=2024",
+		},
+		{
+			name:    "several occurrences",
+			message: "token=aaa secret=bbb token=ccc",
+			want:    "token=[REDACTED] secret=[REDACTED] token=[REDACTED]",
+		},
+		{
+			name:    "prefixed field names",
+			message: "client_secret=s1 refresh_token: t1 OPENAI_API_KEY=k1",
+			want:    "client_secret=[REDACTED] refresh_token: [REDACTED] OPENAI_API_KEY=[REDACTED]",
+		},
+		{
+			name:    "auth scheme kept",
+			message: "Authorization: Bearer sk-live-1",
+			want:    "Authorization: Bearer [REDACTED]",
+		},
+		{
+			name:    "longer field names untouched",
+			message: "max_tokens=256 author=ann tokens: 12",
+			want:    "max_tokens=256 author=ann tokens: 12",
+		},
+		{
+			name:    "empty value untouched",
+			message: `{"api_key":""}`,
+			want:    `{"api_key":""}`,
+		},
+	}
+
+	logger := NewLogger(LogLevelInfo)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := logger.redactSensitiveData(tt.message); got != tt.want {
+				t.Errorf("redactSensitiveData(%s)\n got %s\nwant %s", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStandardLogger_WithSensitiveKeys(t *testing.T) {
+	var stdout bytes.Buffer
+	logger := NewLoggerWithOutput(LogLevelInfo, &stdout, &stdout).WithSensitiveKeys([]string{"session_cookie", "pin"})
+	logger.Info(`cookie {"session_cookie":"c0ff33"} pin=1234 api_key=sk-1 spin=fast`)
+
+	got := stdout.String()
+	for _, secret := range []string{"c0ff33", "1234", "sk-1"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("output leaks %q: %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, "spin=fast") {
+		t.Errorf("output redacted a longer field name: %s", got)
+	}
+
+	// Other loggers keep the built-in keys only
+	if got := NewLogger(LogLevelInfo).redactSensitiveData("pin=1234"); got != "pin=1234" {
+		t.Errorf("custom key leaked into another logger: %s", got)
+	}
+}
+
 func TestStandardLogger_FormatMessage(t *testing.T) {
 	var stdout bytes.Buffer
 	logger := NewLoggerWithOutput(LogLevelInfo, &stdout, &stdout)
@@ -329,4 +419,211 @@ func TestContextLogger(t *testing.T) {
 	if contextLogger.GetLevel() != LogLevelError {
 		t.Errorf("Expected context logger to preserve level operations")
 	}
-}
\ No newline at end of file
+}
+
+func TestRunLogger_IncludesTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewRunLogger(LogLevelDebug, LogFormatText, "4bf92f3577b34da6a3ce929d0e0e4736", &buf)
+
+	logger.Debug("querying model")
+	logger.Error("request failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "[trace=4bf92f3577b34da6a3ce929d0e0e4736]") {
+			t.Errorf("Expected trace ID in log line, got: %s", line)
+		}
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	logger := NewLoggerWithFormat(LogLevelInfo, LogFormatJSON, &stdout, &stderr)
+	logger.Debug("hidden")
+	logger.Info("using api_key=sk-secret123 for %s", "openai")
+	logger.WithContext("trace=abc").Error(`request failed: "quoted"`)
+
+	var info map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatalf("info line is not one JSON object: %v: %s", err, stdout.String())
+	}
+	if info["level"] != "info" || info["msg"] != "using api_key=[REDACTED] for openai" {
+		t.Errorf("info line = %v", info)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, info["ts"]); err != nil {
+		t.Errorf("ts = %q: %v", info["ts"], err)
+	}
+	if _, ok := info["ctx"]; ok {
+		t.Errorf("info line without context has ctx: %v", info)
+	}
+
+	var failure map[string]string
+	if err := json.Unmarshal(stderr.Bytes(), &failure); err != nil {
+		t.Fatalf("error line is not one JSON object: %v: %s", err, stderr.String())
+	}
+	if failure["level"] != "error" || failure["ctx"] != "trace=abc" || failure["msg"] != `request failed: "quoted"` {
+		t.Errorf("error line = %v", failure)
+	}
+}
+
+func TestColorLines(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := NewLoggerWithOutput(LogLevelDebug, LogOutput(ColorLines(&console), &file, false), LogOutput(ColorLines(&console), &file, false))
+	logger.Debug("walking tree")
+	logger.Info("querying model")
+	logger.Error("request failed")
+
+	lines := strings.Split(strings.TrimSuffix(console.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("console = %q, want 3 lines", console.String())
+	}
+	for i, want := range []struct{ prefix, suffix string }{
+		{"\x1b[2m[DEBUG] ", "walking tree\x1b[0m"},
+		{"[INFO]  ", "querying model"},
+		{"\x1b[31m[ERROR] ", "request failed\x1b[0m"},
+	} {
+		if !strings.HasPrefix(lines[i], want.prefix) || !strings.HasSuffix(lines[i], want.suffix) {
+			t.Errorf("line %d = %q, want %q...%q", i, lines[i], want.prefix, want.suffix)
+		}
+	}
+	if strings.Contains(file.String(), "\x1b[") {
+		t.Errorf("log file got colors: %q", file.String())
+	}
+}
+
+func TestStandardLogger_WithCaller(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerWithOutput(LogLevelDebug, &buf, &buf).WithCaller(true)
+	_, _, line, _ := runtime.Caller(0)
+	logger.Debug("direct")
+	logger.WithContext("trace=abc").Info("wrapped")
+
+	want := []string{
+		fmt.Sprintf("logger_test.go:%d: direct", line+1),
+		fmt.Sprintf("logger_test.go:%d: [trace=abc] wrapped", line+2),
+	}
+	for _, w := range want {
+		if !strings.Contains(buf.String(), w) {
+			t.Errorf("output missing %q:\n%s", w, buf.String())
+		}
+	}
+
+	// Only at debug level
+	buf.Reset()
+	logger.SetLevel(LogLevelInfo)
+	logger.Info("quiet")
+	if strings.Contains(buf.String(), "logger_test.go") {
+		t.Errorf("caller logged above debug level: %s", buf.String())
+	}
+
+	// Off by default
+	buf.Reset()
+	NewLoggerWithOutput(LogLevelDebug, &buf, &buf).Debug("plain")
+	if strings.Contains(buf.String(), "logger_test.go") {
+		t.Errorf("caller logged by default: %s", buf.String())
+	}
+}
+
+func TestRunLogger_CallerFromEnv(t *testing.T) {
+	t.Setenv("SORTPATH_LOG_CALLER", "1")
+	var buf bytes.Buffer
+	logger := NewRunLogger(LogLevelDebug, LogFormatJSON, "abc", &buf)
+	_, _, line, _ := runtime.Caller(0)
+	logger.Debug("hello")
+
+	var got map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("not one JSON object: %v: %s", err, buf.String())
+	}
+	if want := fmt.Sprintf("logger_test.go:%d", line+1); got["caller"] != want {
+		t.Errorf("caller = %q, want %q", got["caller"], want)
+	}
+}
+
+func TestParseLogFormat(t *testing.T) {
+	for in, want := range map[string]LogFormat{"json": LogFormatJSON, "JSON": LogFormatJSON, "text": LogFormatText, "": LogFormatText, "xml": LogFormatText} {
+		if got := ParseLogFormat(in); got != want {
+			t.Errorf("ParseLogFormat(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestNewCorrelationID(t *testing.T) {
+	a, b := NewCorrelationID(), NewCorrelationID()
+	if len(a) != 32 {
+		t.Errorf("Expected 32-character ID, got %q", a)
+	}
+	if a == b {
+		t.Errorf("Expected unique IDs, got %q twice", a)
+	}
+}
+
+func TestNoopLogger(t *testing.T) {
+	var logger Logger = NoopLogger{}
+
+	// Nothing may reach the process's stdout or stderr
+	origStdout, origStderr := os.Stdout, os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = w, w
+	defer func() { os.Stdout, os.Stderr = origStdout, origStderr }()
+
+	logger.SetLevel(LogLevelDebug)
+	logger.Debug("debug %s", "message")
+	logger.Info("api_key=%s", "sk-secret")
+	logger.Error("error: %v", errors.New("boom"))
+
+	w.Close()
+	var captured bytes.Buffer
+	captured.ReadFrom(r)
+	if captured.Len() != 0 {
+		t.Errorf("NoopLogger wrote %q", captured.String())
+	}
+	if got := logger.GetLevel(); got != LogLevelSilent {
+		t.Errorf("GetLevel() = %v, want silent even after SetLevel", got)
+	}
+}
+
+func TestLogOutput_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "sortpath.log")
+	for run := 1; run <= 2; run++ {
+		f, err := config.DefaultSecureFileOps.OpenSecureAppend(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var console bytes.Buffer
+		logger := NewRunLogger(LogLevelInfo, LogFormatText, "abc", LogOutput(&console, f, run == 2))
+		logger.Info("run %d with api_key=sk-secret123", run)
+		logger.Error("run %d failed", run)
+		f.Close()
+
+		if run == 1 && !strings.Contains(console.String(), "run 1 failed") {
+			t.Errorf("console = %q, want the log lines too", console.String())
+		}
+		if run == 2 && console.Len() != 0 {
+			t.Errorf("file-only run wrote to the console: %q", console.String())
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if strings.Contains(got, "sk-secret123") {
+		t.Errorf("log file holds the secret: %s", got)
+	}
+	for _, want := range []string{"[trace=abc] run 1 with api_key=[REDACTED]", "run 1 failed", "run 2 with api_key=[REDACTED]", "run 2 failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log file missing %q:\n%s", want, got)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("log file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
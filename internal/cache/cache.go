@@ -0,0 +1,187 @@
+// Package cache manages the files sortpath keeps in its cache directory,
+// $XDG_CACHE_HOME/sortpath or ~/.cache/sortpath.
+//
+// Cached data lives in one subdirectory per kind (e.g. health). Files at the
+// top of the directory are state markers such as the last update check and
+// are never pruned or cleared.
+package cache
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// tempGrace keeps in-progress atomic writes from being pruned; older temp
+// files are leftovers of an interrupted write
+const tempGrace = time.Hour
+
+// Dir returns the sortpath cache directory (see config.CacheDir)
+func Dir() string {
+	return config.CacheDir()
+}
+
+// Entry is one cached file
+type Entry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// PruneOptions select which entries Prune removes. Zero values disable a
+// limit.
+type PruneOptions struct {
+	// MaxAge removes entries last modified longer ago than this
+	MaxAge time.Duration
+	// MaxSize removes the least recently modified entries until the rest fit
+	MaxSize int64
+	// Now is the reference time for MaxAge; defaults to time.Now
+	Now time.Time
+}
+
+// Result reports what a prune or clear removed
+type Result struct {
+	Removed  int
+	Freed    int64
+	Kept     int
+	KeptSize int64
+}
+
+// Entries lists the cached files under root, oldest first. A missing root
+// has no entries.
+func Entries(root string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Entries removed while walking are not an error
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Dir(path) == root {
+			return nil
+		}
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		entries = append(entries, Entry{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.Before(entries[j].ModTime)
+	})
+	return entries, nil
+}
+
+// Prune removes entries older than opts.MaxAge, then the least recently
+// modified entries until the remainder fits in opts.MaxSize. An entry that
+// another process rewrites or removes meanwhile is left alone.
+func Prune(root string, opts PruneOptions) (Result, error) {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	entries, err := Entries(root)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	var res Result
+	for _, e := range entries {
+		age := opts.Now.Sub(e.ModTime)
+		expired := opts.MaxAge > 0 && age > opts.MaxAge
+		overBudget := opts.MaxSize > 0 && total > opts.MaxSize
+		if isTemp(e.Path) && age < tempGrace {
+			expired, overBudget = false, false
+		}
+		if !expired && !overBudget {
+			res.Kept++
+			res.KeptSize += e.Size
+			continue
+		}
+		removed, err := remove(e)
+		if err != nil {
+			return res, err
+		}
+		if removed {
+			res.Removed++
+			res.Freed += e.Size
+		}
+		total -= e.Size
+	}
+	removeEmptyDirs(root)
+	return res, nil
+}
+
+// Clear removes every cache entry under root
+func Clear(root string) (Result, error) {
+	entries, err := Entries(root)
+	if err != nil {
+		return Result{}, err
+	}
+	var res Result
+	for _, e := range entries {
+		if err := config.DefaultSecureFileOps.RemoveFile(e.Path); err != nil {
+			return res, err
+		}
+		res.Removed++
+		res.Freed += e.Size
+	}
+	removeEmptyDirs(root)
+	return res, nil
+}
+
+// remove deletes e unless it changed since it was listed, reporting whether
+// this call removed it
+func remove(e Entry) (bool, error) {
+	info, err := os.Lstat(e.Path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.ModTime().Equal(e.ModTime) {
+		return false, nil
+	}
+	return true, config.DefaultSecureFileOps.RemoveFile(e.Path)
+}
+
+func isTemp(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".tmp-")
+}
+
+// removeEmptyDirs drops cache subdirectories left empty. os.Remove fails on
+// a directory that is not empty, so one refilled concurrently survives.
+func removeEmptyDirs(root string) {
+	var dirs []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
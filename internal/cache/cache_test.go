@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// writeEntry creates a cache file of size bytes last modified age ago
+func writeEntry(t *testing.T, root, name string, size int, age time.Duration) string {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := testNow.Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func TestPrune_MaxAge(t *testing.T) {
+	root := t.TempDir()
+	old := writeEntry(t, root, "health/old", 100, 10*24*time.Hour)
+	fresh := writeEntry(t, root, "health/fresh", 50, time.Hour)
+	marker := writeEntry(t, root, "last-check", 10, 30*24*time.Hour)
+
+	res, err := Prune(root, PruneOptions{MaxAge: 7 * 24 * time.Hour, Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 1 || res.Freed != 100 || res.Kept != 1 || res.KeptSize != 50 {
+		t.Errorf("result = %+v", res)
+	}
+	if exists(old) || !exists(fresh) {
+		t.Errorf("old kept = %v, fresh kept = %v", exists(old), exists(fresh))
+	}
+	if !exists(marker) {
+		t.Error("top-level state marker was pruned")
+	}
+}
+
+func TestPrune_MaxSizeRemovesOldestFirst(t *testing.T) {
+	root := t.TempDir()
+	oldest := writeEntry(t, root, "trees/a", 400, 3*time.Hour)
+	middle := writeEntry(t, root, "responses/b", 400, 2*time.Hour)
+	newest := writeEntry(t, root, "responses/c", 400, time.Hour)
+
+	res, err := Prune(root, PruneOptions{MaxSize: 900, Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 1 || res.Freed != 400 {
+		t.Errorf("result = %+v", res)
+	}
+	if exists(oldest) || !exists(middle) || !exists(newest) {
+		t.Error("expected only the oldest entry to be removed")
+	}
+	if exists(filepath.Join(root, "trees")) {
+		t.Error("empty cache subdirectory was kept")
+	}
+}
+
+func TestPrune_SkipsFreshTempFiles(t *testing.T) {
+	root := t.TempDir()
+	writing := writeEntry(t, root, "health/.tmp-sortpath-1", 100, time.Minute)
+	stale := writeEntry(t, root, "health/.tmp-sortpath-2", 100, 2*time.Hour)
+
+	if _, err := Prune(root, PruneOptions{MaxSize: 1, Now: testNow}); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(writing) || exists(stale) {
+		t.Errorf("in-progress kept = %v, stale kept = %v", exists(writing), exists(stale))
+	}
+}
+
+func TestPrune_EntryChangedConcurrently(t *testing.T) {
+	root := t.TempDir()
+	path := writeEntry(t, root, "health/a", 100, 48*time.Hour)
+	entries, err := Entries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process refreshes the entry after it was listed
+	if err := os.Chtimes(path, testNow, testNow); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := remove(entries[0])
+	if err != nil || removed || !exists(path) {
+		t.Errorf("remove() = %v, %v; refreshed entry must be kept", removed, err)
+	}
+
+	// ...or deletes it
+	os.Remove(path)
+	if removed, err := remove(entries[0]); err != nil || removed {
+		t.Errorf("remove() of a vanished entry = %v, %v", removed, err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	root := t.TempDir()
+	writeEntry(t, root, "health/a", 10, time.Hour)
+	writeEntry(t, root, "responses/nested/b", 20, 0)
+	marker := writeEntry(t, root, "install-declined", 0, 0)
+
+	res, err := Clear(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 2 || res.Freed != 30 {
+		t.Errorf("result = %+v", res)
+	}
+	entries, _ := Entries(root)
+	if len(entries) != 0 || !exists(marker) {
+		t.Errorf("entries left = %v, marker kept = %v", entries, exists(marker))
+	}
+
+	if res, err := Clear(filepath.Join(root, "missing")); err != nil || res.Removed != 0 {
+		t.Errorf("Clear(missing) = %+v, %v", res, err)
+	}
+}
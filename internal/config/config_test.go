@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestConfig_Validation(t *testing.T) {
@@ -48,6 +49,57 @@ func TestConfig_Validation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid log level",
 		},
+		{
+			name: "invalid pinned certificate fingerprint",
+			config: Config{
+				APIKey:           "test-key",
+				APIBase:          "https://api.openai.com/v1",
+				Model:            "gpt-3.5-turbo",
+				TreePath:         "/tmp",
+				LogLevel:         "info",
+				PinnedCertSHA256: "not-a-fingerprint",
+			},
+			wantErr: true,
+			errMsg:  "invalid SHA-256 fingerprint",
+		},
+		{
+			name: "zero temperature",
+			config: Config{
+				APIKey:      "test-key",
+				APIBase:     "https://api.openai.com/v1",
+				Model:       "gpt-3.5-turbo",
+				TreePath:    "/tmp",
+				LogLevel:    "info",
+				Temperature: "0",
+			},
+			wantErr: false,
+		},
+		{
+			name: "temperature out of range",
+			config: Config{
+				APIKey:      "test-key",
+				APIBase:     "https://api.openai.com/v1",
+				Model:       "gpt-3.5-turbo",
+				TreePath:    "/tmp",
+				LogLevel:    "info",
+				Temperature: "2.5",
+			},
+			wantErr: true,
+			errMsg:  "invalid temperature",
+		},
+		{
+			name: "non-positive max tokens",
+			config: Config{
+				APIKey:    "test-key",
+				APIBase:   "https://api.openai.com/v1",
+				Model:     "gpt-3.5-turbo",
+				TreePath:  "/tmp",
+				LogLevel:  "info",
+				MaxTokens: "0",
+			},
+			wantErr: true,
+			errMsg:  "invalid max tokens",
+		},
 	}
 
 	for _, tt := range tests {
@@ -87,6 +139,7 @@ log_level: debug
 	}
 
 	loader := &FileLoader{ConfigPath: configPath}
+	stubEnvironment(t, "interactive")
 
 	tests := []struct {
 		name     string
@@ -403,6 +456,65 @@ func TestFileLoader_ErrorHandling(t *testing.T) {
 	})
 }
 
+func TestFileLoader_ConfigDirConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dir string)
+		wantCode string
+		wantMsg  string
+	}{
+		{
+			name: "file at config dir path",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(dir, []byte("not a dir"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantCode: "config_dir_not_directory",
+			wantMsg:  "is a file, not a directory",
+		},
+		{
+			name: "dangling symlink at config dir path",
+			setup: func(t *testing.T, dir string) {
+				if err := os.Symlink(filepath.Join(filepath.Dir(dir), "missing"), dir); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantCode: "config_dir_broken_symlink",
+			wantMsg:  "which does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "sortpath")
+			tt.setup(t, dir)
+			loader := &FileLoader{ConfigPath: filepath.Join(dir, "config.yaml")}
+
+			check := func(op string, err error) {
+				t.Helper()
+				cfgErr, ok := err.(*ConfigError)
+				if !ok {
+					t.Fatalf("%s error = %v (%T), want *ConfigError", op, err, err)
+				}
+				if cfgErr.Code != tt.wantCode {
+					t.Errorf("%s error code = %s, want %s", op, cfgErr.Code, tt.wantCode)
+				}
+				if !contains(err.Error(), dir) || !contains(err.Error(), tt.wantMsg) {
+					t.Errorf("%s error = %q, want it to name %s and contain %q", op, err, dir, tt.wantMsg)
+				}
+			}
+
+			_, err := loader.Load()
+			check("Load()", err)
+			check("Save()", loader.Save(&Config{Model: "gpt-4"}))
+
+			_, err = ResolveConfigWithLoader(CLIOptions{APIKey: "k", TreePath: t.TempDir()}, loader)
+			check("ResolveConfigWithLoader()", err)
+		})
+	}
+}
+
 func TestEnvironmentVariableNames(t *testing.T) {
 	// Test that we're using the correct environment variable names
 	envVars := map[string]string{
@@ -507,4 +619,128 @@ func TestConfig_URLValidation(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestConfigPath_Precedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SORTPATH_CONFIG", "")
+	t.Cleanup(func() { SetConfigPath("") })
+
+	if got, want := ConfigPath(), filepath.Join(home, ".config", "sortpath", "config.yaml"); got != want {
+		t.Errorf("default ConfigPath() = %q, want %q", got, want)
+	}
+
+	t.Setenv("SORTPATH_CONFIG", "/env/config.yaml")
+	if got := ConfigPath(); got != "/env/config.yaml" {
+		t.Errorf("env ConfigPath() = %q, want /env/config.yaml", got)
+	}
+
+	SetConfigPath("/flag/config.yaml")
+	if got := ConfigPath(); got != "/flag/config.yaml" {
+		t.Errorf("flag ConfigPath() = %q, want /flag/config.yaml (flag beats env)", got)
+	}
+	if got := NewFileLoader().ConfigPath; got != "/flag/config.yaml" {
+		t.Errorf("NewFileLoader().ConfigPath = %q, want the --config path", got)
+	}
+}
+
+func TestCheckConfigPath(t *testing.T) {
+	t.Cleanup(func() { SetConfigPath("") })
+	path := filepath.Join(t.TempDir(), "client.yaml")
+
+	// Only a file chosen with --config has to exist
+	SetConfigPath("")
+	if err := CheckConfigPath(); err != nil {
+		t.Errorf("CheckConfigPath() = %v without --config", err)
+	}
+	SetConfigPath(path)
+	if err := CheckConfigPath(); err == nil || !contains(err.Error(), "does not exist") {
+		t.Errorf("CheckConfigPath() = %v, want a missing --config file reported", err)
+	}
+	if err := os.WriteFile(path, []byte("model: gpt-4\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckConfigPath(); err != nil {
+		t.Errorf("CheckConfigPath() = %v for an existing file", err)
+	}
+}
+
+func TestResolveConfig_ConfigPathOption(t *testing.T) {
+	stubEnvironment(t, "interactive")
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range []string{"OPENAI_API_KEY", "OPENAI_MODEL", "SORTPATH_CONFIG"} {
+		t.Setenv(name, "")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "client.yaml")
+	if err := os.WriteFile(path, []byte("api_key: sk-client\nmodel: client-model\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := ResolveConfig(CLIOptions{ConfigPath: path, TreePath: dir})
+	if err != nil {
+		t.Fatalf("ResolveConfig() error = %v", err)
+	}
+	if conf.APIKey != "sk-client" || conf.Model != "client-model" {
+		t.Errorf("ResolveConfig() with ConfigPath = key %q, model %q; want values from %s", conf.APIKey, conf.Model, path)
+	}
+}
+
+func TestConfig_APIKeyRequirement(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiBase string
+		noAuth  bool
+		wantKey bool
+	}{
+		{name: "remote host", apiBase: "https://api.openai.com/v1", wantKey: true},
+		{name: "remote http host", apiBase: "http://192.168.1.20:11434/v1", wantKey: true},
+		{name: "localhost", apiBase: "http://localhost:11434/v1"},
+		{name: "localhost upper case", apiBase: "http://LOCALHOST:11434/v1"},
+		{name: "ipv4 loopback", apiBase: "http://127.0.0.1:8080/v1"},
+		{name: "ipv6 loopback", apiBase: "http://[::1]:8080/v1"},
+		{name: "lookalike host", apiBase: "https://localhost.example.com/v1", wantKey: true},
+		{name: "remote host with --no-auth", apiBase: "http://192.168.1.20:11434/v1", noAuth: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{APIBase: tt.apiBase, Model: "llama3", TreePath: "/tmp", LogLevel: "info", NoAuth: tt.noAuth}
+			err := c.Validate()
+			if tt.wantKey && (err == nil || !contains(err.Error(), "API key is required")) {
+				t.Errorf("Validate() = %v, want the API key to be required", err)
+			}
+			if !tt.wantKey && err != nil {
+				t.Errorf("Validate() = %v, want no API key needed", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Timeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{timeout: "", want: 0},
+		{timeout: "30s", want: 30 * time.Second},
+		{timeout: "2m", want: 2 * time.Minute},
+		{timeout: "1d", want: 24 * time.Hour},
+		{timeout: "0", want: 0, wantErr: true},
+		{timeout: "-5s", want: 0, wantErr: true},
+		{timeout: "soon", want: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeout, func(t *testing.T) {
+			if err := ValidateTimeout(tt.timeout); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTimeout(%q) = %v, wantErr %v", tt.timeout, err, tt.wantErr)
+			}
+			c := Config{Timeout: tt.timeout}
+			if got := c.RequestTimeout(); got != tt.want {
+				t.Errorf("RequestTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
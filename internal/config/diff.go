@@ -0,0 +1,37 @@
+package config
+
+// FieldDiff is a config key whose resolved value differs from its default.
+// Value and Default are redacted like FieldSource values.
+type FieldDiff struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+	Source  string `json:"source"`
+}
+
+// DiffFromDefaults returns the ConfigKeys entries in sources that were set by
+// a non-default layer to something other than the default, in ConfigKeys order
+func DiffFromDefaults(sources []FieldSource) []FieldDiff {
+	byKey := make(map[string]FieldSource, len(sources))
+	for _, s := range sources {
+		byKey[s.Key] = s
+	}
+
+	var diffs []FieldDiff
+	for _, key := range ConfigKeys {
+		s, ok := byKey[key]
+		if !ok || s.Source == SourceDefault {
+			continue
+		}
+		def, _ := defaults.Value(key)
+		shown := "(unset)"
+		if def != "" {
+			shown = RedactSensitiveValue(key, def)
+		}
+		if s.Value == shown {
+			continue
+		}
+		diffs = append(diffs, FieldDiff{Key: key, Value: s.Value, Default: shown, Source: s.Source})
+	}
+	return diffs
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HomeDir returns the user's home directory. When it can't be determined
+// (no $HOME), a per-user folder under the temp directory stands in, so
+// sortpath never writes to /.config or the working directory.
+func HomeDir() string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		return home
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("sortpath-%d", os.Getuid()))
+}
+
+// ConfigDir returns the sortpath config directory: $XDG_CONFIG_HOME/sortpath,
+// or ~/.config/sortpath
+func ConfigDir() string {
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "sortpath")
+}
+
+// CacheDir returns the sortpath cache directory: $XDG_CACHE_HOME/sortpath,
+// or ~/.cache/sortpath
+func CacheDir() string {
+	return filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), "sortpath")
+}
+
+// xdgDir returns the XDG base directory in envVar, or fallback under the
+// home directory. The spec says relative values are invalid, so they are
+// ignored.
+func xdgDir(envVar, fallback string) string {
+	if dir := os.Getenv(envVar); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(HomeDir(), fallback)
+}
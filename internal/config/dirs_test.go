@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigAndCacheDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SORTPATH_CONFIG", "")
+	SetConfigPath("")
+
+	tests := []struct {
+		name       string
+		configHome string
+		cacheHome  string
+		wantConfig string
+		wantCache  string
+	}{
+		{name: "defaults", wantConfig: filepath.Join(home, ".config", "sortpath"), wantCache: filepath.Join(home, ".cache", "sortpath")},
+		{name: "xdg", configHome: "/xdg/config", cacheHome: "/xdg/cache", wantConfig: "/xdg/config/sortpath", wantCache: "/xdg/cache/sortpath"},
+		{name: "relative xdg values are ignored", configHome: "rel/config", cacheHome: "rel/cache", wantConfig: filepath.Join(home, ".config", "sortpath"), wantCache: filepath.Join(home, ".cache", "sortpath")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", tt.configHome)
+			t.Setenv("XDG_CACHE_HOME", tt.cacheHome)
+			if got := ConfigDir(); got != tt.wantConfig {
+				t.Errorf("ConfigDir() = %q, want %q", got, tt.wantConfig)
+			}
+			if got := ConfigPath(); got != filepath.Join(tt.wantConfig, "config.yaml") {
+				t.Errorf("ConfigPath() = %q, want config.yaml in %q", got, tt.wantConfig)
+			}
+			if got := CacheDir(); got != tt.wantCache {
+				t.Errorf("CacheDir() = %q, want %q", got, tt.wantCache)
+			}
+		})
+	}
+}
+
+func TestDefaultConfigPath_Legacy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	want := filepath.Join(xdg, "sortpath", "config.yaml")
+	if got := DefaultConfigPath(); got != want {
+		t.Errorf("DefaultConfigPath() with no config = %q, want %q", got, want)
+	}
+
+	legacy := filepath.Join(home, ".config", "sortpath", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("model: gpt-4o\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultConfigPath(); got != legacy {
+		t.Errorf("DefaultConfigPath() with only the legacy file = %q, want %q", got, legacy)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(want), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(want, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultConfigPath(); got != want {
+		t.Errorf("DefaultConfigPath() with both files = %q, want the XDG one %q", got, want)
+	}
+}
+
+func TestHomeDir_Missing(t *testing.T) {
+	t.Setenv("HOME", "")
+	home := HomeDir()
+	if home == "" || !strings.HasPrefix(home, os.TempDir()) {
+		t.Errorf("HomeDir() without $HOME = %q, want a folder under %s", home, os.TempDir())
+	}
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if dir := ConfigDir(); !strings.HasPrefix(dir, home) {
+		t.Errorf("ConfigDir() without $HOME = %q, want it under %q", dir, home)
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"bufio"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
 // EnvironmentDetector provides utilities for detecting the runtime environment
@@ -53,6 +57,16 @@ func (e *EnvironmentDetector) IsNonInteractive() bool {
 	return false
 }
 
+// StdinIsPiped reports whether stdin is a pipe or a regular file, i.e. input
+// was redirected into the process rather than left on a terminal or /dev/null
+func (e *EnvironmentDetector) StdinIsPiped() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeNamedPipe != 0 || fi.Mode().IsRegular()
+}
+
 // isRunningInContainer detects if running inside a container
 func (e *EnvironmentDetector) isRunningInContainer() bool {
 	// Check for Docker
@@ -101,6 +115,11 @@ var DefaultEnvironmentDetector = &EnvironmentDetector{}
 // EdgeCaseHandler provides utilities for handling edge cases
 type EdgeCaseHandler struct {
 	envDetector *EnvironmentDetector
+
+	// declined holds the config directories the user chose not to move, so
+	// each run asks about a conflict once however often the file is loaded
+	mu       sync.Mutex
+	declined map[string]bool
 }
 
 // NewEdgeCaseHandler creates a new EdgeCaseHandler
@@ -177,6 +196,85 @@ func (h *EdgeCaseHandler) HandlePermissionError(path string, operation string) e
 	}
 }
 
+// CheckConfigDir verifies that dir is either missing or a usable directory.
+// A regular file or a dangling symlink at that path yields a ConfigError that
+// names the path and the conflict.
+func (h *EdgeCaseHandler) CheckConfigDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		// Missing directories are created on save; other errors surface later
+		return nil
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(dir)
+		resolved, statErr := os.Stat(dir)
+		if statErr != nil {
+			return &ConfigError{
+				Code:    "config_dir_broken_symlink",
+				Message: fmt.Sprintf("config directory %s is a symlink to %s, which does not exist", dir, target),
+				Cause:   statErr,
+				Context: map[string]interface{}{
+					"path":       dir,
+					"target":     target,
+					"suggestion": fmt.Sprintf("Create %s or remove the symlink with: rm %s", target, dir),
+				},
+			}
+		}
+		info = resolved
+	}
+
+	if !info.IsDir() {
+		return &ConfigError{
+			Code:    "config_dir_not_directory",
+			Message: fmt.Sprintf("config directory %s exists but is a file, not a directory", dir),
+			Context: map[string]interface{}{
+				"path":       dir,
+				"suggestion": fmt.Sprintf("Move the file out of the way with: mv %s %s.bak", dir, dir),
+			},
+		}
+	}
+
+	return nil
+}
+
+// HandleConfigDirConflict resolves a CheckConfigDir error. In interactive
+// mode the user may move the conflicting entry aside so a fresh directory can
+// be created; otherwise the original error is returned.
+func (h *EdgeCaseHandler) HandleConfigDirConflict(dir string, conflict error) error {
+	if conflict == nil || !h.envDetector.ShouldPromptUser() {
+		return conflict
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.declined[dir] {
+		return conflict
+	}
+
+	backup := fmt.Sprintf("%s.bak-%s", dir, time.Now().Format("20060102150405"))
+	fmt.Fprintf(os.Stderr, "⚠️ %v\n", conflict)
+	fmt.Fprintf(os.Stderr, "Move it to %s and create a fresh config directory? [y/N]: ", backup)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	if answer != "y" && answer != "yes" {
+		if h.declined == nil {
+			h.declined = map[string]bool{}
+		}
+		h.declined[dir] = true
+		return conflict
+	}
+
+	if err := os.Rename(dir, backup); err != nil {
+		return &ConfigError{
+			Code:    "config_dir_relocate_failed",
+			Message: fmt.Sprintf("failed to move %s to %s", dir, backup),
+			Cause:   err,
+			Context: map[string]interface{}{"path": dir},
+		}
+	}
+	return nil
+}
+
 // ConfigError represents a configuration-related error with context
 type ConfigError struct {
 	Code    string
@@ -0,0 +1,48 @@
+package config
+
+import "os"
+
+// EnvProfile holds settings applied automatically in a detected environment
+// type (see EnvironmentDetector.GetEnvironmentType). Profile values beat the
+// config file but lose to flags and environment variables.
+type EnvProfile struct {
+	LogLevel string `yaml:"log_level,omitempty"`
+
+	// SkipPrompts turns off the install prompt and the update check
+	SkipPrompts *bool `yaml:"skip_prompts,omitempty"`
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// builtinProfiles keep automated runs quiet and never wait for input
+var builtinProfiles = map[string]EnvProfile{
+	"ci":        {LogLevel: "error", SkipPrompts: boolPtr(true)},
+	"container": {LogLevel: "error", SkipPrompts: boolPtr(true)},
+}
+
+// detectEnvironment names the current environment type; tests replace it
+var detectEnvironment = DefaultEnvironmentDetector.GetEnvironmentType
+
+// currentEnvironment returns SORTPATH_ENVIRONMENT when set, so a profile can
+// be forced or disabled (e.g. "interactive"), and the detected type otherwise
+func currentEnvironment() string {
+	if env := os.Getenv("SORTPATH_ENVIRONMENT"); env != "" {
+		return env
+	}
+	return detectEnvironment()
+}
+
+// profileFor returns the built-in profile for env with any fields set in the
+// config file's environments section layered on top
+func profileFor(env string, overrides map[string]EnvProfile) EnvProfile {
+	profile := builtinProfiles[env]
+	if o, ok := overrides[env]; ok {
+		if o.LogLevel != "" {
+			profile.LogLevel = o.LogLevel
+		}
+		if o.SkipPrompts != nil {
+			profile.SkipPrompts = o.SkipPrompts
+		}
+	}
+	return profile
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stubEnvironment makes environment detection report env
+func stubEnvironment(t *testing.T, env string) {
+	t.Helper()
+	orig := detectEnvironment
+	detectEnvironment = func() string { return env }
+	t.Setenv("SORTPATH_ENVIRONMENT", "")
+	t.Cleanup(func() { detectEnvironment = orig })
+}
+
+func TestEnvProfile_CIOverrides(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("api_key: k\nlog_level: debug\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := &FileLoader{ConfigPath: configPath}
+	t.Setenv("SORTPATH_LOG_LEVEL", "")
+
+	tests := []struct {
+		name            string
+		env             string
+		opts            CLIOptions
+		envLogLevel     string
+		wantLogLevel    string
+		wantSource      string
+		wantSkipPrompts bool
+	}{
+		{name: "interactive uses the file", env: "interactive", wantLogLevel: "debug", wantSource: SourceFile},
+		{name: "ci profile beats the file", env: "ci", wantLogLevel: "error", wantSource: SourceProfile, wantSkipPrompts: true},
+		{name: "container profile", env: "container", wantLogLevel: "error", wantSource: SourceProfile, wantSkipPrompts: true},
+		{name: "flag beats the profile", env: "ci", opts: CLIOptions{LogLevel: "info"}, wantLogLevel: "info", wantSource: SourceCLI, wantSkipPrompts: true},
+		{name: "env var beats the profile", env: "ci", envLogLevel: "debug", wantLogLevel: "debug", wantSource: SourceEnv, wantSkipPrompts: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubEnvironment(t, tt.env)
+			t.Setenv("SORTPATH_LOG_LEVEL", tt.envLogLevel)
+			tt.opts.TreePath = dir
+
+			conf, sources, err := ResolveConfigWithSources(tt.opts, loader, NewFileSecretStore(loader))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conf.LogLevel != tt.wantLogLevel {
+				t.Errorf("LogLevel = %q, want %q", conf.LogLevel, tt.wantLogLevel)
+			}
+			if conf.SkipPrompts != tt.wantSkipPrompts || conf.Environment != tt.env {
+				t.Errorf("SkipPrompts = %v, Environment = %q; want %v, %q", conf.SkipPrompts, conf.Environment, tt.wantSkipPrompts, tt.env)
+			}
+			for _, s := range sources {
+				if s.Key == "log-level" && s.Source != tt.wantSource {
+					t.Errorf("log-level source = %q, want %q", s.Source, tt.wantSource)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvProfile_FileOverridesAndForcedEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	content := "api_key: k\nenvironments:\n  ci:\n    log_level: info\n    skip_prompts: false\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := &FileLoader{ConfigPath: configPath}
+	t.Setenv("SORTPATH_LOG_LEVEL", "")
+
+	stubEnvironment(t, "ci")
+	conf, err := ResolveConfigWithLoader(CLIOptions{TreePath: dir}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.LogLevel != "info" || conf.SkipPrompts {
+		t.Errorf("LogLevel = %q, SkipPrompts = %v; want the file's ci overrides", conf.LogLevel, conf.SkipPrompts)
+	}
+
+	// SORTPATH_ENVIRONMENT forces a profile regardless of detection
+	stubEnvironment(t, "interactive")
+	t.Setenv("SORTPATH_ENVIRONMENT", "container")
+	conf, err = ResolveConfigWithLoader(CLIOptions{TreePath: dir}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Environment != "container" || conf.LogLevel != "error" || !conf.SkipPrompts {
+		t.Errorf("forced container profile not applied: %+v", conf)
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "strings"
+
+// ParseRules splits an extra-rules value into rules, one per line. Blank
+// lines are dropped, as is a leading "- " so the value can be written as a
+// bulleted list.
+func ParseRules(value string) []string {
+	var rules []string
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "-" || strings.HasPrefix(line, "- ") {
+			line = strings.TrimSpace(line[1:])
+		}
+		if line != "" {
+			rules = append(rules, line)
+		}
+	}
+	return rules
+}
+
+// Rules returns the configured extra prompt rules
+func (c *Config) Rules() []string {
+	return ParseRules(c.ExtraRules)
+}
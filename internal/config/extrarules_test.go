@@ -0,0 +1,17 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	value := "- All PDFs go under 06_DOCS\n\n  Never create folders with spaces  \n-   \n"
+	want := []string{"All PDFs go under 06_DOCS", "Never create folders with spaces"}
+	if got := ParseRules(value); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRules() = %q, want %q", got, want)
+	}
+	if got := ParseRules(""); got != nil {
+		t.Errorf("ParseRules(\"\") = %q, want nil", got)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// KeychainSentinel stands in the config file for a secret kept in the OS
+// keychain
+const KeychainSentinel = "keychain:"
+
+// keychainService is the service name secrets are filed under
+const keychainService = "sortpath"
+
+// ErrKeychainUnavailable is returned when there is no usable OS keychain
+var ErrKeychainUnavailable = errors.New("no keychain is available")
+
+// ErrKeychainNotFound is returned when the keychain has no such secret
+var ErrKeychainNotFound = errors.New("secret not found in the keychain")
+
+// Keychain stores secrets by account name in an OS credential store
+type Keychain interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// osKeychain uses the macOS Keychain through security(1) and the Secret
+// Service on Linux through secret-tool(1). Other systems, or a missing tool,
+// give ErrKeychainUnavailable.
+type osKeychain struct{}
+
+// runKeychainTool runs a keychain command with stdin and returns its
+// trimmed stdout; tests replace it
+var runKeychainTool = func(stdin, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", ErrKeychainUnavailable
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && keychainNotFound(name, exitErr.ExitCode()) {
+			return "", ErrKeychainNotFound
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// keychainNotFound reports the exit codes the tools use for a missing item
+func keychainNotFound(tool string, code int) bool {
+	return (tool == "security" && code == 44) || (tool == "secret-tool" && code == 1)
+}
+
+func (osKeychain) Get(account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return runKeychainTool("", "security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	case "linux":
+		return runKeychainTool("", "secret-tool", "lookup", "service", keychainService, "account", account)
+	}
+	return "", ErrKeychainUnavailable
+}
+
+func (osKeychain) Set(account, secret string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		// security(1) only takes the secret as an argument; a quoted
+		// command line for security -i would mangle quotes and spaces
+		_, err = runKeychainTool("", "security", "add-generic-password", "-U", "-s", keychainService, "-a", account, "-w", secret)
+	case "linux":
+		_, err = runKeychainTool(secret, "secret-tool", "store", "--label=sortpath "+account, "service", keychainService, "account", account)
+	default:
+		err = ErrKeychainUnavailable
+	}
+	return err
+}
+
+func (osKeychain) Delete(account string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = runKeychainTool("", "security", "delete-generic-password", "-s", keychainService, "-a", account)
+	case "linux":
+		_, err = runKeychainTool("", "secret-tool", "clear", "service", keychainService, "account", account)
+	default:
+		err = ErrKeychainUnavailable
+	}
+	if errors.Is(err, ErrKeychainNotFound) {
+		return nil
+	}
+	return err
+}
+
+// DefaultKeychain is the OS keychain used by FileSecretStore; tests replace it
+var DefaultKeychain Keychain = osKeychain{}
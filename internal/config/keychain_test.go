@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeKeychain is an in-memory Keychain; unavailable makes every call fail
+// like a system without one
+type fakeKeychain struct {
+	items       map[string]string
+	unavailable bool
+}
+
+func (k *fakeKeychain) Get(account string) (string, error) {
+	if k.unavailable {
+		return "", ErrKeychainUnavailable
+	}
+	secret, ok := k.items[account]
+	if !ok {
+		return "", ErrKeychainNotFound
+	}
+	return secret, nil
+}
+
+func (k *fakeKeychain) Set(account, secret string) error {
+	if k.unavailable {
+		return ErrKeychainUnavailable
+	}
+	k.items[account] = secret
+	return nil
+}
+
+func (k *fakeKeychain) Delete(account string) error {
+	if k.unavailable {
+		return ErrKeychainUnavailable
+	}
+	delete(k.items, account)
+	return nil
+}
+
+func TestFileSecretStore_Keychain(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	stubEnvironment(t, "interactive")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	loader := &FileLoader{ConfigPath: path}
+	keychain := &fakeKeychain{items: map[string]string{}}
+	store := &FileSecretStore{Loader: loader, Keychain: keychain}
+
+	const key = "sk-keychain-1234567890"
+	if err := store.SetSecretInKeychain(APIKeySecret, key); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), key) || !strings.Contains(string(data), "api_key: 'keychain:'") {
+		t.Errorf("config file = %q, want the sentinel instead of the key", data)
+	}
+
+	// Resolution reads the key from the keychain
+	conf, err := ResolveConfigWithStore(CLIOptions{TreePath: filepath.Dir(path)}, loader, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.APIKey != key {
+		t.Errorf("resolved APIKey = %q, want the keychain's", conf.APIKey)
+	}
+
+	// Once in the keychain, a plain set stays there
+	if err := store.SetSecret(APIKeySecret, "sk-rotated-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetSecret(APIKeySecret); got != "sk-rotated-1234567890" || len(keychain.items) != 1 {
+		t.Errorf("GetSecret() = %q with %d keychain items, want the rotated key in the keychain", got, len(keychain.items))
+	}
+
+	// Without the keychain the key can't be read, and validation says why
+	keychain.unavailable = true
+	if _, err := store.GetSecret(APIKeySecret); err == nil || !strings.Contains(err.Error(), "config set api-key") {
+		t.Errorf("GetSecret() error = %v, want a hint to set the key again", err)
+	}
+	_, _, err = mergeConfig(CLIOptions{TreePath: filepath.Dir(path)}, loader, store)
+	if err == nil || !strings.Contains(err.Error(), "keychain") {
+		t.Errorf("mergeConfig() error = %v, want the keychain problem", err)
+	}
+
+	// Nor is it quietly replaced or removed: the sentinel stays in the file
+	if err := store.SetSecret(APIKeySecret, "sk-plain-1234567890"); !errors.Is(err, ErrKeychainUnavailable) {
+		t.Errorf("SetSecret() = %v, want ErrKeychainUnavailable", err)
+	}
+	if err := store.DeleteSecret(APIKeySecret); !errors.Is(err, ErrKeychainUnavailable) {
+		t.Errorf("DeleteSecret() = %v, want ErrKeychainUnavailable", err)
+	}
+	if c, _ := loader.Load(); c.APIKey != KeychainSentinel {
+		t.Errorf("APIKey = %q, want the sentinel kept", c.APIKey)
+	}
+
+	keychain.unavailable = false
+	if err := store.DeleteSecret(APIKeySecret); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetSecret(APIKeySecret); got != "" || len(keychain.items) != 0 {
+		t.Errorf("after DeleteSecret: GetSecret() = %q with %d keychain items, want both empty", got, len(keychain.items))
+	}
+}
+
+func TestFileSecretStore_ProfileKeychain(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("SORTPATH_PROFILE", "")
+	stubEnvironment(t, "interactive")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	loader := &FileLoader{ConfigPath: path}
+	keychain := &fakeKeychain{items: map[string]string{}}
+	store := &FileSecretStore{Loader: loader, Keychain: keychain}
+
+	const key = "sk-work-1234567890"
+	name := ProfileSecretName("work")
+	if err := store.SetSecret(APIKeySecret, "sk-top-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSecretInKeychain(name, key); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profiles["work"].APIKey != KeychainSentinel || c.APIKey != "sk-top-1234567890" {
+		t.Errorf("profile key = %q, top-level key = %q, want only the profile's in the keychain", c.Profiles["work"].APIKey, c.APIKey)
+	}
+
+	conf, err := ResolveConfigWithStore(CLIOptions{TreePath: filepath.Dir(path), Profile: "work"}, loader, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.APIKey != key {
+		t.Errorf("resolved APIKey = %q, want the profile's keychain key", conf.APIKey)
+	}
+
+	if err := store.DeleteSecret(name); err != nil {
+		t.Fatal(err)
+	}
+	c, _ = loader.Load()
+	if _, ok := c.Profiles["work"]; ok || len(keychain.items) != 0 {
+		t.Errorf("after DeleteSecret: profiles = %+v with %d keychain items, want both gone", c.Profiles, len(keychain.items))
+	}
+
+	if _, err := store.GetSecret("profiles.bad name.api-key"); err == nil {
+		t.Error("expected an invalid profile secret name to be rejected")
+	}
+}
+
+func TestFileSecretStore_NoKeychain(t *testing.T) {
+	loader := &FileLoader{ConfigPath: filepath.Join(t.TempDir(), "config.yaml")}
+	store := &FileSecretStore{Loader: loader, Keychain: &fakeKeychain{unavailable: true}}
+
+	if err := store.SetSecretInKeychain(APIKeySecret, "sk-x-1234567890"); err != ErrKeychainUnavailable {
+		t.Fatalf("SetSecretInKeychain() = %v, want ErrKeychainUnavailable", err)
+	}
+	if c, _ := loader.Load(); c.APIKey != "" {
+		t.Errorf("APIKey = %q, want nothing written when the keychain is unavailable", c.APIKey)
+	}
+	if err := store.SetSecret(APIKeySecret, "sk-plain-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetSecret(APIKeySecret); got != "sk-plain-1234567890" {
+		t.Errorf("GetSecret() = %q, want the plaintext key", got)
+	}
+}
+
+func TestOSKeychain_Commands(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("no keychain tool on this system")
+	}
+	var calls []string
+	var stdins []string
+	orig := runKeychainTool
+	runKeychainTool = func(stdin, name string, args ...string) (string, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		stdins = append(stdins, stdin)
+		return "sk-stored", nil
+	}
+	defer func() { runKeychainTool = orig }()
+
+	kc := osKeychain{}
+	const account, secret = `api-key@/My "cfg" \ dir/cfg.yaml`, `sk-secret "value" \ x`
+	if err := kc.Set(account, secret); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := kc.Get(account); err != nil || got != "sk-stored" {
+		t.Fatalf("Get() = %q, %v", got, err)
+	}
+	if runtime.GOOS == "darwin" {
+		want := "security add-generic-password -U -s sortpath -a " + account + " -w " + secret
+		if calls[0] != want {
+			t.Errorf("Set() ran %q, want %q", calls[0], want)
+		}
+		return
+	}
+	for _, call := range calls {
+		if strings.Contains(call, secret) {
+			t.Errorf("command line %q carries the secret; it must go through stdin", call)
+		}
+	}
+	if stdins[0] != secret {
+		t.Errorf("stdin = %q, want the secret", stdins[0])
+	}
+	if !strings.HasSuffix(calls[0], "account "+account) {
+		t.Errorf("Set() ran %q, want the account passed intact", calls[0])
+	}
+}
@@ -1,12 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,29 +20,136 @@ type Config struct {
 	Model    string `yaml:"model"`
 	TreePath string `yaml:"tree_path"`
 	LogLevel string `yaml:"log_level"`
+
+	// OnMissingTreePath decides what happens when TreePath doesn't exist: error, create or cwd
+	OnMissingTreePath string `yaml:"on_missing_tree_path,omitempty"`
+
+	// ModelAliases maps friendly model names to the model IDs sent to the API
+	ModelAliases map[string]string `yaml:"model_aliases,omitempty"`
+
+	// PromptStyle selects the prompt: rich (default) or minimal for fine-tuned models
+	PromptStyle string `yaml:"prompt_style,omitempty"`
+
+	// ResponseFormat selects how the model is asked to answer: xml (default)
+	// or json for providers with a JSON output mode
+	ResponseFormat string `yaml:"response_format,omitempty"`
+
+	// PinnedCertSHA256, when set, is the only API server certificate accepted
+	PinnedCertSHA256 string `yaml:"pinned_cert_sha256,omitempty"`
+
+	// Provider selects the built-in default request parameters (openai, anthropic)
+	Provider string `yaml:"provider,omitempty"`
+
+	// Temperature and MaxTokens are sent with each request when non-empty.
+	// Unset values fall back to the provider's profile.
+	Temperature string `yaml:"temperature,omitempty"`
+	MaxTokens   string `yaml:"max_tokens,omitempty"`
+
+	// Timeout bounds each API request, as a duration such as "30s"
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// TreeDepth limits how deep the folder tree is walked; empty means
+	// unlimited and 0 lists only the top-level entries
+	TreeDepth string `yaml:"tree_depth,omitempty"`
+
+	// TreeMaxBytes caps the size of the tree sent to the model, e.g. "64KB";
+	// "0" means unlimited
+	TreeMaxBytes string `yaml:"tree_max_bytes,omitempty"`
+
+	// Profile names the profile in Profiles whose values are used. In the
+	// config file it is the default; resolved, it is the active profile.
+	Profile string `yaml:"profile,omitempty"`
+
+	// UpdateChannel selects the releases the updater offers: stable
+	// (default) or beta, which includes prereleases
+	UpdateChannel string `yaml:"update_channel,omitempty"`
+
+	// PromptTemplate is a text/template file used instead of the built-in
+	// prompt; empty means the built-in prompt
+	PromptTemplate string `yaml:"prompt_template,omitempty"`
+
+	// LogFormat selects how log lines are written: text (default) or json
+	LogFormat string `yaml:"log_format,omitempty"`
+
+	// LogFile is a file log lines are appended to as well as the console;
+	// empty logs to the console only
+	LogFile string `yaml:"log_file,omitempty"`
+
+	// LogFileOnly keeps log lines off the console when LogFile is set
+	LogFileOnly bool `yaml:"-"`
+
+	// ExtraRules holds the user's own prompt rules, one per line, added to
+	// the built-in ones
+	ExtraRules string `yaml:"extra_rules,omitempty"`
+
+	// Profiles holds named sets of settings (e.g. work, personal) that
+	// override the top-level values when selected
+	Profiles map[string]ProfileConfig `yaml:"profiles,omitempty"`
+
+	// Environments overrides the built-in per-environment profiles, keyed by
+	// environment type (ci, container, ...)
+	Environments map[string]EnvProfile `yaml:"environments,omitempty"`
+
+	// InstalledPath is where `sortpath install` last copied the binary. It is
+	// recorded by the install command rather than set by the user.
+	InstalledPath string `yaml:"installed_path,omitempty"`
+
+	// Environment is the detected environment type whose profile was applied
+	Environment string `yaml:"-"`
+
+	// SkipPrompts disables the install prompt and update check (from the profile)
+	SkipPrompts bool `yaml:"-"`
+
+	// Per-run settings resolved from CLI/ENV only; never written to the config file
+	TraceID     string `yaml:"-"`
+	TraceHeader string `yaml:"-"`
+
+	// StrictXML rejects model output whose <path> isn't inside a complete
+	// <recommendation> element
+	StrictXML bool `yaml:"-"`
+
+	// NoDefaultIgnores walks directories on the built-in skip list
+	// (node_modules, .git, ...) instead of leaving them out
+	NoDefaultIgnores bool `yaml:"-"`
+
+	// DirsOnly leaves files out of the tree sent to the model
+	DirsOnly bool `yaml:"-"`
+
+	// NoCache walks the tree even when a cached copy is still current
+	NoCache bool `yaml:"-"`
+
+	// NoAuth sends requests without an API key, for local servers that
+	// don't check one (see RequiresAPIKey)
+	NoAuth bool `yaml:"-"`
 }
 
-// Validate checks if the configuration is valid and returns helpful error messages
+// Validate checks if the configuration is valid and returns helpful error
+// messages. Only the first problem is returned; ValidateAll reports them all.
 func (c *Config) Validate() error {
-	if c.APIKey == "" {
-		return fmt.Errorf("API key is required. Set it with: sortpath config set api-key YOUR_KEY")
+	if problems := c.problems(); len(problems) > 0 {
+		return problems[0]
 	}
+	return nil
+}
 
-	if c.APIBase == "" {
-		return fmt.Errorf("API base URL is required. Set it with: sortpath config set api-base https://api.openai.com/v1")
+// problems lists every validation failure in a fixed order, most
+// fundamental first
+func (c *Config) problems() []error {
+	var errs []error
+	if c.APIKey == "" && c.RequiresAPIKey() {
+		errs = append(errs, fieldError("api-key", "API key is required. Set it with: sortpath config set api-key YOUR_KEY (or pass --no-auth for a server that needs none)"))
 	}
 
-	// Validate API base URL format
-	parsedURL, err := url.Parse(c.APIBase)
-	if err != nil {
-		return fmt.Errorf("invalid API base URL '%s': %v. Use format: https://api.openai.com/v1", c.APIBase, err)
-	}
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return fmt.Errorf("API base URL must use http or https scheme, got '%s'. Use format: https://api.openai.com/v1", c.APIBase)
+	if c.APIBase == "" {
+		errs = append(errs, fieldError("api-base", "API base URL is required. Set it with: sortpath config set api-base https://api.openai.com/v1"))
+	} else if parsedURL, err := url.Parse(c.APIBase); err != nil {
+		errs = append(errs, fieldError("api-base", "invalid API base URL '%s': %v. Use format: https://api.openai.com/v1", c.APIBase, err))
+	} else if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		errs = append(errs, fieldError("api-base", "API base URL must use http or https scheme, got '%s'. Use format: https://api.openai.com/v1", c.APIBase))
 	}
 
 	if c.Model == "" {
-		return fmt.Errorf("model is required. Set it with: sortpath config set model gpt-3.5-turbo")
+		errs = append(errs, fieldError("model", "model is required. Set it with: sortpath config set model gpt-3.5-turbo"))
 	}
 
 	// Validate log level
@@ -53,23 +163,216 @@ func (c *Config) Validate() error {
 			}
 		}
 		if !valid {
-			return fmt.Errorf("invalid log level '%s'. Valid options: %s", c.LogLevel, strings.Join(validLogLevels, ", "))
+			errs = append(errs, fieldError("log-level", "invalid log level '%s'. Valid options: %s", c.LogLevel, strings.Join(validLogLevels, ", ")))
+		}
+	}
+
+	// traceparent requires a W3C trace ID; other headers accept any value
+	if c.TraceID != "" && strings.EqualFold(c.TraceHeader, "traceparent") && !IsW3CTraceID(c.TraceID) {
+		errs = append(errs, fmt.Errorf("trace ID '%s' is not a valid traceparent trace ID (32 hex characters). Use --trace-header to send free-form IDs", c.TraceID))
+	}
+
+	if err := ValidateMissingTreePolicy(c.OnMissingTreePath); err != nil {
+		errs = append(errs, &FieldError{Key: "on-missing-tree", Err: err})
+	}
+
+	if err := ValidatePromptStyle(c.PromptStyle); err != nil {
+		errs = append(errs, &FieldError{Key: "prompt-style", Err: err})
+	}
+	if err := ValidateResponseFormat(c.ResponseFormat); err != nil {
+		errs = append(errs, &FieldError{Key: "response-format", Err: err})
+	}
+
+	if c.PinnedCertSHA256 != "" {
+		if _, err := httpx.ParseFingerprint(c.PinnedCertSHA256); err != nil {
+			errs = append(errs, fieldError("pinned-cert-sha256", "%v. Get it with: openssl x509 -in cert.pem -noout -fingerprint -sha256", err))
 		}
 	}
 
+	if err := ValidateProvider(c.Provider); err != nil {
+		errs = append(errs, &FieldError{Key: "provider", Err: err})
+	}
+	if err := ValidateTemperature(c.Temperature); err != nil {
+		errs = append(errs, &FieldError{Key: "temperature", Err: err})
+	}
+	if err := ValidateMaxTokens(c.MaxTokens); err != nil {
+		errs = append(errs, &FieldError{Key: "max-tokens", Err: err})
+	}
+	if err := ValidateTimeout(c.Timeout); err != nil {
+		errs = append(errs, &FieldError{Key: "timeout", Err: err})
+	}
+	if err := ValidateUpdateChannel(c.UpdateChannel); err != nil {
+		errs = append(errs, &FieldError{Key: "update-channel", Err: err})
+	}
+	if err := ValidateLogFormat(c.LogFormat); err != nil {
+		errs = append(errs, &FieldError{Key: "log-format", Err: err})
+	}
+	if c.LogFileOnly && c.LogFile == "" {
+		errs = append(errs, fieldError("log-file", "--log-file-only needs a log file. Set it with --log-file or SORTPATH_LOG_FILE"))
+	}
+	if err := ValidatePromptTemplate(c.PromptTemplate); err != nil {
+		errs = append(errs, &FieldError{Key: "prompt-template", Err: err})
+	}
+	if err := ValidateTreeDepth(c.TreeDepth); err != nil {
+		errs = append(errs, &FieldError{Key: "tree-depth", Err: err})
+	}
+	if err := ValidateTreeMaxBytes(c.TreeMaxBytes); err != nil {
+		errs = append(errs, &FieldError{Key: "tree-max-bytes", Err: err})
+	}
+	if err := c.profileProblem(); err != nil {
+		errs = append(errs, &FieldError{Key: "profile", Err: err})
+	}
+
 	// Validate tree path exists and is readable
 	if c.TreePath != "" && c.TreePath != "." {
-		if _, err := os.Stat(c.TreePath); err != nil {
+		if info, err := os.Stat(c.TreePath); err != nil {
 			if os.IsNotExist(err) {
-				return fmt.Errorf("tree path '%s' does not exist. Use an existing directory path", c.TreePath)
+				errs = append(errs, fieldError("tree-path", "tree path '%s' does not exist. Use an existing directory path", c.TreePath))
+			} else {
+				errs = append(errs, fieldError("tree-path", "cannot access tree path '%s': %v", c.TreePath, err))
 			}
-			return fmt.Errorf("cannot access tree path '%s': %v", c.TreePath, err)
+		} else if !info.IsDir() {
+			errs = append(errs, fieldError("tree-path", "tree path '%s' is not a directory. Use an existing directory path", c.TreePath))
+		} else if f, err := os.Open(c.TreePath); err != nil {
+			errs = append(errs, fieldError("tree-path", "cannot read tree path '%s': %v", c.TreePath, err))
+		} else {
+			f.Close()
 		}
 	}
 
+	return errs
+}
+
+// RequestModel returns the model ID to send to the API: Model translated
+// through ModelAliases, or Model itself when it isn't an alias
+func (c *Config) RequestModel() string {
+	if id, ok := c.ModelAliases[c.Model]; ok && id != "" {
+		return id
+	}
+	return c.Model
+}
+
+// Value returns the raw value stored under a ConfigKeys key
+func (c *Config) Value(key string) (string, error) {
+	switch key {
+	case "api-key":
+		return c.APIKey, nil
+	case "api-base":
+		return c.APIBase, nil
+	case "model":
+		return c.Model, nil
+	case "tree-path":
+		return c.TreePath, nil
+	case "log-level":
+		return c.LogLevel, nil
+	case "on-missing-tree":
+		return c.OnMissingTreePath, nil
+	case "prompt-style":
+		return c.PromptStyle, nil
+	case "response-format":
+		return c.ResponseFormat, nil
+	case "pinned-cert-sha256":
+		return c.PinnedCertSHA256, nil
+	case "provider":
+		return c.Provider, nil
+	case "temperature":
+		return c.Temperature, nil
+	case "max-tokens":
+		return c.MaxTokens, nil
+	case "timeout":
+		return c.Timeout, nil
+	case "tree-depth":
+		return c.TreeDepth, nil
+	case "tree-max-bytes":
+		return c.TreeMaxBytes, nil
+	case "profile":
+		return c.Profile, nil
+	case "update-channel":
+		return c.UpdateChannel, nil
+	case "prompt-template":
+		return c.PromptTemplate, nil
+	case "extra-rules":
+		return c.ExtraRules, nil
+	case "log-format":
+		return c.LogFormat, nil
+	case "log-file":
+		return c.LogFile, nil
+	default:
+		return "", fmt.Errorf("unknown config key: %s", key)
+	}
+}
+
+// SetValue stores value under a ConfigKeys key
+func (c *Config) SetValue(key, value string) error {
+	switch key {
+	case "api-key":
+		c.APIKey = value
+	case "api-base":
+		c.APIBase = value
+	case "model":
+		c.Model = value
+	case "tree-path":
+		c.TreePath = value
+	case "log-level":
+		c.LogLevel = value
+	case "on-missing-tree":
+		c.OnMissingTreePath = value
+	case "prompt-style":
+		c.PromptStyle = value
+	case "response-format":
+		c.ResponseFormat = value
+	case "pinned-cert-sha256":
+		c.PinnedCertSHA256 = value
+	case "provider":
+		c.Provider = value
+	case "temperature":
+		c.Temperature = value
+	case "max-tokens":
+		c.MaxTokens = value
+	case "timeout":
+		c.Timeout = value
+	case "tree-depth":
+		c.TreeDepth = value
+	case "tree-max-bytes":
+		c.TreeMaxBytes = value
+	case "profile":
+		c.Profile = value
+	case "update-channel":
+		c.UpdateChannel = value
+	case "prompt-template":
+		c.PromptTemplate = value
+	case "extra-rules":
+		c.ExtraRules = value
+	case "log-format":
+		c.LogFormat = value
+	case "log-file":
+		c.LogFile = value
+	default:
+		return fmt.Errorf("unknown config key: %s", key)
+	}
 	return nil
 }
 
+// FieldError is a validation error attributable to a single config key, so
+// callers can offer to fix that key
+type FieldError struct {
+	Key string
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError builds a FieldError with a formatted message
+func fieldError(key, format string, args ...interface{}) error {
+	return &FieldError{Key: key, Err: fmt.Errorf(format, args...)}
+}
+
 // Loader interface for configuration operations
 type Loader interface {
 	Load() (*Config, error)
@@ -79,16 +382,74 @@ type Loader interface {
 // FileLoader implements the Loader interface for file-based configuration
 type FileLoader struct {
 	ConfigPath string
+
+	// LockTimeout bounds how long Update waits for the config lock (0 = DefaultLockTimeout)
+	LockTimeout time.Duration
+}
+
+// configPathOverride is the config file chosen with --config, if any
+var configPathOverride string
+
+// SetConfigPath makes path the config file for the rest of the run, ahead of
+// SORTPATH_CONFIG and the default location. "" restores the usual lookup.
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// ConfigPath returns the config file in use: the --config path, then
+// SORTPATH_CONFIG, then DefaultConfigPath
+func ConfigPath() string {
+	if configPathOverride != "" {
+		return configPathOverride
+	}
+	if path := os.Getenv("SORTPATH_CONFIG"); path != "" {
+		return path
+	}
+	return DefaultConfigPath()
+}
+
+// DefaultConfigPath returns config.yaml in ConfigDir. When $XDG_CONFIG_HOME
+// moves that away from ~/.config and only ~/.config/sortpath/config.yaml
+// exists, that older file is used so an existing config isn't lost.
+func DefaultConfigPath() string {
+	path := filepath.Join(ConfigDir(), "config.yaml")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return path
+	}
+	legacy := filepath.Join(HomeDir(), ".config", "sortpath", "config.yaml")
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	return path
 }
 
-// NewFileLoader creates a new FileLoader with the default config path
+// CheckConfigPath reports an error when the config file chosen with
+// SetConfigPath doesn't exist, so a mistyped --config isn't silently
+// replaced by defaults. A missing default file just means no config yet.
+func CheckConfigPath() error {
+	if configPathOverride == "" {
+		return nil
+	}
+	if _, err := os.Stat(configPathOverride); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("config file %s does not exist. Create it with: sortpath --config %s config init", configPathOverride, configPathOverride)
+		}
+		return fmt.Errorf("cannot read config file %s: %w", configPathOverride, err)
+	}
+	return nil
+}
+
+// NewFileLoader creates a new FileLoader for the config file in use (see ConfigPath)
 func NewFileLoader() *FileLoader {
-	configPath := filepath.Join(os.Getenv("HOME"), ".config", "sortpath", "config.yaml")
-	return &FileLoader{ConfigPath: configPath}
+	return &FileLoader{ConfigPath: ConfigPath()}
 }
 
 // Load reads configuration from file, returns empty config if file doesn't exist
 func (fl *FileLoader) Load() (*Config, error) {
+	if err := fl.checkDir(); err != nil {
+		return nil, err
+	}
+
 	f, err := os.Open(fl.ConfigPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -102,6 +463,7 @@ func (fl *FileLoader) Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
 	defer f.Close()
+	fl.checkPermissions()
 
 	var c Config
 	dec := yaml.NewDecoder(f)
@@ -119,6 +481,10 @@ func (fl *FileLoader) Load() (*Config, error) {
 
 // Save writes configuration to file with secure permissions using atomic operations
 func (fl *FileLoader) Save(c *Config) error {
+	if err := fl.checkDir(); err != nil {
+		return err
+	}
+
 	// Marshal the config to YAML
 	data, err := yaml.Marshal(c)
 	if err != nil {
@@ -133,12 +499,34 @@ func (fl *FileLoader) Save(c *Config) error {
 	return nil
 }
 
+// checkDir reports a config directory that is a file or a broken symlink
+func (fl *FileLoader) checkDir() error {
+	dir := filepath.Dir(fl.ConfigPath)
+	return DefaultEdgeCaseHandler.HandleConfigDirConflict(dir, DefaultEdgeCaseHandler.CheckConfigDir(dir))
+}
+
 // Default configuration values
 var defaults = Config{
 	APIBase:  "https://api.openai.com/v1",
 	Model:    "gpt-3.5-turbo",
 	TreePath: ".",
 	LogLevel: "info",
+
+	OnMissingTreePath: MissingTreeError,
+	PromptStyle:       PromptStyleRich,
+	ResponseFormat:    ResponseFormatXML,
+	Provider:          ProviderOpenAI,
+	TreeMaxBytes:      "64KB",
+	Timeout:           "60s",
+	UpdateChannel:     UpdateChannelStable,
+	LogFormat:         LogFormatText,
+}
+
+// DefaultValue returns the built-in default for a ConfigKeys key ("" when
+// there is none)
+func DefaultValue(key string) string {
+	v, _ := defaults.Value(key)
+	return v
 }
 
 // Load is a convenience function that uses the default FileLoader
@@ -160,30 +548,325 @@ type CLIOptions struct {
 	Model    string
 	TreePath string
 	LogLevel string
+
+	// ConfigPath reads this config file instead of the default (--config)
+	ConfigPath string
+
+	// Profile selects a named profile from the config file (--profile)
+	Profile string
+
+	// Trees holds every --tree value when more than one was given; the model
+	// then picks one of them as well as a folder within it
+	Trees []string
+
+	// OnMissingTree is the missing tree path policy (error, create, cwd)
+	OnMissingTree string
+
+	// ExplainTree prints the tree and why entries were skipped, without querying the API
+	ExplainTree bool
+
+	// ContextWindow, when positive, shrinks the tree until the prompt fits in this many tokens
+	ContextWindow int
+
+	// TreeDepth limits how deep the tree is walked (--tree-depth); empty means unlimited
+	TreeDepth string
+
+	// TreeMaxBytes caps the size of the tree (--tree-max-bytes)
+	TreeMaxBytes string
+
+	// NoTree classifies into a generic taxonomy without walking the folder tree
+	NoTree bool
+
+	// TraceID correlates the API request and logs with an external trace
+	TraceID string
+
+	// TraceHeader is the request header carrying TraceID (default traceparent)
+	TraceHeader string
+
+	// StrictXML fails on malformed model output (--strict-xml)
+	StrictXML bool
+
+	// Strict fails when the recommended path isn't in the folder tree
+	// (--strict)
+	Strict bool
+
+	// NoDefaultIgnores disables the built-in directory skip list (--no-default-ignores)
+	NoDefaultIgnores bool
+
+	// DirsOnly lists only directories in the tree (--dirs-only)
+	DirsOnly bool
+
+	// NoCache rebuilds the tree instead of reusing the cached one (--no-cache)
+	NoCache bool
+
+	// NoAuth drops the API key requirement and header (--no-auth)
+	NoAuth bool
+
+	// Pick lets the user choose the folder from the tree (--pick)
+	Pick bool
+
+	// JSONSchema prints the schema of the --json output and exits
+	JSONSchema bool
+
+	// PromptStyle overrides the configured prompt style (--minimal-prompt sets minimal)
+	PromptStyle string
+
+	// LogFormat overrides the configured log format (--log-format)
+	LogFormat string
+
+	// LogFile overrides the configured log file (--log-file)
+	LogFile string
+
+	// LogFileOnly writes log lines to the log file only (--log-file-only)
+	LogFileOnly bool
+
+	// PromptTemplate overrides the configured prompt template (--prompt-template)
+	PromptTemplate string
+
+	// ExtraRules replaces the configured extra prompt rules, one per line
+	// (repeated --rule)
+	ExtraRules string
+
+	// ResponseFormat overrides the configured response format (--response-format)
+	ResponseFormat string
+
+	// UpdateChannel overrides the configured update channel (update --channel)
+	UpdateChannel string
+
+	// FromFile describes this file automatically instead of (or in addition to)
+	// a typed description
+	FromFile string
+
+	// PreviewBytes includes up to this many bytes of a text FromFile's content
+	PreviewBytes int
+
+	// Move and Copy name a file to move or copy into the recommended folder
+	// (--move, --copy); by default the recommendation is only printed
+	Move string
+	Copy string
+
+	// DryRun prints the --move or --copy plan without changing any files
+	DryRun bool
+
+	// Batch sorts each positional argument as its own item (--batch); "-"
+	// reads more items from stdin, one per line. BatchInputs holds the
+	// arguments.
+	Batch       bool
+	BatchInputs []string
+
+	// Stdin reads the descriptions from stdin, one per line (--stdin)
+	Stdin bool
+
+	// Concurrency bounds how many batch items are queried at once
+	Concurrency int
+
+	// LargeTree picks a top-level branch first, then sorts within that branch
+	LargeTree bool
+
+	// Budget stops a run once cumulative token usage exceeds it (0 = unlimited)
+	Budget int
+
+	// Provider, Temperature and MaxTokens override the configured request
+	// parameters; unset parameters come from the provider's profile
+	Provider    string
+	Temperature string
+	MaxTokens   string
+
+	// Timeout bounds each API request (--timeout), e.g. "30s"
+	Timeout string
+
+	// RecordDataset appends each successful recommendation to this JSONL file
+	RecordDataset string
+
+	// DatasetTreeRef stores recorded trees by SHA-256 reference instead of inline
+	DatasetTreeRef bool
+
+	// Format picks how results are printed: full (default), path or json
+	// (--format; -q implies path)
+	Format string
+
+	// JSON prints results as JSON lines; Quiet hides diagnostics; NoColor
+	// drops the emoji status markers
+	JSON    bool
+	Quiet   bool
+	NoColor bool
+
+	// Color colors log lines on the console by level: auto (default),
+	// always or never (--color)
+	Color string
+
+	// ShowURL prints the effective request URL, with secrets masked, before the request
+	ShowURL bool
+
+	// ShowUsage prints the tokens each query used (--show-usage); PriceInput
+	// and PriceOutput, per 1,000 tokens, add a cost estimate
+	ShowUsage   bool
+	PriceInput  float64
+	PriceOutput float64
+
+	// MaxReasonLength truncates the returned reason to this many characters (0 = unlimited)
+	MaxReasonLength int
+
+	// Count asks the model for this many ranked recommendations (--count)
+	Count int
 }
 
 // ResolveConfig resolves configuration with priority: CLI > ENV > file > defaults
 func ResolveConfig(opts CLIOptions) (*Config, error) {
-	return ResolveConfigWithLoader(opts, NewFileLoader())
+	loader, store := defaultSources(opts)
+	return ResolveConfigWithStore(opts, loader, store)
+}
+
+// defaultSources returns the loader and secret store for opts: the config
+// file in use, or opts.ConfigPath when set. A file-backed default store
+// follows the loader so the API key comes from the same file.
+func defaultSources(opts CLIOptions) (Loader, SecretStore) {
+	if opts.ConfigPath == "" {
+		return NewFileLoader(), DefaultSecretStore
+	}
+	loader := &FileLoader{ConfigPath: opts.ConfigPath}
+	if fs, ok := DefaultSecretStore.(*FileSecretStore); ok && fs.Loader == nil {
+		return loader, NewFileSecretStore(loader)
+	}
+	return loader, DefaultSecretStore
 }
 
 // ResolveConfigWithLoader resolves configuration using a custom loader (useful for testing)
 func ResolveConfigWithLoader(opts CLIOptions, loader Loader) (*Config, error) {
+	return ResolveConfigWithStore(opts, loader, NewFileSecretStore(loader))
+}
+
+// ResolveConfigWithStore resolves configuration using a custom loader and secret store
+func ResolveConfigWithStore(opts CLIOptions, loader Loader, store SecretStore) (*Config, error) {
+	resolved, _, err := ResolveConfigWithSources(opts, loader, store)
+	return resolved, err
+}
+
+// ResolveConfigWithSources resolves configuration like ResolveConfigWithStore and
+// also returns where each value came from, for debug logging via LogSources.
+// Sources are returned even when validation fails.
+func ResolveConfigWithSources(opts CLIOptions, loader Loader, store SecretStore) (*Config, []FieldSource, error) {
+	resolved, sources, loadErr := mergeConfig(opts, loader, store)
+
+	// A broken config directory explains missing values better than validation does
+	if isConfigDirConflict(loadErr) {
+		return nil, sources, loadErr
+	}
+
+	if err := resolved.applyMissingTreePolicy(); err != nil {
+		return nil, sources, err
+	}
+
+	// Validate the resolved configuration
+	if err := resolved.Validate(); err != nil {
+		return nil, sources, err
+	}
+
+	return resolved, sources, nil
+}
+
+// ResolveConfigUnvalidated applies the same priority resolution as ResolveConfig
+// but skips validation, for commands that don't talk to the API
+func ResolveConfigUnvalidated(opts CLIOptions) *Config {
+	loader, store := defaultSources(opts)
+	resolved, _, _ := mergeConfig(opts, loader, store)
+	return resolved
+}
+
+// mergeConfig applies priority resolution: CLI > ENV > environment profile
+// or project file (.sortpath.yaml) > file > defaults.
+// The provenance of each field and the loader error are returned alongside the
+// merged config for callers that care.
+func mergeConfig(opts CLIOptions, loader Loader, store SecretStore) (*Config, []FieldSource, error) {
 	// Load from file first
-	fileConfig, _ := loader.Load()
+	fileConfig, loadErr := loader.Load()
 	if fileConfig == nil {
 		fileConfig = &Config{} // Use empty config if loading failed
 	}
 
-	// Apply priority resolution: CLI > ENV > file > defaults
+	// Secrets come from the store rather than the raw file
+	loaded := fileConfig
+	storedKey, secretErr := loadedSecret(store, loader, loaded, loadErr, APIKeySecret)
+	if loadErr == nil && secretErr != nil && fileConfig.APIKey == KeychainSentinel && opts.APIKey == "" && os.Getenv("OPENAI_API_KEY") == "" {
+		// A keychain that can't be read explains a missing key
+		loadErr = secretErr
+	}
+
+	// A selected profile's values replace the top-level ones in the file
+	// layer, API key included
+	profiles := fileConfig.Profiles
+	var p provenance
+	named := p.resolve("profile", opts.Profile, "SORTPATH_PROFILE", fileConfig.Profile, "")
+	fileConfig = fileConfig.withProfile(named)
+	if named != "" && profiles[named].APIKey != "" {
+		profileKey, err := loadedSecret(store, loader, loaded, loadErr, ProfileSecretName(named))
+		if err != nil && loadErr == nil && opts.APIKey == "" && os.Getenv("OPENAI_API_KEY") == "" {
+			loadErr = err
+		}
+		storedKey = profileKey
+	}
+
+	// The detected environment's profile ranks between ENV and file
+	env := currentEnvironment()
+	envProfile := profileFor(env, fileConfig.Environments)
+
+	// A .sortpath.yaml in the tree ranks between ENV and file too
+	project := &ProjectConfig{}
+	if path := FindProjectConfig(projectLookupDir(opts, fileConfig)); path != "" {
+		if pc, err := LoadProjectConfig(path); err == nil {
+			project = pc
+		} else if loadErr == nil {
+			loadErr = err
+		}
+	}
+
+	// Apply priority resolution: CLI > ENV > profile/project > file > defaults
 	resolved := &Config{
-		APIKey:   resolveValue(opts.APIKey, os.Getenv("OPENAI_API_KEY"), fileConfig.APIKey, ""),
-		APIBase:  resolveValue(opts.APIBase, os.Getenv("OPENAI_API_BASE"), fileConfig.APIBase, defaults.APIBase),
-		Model:    resolveValue(opts.Model, os.Getenv("OPENAI_MODEL"), fileConfig.Model, defaults.Model),
-		TreePath: resolveValue(opts.TreePath, os.Getenv("SORTPATH_FOLDER_TREE"), fileConfig.TreePath, defaults.TreePath),
-		LogLevel: resolveValue(opts.LogLevel, os.Getenv("SORTPATH_LOG_LEVEL"), fileConfig.LogLevel, defaults.LogLevel),
+		APIKey:   p.resolve("api-key", opts.APIKey, "OPENAI_API_KEY", storedKey, ""),
+		APIBase:  p.resolve("api-base", opts.APIBase, "OPENAI_API_BASE", fileConfig.APIBase, defaults.APIBase),
+		Model:    p.resolveProject("model", opts.Model, "OPENAI_MODEL", project.Model, fileConfig.Model, defaults.Model),
+		TreePath: p.resolveProject("tree-path", opts.TreePath, "SORTPATH_FOLDER_TREE", project.TreePath, fileConfig.TreePath, defaults.TreePath),
+		LogLevel: p.resolveProfiled("log-level", opts.LogLevel, "SORTPATH_LOG_LEVEL", envProfile.LogLevel, fileConfig.LogLevel, defaults.LogLevel),
+
+		OnMissingTreePath: p.resolve("on-missing-tree", opts.OnMissingTree, "SORTPATH_ON_MISSING_TREE", fileConfig.OnMissingTreePath, defaults.OnMissingTreePath),
+
+		ModelAliases:     fileConfig.ModelAliases,
+		Environments:     fileConfig.Environments,
+		Profile:          named,
+		Profiles:         profiles,
+		PromptStyle:      p.resolve("prompt-style", opts.PromptStyle, "SORTPATH_PROMPT_STYLE", fileConfig.PromptStyle, defaults.PromptStyle),
+		PromptTemplate:   p.resolveProject("prompt-template", opts.PromptTemplate, "SORTPATH_PROMPT_TEMPLATE", project.PromptTemplate, fileConfig.PromptTemplate, ""),
+		LogFormat:        p.resolve("log-format", strings.ToLower(opts.LogFormat), "SORTPATH_LOG_FORMAT", fileConfig.LogFormat, defaults.LogFormat),
+		LogFile:          p.resolve("log-file", opts.LogFile, "SORTPATH_LOG_FILE", fileConfig.LogFile, ""),
+		ExtraRules:       p.resolveProject("extra-rules", opts.ExtraRules, "SORTPATH_EXTRA_RULES", project.ExtraRules, fileConfig.ExtraRules, ""),
+		ResponseFormat:   p.resolve("response-format", strings.ToLower(opts.ResponseFormat), "SORTPATH_RESPONSE_FORMAT", fileConfig.ResponseFormat, defaults.ResponseFormat),
+		PinnedCertSHA256: p.resolve("pinned-cert-sha256", "", "SORTPATH_PINNED_CERT_SHA256", fileConfig.PinnedCertSHA256, ""),
+		TreeDepth:        p.resolve("tree-depth", opts.TreeDepth, "SORTPATH_TREE_DEPTH", fileConfig.TreeDepth, ""),
+		TreeMaxBytes:     p.resolve("tree-max-bytes", opts.TreeMaxBytes, "SORTPATH_TREE_MAX_BYTES", fileConfig.TreeMaxBytes, defaults.TreeMaxBytes),
+		Timeout:          p.resolve("timeout", opts.Timeout, "SORTPATH_TIMEOUT", fileConfig.Timeout, defaults.Timeout),
+		Provider:         p.resolve("provider", strings.ToLower(opts.Provider), "SORTPATH_PROVIDER", fileConfig.Provider, defaults.Provider),
+		UpdateChannel:    p.resolve("update-channel", strings.ToLower(opts.UpdateChannel), "SORTPATH_UPDATE_CHANNEL", fileConfig.UpdateChannel, defaults.UpdateChannel),
+
+		TraceID:     p.resolve("trace-id", opts.TraceID, "SORTPATH_TRACE_ID", "", ""),
+		TraceHeader: p.resolve("trace-header", opts.TraceHeader, "SORTPATH_TRACE_HEADER", "", "traceparent"),
+
+		StrictXML:        opts.StrictXML,
+		NoDefaultIgnores: opts.NoDefaultIgnores,
+		DirsOnly:         opts.DirsOnly,
+		NoCache:          opts.NoCache,
+		NoAuth:           opts.NoAuth,
+		LogFileOnly:      opts.LogFileOnly,
+
+		Environment: env,
+		SkipPrompts: envProfile.SkipPrompts != nil && *envProfile.SkipPrompts,
 	}
 
+	// The provider's profile is the default layer for request parameters, so
+	// explicit values from any source always win
+	profile := ProfileFor(resolved.Provider)
+	resolved.Temperature = p.resolve("temperature", opts.Temperature, "SORTPATH_TEMPERATURE", fileConfig.Temperature, profile.Temperature)
+	resolved.MaxTokens = p.resolve("max-tokens", opts.MaxTokens, "SORTPATH_MAX_TOKENS", fileConfig.MaxTokens, profile.MaxTokens)
+
 	// Apply default for TreePath if still empty
 	if resolved.TreePath == "." || resolved.TreePath == "" {
 		if wd, err := os.Getwd(); err == nil {
@@ -191,26 +874,31 @@ func ResolveConfigWithLoader(opts CLIOptions, loader Loader) (*Config, error) {
 		} else {
 			resolved.TreePath = "."
 		}
+		p.set("tree-path", resolved.TreePath, SourceDefault)
 	}
 
-	// Validate the resolved configuration
-	if err := resolved.Validate(); err != nil {
-		return nil, err
-	}
-
-	return resolved, nil
+	return resolved, p, loadErr
 }
 
-// resolveValue applies priority resolution for a single config value
-func resolveValue(cli, env, file, defaultVal string) string {
-	if cli != "" {
-		return cli
+// IsW3CTraceID reports whether id is a 32-character hex trace ID or a full
+// W3C traceparent value
+func IsW3CTraceID(id string) bool {
+	if parts := strings.Split(id, "-"); len(parts) == 4 && len(parts[0]) == 2 && len(parts[2]) == 16 && len(parts[3]) == 2 {
+		id = parts[1]
 	}
-	if env != "" {
-		return env
+	if len(id) != 32 {
+		return false
 	}
-	if file != "" {
-		return file
+	for _, r := range id {
+		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f')) {
+			return false
+		}
 	}
-	return defaultVal
-}
\ No newline at end of file
+	return id != strings.Repeat("0", 32)
+}
+
+// isConfigDirConflict reports whether err came from CheckConfigDir
+func isConfigDirConflict(err error) bool {
+	var cfgErr *ConfigError
+	return errors.As(err, &cfgErr) && strings.HasPrefix(cfgErr.Code, "config_dir_")
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLockTimeout bounds how long a config write waits for another process
+const DefaultLockTimeout = 5 * time.Second
+
+// lockPollInterval is how often a contended lock is retried
+const lockPollInterval = 25 * time.Millisecond
+
+// lockFile takes an exclusive advisory lock on path, creating it if needed.
+// It gives up after timeout so a stuck process can't block writers forever.
+func lockFile(path string, timeout time.Duration) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for lock %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			return f, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out after %v waiting for config lock %s; another sortpath process may be stuck", timeout, path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// unlock releases a lock taken by lockFile. The lock file itself is left in
+// place; removing it would let two processes lock different inodes.
+func unlock(f *os.File) error {
+	defer f.Close()
+	return unlockFile(f)
+}
+
+// Update performs a locked load-modify-save cycle so concurrent sortpath
+// processes don't overwrite each other's changes
+func (fl *FileLoader) Update(fn func(*Config) error) error {
+	if err := fl.checkDir(); err != nil {
+		return err
+	}
+
+	timeout := fl.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+	lf, err := lockFile(fl.ConfigPath+".lock", timeout)
+	if err != nil {
+		return err
+	}
+	defer unlock(lf)
+
+	c, err := fl.Load()
+	if err != nil {
+		return err
+	}
+	if err := fn(c); err != nil {
+		return err
+	}
+	return fl.Save(c)
+}
+
+// Update is a convenience function that uses the default FileLoader
+func Update(fn func(*Config) error) error {
+	return NewFileLoader().Update(fn)
+}
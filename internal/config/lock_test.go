@@ -0,0 +1,89 @@
+package config
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileLoader_Update_ConcurrentWriters(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "sortpath", "config.yaml")
+
+	// Each writer uses its own loader, like separate processes would
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers+2)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loader := &FileLoader{ConfigPath: configPath}
+			errs <- loader.Update(func(c *Config) error {
+				n, _ := strconv.Atoi(c.Model)
+				c.Model = strconv.Itoa(n + 1)
+				return nil
+			})
+		}()
+	}
+
+	// Two writers touching different keys must both survive
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		errs <- (&FileLoader{ConfigPath: configPath}).Update(func(c *Config) error {
+			c.LogLevel = "debug"
+			return nil
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		errs <- (&FileLoader{ConfigPath: configPath}).Update(func(c *Config) error {
+			c.APIBase = "https://example.com/v1"
+			return nil
+		})
+	}()
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	c, err := (&FileLoader{ConfigPath: configPath}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Model != strconv.Itoa(writers) {
+		t.Errorf("Model counter = %s, want %d (lost updates)", c.Model, writers)
+	}
+	if c.LogLevel != "debug" || c.APIBase != "https://example.com/v1" {
+		t.Errorf("config = %+v, want both LogLevel and APIBase changes", c)
+	}
+}
+
+func TestFileLoader_Update_LockTimeout(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	held, err := lockFile(configPath+".lock", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loader := &FileLoader{ConfigPath: configPath, LockTimeout: 100 * time.Millisecond}
+	err = loader.Update(func(c *Config) error { return nil })
+	if err == nil || !contains(err.Error(), "timed out") {
+		t.Errorf("Update() error = %v, want lock timeout", err)
+	}
+
+	// Once released, the lock can be taken again
+	if err := unlock(held); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Update(func(c *Config) error { return nil }); err != nil {
+		t.Errorf("Update() after unlock error = %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package config
+
+import "os"
+
+// tryLockFile is a no-op on Windows; config writes there remain atomic but
+// are not serialized between processes
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
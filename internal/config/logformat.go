@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var logFormats = []string{LogFormatText, LogFormatJSON}
+
+// ValidateLogFormat checks that format is empty or a known log format
+func ValidateLogFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range logFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid log format '%s'. Valid options: %s", format, strings.Join(logFormats, ", "))
+}
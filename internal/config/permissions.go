@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Config file permission policies, chosen with SORTPATH_CONFIG_PERMISSIONS
+const (
+	PermissionsWarn   = "warn"
+	PermissionsFix    = "fix"
+	PermissionsIgnore = "ignore"
+)
+
+var (
+	permissionsMu     sync.Mutex
+	permissionsWarned = map[string]bool{}
+)
+
+// checkPermissions handles a config file other users can read, since it may
+// hold the API key. By default a warning is printed, once per file per run;
+// SORTPATH_CONFIG_PERMISSIONS=fix tightens the file to 0600 instead, and
+// ignore skips the check. Windows has no such modes, so nothing is checked.
+func (fl *FileLoader) checkPermissions() {
+	policy := strings.ToLower(strings.TrimSpace(os.Getenv("SORTPATH_CONFIG_PERMISSIONS")))
+	if runtime.GOOS == "windows" || policy == PermissionsIgnore {
+		return
+	}
+	problem := DefaultSecureFileOps.ValidateFilePermissions(fl.ConfigPath)
+	if problem == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("⚠️ %v; other users may be able to read your API key. Run: chmod 600 %s (or set SORTPATH_CONFIG_PERMISSIONS=fix)", problem, fl.ConfigPath)
+	if policy == PermissionsFix {
+		if err := DefaultSecureFileOps.EnsureSecurePermissions(fl.ConfigPath); err == nil {
+			msg = fmt.Sprintf("🔒 Tightened permissions on %s to 0600", fl.ConfigPath)
+		}
+	}
+
+	permissionsMu.Lock()
+	defer permissionsMu.Unlock()
+	if !permissionsWarned[fl.ConfigPath] {
+		permissionsWarned[fl.ConfigPath] = true
+		fmt.Fprintln(warnOutput, msg)
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileLoader_InsecurePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes aren't checked on Windows")
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		wantMode os.FileMode
+		wantWarn string
+	}{
+		{name: "warns by default", wantMode: 0644, wantWarn: "insecure permissions 644"},
+		{name: "fix tightens the file", policy: PermissionsFix, wantMode: 0600, wantWarn: "Tightened permissions"},
+		{name: "ignore", policy: PermissionsIgnore, wantMode: 0644},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SORTPATH_CONFIG_PERMISSIONS", tt.policy)
+			var warnings bytes.Buffer
+			warnOutput = &warnings
+			defer func() { warnOutput = os.Stderr }()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("api_key: sk-shared-1234567890\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, 0644); err != nil {
+				t.Fatal(err)
+			}
+			loader := &FileLoader{ConfigPath: path}
+			for i := 0; i < 2; i++ {
+				c, err := loader.Load()
+				if err != nil || c.APIKey != "sk-shared-1234567890" {
+					t.Fatalf("Load() = %+v, %v; want the file loaded", c, err)
+				}
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.wantMode {
+				t.Errorf("mode = %o, want %o", info.Mode().Perm(), tt.wantMode)
+			}
+			got := warnings.String()
+			if tt.wantWarn == "" {
+				if got != "" {
+					t.Errorf("warnings = %q, want none", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.wantWarn) || strings.Count(got, "\n") != 1 {
+				t.Errorf("warnings = %q, want one line containing %q", got, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestFileLoader_SecurePermissionsQuiet(t *testing.T) {
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	defer func() { warnOutput = os.Stderr }()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("model: gpt-4\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&FileLoader{ConfigPath: path}).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("warnings = %q for a 0600 file, want none", warnings.String())
+	}
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// profileNamePattern keeps profile names usable as flags and YAML keys
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateProfileName checks that name is a usable profile name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name '%s'. Use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ProfileNames returns the names of the profiles in the config file, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileConfig is a named set of settings in the config file. It holds
+// only the keys a profile may override: everything in ConfigKeys but the
+// profile selection itself.
+type ProfileConfig struct {
+	// APIKey is written by the secret store under ProfileSecretName, like
+	// the top-level key
+	APIKey   string `yaml:"api_key,omitempty"`
+	APIBase  string `yaml:"api_base,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+	TreePath string `yaml:"tree_path,omitempty"`
+	LogLevel string `yaml:"log_level,omitempty"`
+
+	OnMissingTreePath string `yaml:"on_missing_tree_path,omitempty"`
+	PromptStyle       string `yaml:"prompt_style,omitempty"`
+	ResponseFormat    string `yaml:"response_format,omitempty"`
+	PinnedCertSHA256  string `yaml:"pinned_cert_sha256,omitempty"`
+	Provider          string `yaml:"provider,omitempty"`
+	Temperature       string `yaml:"temperature,omitempty"`
+	MaxTokens         string `yaml:"max_tokens,omitempty"`
+	Timeout           string `yaml:"timeout,omitempty"`
+	TreeDepth         string `yaml:"tree_depth,omitempty"`
+	TreeMaxBytes      string `yaml:"tree_max_bytes,omitempty"`
+	UpdateChannel     string `yaml:"update_channel,omitempty"`
+	PromptTemplate    string `yaml:"prompt_template,omitempty"`
+	ExtraRules        string `yaml:"extra_rules,omitempty"`
+	LogFormat         string `yaml:"log_format,omitempty"`
+	LogFile           string `yaml:"log_file,omitempty"`
+}
+
+// field returns the setting stored under a ConfigKeys key
+func (p *ProfileConfig) field(key string) (*string, error) {
+	switch key {
+	case "api-key":
+		return &p.APIKey, nil
+	case "api-base":
+		return &p.APIBase, nil
+	case "model":
+		return &p.Model, nil
+	case "tree-path":
+		return &p.TreePath, nil
+	case "log-level":
+		return &p.LogLevel, nil
+	case "on-missing-tree":
+		return &p.OnMissingTreePath, nil
+	case "prompt-style":
+		return &p.PromptStyle, nil
+	case "response-format":
+		return &p.ResponseFormat, nil
+	case "pinned-cert-sha256":
+		return &p.PinnedCertSHA256, nil
+	case "provider":
+		return &p.Provider, nil
+	case "temperature":
+		return &p.Temperature, nil
+	case "max-tokens":
+		return &p.MaxTokens, nil
+	case "timeout":
+		return &p.Timeout, nil
+	case "tree-depth":
+		return &p.TreeDepth, nil
+	case "tree-max-bytes":
+		return &p.TreeMaxBytes, nil
+	case "update-channel":
+		return &p.UpdateChannel, nil
+	case "prompt-template":
+		return &p.PromptTemplate, nil
+	case "extra-rules":
+		return &p.ExtraRules, nil
+	case "log-format":
+		return &p.LogFormat, nil
+	case "log-file":
+		return &p.LogFile, nil
+	case "profile":
+		return nil, fmt.Errorf("profiles can't select another profile")
+	default:
+		return nil, fmt.Errorf("unknown config key: %s", key)
+	}
+}
+
+// Value returns the raw value stored under a ConfigKeys key. The profile
+// key is always empty.
+func (p *ProfileConfig) Value(key string) (string, error) {
+	if key == "profile" {
+		return "", nil
+	}
+	f, err := p.field(key)
+	if err != nil {
+		return "", err
+	}
+	return *f, nil
+}
+
+// SetValue stores value under a ConfigKeys key other than profile
+func (p *ProfileConfig) SetValue(key, value string) error {
+	f, err := p.field(key)
+	if err != nil {
+		return err
+	}
+	*f = value
+	return nil
+}
+
+// isEmpty reports whether no value is set
+func (p *ProfileConfig) isEmpty() bool {
+	return *p == ProfileConfig{}
+}
+
+// ProfileSecretName is the secret name of the named profile's API key in a
+// SecretStore; an empty profile names the top-level key
+func ProfileSecretName(profile string) string {
+	if profile == "" {
+		return APIKeySecret
+	}
+	return "profiles." + profile + "." + APIKeySecret
+}
+
+// secretProfile returns the profile a secret name belongs to, "" for the
+// top-level API key
+func secretProfile(name string) (string, error) {
+	if name == APIKeySecret {
+		return "", nil
+	}
+	profile, ok := strings.CutPrefix(name, "profiles.")
+	if ok {
+		profile, ok = strings.CutSuffix(profile, "."+APIKeySecret)
+	}
+	if !ok || ValidateProfileName(profile) != nil {
+		return "", fmt.Errorf("unknown secret: %s", name)
+	}
+	return profile, nil
+}
+
+// SetProfileValue stores value under a ConfigKeys key in the named profile,
+// creating the profile if needed. An empty value removes the key, and a
+// profile left with no values is removed. The API key goes through the
+// secret store under ProfileSecretName instead.
+func (c *Config) SetProfileValue(profile, key, value string) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+	if key == APIKeySecret {
+		return fmt.Errorf("a profile's api-key is kept in the secret store, not set directly")
+	}
+	p := c.Profiles[profile]
+	if err := p.SetValue(key, value); err != nil {
+		return err
+	}
+	c.putProfile(profile, p)
+	return nil
+}
+
+// putProfile stores p under name, removing the profile when it is empty
+func (c *Config) putProfile(name string, p ProfileConfig) {
+	if p.isEmpty() {
+		delete(c.Profiles, name)
+		return
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]ProfileConfig{}
+	}
+	c.Profiles[name] = p
+}
+
+// withProfile returns the file config with the named profile's values laid
+// over the top-level ones. An unknown profile changes nothing; validation
+// reports it.
+func (c *Config) withProfile(name string) *Config {
+	p, ok := c.Profiles[name]
+	if name == "" || !ok {
+		return c
+	}
+	merged := *c
+	for _, key := range ConfigKeys {
+		if v, _ := p.Value(key); v != "" {
+			merged.SetValue(key, v)
+		}
+	}
+	return &merged
+}
+
+// profileProblem reports an active profile missing from the config file
+func (c *Config) profileProblem() error {
+	if c.Profile == "" {
+		return nil
+	}
+	if _, ok := c.Profiles[c.Profile]; ok {
+		return nil
+	}
+	if len(c.Profiles) == 0 {
+		return fmt.Errorf("unknown profile '%s'. Create it with: sortpath config set --profile %s <key> <value>", c.Profile, c.Profile)
+	}
+	return fmt.Errorf("unknown profile '%s'. Available profiles: %s", c.Profile, strings.Join(c.ProfileNames(), ", "))
+}
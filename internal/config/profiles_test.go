@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveConfig_Profiles(t *testing.T) {
+	stubEnvironment(t, "interactive")
+	for _, name := range []string{"OPENAI_API_KEY", "OPENAI_API_BASE", "OPENAI_MODEL", "SORTPATH_PROFILE"} {
+		t.Setenv(name, "")
+	}
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	yaml := `api_key: sk-top
+model: top-model
+api_base: https://api.openai.com/v1
+profile: work
+profiles:
+  work:
+    api_base: https://work.example.com/v1
+    model: work-model
+  personal:
+    api_key: sk-personal
+    model: personal-model
+`
+	if err := os.WriteFile(configPath, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := &FileLoader{ConfigPath: configPath}
+	resolve := func(opts CLIOptions) *Config {
+		t.Helper()
+		opts.TreePath = dir
+		conf, err := ResolveConfigWithLoader(opts, loader)
+		if err != nil {
+			t.Fatalf("ResolveConfigWithLoader() error = %v", err)
+		}
+		return conf
+	}
+
+	conf := resolve(CLIOptions{})
+	if conf.Profile != "work" || conf.Model != "work-model" || conf.APIBase != "https://work.example.com/v1" {
+		t.Errorf("default profile: profile %q, model %q, base %q", conf.Profile, conf.Model, conf.APIBase)
+	}
+	if conf.APIKey != "sk-top" {
+		t.Errorf("keys a profile doesn't set fall back to the top level; api key = %q", conf.APIKey)
+	}
+
+	t.Setenv("SORTPATH_PROFILE", "personal")
+	conf = resolve(CLIOptions{})
+	if conf.Profile != "personal" || conf.Model != "personal-model" || conf.APIKey != "sk-personal" {
+		t.Errorf("env beats default profile: profile %q, model %q, key %q", conf.Profile, conf.Model, conf.APIKey)
+	}
+	if conf.APIBase != "https://api.openai.com/v1" {
+		t.Errorf("personal profile should keep the top-level base, got %q", conf.APIBase)
+	}
+
+	conf = resolve(CLIOptions{Profile: "work"})
+	if conf.Profile != "work" || conf.Model != "work-model" {
+		t.Errorf("flag beats env: profile %q, model %q", conf.Profile, conf.Model)
+	}
+
+	conf = resolve(CLIOptions{Profile: "work", Model: "flag-model"})
+	if conf.Model != "flag-model" {
+		t.Errorf("a flag beats the profile's value, got model %q", conf.Model)
+	}
+
+	_, err := ResolveConfigWithLoader(CLIOptions{TreePath: dir, Profile: "missing"}, loader)
+	if err == nil || !strings.Contains(err.Error(), "personal, work") {
+		t.Errorf("unknown profile error = %v, want it to list the profiles", err)
+	}
+}
+
+func TestConfig_SetProfileValue(t *testing.T) {
+	var c Config
+	if err := c.SetProfileValue("work", "model", "gpt-4o"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Profiles["work"].Model; got != "gpt-4o" {
+		t.Errorf("profile model = %q, want gpt-4o", got)
+	}
+	if c.Model != "" {
+		t.Errorf("top-level model changed to %q", c.Model)
+	}
+
+	if err := c.SetProfileValue("work", "profile", "other"); err == nil {
+		t.Error("expected a profile to be unable to select another profile")
+	}
+	if err := c.SetProfileValue("work", "api-key", "sk-x"); err == nil {
+		t.Error("expected a profile's api-key to be left to the secret store")
+	}
+	if err := c.SetProfileValue("bad name", "model", "x"); err == nil {
+		t.Error("expected an invalid profile name to be rejected")
+	}
+
+	// Clearing the last value removes the profile
+	if err := c.SetProfileValue("work", "model", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Profiles["work"]; ok {
+		t.Error("empty profile should be removed")
+	}
+}
+
+func TestLoad_ProfileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `profiles:
+  work:
+    model: work-model
+    installed_path: /usr/local/bin/sortpath
+    profiles:
+      nested:
+        model: x
+`
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := (&FileLoader{ConfigPath: path}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProfileConfig{Model: "work-model"}
+	if got := c.Profiles["work"]; got != want {
+		t.Errorf("work profile = %+v, want only the keys a profile may set", got)
+	}
+	if c.InstalledPath != "" {
+		t.Errorf("InstalledPath = %q, a profile's value leaked to the top level", c.InstalledPath)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigName is the per-directory config file looked up in the tree
+const ProjectConfigName = ".sortpath.yaml"
+
+// ProjectConfig holds the settings a .sortpath.yaml may override for its
+// tree. They beat the global config file but lose to flags and environment
+// variables. Credentials and endpoints are deliberately absent: an api_key
+// or api_base in a project file is ignored, so a folder someone else
+// controls can't redirect or replace your key.
+type ProjectConfig struct {
+	Model          string `yaml:"model,omitempty"`
+	TreePath       string `yaml:"tree_path,omitempty"`
+	ExtraRules     string `yaml:"extra_rules,omitempty"`
+	PromptTemplate string `yaml:"prompt_template,omitempty"`
+}
+
+// vcsMarkers name the entries that make a folder a repository root
+var vcsMarkers = []string{".git", ".hg", ".svn"}
+
+// FindProjectConfig returns the nearest .sortpath.yaml in dir or one of its
+// parents up to the enclosing repository root, or "" when there is none.
+// Outside a repository only dir itself is searched, so a stray file in $HOME
+// or / doesn't apply to every tree.
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	stop := vcsRoot(dir)
+	if stop == "" {
+		stop = dir
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+		if dir == stop {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// vcsRoot returns the nearest folder at or above dir holding a VCS marker,
+// or "" when dir isn't in a repository
+func vcsRoot(dir string) string {
+	for {
+		for _, marker := range vcsMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig reads the project file at path. Relative tree_path and
+// prompt_template values are taken relative to the file's folder.
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pc ProjectConfig
+	if err := yaml.Unmarshal(data, &pc); err != nil {
+		return nil, fmt.Errorf("project config %s is not valid YAML, so its settings are ignored: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&pc.TreePath, &pc.PromptTemplate} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return &pc, nil
+}
+
+// projectLookupDir is the folder the project file search starts from: the
+// tree path as CLI, ENV or the global file set it, else the working directory
+func projectLookupDir(opts CLIOptions, fileConfig *Config) string {
+	for _, dir := range []string{opts.TreePath, os.Getenv("SORTPATH_FOLDER_TREE"), fileConfig.TreePath} {
+		if dir != "" && dir != "." {
+			return dir
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return wd
+}
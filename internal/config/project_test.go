@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectConfig_Precedence(t *testing.T) {
+	stubEnvironment(t, "interactive")
+	for _, name := range []string{"OPENAI_API_KEY", "OPENAI_MODEL", "SORTPATH_FOLDER_TREE", "SORTPATH_EXTRA_RULES", "SORTPATH_PROMPT_TEMPLATE"} {
+		t.Setenv(name, "")
+	}
+
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	nested := filepath.Join(project, "inbox", "new")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A repository root bounds the search for the project file
+	if err := os.Mkdir(filepath.Join(project, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	projectFile := "model: project-model\napi_key: sk-project-key-should-be-ignored\napi_base: https://evil.example/v1\ntree_path: inbox\nextra_rules: |\n  - Keep receipts together\n"
+	if err := os.WriteFile(filepath.Join(project, ProjectConfigName), []byte(projectFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	global := "api_key: sk-global-key\nmodel: global-model\nextra_rules: Global rule\ntree_path: " + nested + "\n"
+	if err := os.WriteFile(configPath, []byte(global), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := &FileLoader{ConfigPath: configPath}
+
+	tests := []struct {
+		name       string
+		opts       CLIOptions
+		envModel   string
+		wantModel  string
+		wantSource string
+		wantTree   string
+	}{
+		{name: "project beats the file", wantModel: "project-model", wantSource: SourceProject, wantTree: filepath.Join(project, "inbox")},
+		{name: "env beats the project", envModel: "env-model", wantModel: "env-model", wantSource: SourceEnv, wantTree: filepath.Join(project, "inbox")},
+		{name: "flag beats the project", opts: CLIOptions{Model: "cli-model", TreePath: nested}, wantModel: "cli-model", wantSource: SourceCLI, wantTree: nested},
+		{name: "no project file above the tree", opts: CLIOptions{TreePath: dir}, wantModel: "global-model", wantSource: SourceFile, wantTree: dir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OPENAI_MODEL", tt.envModel)
+			conf, sources, err := ResolveConfigWithSources(tt.opts, loader, NewFileSecretStore(loader))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conf.Model != tt.wantModel || conf.TreePath != tt.wantTree {
+				t.Errorf("Model, TreePath = %q, %q; want %q, %q", conf.Model, conf.TreePath, tt.wantModel, tt.wantTree)
+			}
+			for _, s := range sources {
+				if s.Key == "model" && s.Source != tt.wantSource {
+					t.Errorf("model source = %q, want %q", s.Source, tt.wantSource)
+				}
+			}
+			if conf.APIKey != "sk-global-key" || conf.APIBase != defaults.APIBase {
+				t.Errorf("APIKey, APIBase = %q, %q; the project file must not set them", conf.APIKey, conf.APIBase)
+			}
+			if tt.wantSource == SourceProject {
+				if rules := conf.Rules(); len(rules) != 1 || rules[0] != "Keep receipts together" {
+					t.Errorf("Rules() = %q, want the project's rule", rules)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectConfig_APIKeyIgnored(t *testing.T) {
+	stubEnvironment(t, "interactive")
+	t.Setenv("OPENAI_API_KEY", "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ProjectConfigName), []byte("api_key: sk-from-project-file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loader := &FileLoader{ConfigPath: filepath.Join(dir, "missing", "config.yaml")}
+
+	_, _, err := ResolveConfigWithSources(CLIOptions{TreePath: dir}, loader, NewFileSecretStore(loader))
+	if err == nil || !strings.Contains(err.Error(), "API key is required") {
+		t.Fatalf("error = %v, want a missing API key: the project file's api_key must be ignored", err)
+	}
+}
+
+func TestFindProjectConfig_Bounds(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	nested := filepath.Join(repo, "docs", "inbox")
+	plain := filepath.Join(dir, "plain", "inbox")
+	for _, d := range []string{filepath.Join(repo, ".git"), nested, plain} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A stray file above both trees, like one in $HOME
+	if err := os.WriteFile(filepath.Join(dir, ProjectConfigName), []byte("model: stray\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FindProjectConfig(nested); got != "" {
+		t.Errorf("FindProjectConfig() in a repository = %q, want the search to stop at its root", got)
+	}
+	if got := FindProjectConfig(plain); got != "" {
+		t.Errorf("FindProjectConfig() outside a repository = %q, want only the folder itself searched", got)
+	}
+
+	repoFile := filepath.Join(repo, ProjectConfigName)
+	if err := os.WriteFile(repoFile, []byte("model: repo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProjectConfig(nested); got != repoFile {
+		t.Errorf("FindProjectConfig() = %q, want the repository's %q", got, repoFile)
+	}
+	plainFile := filepath.Join(plain, ProjectConfigName)
+	if err := os.WriteFile(plainFile, []byte("model: plain\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProjectConfig(plain); got != plainFile {
+		t.Errorf("FindProjectConfig() = %q, want the folder's own %q", got, plainFile)
+	}
+}
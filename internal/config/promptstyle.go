@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prompt styles
+const (
+	PromptStyleRich    = "rich"
+	PromptStyleMinimal = "minimal"
+)
+
+var promptStyles = []string{PromptStyleRich, PromptStyleMinimal}
+
+// ValidatePromptStyle checks that style is empty or a known prompt style
+func ValidatePromptStyle(style string) error {
+	if style == "" {
+		return nil
+	}
+	for _, s := range promptStyles {
+		if style == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid prompt style '%s'. Valid options: %s", style, strings.Join(promptStyles, ", "))
+}
@@ -0,0 +1,16 @@
+package config
+
+import "github.com/kacperkwapisz/sortpath/internal/ai"
+
+// ValidatePromptTemplate checks that path is empty or a prompt template file
+// that parses and uses {{.Description}}
+func ValidatePromptTemplate(path string) error {
+	if path == "" {
+		return nil
+	}
+	tmpl, err := ai.LoadTemplate(path)
+	if err != nil {
+		return err
+	}
+	return ai.CheckTemplate(tmpl)
+}
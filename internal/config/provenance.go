@@ -0,0 +1,96 @@
+package config
+
+import "os"
+
+// Value sources reported by config resolution
+const (
+	SourceCLI     = "cli"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceProfile = "profile"
+	SourceProject = "project"
+	SourceDefault = "default"
+)
+
+// FieldSource records where a resolved config value came from. Value is
+// already redacted and safe to log.
+type FieldSource struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// DebugLogger is the subset of the application logger used to trace resolution
+type DebugLogger interface {
+	Debug(msg string, args ...interface{})
+}
+
+// LogSources writes one debug line per resolved field
+func LogSources(logger DebugLogger, sources []FieldSource) {
+	for _, s := range sources {
+		logger.Debug("config %s = %s (source: %s)", s.Key, s.Value, s.Source)
+	}
+}
+
+// provenance accumulates FieldSource entries while merging
+type provenance []FieldSource
+
+// resolve applies resolveValue's priority and records which layer won
+func (p *provenance) resolve(key, cli, envVar, file, defaultVal string) string {
+	env := ""
+	if envVar != "" {
+		env = os.Getenv(envVar)
+	}
+
+	value, source := defaultVal, SourceDefault
+	switch {
+	case cli != "":
+		value, source = cli, SourceCLI
+	case env != "":
+		value, source = env, SourceEnv
+	case file != "":
+		value, source = file, SourceFile
+	}
+
+	p.record(key, value, source)
+	return value
+}
+
+// resolveProfiled is resolve with an environment profile layer that sits
+// between the environment variable and the config file
+func (p *provenance) resolveProfiled(key, cli, envVar, profile, file, defaultVal string) string {
+	if profile != "" && cli == "" && (envVar == "" || os.Getenv(envVar) == "") {
+		p.record(key, profile, SourceProfile)
+		return profile
+	}
+	return p.resolve(key, cli, envVar, file, defaultVal)
+}
+
+// resolveProject is resolve with a .sortpath.yaml layer that sits between
+// the environment variable and the config file
+func (p *provenance) resolveProject(key, cli, envVar, project, file, defaultVal string) string {
+	if project != "" && cli == "" && (envVar == "" || os.Getenv(envVar) == "") {
+		p.record(key, project, SourceProject)
+		return project
+	}
+	return p.resolve(key, cli, envVar, file, defaultVal)
+}
+
+// record appends a field, redacting secrets so the full API key is never kept
+func (p *provenance) record(key, value, source string) {
+	shown := "(unset)"
+	if value != "" {
+		shown = RedactSensitiveValue(key, value)
+	}
+	*p = append(*p, FieldSource{Key: key, Value: shown, Source: source})
+}
+
+// set updates the recorded value of key after a post-merge adjustment
+func (p provenance) set(key, value, source string) {
+	for i := range p {
+		if p[i].Key == key {
+			p[i].Value = RedactSensitiveValue(key, value)
+			p[i].Source = source
+		}
+	}
+}
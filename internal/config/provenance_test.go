@@ -0,0 +1,52 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/app"
+)
+
+func TestLogSources_AnnotatesAndRedacts(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("model: file-model\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-env-secret-1234567890")
+	t.Setenv("OPENAI_API_BASE", "")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("SORTPATH_FOLDER_TREE", "")
+	t.Setenv("SORTPATH_LOG_LEVEL", "")
+	t.Setenv("SORTPATH_ON_MISSING_TREE", "")
+	t.Setenv("SORTPATH_TRACE_ID", "")
+	t.Setenv("SORTPATH_TRACE_HEADER", "")
+
+	loader := &FileLoader{ConfigPath: configPath}
+	_, sources, err := ResolveConfigWithSources(CLIOptions{TreePath: tmpDir}, loader, &fakeSecretStore{})
+	if err != nil {
+		t.Fatalf("ResolveConfigWithSources() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	LogSources(app.NewLoggerWithOutput(app.LogLevelDebug, &buf, &buf), sources)
+	out := buf.String()
+
+	for _, want := range []string{
+		"config api-key = sk-e...7890 (source: env)",
+		"config model = file-model (source: file)",
+		"config tree-path = " + tmpDir + " (source: cli)",
+		"config api-base = https://api.openai.com/v1 (source: default)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("debug output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "sk-env-secret-1234567890") {
+		t.Errorf("debug output leaked the full API key:\n%s", out)
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/util"
+)
+
+// Providers with built-in request parameter profiles
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// ProviderProfile holds the request parameters sent to a provider when the
+// user hasn't set them. Empty fields are not sent at all.
+type ProviderProfile struct {
+	Temperature string
+	MaxTokens   string
+}
+
+// providerProfiles are the per-provider defaults. Anthropic rejects requests
+// without max_tokens; OpenAI-compatible servers pick their own defaults.
+var providerProfiles = map[string]ProviderProfile{
+	ProviderOpenAI:    {},
+	ProviderAnthropic: {MaxTokens: "1024"},
+}
+
+// ProfileFor returns the default parameters for provider, or an empty profile
+// for unknown providers
+func ProfileFor(provider string) ProviderProfile {
+	return providerProfiles[strings.ToLower(provider)]
+}
+
+// ValidateProvider checks that provider is empty or has a built-in profile
+func ValidateProvider(provider string) error {
+	if provider == "" {
+		return nil
+	}
+	if _, ok := providerProfiles[provider]; ok {
+		return nil
+	}
+	names := make([]string, 0, len(providerProfiles))
+	for name := range providerProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("invalid provider '%s'. Valid options: %s", provider, strings.Join(names, ", "))
+}
+
+// ValidateTemperature checks that t is empty or a number between 0 and 2
+func ValidateTemperature(t string) error {
+	if t == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(t, 64)
+	if err != nil || v < 0 || v > 2 {
+		return fmt.Errorf("invalid temperature '%s'. Use a number between 0 and 2", t)
+	}
+	return nil
+}
+
+// ValidateMaxTokens checks that n is empty or a positive integer
+func ValidateMaxTokens(n string) error {
+	if n == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(n)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("invalid max tokens '%s'. Use a positive whole number", n)
+	}
+	return nil
+}
+
+// ValidateTimeout checks that d is empty or a positive duration such as 30s,
+// 2m or 1d
+func ValidateTimeout(d string) error {
+	if d == "" {
+		return nil
+	}
+	if v, err := util.ParseDuration(d, "timeout"); err != nil || v <= 0 {
+		return fmt.Errorf("invalid timeout '%s'. Use a positive duration such as 30s or 2m", d)
+	}
+	return nil
+}
+
+// RequestTimeout returns Timeout as a duration for the API client, or 0 (no
+// limit) when it is unset or invalid
+func (c *Config) RequestTimeout() time.Duration {
+	d, err := util.ParseDuration(c.Timeout, "timeout")
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// RequiresAPIKey reports whether requests need an API key. Servers on this
+// machine (see IsLocalAPIBase), such as Ollama, usually don't check one, and
+// --no-auth drops the requirement for any server.
+func (c *Config) RequiresAPIKey() bool {
+	return !c.NoAuth && !IsLocalAPIBase(c.APIBase)
+}
+
+// IsLocalAPIBase reports whether base points at localhost or a loopback
+// address such as 127.0.0.1 or [::1]
+func IsLocalAPIBase(base string) bool {
+	u, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Response formats: the XML <recommendation> block every provider can
+// produce, or a JSON object requested with the provider's JSON output mode
+const (
+	ResponseFormatXML  = "xml"
+	ResponseFormatJSON = "json"
+)
+
+var responseFormats = []string{ResponseFormatXML, ResponseFormatJSON}
+
+// ValidateResponseFormat checks that format is empty or a known response format
+func ValidateResponseFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range responseFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid response format '%s'. Valid options: %s", format, strings.Join(responseFormats, ", "))
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// APIKeySecret is the secret name under which the API key is stored;
+// profiles' keys are named by ProfileSecretName
+const APIKeySecret = "api-key"
+
+// SecretStore abstracts where sensitive configuration values are kept so that
+// keyring or encrypted backends can replace the plaintext config file
+type SecretStore interface {
+	GetSecret(name string) (string, error)
+	SetSecret(name, value string) error
+	DeleteSecret(name string) error
+}
+
+// FileSecretStore keeps secrets in the YAML config file alongside other
+// settings, a profile's API key in that profile. A secret moved to the OS keychain with SetSecretInKeychain is
+// recorded in the file as KeychainSentinel and read from the keychain.
+type FileSecretStore struct {
+	// Loader reads and writes the config file; nil means the default location
+	Loader Loader
+
+	// Keychain holds secrets stored there; nil means DefaultKeychain
+	Keychain Keychain
+}
+
+// NewFileSecretStore creates a FileSecretStore backed by the given loader
+func NewFileSecretStore(loader Loader) *FileSecretStore {
+	return &FileSecretStore{Loader: loader}
+}
+
+func (s *FileSecretStore) loader() Loader {
+	if s.Loader == nil {
+		return NewFileLoader()
+	}
+	return s.Loader
+}
+
+// GetSecret returns the stored secret, or an empty string if it is not set
+func (s *FileSecretStore) GetSecret(name string) (string, error) {
+	if err := checkSecretName(name); err != nil {
+		return "", err
+	}
+	c, err := s.loader().Load()
+	if err != nil {
+		return "", err
+	}
+	return s.secretIn(c, name)
+}
+
+// secretIn returns name's secret from c, a config this store's loader has
+// already read, so resolution doesn't read the file a second time
+func (s *FileSecretStore) secretIn(c *Config, name string) (string, error) {
+	profile, err := secretProfile(name)
+	if err != nil {
+		return "", err
+	}
+	stored := storedSecret(c, profile)
+	if stored != KeychainSentinel {
+		return stored, nil
+	}
+	secret, err := s.keychain().Get(s.account(name))
+	if err != nil {
+		set := "sortpath config set " + APIKeySecret
+		if profile != "" {
+			set = "sortpath config set --profile " + profile + " " + APIKeySecret
+		}
+		return "", fmt.Errorf("the %s is stored in the keychain but can't be read (%w). Set it again with: %s YOUR_KEY", name, err, set)
+	}
+	return secret, nil
+}
+
+// SetSecret stores the secret in the config file, or in the keychain when
+// it is already kept there. If that keychain is unavailable it returns
+// ErrKeychainUnavailable and changes nothing, rather than quietly moving the
+// secret into the file; SetSecretInFile does that on request.
+func (s *FileSecretStore) SetSecret(name, value string) error {
+	if err := checkSecretName(name); err != nil {
+		return err
+	}
+	if s.inKeychain(name) {
+		return s.keychain().Set(s.account(name), value)
+	}
+	return s.update(name, value)
+}
+
+// SetSecretInFile stores the secret in the config file even when it is kept
+// in the keychain, leaving the keychain item as it is. An empty value
+// removes the secret from the file.
+func (s *FileSecretStore) SetSecretInFile(name, value string) error {
+	if err := checkSecretName(name); err != nil {
+		return err
+	}
+	return s.update(name, value)
+}
+
+// SetSecretInKeychain stores the secret in the OS keychain, leaving
+// KeychainSentinel in the config file. It returns ErrKeychainUnavailable,
+// and changes nothing, when there is no usable keychain.
+func (s *FileSecretStore) SetSecretInKeychain(name, value string) error {
+	if err := checkSecretName(name); err != nil {
+		return err
+	}
+	if err := s.keychain().Set(s.account(name), value); err != nil {
+		return err
+	}
+	return s.update(name, KeychainSentinel)
+}
+
+// DeleteSecret removes the secret from the config file and the keychain. If
+// it is kept in a keychain that is unavailable, it returns
+// ErrKeychainUnavailable and changes nothing, so the keychain item isn't
+// left behind unnoticed.
+func (s *FileSecretStore) DeleteSecret(name string) error {
+	if err := checkSecretName(name); err != nil {
+		return err
+	}
+	if s.inKeychain(name) {
+		if err := s.keychain().Delete(s.account(name)); err != nil {
+			return err
+		}
+	}
+	return s.update(name, "")
+}
+
+func (s *FileSecretStore) keychain() Keychain {
+	if s.Keychain == nil {
+		return DefaultKeychain
+	}
+	return s.Keychain
+}
+
+// inKeychain reports whether the config file points at the keychain for name
+func (s *FileSecretStore) inKeychain(name string) bool {
+	profile, _ := secretProfile(name)
+	c, err := s.loader().Load()
+	return err == nil && storedSecret(c, profile) == KeychainSentinel
+}
+
+// account names the keychain item for name. Items are per config file, so
+// configs chosen with --config keep separate keys.
+func (s *FileSecretStore) account(name string) string {
+	if fl, ok := s.loader().(*FileLoader); ok && fl.ConfigPath != "" {
+		path, err := filepath.Abs(fl.ConfigPath)
+		if err != nil {
+			path = fl.ConfigPath
+		}
+		return name + "@" + path
+	}
+	return name
+}
+
+func (s *FileSecretStore) update(name, value string) error {
+	profile, err := secretProfile(name)
+	if err != nil {
+		return err
+	}
+	set := func(c *Config) error {
+		if profile == "" {
+			c.APIKey = value
+			return nil
+		}
+		p := c.Profiles[profile]
+		p.APIKey = value
+		c.putProfile(profile, p)
+		return nil
+	}
+	loader := s.loader()
+	if u, ok := loader.(interface{ Update(func(*Config) error) error }); ok {
+		return u.Update(set)
+	}
+	c, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	if err := set(c); err != nil {
+		return err
+	}
+	return loader.Save(c)
+}
+
+// loadedSecret returns name's secret like store.GetSecret. When store is a
+// FileSecretStore over the same file as loader, it reads the secret from c,
+// the config loader just returned with loadErr, instead of loading the file
+// again, which could repeat a prompt about a broken config directory.
+func loadedSecret(store SecretStore, loader Loader, c *Config, loadErr error, name string) (string, error) {
+	fs, ok := store.(*FileSecretStore)
+	if !ok || !sameConfigFile(fs.loader(), loader) {
+		return store.GetSecret(name)
+	}
+	if loadErr != nil {
+		return "", loadErr
+	}
+	return fs.secretIn(c, name)
+}
+
+// sameConfigFile reports whether two loaders read the same file
+func sameConfigFile(a, b Loader) bool {
+	if a == b {
+		return true
+	}
+	fa, okA := a.(*FileLoader)
+	fb, okB := b.(*FileLoader)
+	return okA && okB && fa.ConfigPath == fb.ConfigPath
+}
+
+// storedSecret returns the API key as written in the config file for the
+// named profile, or the top-level one when profile is empty
+func storedSecret(c *Config, profile string) string {
+	if profile == "" {
+		return c.APIKey
+	}
+	return c.Profiles[profile].APIKey
+}
+
+func checkSecretName(name string) error {
+	_, err := secretProfile(name)
+	return err
+}
+
+// DefaultSecretStore is the store used by ResolveConfig and the config subcommands
+var DefaultSecretStore SecretStore = &FileSecretStore{}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// fakeSecretStore is an in-memory SecretStore for tests
+type fakeSecretStore struct {
+	secrets map[string]string
+}
+
+func (f *fakeSecretStore) GetSecret(name string) (string, error) {
+	return f.secrets[name], nil
+}
+
+func (f *fakeSecretStore) SetSecret(name, value string) error {
+	if f.secrets == nil {
+		f.secrets = make(map[string]string)
+	}
+	f.secrets[name] = value
+	return nil
+}
+
+func (f *fakeSecretStore) DeleteSecret(name string) error {
+	delete(f.secrets, name)
+	return nil
+}
+
+func TestFileSecretStore_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := &FileLoader{ConfigPath: filepath.Join(tmpDir, "config.yaml")}
+	store := NewFileSecretStore(loader)
+
+	// Unset secret reads as empty
+	got, err := store.GetSecret(APIKeySecret)
+	if err != nil {
+		t.Fatalf("GetSecret() error = %v", err)
+	}
+	if got != "" {
+		t.Errorf("GetSecret() = %q, want empty", got)
+	}
+
+	// Pre-existing non-secret values must survive secret writes
+	if err := loader.Save(&Config{Model: "gpt-4"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSecret(APIKeySecret, "sk-file"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	c, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKey != "sk-file" || c.Model != "gpt-4" {
+		t.Errorf("after SetSecret config = %+v, want APIKey sk-file and Model gpt-4", c)
+	}
+
+	got, _ = store.GetSecret(APIKeySecret)
+	if got != "sk-file" {
+		t.Errorf("GetSecret() = %q, want sk-file", got)
+	}
+
+	if err := store.DeleteSecret(APIKeySecret); err != nil {
+		t.Fatalf("DeleteSecret() error = %v", err)
+	}
+	got, _ = store.GetSecret(APIKeySecret)
+	if got != "" {
+		t.Errorf("GetSecret() after delete = %q, want empty", got)
+	}
+}
+
+func TestFileSecretStore_UnknownSecret(t *testing.T) {
+	store := NewFileSecretStore(&FileLoader{ConfigPath: filepath.Join(t.TempDir(), "config.yaml")})
+
+	if _, err := store.GetSecret("model"); err == nil {
+		t.Error("GetSecret() expected error for unknown secret")
+	}
+	if err := store.SetSecret("model", "x"); err == nil {
+		t.Error("SetSecret() expected error for unknown secret")
+	}
+}
+
+func TestResolveConfigWithStore_UsesStore(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	tmpDir := t.TempDir()
+	loader := &FileLoader{ConfigPath: filepath.Join(tmpDir, "config.yaml")}
+	if err := loader.Save(&Config{APIKey: "file-key", TreePath: tmpDir}); err != nil {
+		t.Fatal(err)
+	}
+	store := &fakeSecretStore{secrets: map[string]string{APIKeySecret: "store-key"}}
+
+	c, err := ResolveConfigWithStore(CLIOptions{}, loader, store)
+	if err != nil {
+		t.Fatalf("ResolveConfigWithStore() error = %v", err)
+	}
+	if c.APIKey != "store-key" {
+		t.Errorf("APIKey = %q, want store-key", c.APIKey)
+	}
+
+	// CLI still wins over the store
+	c, err = ResolveConfigWithStore(CLIOptions{APIKey: "cli-key"}, loader, store)
+	if err != nil {
+		t.Fatalf("ResolveConfigWithStore() error = %v", err)
+	}
+	if c.APIKey != "cli-key" {
+		t.Errorf("APIKey = %q, want cli-key", c.APIKey)
+	}
+}
+
+// countingLoader counts how often the config file is read
+type countingLoader struct {
+	*FileLoader
+	loads int
+}
+
+func (l *countingLoader) Load() (*Config, error) {
+	l.loads++
+	return l.FileLoader.Load()
+}
+
+func TestResolveConfig_LoadsFileOnce(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("SORTPATH_PROFILE", "")
+	stubEnvironment(t, "interactive")
+	tmpDir := t.TempDir()
+	file := &FileLoader{ConfigPath: filepath.Join(tmpDir, "config.yaml")}
+	conf := &Config{APIKey: "file-key", TreePath: tmpDir, Profile: "work",
+		Profiles: map[string]ProfileConfig{"work": {APIKey: "work-key"}}}
+	if err := file.Save(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := &countingLoader{FileLoader: file}
+	c, err := ResolveConfigWithLoader(CLIOptions{}, loader)
+	if err != nil {
+		t.Fatalf("ResolveConfigWithLoader() error = %v", err)
+	}
+	if c.APIKey != "work-key" {
+		t.Errorf("APIKey = %q, want the profile's", c.APIKey)
+	}
+	if loader.loads != 1 {
+		t.Errorf("config file read %d times, want once", loader.loads)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
 )
 
 // SanitizePath validates and sanitizes file paths to prevent directory traversal attacks
@@ -31,21 +33,40 @@ func SanitizePath(path string) (string, error) {
 	return cleanPath, nil
 }
 
+// ConfigKeys lists every user-settable configuration key in display order.
+// New keys are appended so that `config list` output stays stable.
+var ConfigKeys = []string{
+	"api-key",
+	"api-base",
+	"model",
+	"tree-path",
+	"log-level",
+	"on-missing-tree",
+	"prompt-style",
+	"response-format",
+	"pinned-cert-sha256",
+	"provider",
+	"temperature",
+	"max-tokens",
+	"timeout",
+	"tree-depth",
+	"tree-max-bytes",
+	"profile",
+	"update-channel",
+	"prompt-template",
+	"extra-rules",
+	"log-format",
+	"log-file",
+}
+
 // ValidateConfigKey ensures the configuration key is one of the allowed values
 func ValidateConfigKey(key string) error {
-	allowedKeys := map[string]bool{
-		"api-key":   true,
-		"api-base":  true,
-		"model":     true,
-		"tree-path": true,
-		"log-level": true,
-	}
-
-	if !allowedKeys[key] {
-		return fmt.Errorf("unknown config key: %s. Valid keys: api-key, api-base, model, tree-path, log-level", key)
+	for _, k := range ConfigKeys {
+		if k == key {
+			return nil
+		}
 	}
-
-	return nil
+	return fmt.Errorf("unknown config key: %s. Valid keys: %s", key, strings.Join(ConfigKeys, ", "))
 }
 
 // SanitizeConfigValue sanitizes configuration values based on their type
@@ -97,6 +118,118 @@ func SanitizeConfigValue(key, value string) (string, error) {
 		
 		return normalized, nil
 
+	case "on-missing-tree":
+		normalized := strings.ToLower(value)
+		if err := ValidateMissingTreePolicy(normalized); err != nil {
+			return "", err
+		}
+		return normalized, nil
+
+	case "prompt-style":
+		normalized := strings.ToLower(value)
+		if err := ValidatePromptStyle(normalized); err != nil {
+			return "", err
+		}
+		return normalized, nil
+
+	case "response-format":
+		normalized := strings.ToLower(value)
+		if err := ValidateResponseFormat(normalized); err != nil {
+			return "", err
+		}
+		return normalized, nil
+
+	case "pinned-cert-sha256":
+		if _, err := httpx.ParseFingerprint(value); err != nil {
+			return "", err
+		}
+		return strings.ToLower(strings.ReplaceAll(value, ":", "")), nil
+
+	case "provider":
+		normalized := strings.ToLower(value)
+		if err := ValidateProvider(normalized); err != nil {
+			return "", err
+		}
+		return normalized, nil
+
+	case "temperature":
+		if err := ValidateTemperature(value); err != nil {
+			return "", err
+		}
+		return value, nil
+
+	case "max-tokens":
+		if err := ValidateMaxTokens(value); err != nil {
+			return "", err
+		}
+		return value, nil
+
+	case "timeout":
+		if err := ValidateTimeout(value); err != nil {
+			return "", err
+		}
+		return value, nil
+
+	case "tree-depth":
+		if err := ValidateTreeDepth(value); err != nil {
+			return "", err
+		}
+		return value, nil
+
+	case "tree-max-bytes":
+		if err := ValidateTreeMaxBytes(value); err != nil {
+			return "", err
+		}
+		return value, nil
+
+	case "profile":
+		if value == "" {
+			return value, nil
+		}
+		if err := ValidateProfileName(value); err != nil {
+			return "", err
+		}
+		return value, nil
+
+	case "update-channel":
+		normalized := strings.ToLower(value)
+		if err := ValidateUpdateChannel(normalized); err != nil {
+			return "", err
+		}
+		return normalized, nil
+
+	case "log-format":
+		normalized := strings.ToLower(value)
+		if err := ValidateLogFormat(normalized); err != nil {
+			return "", err
+		}
+		return normalized, nil
+
+	case "log-file":
+		if value == "" {
+			return value, nil
+		}
+		return SanitizePath(value)
+
+	case "extra-rules":
+		// One rule per line; a literal \n (as printed by config list)
+		// separates rules too
+		value = strings.ReplaceAll(value, `\n`, "\n")
+		return strings.Join(ParseRules(value), "\n"), nil
+
+	case "prompt-template":
+		if value == "" {
+			return value, nil
+		}
+		path, err := SanitizePath(value)
+		if err != nil {
+			return "", err
+		}
+		if err := ValidatePromptTemplate(path); err != nil {
+			return "", err
+		}
+		return path, nil
+
 	default:
 		return "", fmt.Errorf("unknown config key: %s", key)
 	}
@@ -158,6 +291,56 @@ func (s *SecureFileOperations) CreateSecureFile(path string) (*os.File, error) {
 	return file, nil
 }
 
+// OpenSecureAppend opens path for appending, creating it and its parent
+// directories as needed, and makes it readable only by the owner
+func (s *SecureFileOperations) OpenSecureAppend(path string) (*os.File, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+
+	// An existing file may have been created with looser permissions
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to set secure permissions on %s: %w", path, err)
+	}
+
+	return file, nil
+}
+
+// CreateSecureDir creates a directory (and parents) readable only by the owner
+func (s *SecureFileOperations) CreateSecureDir(path string) error {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveFile deletes the file at path without following a symlink in its
+// place. A file that is already gone is not an error, so concurrent cleanups
+// don't fail each other. Directories are refused.
+func (s *SecureFileOperations) RemoveFile(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("refusing to remove directory %s", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
 // AtomicWrite performs an atomic write operation to prevent corruption
 func (s *SecureFileOperations) AtomicWrite(path string, data []byte) error {
 	// Ensure the directory exists
@@ -209,7 +392,8 @@ func (s *SecureFileOperations) AtomicWrite(path string, data []byte) error {
 	return nil
 }
 
-// ValidateFilePermissions checks if a file has secure permissions
+// ValidateFilePermissions checks that only the owner can access a file
+// (0600, or stricter)
 func (s *SecureFileOperations) ValidateFilePermissions(path string) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -217,7 +401,7 @@ func (s *SecureFileOperations) ValidateFilePermissions(path string) error {
 	}
 
 	mode := info.Mode()
-	if mode.Perm() != 0600 {
+	if mode.Perm()&0077 != 0 {
 		return fmt.Errorf("file %s has insecure permissions %o, expected 0600", path, mode.Perm())
 	}
 
@@ -191,6 +191,26 @@ func TestSanitizeConfigValue(t *testing.T) {
 			expected: "debug",
 			wantErr:  false,
 		},
+		{
+			name:     "update-channel normalization",
+			key:      "update-channel",
+			value:    "Beta",
+			expected: "beta",
+			wantErr:  false,
+		},
+		{
+			name:    "invalid update-channel",
+			key:     "update-channel",
+			value:   "nightly",
+			wantErr: true,
+			errMsg:  "invalid update channel",
+		},
+		{
+			name:     "extra-rules from config list form",
+			key:      "extra-rules",
+			value:    `- All PDFs go under 06_DOCS\n\nNever create folders with spaces`,
+			expected: "All PDFs go under 06_DOCS\nNever create folders with spaces",
+		},
 		{
 			name:    "unknown key",
 			key:     "unknown",
@@ -360,6 +380,11 @@ func TestSecureFileOperations_ValidateFilePermissions(t *testing.T) {
 			permissions: 0604,
 			wantErr:     true,
 		},
+		{
+			name:        "owner read-only",
+			permissions: 0400,
+			wantErr:     false,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/util"
+)
+
+// Policies for a TreePath that doesn't exist
+const (
+	MissingTreeError  = "error"
+	MissingTreeCreate = "create"
+	MissingTreeCwd    = "cwd"
+)
+
+var missingTreePolicies = []string{MissingTreeError, MissingTreeCreate, MissingTreeCwd}
+
+// warnOutput receives non-fatal warnings emitted during resolution
+var warnOutput io.Writer = os.Stderr
+
+// ValidateMissingTreePolicy checks that policy is empty or a known policy
+func ValidateMissingTreePolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	for _, p := range missingTreePolicies {
+		if policy == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid missing tree policy '%s'. Valid options: %s", policy, strings.Join(missingTreePolicies, ", "))
+}
+
+// ValidateTreeDepth checks that depth is empty (unlimited) or a non-negative
+// integer
+func ValidateTreeDepth(depth string) error {
+	if depth == "" {
+		return nil
+	}
+	if d, err := strconv.Atoi(depth); err != nil || d < 0 {
+		return fmt.Errorf("invalid tree depth '%s'. Use 0 for only the top-level entries or a larger whole number", depth)
+	}
+	return nil
+}
+
+// MaxTreeDepth returns TreeDepth as a number for fs.WithMaxDepth, or -1 when
+// the depth is unlimited or invalid
+func (c *Config) MaxTreeDepth() int {
+	d, err := strconv.Atoi(c.TreeDepth)
+	if err != nil || d < 0 {
+		return -1
+	}
+	return d
+}
+
+// ValidateTreeMaxBytes checks that limit is empty or a size such as 65536,
+// 64K or 1MB; 0 means unlimited
+func ValidateTreeMaxBytes(limit string) error {
+	if limit == "" {
+		return nil
+	}
+	if _, err := util.ParseSize(limit, "tree-max-bytes"); err != nil {
+		return fmt.Errorf("invalid tree size limit '%s'. Use a size such as 65536, 64K or 1MB, or 0 for unlimited", limit)
+	}
+	return nil
+}
+
+// MaxTreeBytes returns TreeMaxBytes in bytes for fs.WithMaxBytes, or 0 when
+// the size is unlimited or invalid
+func (c *Config) MaxTreeBytes() int {
+	n, err := util.ParseSize(c.TreeMaxBytes, "tree-max-bytes")
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// applyMissingTreePolicy resolves a non-existent TreePath according to
+// OnMissingTreePath. The default "error" policy leaves it for Validate to report.
+func (c *Config) applyMissingTreePolicy() error {
+	if c.TreePath == "" || c.TreePath == "." {
+		return nil
+	}
+	if _, err := os.Stat(c.TreePath); !os.IsNotExist(err) {
+		return nil
+	}
+
+	switch c.OnMissingTreePath {
+	case MissingTreeCreate:
+		if err := DefaultSecureFileOps.CreateSecureDir(c.TreePath); err != nil {
+			return fmt.Errorf("tree path '%s' does not exist and could not be created: %w", c.TreePath, err)
+		}
+	case MissingTreeCwd:
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("tree path '%s' does not exist and the current directory is unavailable: %w", c.TreePath, err)
+		}
+		fmt.Fprintf(warnOutput, "⚠️ Tree path '%s' does not exist; using current directory %s\n", c.TreePath, wd)
+		c.TreePath = wd
+	}
+	return nil
+}
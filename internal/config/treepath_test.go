@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConfig_MissingTreePolicy(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		policy    string
+		wantErr   string
+		wantTree  func(missing string) string
+		wantWarn  bool
+		wantExist bool
+	}{
+		{
+			name:    "default errors",
+			policy:  "",
+			wantErr: "does not exist",
+		},
+		{
+			name:    "error policy",
+			policy:  MissingTreeError,
+			wantErr: "does not exist",
+		},
+		{
+			name:      "create policy",
+			policy:    MissingTreeCreate,
+			wantTree:  func(missing string) string { return missing },
+			wantExist: true,
+		},
+		{
+			name:     "cwd policy",
+			policy:   MissingTreeCwd,
+			wantTree: func(string) string { return wd },
+			wantWarn: true,
+		},
+		{
+			name:    "unknown policy",
+			policy:  "ignore",
+			wantErr: "invalid missing tree policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings bytes.Buffer
+			warnOutput = &warnings
+			defer func() { warnOutput = os.Stderr }()
+
+			tmpDir := t.TempDir()
+			missing := filepath.Join(tmpDir, "archive", "2024")
+			loader := &FileLoader{ConfigPath: filepath.Join(tmpDir, "config.yaml")}
+			opts := CLIOptions{APIKey: "k", TreePath: missing, OnMissingTree: tt.policy}
+
+			c, err := ResolveConfigWithLoader(opts, loader)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveConfigWithLoader() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveConfigWithLoader() unexpected error = %v", err)
+			}
+			if c.TreePath != tt.wantTree(missing) {
+				t.Errorf("TreePath = %s, want %s", c.TreePath, tt.wantTree(missing))
+			}
+			if tt.wantExist {
+				info, err := os.Stat(missing)
+				if err != nil || !info.IsDir() {
+					t.Errorf("expected %s to be created as a directory", missing)
+				} else if info.Mode().Perm() != 0700 {
+					t.Errorf("created dir perms = %o, want 0700", info.Mode().Perm())
+				}
+			}
+			if tt.wantWarn != (warnings.Len() > 0) {
+				t.Errorf("warning output = %q, want warning: %v", warnings.String(), tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestResolveConfig_TreeDepth(t *testing.T) {
+	stubEnvironment(t, "interactive")
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("api_key: k\ntree_depth: \"3\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loader := &FileLoader{ConfigPath: configPath}
+	t.Setenv("SORTPATH_TREE_DEPTH", "")
+
+	conf, err := ResolveConfigWithLoader(CLIOptions{TreePath: dir}, loader)
+	if err != nil || conf.TreeDepth != "3" || conf.MaxTreeDepth() != 3 {
+		t.Fatalf("file tree_depth: conf = %+v, err = %v", conf, err)
+	}
+
+	t.Setenv("SORTPATH_TREE_DEPTH", "2")
+	conf, _ = ResolveConfigWithLoader(CLIOptions{TreePath: dir}, loader)
+	if conf.MaxTreeDepth() != 2 {
+		t.Errorf("env beats file: MaxTreeDepth() = %d, want 2", conf.MaxTreeDepth())
+	}
+
+	conf, _ = ResolveConfigWithLoader(CLIOptions{TreePath: dir, TreeDepth: "0"}, loader)
+	if conf.MaxTreeDepth() != 0 {
+		t.Errorf("flag beats env: MaxTreeDepth() = %d, want 0", conf.MaxTreeDepth())
+	}
+
+	if _, err := ResolveConfigWithLoader(CLIOptions{TreePath: dir, TreeDepth: "-1"}, loader); err == nil {
+		t.Error("expected a negative tree depth to be rejected")
+	}
+	if d := (&Config{}).MaxTreeDepth(); d != -1 {
+		t.Errorf("unset MaxTreeDepth() = %d, want -1 (unlimited)", d)
+	}
+}
+
+func TestResolveConfig_TreeMaxBytes(t *testing.T) {
+	stubEnvironment(t, "interactive")
+	dir := t.TempDir()
+	loader := &FileLoader{ConfigPath: filepath.Join(dir, "config.yaml")}
+	t.Setenv("SORTPATH_TREE_MAX_BYTES", "")
+
+	conf, err := ResolveConfigWithLoader(CLIOptions{APIKey: "k", TreePath: dir}, loader)
+	if err != nil || conf.MaxTreeBytes() != 64<<10 {
+		t.Fatalf("default: MaxTreeBytes() = %d, err = %v; want %d", conf.MaxTreeBytes(), err, 64<<10)
+	}
+
+	t.Setenv("SORTPATH_TREE_MAX_BYTES", "1MB")
+	conf, _ = ResolveConfigWithLoader(CLIOptions{APIKey: "k", TreePath: dir}, loader)
+	if conf.MaxTreeBytes() != 1<<20 {
+		t.Errorf("env: MaxTreeBytes() = %d, want %d", conf.MaxTreeBytes(), 1<<20)
+	}
+
+	conf, _ = ResolveConfigWithLoader(CLIOptions{APIKey: "k", TreePath: dir, TreeMaxBytes: "0"}, loader)
+	if conf.MaxTreeBytes() != 0 {
+		t.Errorf("flag 0 should disable the cap, got %d", conf.MaxTreeBytes())
+	}
+
+	if _, err := ResolveConfigWithLoader(CLIOptions{APIKey: "k", TreePath: dir, TreeMaxBytes: "lots"}, loader); err == nil {
+		t.Error("expected an invalid size to be rejected")
+	}
+}
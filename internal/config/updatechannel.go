@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Update channels: stable follows GitHub's latest release, which skips
+// prereleases; beta takes the newest release including prereleases
+const (
+	UpdateChannelStable = "stable"
+	UpdateChannelBeta   = "beta"
+)
+
+var updateChannels = []string{UpdateChannelStable, UpdateChannelBeta}
+
+// ValidateUpdateChannel checks that channel is empty or a known update channel
+func ValidateUpdateChannel(channel string) error {
+	if channel == "" {
+		return nil
+	}
+	for _, c := range updateChannels {
+		if channel == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid update channel '%s'. Valid options: %s", channel, strings.Join(updateChannels, ", "))
+}
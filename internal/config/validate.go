@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MultiError collects every validation failure of a config so they can be
+// fixed in one go. errors.As and errors.Is see each of them.
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	lines := make([]string, 0, len(e.Errors)+1)
+	noun := "problems"
+	if len(e.Errors) == 1 {
+		noun = "problem"
+	}
+	lines = append(lines, fmt.Sprintf("%d config %s:", len(e.Errors), noun))
+	for _, err := range e.Errors {
+		lines = append(lines, "  - "+problemText(err))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// ValidateAll checks the configuration like Validate but reports every
+// problem. A single problem is returned as is, several as a *MultiError.
+func (c *Config) ValidateAll() error {
+	switch problems := c.problems(); len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return problems[0]
+	default:
+		return &MultiError{Errors: problems}
+	}
+}
+
+// CheckConfig resolves the configuration like a run would and lists every
+// problem: a config file that can't be loaded first, then the validation
+// failures. The sources say where each value came from. The missing-tree
+// policy isn't applied, so nothing is created, and no request is made.
+func CheckConfig(opts CLIOptions) (*Config, []FieldSource, []error) {
+	loader, store := defaultSources(opts)
+	resolved, sources, loadErr := mergeConfig(opts, loader, store)
+	var problems []error
+	if loadErr != nil {
+		problems = append(problems, loadErr)
+	} else if err := parseProblem(loader); err != nil {
+		problems = append(problems, err)
+	}
+	return resolved, sources, append(problems, resolved.problems()...)
+}
+
+// parseProblem reports a config file that isn't valid YAML, which loading
+// otherwise replaces with the defaults without a word
+func parseProblem(loader Loader) error {
+	fl, ok := loader.(*FileLoader)
+	if !ok {
+		return nil
+	}
+	data, err := os.ReadFile(fl.ConfigPath)
+	if err != nil {
+		// Missing and unreadable files are Load's to report
+		return nil
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("config file %s is not valid YAML, so its settings are ignored: %w", fl.ConfigPath, err)
+	}
+	return nil
+}
+
+// Problems splits an error from ValidateAll back into the individual problems
+func Problems(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if multi, ok := err.(*MultiError); ok {
+		return multi.Errors
+	}
+	return []error{err}
+}
+
+// problemText prefixes a field error with its key, e.g. "log-level: ..."
+func problemText(err error) string {
+	if fieldErr, ok := err.(*FieldError); ok {
+		return fieldErr.Key + ": " + fieldErr.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateAll_ReportsEveryProblem(t *testing.T) {
+	c := &Config{
+		APIBase:  "ftp://example.com",
+		Model:    "gpt-4",
+		LogLevel: "verbose",
+		TreePath: ".",
+	}
+
+	err := c.ValidateAll()
+	var multi *MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("ValidateAll() = %v, want a *MultiError", err)
+	}
+	var keys []string
+	for _, p := range multi.Errors {
+		var fieldErr *FieldError
+		if !errors.As(p, &fieldErr) {
+			t.Fatalf("problem %v is not a FieldError", p)
+		}
+		keys = append(keys, fieldErr.Key)
+	}
+	if got := strings.Join(keys, ","); got != "api-key,api-base,log-level" {
+		t.Errorf("problem keys = %s, want api-key,api-base,log-level", got)
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"3 config problems:", "  - api-key: API key is required", "  - api-base: API base URL must use http or https", "  - log-level: invalid log level 'verbose'"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error message missing %q:\n%s", want, msg)
+		}
+	}
+
+	// errors.As still finds the first field error, and Validate keeps
+	// returning only that one
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "api-key" {
+		t.Errorf("errors.As found %v, want the api-key error", fieldErr)
+	}
+	if err := c.Validate(); !errors.As(err, &fieldErr) || fieldErr.Key != "api-key" {
+		t.Errorf("Validate() = %v, want only the api-key error", err)
+	}
+}
+
+func TestValidateAll_SingleProblem(t *testing.T) {
+	c := &Config{APIKey: "sk-test", APIBase: "https://api.openai.com/v1", Model: "gpt-4", LogLevel: "loud", TreePath: "."}
+
+	err := c.ValidateAll()
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "log-level" {
+		t.Fatalf("ValidateAll() = %v, want the log-level FieldError", err)
+	}
+	if problems := Problems(err); len(problems) != 1 {
+		t.Errorf("Problems() = %v, want one problem", problems)
+	}
+
+	c.LogLevel = "debug"
+	if err := c.ValidateAll(); err != nil || Problems(err) != nil {
+		t.Errorf("ValidateAll() = %v for a valid config", err)
+	}
+}
+
+func TestValidateAll_PromptTemplate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "valid", path: write("ok.tmpl", "{{.Tree}}\nFile: {{.Description}}\n")},
+		{name: "missing file", path: filepath.Join(dir, "none.tmpl"), wantErr: "failed to read prompt template"},
+		{name: "syntax error", path: write("broken.tmpl", "{{.Description"), wantErr: "template syntax error"},
+		{name: "no description", path: write("nodesc.tmpl", "{{.Tree}}"), wantErr: "never uses {{.Description}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{APIKey: "sk-test", APIBase: "https://api.openai.com/v1", Model: "gpt-4", TreePath: ".", PromptTemplate: tt.path}
+			err := c.ValidateAll()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateAll() = %v", err)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Key != "prompt-template" || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateAll() = %v, want a prompt-template error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
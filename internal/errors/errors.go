@@ -11,6 +11,10 @@ type AppError struct {
 	Message string                 `json:"message"`
 	Cause   error                  `json:"-"`
 	Context map[string]interface{} `json:"context,omitempty"`
+
+	// Retryable reports that repeating the failed operation may succeed,
+	// as after a network failure or a rate limit (see IsRetryable)
+	Retryable bool `json:"retryable,omitempty"`
 }
 
 // Error implements the error interface
@@ -40,6 +44,20 @@ func (e *AppError) WithContext(key string, value interface{}) *AppError {
 	return e
 }
 
+// WithStatus records the HTTP status of a failed response in the "status"
+// context and marks the error retryable for a timeout, rate limit or server
+// error
+func (e *AppError) WithStatus(status int) *AppError {
+	e.Retryable = retryableStatus(status)
+	return e.WithContext("status", status)
+}
+
+// WithRetryable overrides whether the error is retryable
+func (e *AppError) WithRetryable(retryable bool) *AppError {
+	e.Retryable = retryable
+	return e
+}
+
 // ConfigError creates a configuration-related error
 func ConfigError(msg string, cause error) *AppError {
 	return &AppError{
@@ -93,12 +111,13 @@ func ValidationError(msg string, field string) *AppError {
 	return err
 }
 
-// NetworkError creates a network-related error
+// NetworkError creates a network-related error, which is retryable
 func NetworkError(msg string, cause error) *AppError {
 	return &AppError{
-		Code:    "NETWORK_ERROR",
-		Message: msg,
-		Cause:   cause,
+		Code:      "NETWORK_ERROR",
+		Message:   msg,
+		Cause:     cause,
+		Retryable: true,
 	}
 }
 
@@ -111,10 +130,11 @@ func Wrap(err error, msg string) *AppError {
 	// If it's already an AppError, preserve the original code
 	if appErr, ok := err.(*AppError); ok {
 		return &AppError{
-			Code:    appErr.Code,
-			Message: fmt.Sprintf("%s: %s", msg, appErr.Message),
-			Cause:   appErr.Cause,
-			Context: appErr.Context,
+			Code:      appErr.Code,
+			Message:   fmt.Sprintf("%s: %s", msg, appErr.Message),
+			Cause:     appErr.Cause,
+			Context:   appErr.Context,
+			Retryable: appErr.Retryable,
 		}
 	}
 	
@@ -159,14 +179,16 @@ func FormatUserError(err error) string {
 	// Add context-specific suggestions
 	switch appErr.Code {
 	case "CONFIG_ERROR":
-		if strings.Contains(appErr.Message, "API key") {
+		if strings.Contains(appErr.Message, "API key") && !strings.Contains(appErr.Message, "config set api-key") {
 			parts = append(parts, "💡 Set your API key with: sortpath config set api-key YOUR_KEY")
 		}
 		if strings.Contains(appErr.Message, "config file") {
 			parts = append(parts, "💡 Create config with: sortpath config init")
 		}
 	case "API_ERROR":
-		if strings.Contains(appErr.Message, "401") || strings.Contains(appErr.Message, "unauthorized") {
+		if hint := providerHint(err); hint != "" {
+			parts = append(parts, hint)
+		} else if strings.Contains(appErr.Message, "401") || strings.Contains(appErr.Message, "unauthorized") {
 			parts = append(parts, "💡 Check your API key with: sortpath config get api-key")
 		}
 		if strings.Contains(appErr.Message, "network") || strings.Contains(appErr.Message, "timeout") {
@@ -177,7 +199,7 @@ func FormatUserError(err error) string {
 			if strings.Contains(appErr.Message, "permission") {
 				parts = append(parts, fmt.Sprintf("💡 Try: chmod +r %v", path))
 			}
-			if strings.Contains(appErr.Message, "not found") {
+			if strings.Contains(appErr.Message, "not found") || strings.Contains(appErr.Message, "does not exist") {
 				parts = append(parts, fmt.Sprintf("💡 Check if path exists: %v", path))
 			}
 		}
@@ -188,4 +210,18 @@ func FormatUserError(err error) string {
 	}
 	
 	return strings.Join(parts, "\n")
-}
\ No newline at end of file
+}
+
+// providerHint suggests a fix for the provider_code recorded on API errors
+func providerHint(err error) string {
+	code, _ := GetContext(err, "provider_code")
+	switch code {
+	case "invalid_api_key":
+		return "💡 Your API key was rejected. Re-set it with: sortpath config set api-key YOUR_KEY"
+	case "expired_api_key":
+		return "💡 Your API key has expired. Create a new key with your provider, then run: sortpath config set api-key NEW_KEY"
+	case "insufficient_quota":
+		return "💡 Your account has run out of quota. Check your plan and billing details with your provider"
+	}
+	return ""
+}
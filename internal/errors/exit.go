@@ -0,0 +1,43 @@
+package errors
+
+import stderrors "errors"
+
+// Process exit codes, stable so scripts can tell failures apart. 3 is taken
+// by a run stopped by --budget (cli.ExitBudgetExceeded).
+const (
+	ExitOK         = 0
+	ExitFailure    = 1
+	ExitConfig     = 2
+	ExitAPI        = 4
+	ExitNetwork    = 5
+	ExitFS         = 6
+	ExitValidation = 7
+	ExitInstall    = 8
+)
+
+// exitCodes maps AppError codes to exit codes
+var exitCodes = map[string]int{
+	"CONFIG_ERROR":     ExitConfig,
+	"API_ERROR":        ExitAPI,
+	"NETWORK_ERROR":    ExitNetwork,
+	"FS_ERROR":         ExitFS,
+	"VALIDATION_ERROR": ExitValidation,
+	"INSTALL_ERROR":    ExitInstall,
+}
+
+// ExitCode returns the process exit code for err: ExitOK for nil, the code
+// of the first AppError in its chain with a known Code, and ExitFailure
+// otherwise
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	for e := err; e != nil; e = stderrors.Unwrap(e) {
+		if appErr, ok := e.(*AppError); ok {
+			if code, known := exitCodes[appErr.Code]; known {
+				return code
+			}
+		}
+	}
+	return ExitFailure
+}
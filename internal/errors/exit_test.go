@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("boom")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitOK},
+		{name: "plain error", err: cause, want: ExitFailure},
+		{name: "config", err: ConfigError("bad config", cause), want: ExitConfig},
+		{name: "api", err: APIError("API error (401)", nil), want: ExitAPI},
+		{name: "network", err: NetworkError("connection reset", cause), want: ExitNetwork},
+		{name: "fs", err: FSError("not found", "/tmp/x", cause), want: ExitFS},
+		{name: "validation", err: ValidationError("invalid size", "tree-max-bytes"), want: ExitValidation},
+		{name: "install", err: InstallError("permission denied", cause), want: ExitInstall},
+		{name: "wrapped app error keeps its code", err: Wrap(NetworkError("timeout", nil), "query"), want: ExitNetwork},
+		{name: "wrapped plain error", err: Wrap(cause, "query"), want: ExitFailure},
+		{name: "fmt wrapped", err: fmt.Errorf("item 2: %w", APIError("no response", nil)), want: ExitAPI},
+		{name: "code found below a wrapper", err: &AppError{Code: "WRAPPED_ERROR", Message: "query", Cause: ConfigError("no key", nil)}, want: ExitConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+)
+
+// IsRetryable reports whether repeating the operation that failed with err
+// may succeed: the first AppError in its chain is marked Retryable. Network
+// errors are, as are API errors given a timeout, rate limit or server error
+// status with WithStatus. Errors that aren't AppErrors are never retryable.
+func IsRetryable(err error) bool {
+	var appErr *AppError
+	return stderrors.As(err, &appErr) && appErr.Retryable
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
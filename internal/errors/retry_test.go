@@ -0,0 +1,54 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	apiErr := func(status int) error {
+		return APIError(fmt.Sprintf("API error (%d)", status), nil).WithStatus(status)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network error", err: NetworkError("connection reset", errors.New("EOF")), want: true},
+		{name: "wrapped network error", err: fmt.Errorf("query: %w", NetworkError("timeout", nil)), want: true},
+		{name: "unauthorized", err: apiErr(401), want: false},
+		{name: "rate limited", err: apiErr(429), want: true},
+		{name: "server error", err: apiErr(503), want: true},
+		{name: "quota exhausted", err: APIError("API error (429)", nil).WithStatus(429).WithRetryable(false), want: false},
+		{name: "malformed model output", err: APIError("model returned malformed output", nil).WithRetryable(true), want: true},
+		{name: "validation error", err: ValidationError("invalid size", "tree-max-bytes"), want: false},
+		{name: "wrapped network error keeps the flag", err: Wrap(NetworkError("timeout", nil), "query"), want: true},
+		{name: "wrapped plain error", err: Wrap(errors.New("boom"), "query"), want: false},
+		{name: "api error without status", err: APIError("no response", nil), want: false},
+		{name: "config error", err: ConfigError("bad config", nil), want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrap_PreservesRetryable(t *testing.T) {
+	wrapped := Wrap(NetworkError("connection reset", nil), "query")
+	if !wrapped.Retryable {
+		t.Error("Wrap(NetworkError) is not retryable")
+	}
+	if Wrap(ConfigError("bad config", nil), "load").Retryable {
+		t.Error("Wrap(ConfigError) is retryable")
+	}
+	if status, _ := GetContext(APIError("API error (503)", nil).WithStatus(503), "status"); status != 503 {
+		t.Errorf("WithStatus context = %v, want 503", status)
+	}
+}
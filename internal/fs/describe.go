@@ -0,0 +1,106 @@
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kacperkwapisz/sortpath/internal/util"
+)
+
+// MaxPreviewBytes caps how much file content DescribeFile will include
+const MaxPreviewBytes = 4096
+
+// DescribeFile builds a description of the file at path for the model: its
+// name, detected type and size and, for text files, up to previewBytes of
+// content with secrets redacted. Binary files never contribute content.
+func DescribeFile(path string, previewBytes int) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if previewBytes > MaxPreviewBytes {
+		previewBytes = MaxPreviewBytes
+	}
+	// Sniff at least 512 bytes so type detection works with small previews
+	head := make([]byte, max(previewBytes, 512))
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	desc := fmt.Sprintf("File: %s (%s, %s)", filepath.Base(path), contentType, util.FormatSize(info.Size()))
+
+	if previewBytes <= 0 || !isText(head) {
+		return desc, nil
+	}
+	preview := sanitizePreview(head[:min(previewBytes, len(head))])
+	if preview == "" {
+		return desc, nil
+	}
+	return desc + "\nContent preview:\n" + preview, nil
+}
+
+// isText reports whether data looks like UTF-8 text rather than a binary format
+func isText(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	// A preview may cut the last rune short
+	for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return utf8.Valid(data)
+}
+
+// sanitizePreview drops a trailing partial rune and control characters and
+// redacts anything that looks like a credential
+func sanitizePreview(data []byte) string {
+	for len(data) > 0 && !utf8.Valid(data) {
+		data = data[:len(data)-1]
+	}
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, string(data))
+	return strings.TrimSpace(RedactSecrets(cleaned))
+}
+
+var (
+	// secretAssignment matches key=value and key: value pairs with sensitive keys
+	secretAssignment = regexp.MustCompile(`(?i)((?:api[_-]?key|secret|token|passw(?:or)?d|access[_-]?key|private[_-]?key|auth(?:orization)?)["']?\s*[:=]\s*["']?)[^\s"',;]+`)
+
+	// secretTokens matches well-known credential formats on their own
+	secretTokens = regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{16,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,})\b`)
+
+	// privateKeyBlock matches PEM private keys, including a truncated tail
+	privateKeyBlock = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?(?:-----END [A-Z ]*PRIVATE KEY-----|\z)`)
+)
+
+// RedactSecrets masks credentials and private keys found anywhere in s
+func RedactSecrets(s string) string {
+	s = privateKeyBlock.ReplaceAllString(s, "[REDACTED PRIVATE KEY]")
+	s = secretAssignment.ReplaceAllString(s, "${1}[REDACTED]")
+	return secretTokens.ReplaceAllString(s, "[REDACTED]")
+}
@@ -0,0 +1,39 @@
+package fs
+
+// Directories flattens a walked tree into the slash-separated paths of its
+// directories, in tree order and shown with SafeName, e.g. "/Work/Invoices".
+// The root itself is not listed.
+func Directories(root *Node) []string {
+	var dirs []string
+	collectDirs(root, "", &dirs)
+	return dirs
+}
+
+func collectDirs(dir *Node, prefix string, dirs *[]string) {
+	for _, child := range dir.Children {
+		if !child.IsDir {
+			continue
+		}
+		path := prefix + "/" + SafeName(child.Name)
+		*dirs = append(*dirs, path)
+		collectDirs(child, path, dirs)
+	}
+}
+
+// Unreadable lists the directories of a walked tree whose entries could not
+// be read, as slash-separated paths relative to the root, so callers can
+// report what the partial tree is missing.
+func Unreadable(root *Node) []string {
+	var paths []string
+	collectUnreadable(root, &paths)
+	return paths
+}
+
+func collectUnreadable(dir *Node, paths *[]string) {
+	for _, child := range dir.Children {
+		if child.readErr != "" {
+			*paths = append(*paths, child.path)
+		}
+		collectUnreadable(child, paths)
+	}
+}
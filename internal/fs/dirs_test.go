@@ -0,0 +1,66 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDirectories(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"Work/Invoices/2024", "Work/Contracts", "Photos", "bad\x01name"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(root, "notes.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(root, "Work", "todo.md"), nil, 0644)
+
+	node, err := Walk(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/Photos",
+		"/Work",
+		"/Work/Contracts",
+		"/Work/Invoices",
+		"/Work/Invoices/2024",
+		"/bad�name",
+	}
+	if got := Directories(node); !reflect.DeepEqual(got, want) {
+		t.Errorf("Directories() = %q, want %q", got, want)
+	}
+
+	if got := Directories(&Node{Name: "empty", IsDir: true}); len(got) != 0 {
+		t.Errorf("Directories(empty) = %q", got)
+	}
+}
+
+func TestUnreadable(t *testing.T) {
+	root := &Node{Name: "root", IsDir: true, path: ".", Children: []*Node{
+		{Name: "Private", IsDir: true, path: "Private", readErr: "permission denied"},
+		{Name: "Work", IsDir: true, path: "Work", Children: []*Node{
+			{Name: "HR", IsDir: true, path: "Work/HR", readErr: "permission denied"},
+			{Name: "Invoices", IsDir: true, path: "Work/Invoices"},
+		}},
+	}}
+
+	want := []string{"Private", "Work/HR"}
+	if got := Unreadable(root); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unreadable() = %q, want %q", got, want)
+	}
+
+	tree, err := UnicodeFormatter{}.Format(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTree := "├── Private <permission denied>\n" +
+		"└── Work\n" +
+		"    ├── HR <permission denied>\n" +
+		"    └── Invoices\n"
+	if tree != wantTree {
+		t.Errorf("Format() =\n%s\nwant:\n%s", tree, wantTree)
+	}
+}
@@ -0,0 +1,88 @@
+package fs
+
+import (
+	"fmt"
+)
+
+// fitEntryLimits are the per-directory entry caps tried at each depth, loosest first
+var fitEntryLimits = []int{0, 50, 20, 10, 5, 3}
+
+// FitTree walks dirPath once and re-renders it under progressively tighter
+// depth and entry limits until fits accepts the result. It returns the
+// rendered tree and a human-readable list of the limits that were applied.
+// If nothing fits, an error describing the tightest attempt is returned.
+func FitTree(dirPath string, fits func(tree string) bool, opts ...TreeOption) (string, []string, error) {
+	root, err := Walk(dirPath, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	return FitNode(root, fits, opts...)
+}
+
+// FitNode is FitTree for a tree already walked with the same options, so a
+// caller that sorts several items into one tree walks it only once
+func FitNode(root *Node, fits func(tree string) bool, opts ...TreeOption) (string, []string, error) {
+	o := newTreeOptions(opts)
+	explainFn := o.Explain
+	o.Explain = nil
+
+	maxDepth := treeDepth(root)
+	if o.MaxDepth >= 0 && o.MaxDepth < maxDepth {
+		maxDepth = o.MaxDepth
+	}
+
+	for depth := maxDepth; depth >= 0; depth-- {
+		for _, entries := range fitEntryLimits {
+			if o.MaxEntries > 0 && (entries == 0 || entries > o.MaxEntries) {
+				entries = o.MaxEntries
+			}
+			try := o
+			try.MaxDepth = depth
+			try.MaxEntries = entries
+			if depth == maxDepth {
+				// The full depth needs no depth limit at all
+				try.MaxDepth = o.MaxDepth
+			}
+			tree, err := Render(root, try)
+			if err != nil {
+				return "", nil, err
+			}
+			if !fits(tree) {
+				continue
+			}
+
+			// Re-render once with explanations for the accepted limits
+			if explainFn != nil {
+				try.Explain = explainFn
+				_, _ = Render(root, try)
+			}
+			return tree, describeLimits(try, o), nil
+		}
+	}
+	return "", nil, fmt.Errorf("tree does not fit even at depth 0 with %d entries per directory", fitEntryLimits[len(fitEntryLimits)-1])
+}
+
+// treeDepth returns the deepest directory level that has children listed
+func treeDepth(n *Node) int {
+	deepest := 0
+	for _, c := range n.Children {
+		if c.IsDir && len(c.Children) > 0 {
+			if d := treeDepth(c) + 1; d > deepest {
+				deepest = d
+			}
+		}
+	}
+	return deepest
+}
+
+// describeLimits lists the limits in applied that are tighter than requested
+func describeLimits(applied, requested TreeOptions) []string {
+	var limits []string
+	if applied.MaxDepth >= 0 && applied.MaxDepth != requested.MaxDepth {
+		limits = append(limits, fmt.Sprintf("max depth %d", applied.MaxDepth))
+	}
+	if applied.MaxEntries > 0 && applied.MaxEntries != requested.MaxEntries {
+		limits = append(limits, fmt.Sprintf("max %d entries per directory", applied.MaxEntries))
+	}
+	return limits
+}
@@ -0,0 +1,78 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mkLargeTree builds width^depth directories, each holding files files
+func mkLargeTree(t testing.TB, width, depth, files int) string {
+	t.Helper()
+	root := t.TempDir()
+	var fill func(dir string, level int)
+	fill = func(dir string, level int) {
+		for f := 0; f < files; f++ {
+			os.WriteFile(filepath.Join(dir, fmt.Sprintf("file_%02d.txt", f)), nil, 0644)
+		}
+		if level == depth {
+			return
+		}
+		for w := 0; w < width; w++ {
+			sub := filepath.Join(dir, fmt.Sprintf("dir_%02d", w))
+			os.Mkdir(sub, 0755)
+			fill(sub, level+1)
+		}
+	}
+	fill(root, 0)
+	return root
+}
+
+func TestFitTree_FitsUnderTarget(t *testing.T) {
+	root := mkLargeTree(t, 5, 3, 6)
+	full, err := Tree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []int{len(full) / 2, len(full) / 10, 2000, 400} {
+		t.Run(fmt.Sprintf("%d bytes", target), func(t *testing.T) {
+			fits := func(tree string) bool { return len(tree) <= target }
+			tree, limits, err := FitTree(root, fits)
+			if err != nil {
+				t.Fatalf("FitTree() error = %v", err)
+			}
+			if len(tree) > target {
+				t.Errorf("fitted tree is %d bytes, want at most %d", len(tree), target)
+			}
+			if len(limits) == 0 {
+				t.Error("expected FitTree to report the limits it applied")
+			}
+			if !strings.Contains(tree, "dir_00") {
+				t.Error("fitted tree lost its top-level directories")
+			}
+		})
+	}
+}
+
+func TestFitTree_NoLimitsWhenItFits(t *testing.T) {
+	root := mkTree(t, "a/", "a/x.txt", "b.txt")
+	full, _ := Tree(root)
+
+	tree, limits, err := FitTree(root, func(string) bool { return true })
+	if err != nil {
+		t.Fatalf("FitTree() error = %v", err)
+	}
+	if tree != full || len(limits) != 0 {
+		t.Errorf("FitTree() = %q, %v; want the full tree and no limits", tree, limits)
+	}
+}
+
+func TestFitTree_Impossible(t *testing.T) {
+	root := mkTree(t, "a/", "b.txt")
+	if _, _, err := FitTree(root, func(string) bool { return false }); err == nil {
+		t.Error("FitTree() expected error when nothing fits")
+	}
+}
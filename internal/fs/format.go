@@ -0,0 +1,102 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TreeFormatter draws a pruned Node tree. The root itself is not printed,
+// only its contents.
+type TreeFormatter interface {
+	Format(root *Node) (string, error)
+}
+
+// glyphs are the connector strings used by the line-based formatters
+type glyphs struct {
+	space, branch, tee, last, ellipsis string
+}
+
+// UnicodeFormatter draws the tree with box-drawing characters, like tree(1).
+type UnicodeFormatter struct{}
+
+// Format implements TreeFormatter.
+func (UnicodeFormatter) Format(root *Node) (string, error) {
+	return drawLines(root, glyphs{"    ", "│   ", "├── ", "└── ", "…"}), nil
+}
+
+// ASCIIFormatter draws the tree with plain ASCII for terminals and models
+// that mangle box-drawing characters.
+type ASCIIFormatter struct{}
+
+// Format implements TreeFormatter.
+func (ASCIIFormatter) Format(root *Node) (string, error) {
+	return drawLines(root, glyphs{"    ", "|   ", "|-- ", "`-- ", "..."}), nil
+}
+
+// JSONFormatter encodes the tree, including its root, as indented JSON made
+// of JSONNode values, the schema TreeJSON uses.
+type JSONFormatter struct{}
+
+// Format implements TreeFormatter.
+func (JSONFormatter) Format(root *Node) (string, error) {
+	data, err := json.MarshalIndent(toJSONNode(root), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// fileCount labels a directory with its file count when one was set
+func fileCount(n *Node) string {
+	if n.Files == nil {
+		return ""
+	}
+	if *n.Files == 1 {
+		return " (1 file)"
+	}
+	return fmt.Sprintf(" (%d files)", *n.Files)
+}
+
+func drawLines(root *Node, g glyphs) string {
+	var builder strings.Builder
+	drawChildren(&builder, root, "", g)
+	return builder.String()
+}
+
+func drawChildren(builder *strings.Builder, dir *Node, prefix string, g glyphs) {
+	for i, child := range dir.Children {
+		pointer := g.tee
+		if i == len(dir.Children)-1 && dir.Omitted == 0 {
+			pointer = g.last
+		}
+		if child.readErr != "" {
+			// Unreadable directories stay in the tree, marked, so the rest of
+			// the walk is still useful
+			builder.WriteString(prefix + pointer + SafeName(child.Name) + " <" + child.readErr + ">\n")
+			continue
+		}
+		if child.cycle != "" {
+			builder.WriteString(prefix + pointer + SafeName(child.Name) + " -> " + SafeName(child.cycle) + " (cycle)\n")
+			continue
+		}
+		builder.WriteString(prefix + pointer + SafeName(child.Name) + fileCount(child) + "\n")
+		if !child.IsDir {
+			continue
+		}
+
+		extension := g.branch
+		if pointer == g.last {
+			extension = g.space
+		}
+		if child.Truncated {
+			builder.WriteString(prefix + extension + g.last + g.ellipsis + "\n")
+			continue
+		}
+		drawChildren(builder, child, prefix+extension, g)
+	}
+
+	if dir.Omitted > 0 {
+		builder.WriteString(fmt.Sprintf("%s%s%s (%d more)\n", prefix, g.last, g.ellipsis, dir.Omitted))
+	}
+}
@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// sampleTree is the shared model every formatter is checked against: a
+// depth-truncated directory, a nested file and an entry-limit marker.
+func sampleTree() *Node {
+	return &Node{
+		Name:  "root",
+		IsDir: true,
+		Children: []*Node{
+			{Name: "Archive", IsDir: true, Truncated: true},
+			{Name: "Projects", IsDir: true, Children: []*Node{
+				{Name: "plan.md"},
+			}},
+			{Name: "notes.txt"},
+		},
+		Omitted: 2,
+	}
+}
+
+func TestFormatters(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter TreeFormatter
+		want      string
+	}{
+		{
+			name:      "unicode",
+			formatter: UnicodeFormatter{},
+			want: "├── Archive\n" +
+				"│   └── …\n" +
+				"├── Projects\n" +
+				"│   └── plan.md\n" +
+				"├── notes.txt\n" +
+				"└── … (2 more)\n",
+		},
+		{
+			name:      "ascii",
+			formatter: ASCIIFormatter{},
+			want: "|-- Archive\n" +
+				"|   `-- ...\n" +
+				"|-- Projects\n" +
+				"|   `-- plan.md\n" +
+				"|-- notes.txt\n" +
+				"`-- ... (2 more)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.formatter.Format(sampleTree())
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	out, err := JSONFormatter{}.Format(sampleTree())
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	var got JSONNode
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `"isDir": true`) {
+		t.Errorf("output should use the TreeJSON schema, got:\n%s", out)
+	}
+	if got.Name != "root" || got.Omitted != 2 || len(got.Children) != 3 {
+		t.Fatalf("decoded root = %+v", got)
+	}
+	if !got.Children[0].Truncated || got.Children[1].Children[0].Name != "plan.md" {
+		t.Errorf("decoded children = %+v", got.Children)
+	}
+}
+
+func TestTree_WithFormatter(t *testing.T) {
+	dir := mkTree(t, "a/b.txt", "c.txt")
+
+	got, err := Tree(dir, WithFormatter(ASCIIFormatter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "|-- a\n|   `-- b.txt\n`-- c.txt\n"
+	if got != want {
+		t.Errorf("Tree() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTree_WithCounts(t *testing.T) {
+	dir := mkTree(t,
+		"mixed/a.txt", "mixed/b.txt", "mixed/sub/", "mixed/sub/c.txt",
+		"one/only.txt",
+		"empty/",
+		"top.txt",
+	)
+
+	got, err := Tree(dir, WithCounts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "├── empty (0 files)\n" +
+		"├── mixed (2 files)\n" +
+		"│   ├── sub (1 file)\n" +
+		"│   │   └── c.txt\n" +
+		"│   ├── a.txt\n" +
+		"│   └── b.txt\n" +
+		"├── one (1 file)\n" +
+		"│   └── only.txt\n" +
+		"└── top.txt\n"
+	if got != want {
+		t.Errorf("Tree(WithCounts()) =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Counts still describe the files when they aren't listed
+	got, err = Tree(dir, WithCounts(), WithDirsOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "mixed (2 files)\n") || strings.Contains(got, "a.txt") {
+		t.Errorf("Tree(WithCounts(), WithDirsOnly()) =\n%s", got)
+	}
+
+	plain, err := Tree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(plain, "files)") {
+		t.Errorf("counts should be opt-in:\n%s", plain)
+	}
+}
@@ -0,0 +1,124 @@
+package fs
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// DefaultIgnoreFile is the ignore file Walk looks for at the tree root
+const DefaultIgnoreFile = ".sortpathignore"
+
+// ignoreRule is one compiled line of an ignore file
+type ignoreRule struct {
+	pattern string // the line as written, for explanations
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules holds the patterns of an ignore file in order; the last
+// pattern matching a path decides whether it is ignored
+type ignoreRules []ignoreRule
+
+// parseIgnore reads gitignore-style patterns: blank lines and # comments are
+// skipped, ! negates, a trailing / matches directories only, a pattern with
+// a / elsewhere is relative to the root, and * ? [..] and ** glob as in git.
+func parseIgnore(data string) ignoreRules {
+	var rules ignoreRules
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{pattern: line}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if anchored {
+			expr = "^" + expr + "$"
+		} else {
+			expr = "^(?:.*/)?" + expr + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			continue // an unbalanced [ can't match anything
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globToRegexp translates one glob into a regular expression fragment
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			// Zero or more leading directories
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			// Everything inside the directory
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match reports whether the slash-separated path relative to the root is
+// ignored, and the pattern that decided it
+func (rules ignoreRules) match(path string, isDir bool) (string, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(path) {
+			return rule.pattern, !rule.negate
+		}
+	}
+	return "", false
+}
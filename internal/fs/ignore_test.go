@@ -0,0 +1,127 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreRules_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{name: "base name at top level", patterns: "node_modules/", path: "node_modules", isDir: true, want: true},
+		{name: "base name nested", patterns: "node_modules/", path: "web/app/node_modules", isDir: true, want: true},
+		{name: "dir-only skips files", patterns: "build/", path: "notes/build", isDir: false, want: false},
+		{name: "glob on base name", patterns: "*.log", path: "logs/2024/app.log", want: true},
+		{name: "star stays within a segment", patterns: "docs/*.md", path: "docs/old/a.md", want: false},
+		{name: "anchored pattern", patterns: "/tmp", path: "tmp", isDir: true, want: true},
+		{name: "anchored pattern not nested", patterns: "/tmp", path: "src/tmp", isDir: true, want: false},
+		{name: "slash anchors to root", patterns: "docs/drafts", path: "work/docs/drafts", isDir: true, want: false},
+		{name: "leading double star", patterns: "**/cache", path: "a/b/cache", isDir: true, want: true},
+		{name: "middle double star", patterns: "photos/**/raw", path: "photos/2024/trip/raw", isDir: true, want: true},
+		{name: "middle double star matches zero dirs", patterns: "photos/**/raw", path: "photos/raw", isDir: true, want: true},
+		{name: "trailing double star", patterns: "archive/**", path: "archive/2019/a.pdf", want: true},
+		{name: "question mark", patterns: "v?", path: "v1", isDir: true, want: true},
+		{name: "character class", patterns: "[ab].txt", path: "c.txt", want: false},
+		{name: "comments and blanks", patterns: "# *.txt\n\n", path: "a.txt", want: false},
+		{name: "negation re-includes", patterns: "*.log\n!keep.log", path: "keep.log", want: false},
+		{name: "negation leaves others ignored", patterns: "*.log\n!keep.log", path: "drop.log", want: true},
+		{name: "last matching rule wins", patterns: "!keep.log\n*.log", path: "keep.log", want: true},
+		{name: "escaped bang is literal", patterns: `\!important`, path: "!important", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := parseIgnore(tt.patterns).match(tt.path, tt.isDir)
+			if got != tt.want {
+				t.Errorf("match(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func writeIgnore(t *testing.T, root, patterns string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, DefaultIgnoreFile), []byte(patterns), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTree_IgnoreFile(t *testing.T) {
+	root := mkTree(t,
+		"web/", "web/node_modules/", "web/node_modules/pkg.js", "web/index.html",
+		"logs/", "logs/app.log", "logs/keep.log",
+		"node_modules/", "node_modules/x.js",
+	)
+	writeIgnore(t, root, "node_modules/\n*.log\n!keep.log\n")
+
+	var got []explained
+	record := func(path string, reason SkipReason, detail string) {
+		got = append(got, explained{path: path, reason: reason})
+	}
+	// Without the skip list, which would catch node_modules first
+	tree, err := Tree(root, WithExplain(record), WithSkipDirs())
+	if err != nil {
+		t.Fatalf("Tree() unexpected error = %v", err)
+	}
+	want := "├── logs\n" +
+		"│   └── keep.log\n" +
+		"├── web\n" +
+		"│   └── index.html\n" +
+		"└── .sortpathignore\n"
+	if tree != want {
+		t.Errorf("Tree() =\n%s\nwant:\n%s", tree, want)
+	}
+
+	wantExplained := []explained{
+		{path: "node_modules", reason: SkipIgnored},
+		{path: "logs/app.log", reason: SkipIgnored},
+		{path: "web/node_modules", reason: SkipIgnored},
+	}
+	if len(got) != len(wantExplained) {
+		t.Fatalf("explanations = %v, want %v", got, wantExplained)
+	}
+	for i := range got {
+		if got[i] != wantExplained[i] {
+			t.Errorf("explanation[%d] = %v, want %v", i, got[i], wantExplained[i])
+		}
+	}
+}
+
+func TestTree_IgnoreFileOptions(t *testing.T) {
+	paths := []string{"a/", "a/x.txt", "b.txt"}
+
+	t.Run("missing file changes nothing", func(t *testing.T) {
+		root := mkTree(t, paths...)
+		want := "├── a\n│   └── x.txt\n└── b.txt\n"
+		if got, err := Tree(root); err != nil || got != want {
+			t.Errorf("Tree() = %q, %v; want %q", got, err, want)
+		}
+	})
+
+	t.Run("empty name disables ignoring", func(t *testing.T) {
+		root := mkTree(t, paths...)
+		writeIgnore(t, root, "*.txt\n")
+		want := "├── a\n│   └── x.txt\n├── .sortpathignore\n└── b.txt\n"
+		if got, err := Tree(root, WithIgnoreFile("")); err != nil || got != want {
+			t.Errorf("Tree() = %q, %v; want %q", got, err, want)
+		}
+	})
+
+	t.Run("absolute path elsewhere", func(t *testing.T) {
+		root := mkTree(t, paths...)
+		ignore := filepath.Join(t.TempDir(), "patterns")
+		if err := os.WriteFile(ignore, []byte("a/\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want := "└── b.txt\n"
+		if got, err := Tree(root, WithIgnoreFile(ignore)); err != nil || got != want {
+			t.Errorf("Tree() = %q, %v; want %q", got, err, want)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamePlaceholder replaces bytes of a filename that are not valid UTF-8, and
+// control characters, when the name is shown to the model.
+const NamePlaceholder = '�'
+
+// SafeName returns name with invalid UTF-8 sequences and control characters
+// replaced by NamePlaceholder. Valid names are returned unchanged.
+func SafeName(name string) string {
+	if utf8.ValidString(name) && strings.IndexFunc(name, unicode.IsControl) < 0 {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if (r == utf8.RuneError && size == 1) || unicode.IsControl(r) {
+			b.WriteRune(NamePlaceholder)
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// ResolvePath maps a slash-separated path as shown by the formatters (using
+// SafeName) back to the real path relative to n, so entries with sanitized
+// names can still be acted upon. It fails if a segment doesn't exist or
+// matches more than one real name.
+func (n *Node) ResolvePath(display string) (string, error) {
+	var real []string
+	dir := n
+	for _, seg := range strings.Split(strings.Trim(display, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		var match *Node
+		for _, child := range dir.Children {
+			if SafeName(child.Name) != seg {
+				continue
+			}
+			if match != nil {
+				return "", fmt.Errorf("path %q is ambiguous: more than one entry is shown as %q", display, seg)
+			}
+			match = child
+		}
+		if match == nil {
+			return "", fmt.Errorf("path %q not found in tree", display)
+		}
+		real = append(real, match.Name)
+		dir = match
+	}
+	return strings.Join(real, "/"), nil
+}
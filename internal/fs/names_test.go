@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSafeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Zürich 2025.pdf", want: "Zürich 2025.pdf"},
+		{name: "bad\xffname.txt", want: "bad�name.txt"},
+		{name: "latin1-caf\xe9", want: "latin1-caf�"},
+		{name: "cut-\xe2\x82", want: "cut-��"},
+		{name: "line\nbreak", want: "line�break"},
+	}
+	for _, tt := range tests {
+		if got := SafeName(tt.name); got != tt.want {
+			t.Errorf("SafeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTree_InvalidUTF8Names(t *testing.T) {
+	dir := t.TempDir()
+	realDir := "Arch\xffive"
+	realFile := "caf\xe9.txt"
+	if err := os.MkdirAll(filepath.Join(dir, realDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, realDir, realFile), nil, 0644); err != nil {
+		t.Skipf("filesystem rejects non-UTF-8 names: %v", err)
+	}
+
+	root, err := Walk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []TreeFormatter{UnicodeFormatter{}, ASCIIFormatter{}, JSONFormatter{}} {
+		out, err := f.Format(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !utf8.ValidString(out) {
+			t.Errorf("%T output is not valid UTF-8: %q", f, out)
+		}
+		if !strings.Contains(out, "Arch�ive") || !strings.Contains(out, "caf�.txt") {
+			t.Errorf("%T output missing placeholders:\n%s", f, out)
+		}
+	}
+
+	real, err := root.ResolvePath("/Arch�ive/caf�.txt")
+	if err != nil {
+		t.Fatalf("ResolvePath() error = %v", err)
+	}
+	if real != realDir+"/"+realFile {
+		t.Errorf("ResolvePath() = %q, want %q", real, realDir+"/"+realFile)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(real))); err != nil {
+		t.Errorf("resolved path is not usable: %v", err)
+	}
+}
+
+func TestResolvePath_Ambiguous(t *testing.T) {
+	root := &Node{Name: "root", IsDir: true, Children: []*Node{
+		{Name: "a\xff"},
+		{Name: "a\xfe"},
+		{Name: "b"},
+	}}
+
+	if _, err := root.ResolvePath("a�"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("ResolvePath() error = %v, want ambiguity error", err)
+	}
+	if got, err := root.ResolvePath("b"); err != nil || got != "b" {
+		t.Errorf("ResolvePath(b) = %q, %v", got, err)
+	}
+	if _, err := root.ResolvePath("missing"); err == nil {
+		t.Error("ResolvePath() expected not-found error")
+	}
+}
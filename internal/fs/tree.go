@@ -1,53 +1,590 @@
 package fs
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
-	"strings"
+	"sync"
 )
 
-func Tree(dirPath string) (string, error) {
-	var builder strings.Builder
-	err := buildTree(&builder, dirPath, "")
+// SkipReason describes why an entry was left out of the tree.
+type SkipReason string
+
+const (
+	// SkipUnreadable marks a directory whose entries could not be listed.
+	SkipUnreadable SkipReason = "unreadable"
+	// SkipDepth marks a directory whose contents lie below the depth limit.
+	SkipDepth SkipReason = "depth exceeded"
+	// SkipEntryLimit marks entries beyond the per-directory entry limit.
+	SkipEntryLimit SkipReason = "entry limit"
+	// SkipIgnored marks entries matched by the ignore file.
+	SkipIgnored SkipReason = "ignored"
+	// SkipListed marks directories named in the skip list.
+	SkipListed SkipReason = "skip list"
+	// SkipCycle marks a followed symlink leading back to one of its own
+	// parent directories.
+	SkipCycle SkipReason = "symlink cycle"
+)
+
+// DefaultSkipDirs are directory names left out of every walk unless
+// TreeOptions.SkipDirs says otherwise: dependencies, VCS metadata and build
+// output that bloat the tree without being places to file anything.
+var DefaultSkipDirs = []string{"node_modules", ".git", "vendor", "dist", "build", ".cache"}
+
+// ExplainFunc receives every entry left out of the tree. path is relative to
+// the tree root and detail carries rule-specific context (e.g. the error).
+type ExplainFunc func(path string, reason SkipReason, detail string)
+
+// TreeOptions controls how Tree walks and renders a directory.
+type TreeOptions struct {
+	// Explain, when set, is called for every entry skipped during traversal.
+	Explain ExplainFunc
+
+	// MaxDepth stops descending below this depth. 0 lists only the
+	// top-level entries; a negative value means unlimited.
+	MaxDepth int
+
+	// MaxEntries caps how many entries are listed per directory; the rest
+	// are summarised in a single marker line. 0 means unlimited.
+	MaxEntries int
+
+	// Formatter draws the pruned tree. nil means UnicodeFormatter.
+	Formatter TreeFormatter
+
+	// ShowCounts adds each directory's number of files, not counting
+	// subdirectories or ignored files, e.g. "Invoices (12 files)".
+	ShowCounts bool
+
+	// MaxBytes caps the size of line-based output, marker included. Entries
+	// past the cap are dropped, each folder cut short ends with a "(N more)"
+	// line and a marker line says the tree was cut. JSON output is never
+	// cut. 0 means unlimited.
+	MaxBytes int
+
+	// IgnoreFile names a file of gitignore-style patterns, relative to the
+	// tree root unless absolute. Matching entries are left out of the walk.
+	// It defaults to DefaultIgnoreFile; a missing file ignores nothing and
+	// "" turns ignoring off.
+	IgnoreFile string
+
+	// SkipDirs names directories left out of the walk at any level, matched
+	// by exact name. It defaults to DefaultSkipDirs; an empty list skips
+	// nothing.
+	SkipDirs []string
+
+	// DirsOnly leaves files out of the walk so only the folder structure
+	// is listed.
+	DirsOnly bool
+
+	// FollowSymlinks descends into symlinks to directories. A link back to a
+	// directory on its own path is drawn as "-> target (cycle)" and not
+	// followed. By default symlinks are listed like files.
+	FollowSymlinks bool
+
+	// Concurrency is how many directories are read at once. 0 means
+	// GOMAXPROCS and 1 walks sequentially. The result is the same either way.
+	Concurrency int
+
+	// CacheDir, when set, makes Tree reuse the tree rendered by an earlier
+	// run while no walked directory has changed. Walk ignores it.
+	CacheDir string
+}
+
+// TreeOption configures a TreeOptions value.
+type TreeOption func(*TreeOptions)
+
+// WithExplain reports skipped entries to fn.
+func WithExplain(fn ExplainFunc) TreeOption {
+	return func(o *TreeOptions) {
+		o.Explain = fn
+	}
+}
+
+// WithMaxDepth limits how deep the tree is walked.
+func WithMaxDepth(n int) TreeOption {
+	return func(o *TreeOptions) {
+		o.MaxDepth = n
+	}
+}
+
+// WithMaxEntries limits how many entries are listed per directory.
+func WithMaxEntries(n int) TreeOption {
+	return func(o *TreeOptions) {
+		o.MaxEntries = n
+	}
+}
+
+// WithFormatter selects how the tree is drawn.
+func WithFormatter(f TreeFormatter) TreeOption {
+	return func(o *TreeOptions) {
+		o.Formatter = f
+	}
+}
+
+// WithCounts shows how many files each directory holds.
+func WithCounts() TreeOption {
+	return func(o *TreeOptions) {
+		o.ShowCounts = true
+	}
+}
+
+// WithMaxBytes caps the size of the drawn tree.
+func WithMaxBytes(n int) TreeOption {
+	return func(o *TreeOptions) {
+		o.MaxBytes = n
+	}
+}
+
+// WithDirsOnly lists directories only.
+func WithDirsOnly() TreeOption {
+	return func(o *TreeOptions) {
+		o.DirsOnly = true
+	}
+}
+
+// WithFollowSymlinks descends into symlinked directories.
+func WithFollowSymlinks() TreeOption {
+	return func(o *TreeOptions) {
+		o.FollowSymlinks = true
+	}
+}
+
+// WithConcurrency sets how many directories are read at once.
+func WithConcurrency(n int) TreeOption {
+	return func(o *TreeOptions) {
+		o.Concurrency = n
+	}
+}
+
+// WithIgnoreFile reads ignore patterns from name instead of DefaultIgnoreFile.
+func WithIgnoreFile(name string) TreeOption {
+	return func(o *TreeOptions) {
+		o.IgnoreFile = name
+	}
+}
+
+// WithSkipDirs replaces the skip list. With no names nothing is skipped.
+func WithSkipDirs(names ...string) TreeOption {
+	return func(o *TreeOptions) {
+		o.SkipDirs = append([]string{}, names...)
+	}
+}
+
+// ExplainTo returns an ExplainFunc that writes one line per skipped entry to w.
+func ExplainTo(w io.Writer) ExplainFunc {
+	return func(path string, reason SkipReason, detail string) {
+		path = SafeName(path)
+		if detail != "" {
+			fmt.Fprintf(w, "skipped %s: %s (%s)\n", path, reason, detail)
+			return
+		}
+		fmt.Fprintf(w, "skipped %s: %s\n", path, reason)
+	}
+}
+
+func newTreeOptions(opts []TreeOption) TreeOptions {
+	o := TreeOptions{MaxDepth: -1, IgnoreFile: DefaultIgnoreFile, SkipDirs: DefaultSkipDirs}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func Tree(dirPath string, opts ...TreeOption) (string, error) {
+	o := newTreeOptions(opts)
+	if o.CacheDir != "" {
+		return cachedTree(dirPath, o, opts)
+	}
+	root, err := Walk(dirPath, opts...)
 	if err != nil {
 		return "", err
 	}
-	return builder.String(), nil
+	return Render(root, o)
+}
+
+// Node is one entry in a walked directory tree. JSONNode is its JSON form.
+type Node struct {
+	Name     string
+	IsDir    bool
+	Children []*Node
+
+	// Truncated marks a directory with contents that are not listed
+	// because of the depth limit.
+	Truncated bool
+
+	// Omitted counts entries hidden by the per-directory entry limit.
+	Omitted int
+
+	// Files is the number of files directly inside a directory, set by
+	// Prune with ShowCounts for directories that were listed.
+	Files *int
+
+	path    string // relative to the walk root, for explanations
+	files   int    // files directly inside, when listed
+	listed  bool   // whether the directory's entries were read
+	readErr string // why the directory could not be listed
+	cycle   string // target of a followed symlink that leads back up the path
+	skipped []skippedEntry
+}
+
+// skippedEntry records an entry of a directory left out during the walk
+type skippedEntry struct {
+	path   string
+	reason SkipReason
+	detail string
+}
+
+// Walk reads dirPath into a Node tree, sorted dirs first and then
+// alphabetically. Only MaxDepth, IgnoreFile, SkipDirs, DirsOnly and
+// FollowSymlinks affect the walk; other limits apply when rendering, so one
+// walk can be rendered under several limits.
+//
+// Subdirectories are read concurrently, up to o.Concurrency at a time. Each
+// directory's children are filled in by a single goroutine in sorted order,
+// so the tree doesn't depend on scheduling.
+func Walk(dirPath string, opts ...TreeOption) (*Node, error) {
+	o := newTreeOptions(opts)
+	entries, err := readSorted(dirPath, o.FollowSymlinks)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := loadIgnore(dirPath, o.IgnoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := o.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// The calling goroutine is one of the workers
+	w := &walker{o: o, rules: rules, sem: make(chan struct{}, workers-1)}
+
+	var onPath *realPath
+	if o.FollowSymlinks {
+		if real, err := filepath.EvalSymlinks(dirPath); err == nil {
+			onPath = &realPath{path: real}
+		}
+	}
+	root := &Node{Name: filepath.Base(dirPath), IsDir: true, path: "."}
+	w.walkEntries(root, dirPath, entries, 0, onPath)
+	w.wg.Wait()
+	return root, nil
+}
+
+// walker holds what stays the same across one walk
+type walker struct {
+	o     TreeOptions
+	rules ignoreRules
+	sem   chan struct{} // one slot per extra goroutine
+	wg    sync.WaitGroup
+}
+
+// spawn runs fn on a new goroutine when a worker slot is free and inline
+// otherwise, so a full pool never blocks the walk
+func (w *walker) spawn(fn func()) {
+	select {
+	case w.sem <- struct{}{}:
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			defer func() { <-w.sem }()
+			fn()
+		}()
+	default:
+		fn()
+	}
+}
+
+// realPath lists the real paths of the directories from the root down to
+// the one being walked, for catching symlink cycles. Branches share their
+// common ancestors, so it is safe to use from several goroutines.
+type realPath struct {
+	path   string
+	parent *realPath
+}
+
+func (p *realPath) contains(path string) bool {
+	for ; p != nil; p = p.parent {
+		if p.path == path {
+			return true
+		}
+	}
+	return false
 }
 
-func buildTree(builder *strings.Builder, dirPath, prefix string) error {
-	entries, err := os.ReadDir(dirPath)
+// loadIgnore reads the ignore file for the tree at dirPath; a missing file
+// ignores nothing
+func loadIgnore(dirPath, name string) (ignoreRules, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dirPath, name)
+	}
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("cannot read ignore file: %w", err)
+	}
+	return parseIgnore(string(data)), nil
+}
+
+func (w *walker) walkEntries(parent *Node, dirPath string, entries []dirEntry, depth int, onPath *realPath) {
+	o := w.o
+	parent.listed = true
+	for _, entry := range entries {
+		path := joinRel(parent.path, entry.Name())
+		if entry.isDir && skipListed(o.SkipDirs, entry.Name()) {
+			parent.skipped = append(parent.skipped, skippedEntry{path: path, reason: SkipListed})
+			continue
+		}
+		if pattern, ignored := w.rules.match(path, entry.isDir); ignored {
+			parent.skipped = append(parent.skipped, skippedEntry{path: path, reason: SkipIgnored, detail: pattern})
+			continue
+		}
+		if !entry.isDir {
+			parent.files++
+			if o.DirsOnly {
+				continue
+			}
+		}
+		child := &Node{Name: entry.Name(), IsDir: entry.isDir, path: path}
+		parent.Children = append(parent.Children, child)
+		if !entry.isDir {
+			continue
+		}
+
+		nextPath := filepath.Join(dirPath, entry.Name())
+		childPath := onPath
+		if o.FollowSymlinks {
+			if real, err := filepath.EvalSymlinks(nextPath); err == nil {
+				if onPath.contains(real) {
+					child.cycle = real
+					if target, err := os.Readlink(nextPath); err == nil {
+						child.cycle = target
+					}
+					continue
+				}
+				childPath = &realPath{path: real, parent: onPath}
+			}
+		}
+		if o.MaxDepth >= 0 && depth >= o.MaxDepth {
+			// Only check whether there is anything to elide
+			if f, err := os.Open(nextPath); err == nil {
+				names, _ := f.Readdirnames(1)
+				f.Close()
+				child.Truncated = len(names) > 0
+			}
+			continue
+		}
+
+		w.spawn(func() {
+			sub, err := readSorted(nextPath, o.FollowSymlinks)
+			if err != nil {
+				detail := err.Error()
+				var pathErr *os.PathError
+				if errors.As(err, &pathErr) {
+					detail = pathErr.Err.Error()
+				}
+				child.readErr = detail
+				return
+			}
+			w.walkEntries(child, nextPath, sub, depth+1, childPath)
+		})
+	}
+}
+
+func skipListed(skip []string, name string) bool {
+	for _, s := range skip {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// dirEntry is a directory entry with isDir resolved through symlinks when
+// they are followed
+type dirEntry struct {
+	os.DirEntry
+	isDir bool
+}
+
+// readSorted lists dirPath with dirs first, then files, both alphabetically.
+// With follow set, symlinks to directories count as directories.
+func readSorted(dirPath string, follow bool) ([]dirEntry, error) {
+	list, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]dirEntry, len(list))
+	for i, e := range list {
+		entries[i] = dirEntry{DirEntry: e, isDir: e.IsDir()}
+		if follow && e.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dirPath, e.Name())); err == nil {
+				entries[i].isDir = info.IsDir()
+			}
+		}
 	}
-	// Sort entries: dirs first, then files, both alphabetically
 	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].IsDir() == entries[j].IsDir() {
+		if entries[i].isDir == entries[j].isDir {
 			return entries[i].Name() < entries[j].Name()
 		}
-		return entries[i].IsDir()
+		return entries[i].isDir
 	})
+	return entries, nil
+}
+
+func joinRel(parent, name string) string {
+	if parent == "." {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// Render applies the limits in o to a walked tree and draws it with
+// o.Formatter, reporting anything left out to o.Explain.
+func Render(root *Node, o TreeOptions) (string, error) {
+	f := o.Formatter
+	if f == nil {
+		f = UnicodeFormatter{}
+	}
+	pruned := Prune(root, o)
+	out, err := f.Format(pruned)
+	if err != nil || o.MaxBytes <= 0 || len(out) <= o.MaxBytes {
+		return out, err
+	}
+	if _, isJSON := f.(JSONFormatter); isJSON {
+		return out, nil
+	}
+	return truncateTree(f, pruned, o.MaxBytes)
+}
+
+// truncateTree draws as many of root's entries, in listing order, as fit in
+// max bytes together with the truncation marker. It keeps whole entries at
+// the top level, then as much as fits of the first entry that didn't fit
+// whole, and so on down. Folders that lose entries end with the formatter's
+// "(N more)" line, so no folder looks complete when part of it was cut.
+func truncateTree(f TreeFormatter, root *Node, max int) (string, error) {
+	marker := fmt.Sprintf("... (tree truncated at %d bytes)\n", max)
+	budget := max - len(marker)
+	var path []int
+	best := ""
+	for dir := root; ; dir = dir.Children[path[len(path)-1]] {
+		// The largest number of whole entries of dir that fits. Listing them
+		// all is known not to fit: that is the whole folder.
+		kept := -1
+		lo, hi := 0, len(dir.Children)-1
+		for lo <= hi {
+			mid := (lo + hi) / 2
+			out, err := f.Format(cutTree(root, append(path, mid)))
+			if err != nil {
+				return "", err
+			}
+			if len(out) <= budget {
+				kept, best = mid, out
+				lo = mid + 1
+			} else {
+				hi = mid - 1
+			}
+		}
+		if kept < 0 {
+			break
+		}
+		path = append(path, kept)
+		if next := dir.Children[kept]; !next.IsDir || len(next.Children) == 0 {
+			break
+		}
+	}
+	return best + marker, nil
+}
+
+// cutTree copies root keeping path[0] of its entries whole. With more of
+// path, the next entry is kept too, cut the same way by path[1:]. Folders
+// that lose entries count them in Omitted.
+func cutTree(root *Node, path []int) *Node {
+	c := *root
+	if len(path) == 0 {
+		return &c
+	}
+	keep := path[0]
+	c.Children = append([]*Node{}, root.Children[:keep]...)
+	if len(path) > 1 {
+		c.Children = append(c.Children, cutTree(root.Children[keep], path[1:]))
+	}
+	c.Omitted += len(root.Children) - len(c.Children)
+	return &c
+}
 
-	space := "    "
-	branch := "│   "
-	tee := "├── "
-	last := "└── "
+// Prune returns a copy of a walked tree with the depth and entry limits in o
+// applied. Directories cut by the depth limit are marked Truncated and
+// directories cut by the entry limit record how many entries were Omitted.
+// With ShowCounts, every listed directory carries its Files count.
+func Prune(root *Node, o TreeOptions) *Node {
+	pruned := *root
+	setCount(&pruned, o)
+	pruneChildren(&pruned, root, 0, o)
+	return &pruned
+}
 
-	for i, entry := range entries {
-		pointer := tee
-		if i == len(entries)-1 {
-			pointer = last
+// setCount fills in Files on a pruned directory when counts are shown
+func setCount(n *Node, o TreeOptions) {
+	n.Files = nil
+	if o.ShowCounts && n.IsDir && n.listed {
+		files := n.files
+		n.Files = &files
+	}
+}
+
+func pruneChildren(out, dir *Node, depth int, o TreeOptions) {
+	for _, s := range dir.skipped {
+		explain(o, s.path, s.reason, s.detail)
+	}
+	shown := dir.Children
+	out.Children = nil
+	out.Omitted = 0
+	if o.MaxEntries > 0 && len(shown) > o.MaxEntries {
+		out.Omitted = len(shown) - o.MaxEntries
+		shown = shown[:o.MaxEntries]
+	}
+
+	for _, child := range shown {
+		c := *child
+		setCount(&c, o)
+		out.Children = append(out.Children, &c)
+		if !child.IsDir {
+			continue
 		}
-		builder.WriteString(prefix + pointer + entry.Name() + "\n")
-		if entry.IsDir() {
-			extension := branch
-			if pointer == last {
-				extension = space
+		switch {
+		case child.readErr != "":
+			explain(o, child.path, SkipUnreadable, child.readErr)
+		case child.cycle != "":
+			explain(o, child.path, SkipCycle, "-> "+child.cycle)
+		case o.MaxDepth >= 0 && depth >= o.MaxDepth:
+			c.Children = nil
+			c.Truncated = child.Truncated || len(child.Children) > 0
+			if c.Truncated {
+				explain(o, child.path, SkipDepth, fmt.Sprintf("max depth %d", o.MaxDepth))
 			}
-			nextPath := filepath.Join(dirPath, entry.Name())
-			buildTree(builder, nextPath, prefix+extension)
+		default:
+			pruneChildren(&c, child, depth+1, o)
 		}
 	}
-	return nil
+
+	for _, child := range dir.Children[len(shown):] {
+		explain(o, child.path, SkipEntryLimit, fmt.Sprintf("max %d entries per directory", o.MaxEntries))
+	}
+}
+
+func explain(o TreeOptions, path string, reason SkipReason, detail string) {
+	if o.Explain != nil {
+		o.Explain(path, reason, detail)
+	}
 }
@@ -0,0 +1,484 @@
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mkTree creates the given relative paths under a temp dir. Paths ending in
+// "/" are created as directories, everything else as empty files.
+func mkTree(t *testing.T, paths ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, p := range paths {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if p[len(p)-1] == '/' {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestTree_DefaultOutput(t *testing.T) {
+	root := mkTree(t, "b.txt", "a/", "a/x.txt", "c/")
+
+	got, err := Tree(root)
+	if err != nil {
+		t.Fatalf("Tree() unexpected error = %v", err)
+	}
+	want := "├── a\n" +
+		"│   └── x.txt\n" +
+		"├── c\n" +
+		"└── b.txt\n"
+	if got != want {
+		t.Errorf("Tree() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+type explained struct {
+	path   string
+	reason SkipReason
+}
+
+func TestTree_Explain(t *testing.T) {
+	tests := []struct {
+		name   string
+		paths  []string
+		setup  func(t *testing.T, root string)
+		opts   []TreeOption
+		expect []explained
+	}{
+		{
+			name:   "nothing skipped",
+			paths:  []string{"a/", "a/x.txt", "b.txt"},
+			expect: nil,
+		},
+		{
+			name:  "unreadable directory",
+			paths: []string{"open/", "open/x.txt", "locked/", "locked/secret.txt"},
+			setup: func(t *testing.T, root string) {
+				if os.Geteuid() == 0 {
+					t.Skip("permission checks are bypassed when running as root")
+				}
+				locked := filepath.Join(root, "locked")
+				if err := os.Chmod(locked, 0); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.Chmod(locked, 0755) })
+			},
+			expect: []explained{{path: "locked", reason: SkipUnreadable}},
+		},
+		{
+			name:   "depth exceeded",
+			paths:  []string{"a/", "a/b/", "a/b/deep.txt"},
+			opts:   []TreeOption{WithMaxDepth(1)},
+			expect: []explained{{path: "a/b", reason: SkipDepth}},
+		},
+		{
+			name:   "entry limit",
+			paths:  []string{"a.txt", "b.txt", "c.txt"},
+			opts:   []TreeOption{WithMaxEntries(1)},
+			expect: []explained{{path: "b.txt", reason: SkipEntryLimit}, {path: "c.txt", reason: SkipEntryLimit}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := mkTree(t, tt.paths...)
+			if tt.setup != nil {
+				tt.setup(t, root)
+			}
+
+			var got []explained
+			record := func(path string, reason SkipReason, detail string) {
+				got = append(got, explained{path: path, reason: reason})
+			}
+			opts := append([]TreeOption{WithExplain(record)}, tt.opts...)
+			if _, err := Tree(root, opts...); err != nil {
+				t.Fatalf("Tree() unexpected error = %v", err)
+			}
+
+			if len(got) != len(tt.expect) {
+				t.Fatalf("explanations = %v, want %v", got, tt.expect)
+			}
+			for i := range got {
+				if got[i] != tt.expect[i] {
+					t.Errorf("explanation[%d] = %v, want %v", i, got[i], tt.expect[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExplainTo(t *testing.T) {
+	var buf bytes.Buffer
+	explain := ExplainTo(&buf)
+	explain("photos/private", SkipUnreadable, "permission denied")
+
+	want := "skipped photos/private: unreadable (permission denied)\n"
+	if buf.String() != want {
+		t.Errorf("ExplainTo() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTree_Limits(t *testing.T) {
+	root := mkTree(t, "a/", "a/b/", "a/b/deep.txt", "a/x.txt", "c.txt", "d.txt", "e.txt")
+
+	tests := []struct {
+		name string
+		opts []TreeOption
+		want string
+	}{
+		{
+			name: "max depth 0",
+			opts: []TreeOption{WithMaxDepth(0)},
+			want: "├── a\n" +
+				"│   └── …\n" +
+				"├── c.txt\n" +
+				"├── d.txt\n" +
+				"└── e.txt\n",
+		},
+		{
+			name: "max depth 1",
+			opts: []TreeOption{WithMaxDepth(1)},
+			want: "├── a\n" +
+				"│   ├── b\n" +
+				"│   │   └── …\n" +
+				"│   └── x.txt\n" +
+				"├── c.txt\n" +
+				"├── d.txt\n" +
+				"└── e.txt\n",
+		},
+		{
+			name: "max entries",
+			opts: []TreeOption{WithMaxEntries(2), WithMaxDepth(0)},
+			want: "├── a\n" +
+				"│   └── …\n" +
+				"├── c.txt\n" +
+				"└── … (2 more)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tree(root, tt.opts...)
+			if err != nil {
+				t.Fatalf("Tree() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Tree() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTree_SkipDirs(t *testing.T) {
+	paths := []string{
+		"node_modules/", "node_modules/pkg/", "node_modules/pkg/index.js",
+		"src/", "src/vendor/", "src/vendor/lib.go", "src/main.go",
+		"web/", "web/app/", "web/app/dist/", "web/app/dist/bundle.js",
+		"notes/", "notes/build", // a file named like a skipped directory
+	}
+
+	t.Run("default list applied recursively", func(t *testing.T) {
+		root := mkTree(t, paths...)
+		var got []explained
+		record := func(path string, reason SkipReason, detail string) {
+			got = append(got, explained{path: path, reason: reason})
+		}
+		tree, err := Tree(root, WithExplain(record))
+		if err != nil {
+			t.Fatalf("Tree() unexpected error = %v", err)
+		}
+		want := "├── notes\n" +
+			"│   └── build\n" +
+			"├── src\n" +
+			"│   └── main.go\n" +
+			"└── web\n" +
+			"    └── app\n"
+		if tree != want {
+			t.Errorf("Tree() =\n%s\nwant:\n%s", tree, want)
+		}
+		wantExplained := []explained{
+			{path: "node_modules", reason: SkipListed},
+			{path: "src/vendor", reason: SkipListed},
+			{path: "web/app/dist", reason: SkipListed},
+		}
+		if len(got) != len(wantExplained) {
+			t.Fatalf("explanations = %v, want %v", got, wantExplained)
+		}
+		for i := range got {
+			if got[i] != wantExplained[i] {
+				t.Errorf("explanation[%d] = %v, want %v", i, got[i], wantExplained[i])
+			}
+		}
+	})
+
+	t.Run("empty list restores full traversal", func(t *testing.T) {
+		root := mkTree(t, paths...)
+		tree, err := Tree(root, WithSkipDirs())
+		if err != nil {
+			t.Fatalf("Tree() unexpected error = %v", err)
+		}
+		for _, name := range []string{"node_modules", "index.js", "vendor", "lib.go", "dist", "bundle.js"} {
+			if !strings.Contains(tree, name) {
+				t.Errorf("Tree() without a skip list is missing %q:\n%s", name, tree)
+			}
+		}
+	})
+
+	t.Run("custom list replaces the default", func(t *testing.T) {
+		root := mkTree(t, paths...)
+		tree, err := Tree(root, WithSkipDirs("web"))
+		if err != nil {
+			t.Fatalf("Tree() unexpected error = %v", err)
+		}
+		if strings.Contains(tree, "web") || !strings.Contains(tree, "node_modules") {
+			t.Errorf("Tree() with skip list [web] =\n%s", tree)
+		}
+	})
+}
+
+func TestWalk_UnreadableSubdirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks are bypassed when running as root")
+	}
+	root := mkTree(t, "open/", "open/x.txt", "locked/", "locked/secret.txt", "z.txt")
+	locked := filepath.Join(root, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	node, err := Walk(root)
+	if err != nil {
+		t.Fatalf("Walk() should succeed with an unreadable subdirectory, got %v", err)
+	}
+	if got := Unreadable(node); len(got) != 1 || got[0] != "locked" {
+		t.Errorf("Unreadable() = %q, want [locked]", got)
+	}
+
+	tree, err := Render(node, TreeOptions{MaxDepth: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "├── locked <permission denied>\n" +
+		"├── open\n" +
+		"│   └── x.txt\n" +
+		"└── z.txt\n"
+	if tree != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", tree, want)
+	}
+}
+
+func TestTree_MaxBytes(t *testing.T) {
+	root := mkTree(t, "alpha/", "alpha/one.txt", "alpha/two.txt", "beta/", "beta/three.txt")
+	full, err := Tree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("fits", func(t *testing.T) {
+		got, err := Tree(root, WithMaxBytes(len(full)))
+		if err != nil || got != full {
+			t.Errorf("Tree() = %q, %v; want the full tree", got, err)
+		}
+	})
+
+	big := mkTree(t, "alpha/one.txt", "alpha/two.txt", "beta/three.txt", "beta/four.txt", "beta/five.txt", "gamma.txt",
+		"zeta/a-rather-long-file-name.txt", "zeta/another-long-file-name.txt")
+
+	t.Run("cut between entries", func(t *testing.T) {
+		// Room for alpha and its files but not for beta
+		body := "├── alpha\n│   ├── one.txt\n│   └── two.txt\n└── … (3 more)\n"
+		limit := len(body) + len("... (tree truncated at 100 bytes)\n")
+		got, err := Tree(big, WithMaxBytes(limit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := body + fmt.Sprintf("... (tree truncated at %d bytes)\n", limit)
+		if got != want {
+			t.Errorf("Tree() =\n%s\nwant:\n%s", got, want)
+		}
+		if len(got) > limit {
+			t.Errorf("Tree() is %d bytes, over the %d byte limit", len(got), limit)
+		}
+	})
+
+	t.Run("cut inside a folder", func(t *testing.T) {
+		// Room for part of beta only
+		limit := len("├── alpha\n│   ├── one.txt\n│   └── two.txt\n├── beta\n│   ├── five.txt\n│   └── … (2 more)\n└── … (2 more)\n") + len("... (tree truncated at 100 bytes)\n")
+		got, err := Tree(big, WithMaxBytes(limit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) > limit {
+			t.Errorf("Tree() is %d bytes, over the %d byte limit", len(got), limit)
+		}
+		if !strings.Contains(got, "five.txt") || !strings.Contains(got, "(2 more)") || strings.Contains(got, "four.txt") {
+			t.Errorf("Tree() =\n%s\nwant beta cut short with a (2 more) line", got)
+		}
+	})
+
+	t.Run("json is never cut", func(t *testing.T) {
+		got, err := Tree(root, WithMaxBytes(10), WithFormatter(JSONFormatter{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(got, "truncated at") || !strings.Contains(got, "three.txt") {
+			t.Errorf("JSON tree was cut:\n%s", got)
+		}
+	})
+}
+
+func TestTree_DirsOnly(t *testing.T) {
+	root := mkTree(t, "top.txt", "a/", "a/one.txt", "a/b/", "a/b/two.txt", "a/b/c/", "a/b/c/three.txt", "empty/")
+
+	got, err := Tree(root, WithDirsOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "├── a\n" +
+		"│   └── b\n" +
+		"│       └── c\n" +
+		"└── empty\n"
+	if got != want {
+		t.Errorf("Tree(WithDirsOnly()) =\n%s\nwant:\n%s", got, want)
+	}
+
+	full, err := Tree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"top.txt", "one.txt", "two.txt", "three.txt"} {
+		if !strings.Contains(full, name) {
+			t.Errorf("files should be listed by default; %q missing:\n%s", name, full)
+		}
+	}
+}
+
+func TestTree_SymlinkCycle(t *testing.T) {
+	root := mkTree(t, "a/", "a/file.txt", "b/")
+	if err := os.Symlink("..", filepath.Join(root, "a", "up")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "b", "to-a")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("not followed by default", func(t *testing.T) {
+		got, err := Tree(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "├── a\n" +
+			"│   ├── file.txt\n" +
+			"│   └── up\n" +
+			"└── b\n" +
+			"    └── to-a\n"
+		if got != want {
+			t.Errorf("Tree() =\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("followed with cycles cut", func(t *testing.T) {
+		var got []explained
+		record := func(path string, reason SkipReason, detail string) {
+			got = append(got, explained{path: path, reason: reason})
+		}
+		done := make(chan struct{})
+		var tree string
+		var err error
+		go func() {
+			defer close(done)
+			tree, err = Tree(root, WithFollowSymlinks(), WithExplain(record))
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Tree() did not terminate on a symlink cycle")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// b/to-a is followed once; inside it, up leads back to the root
+		want := "├── a\n" +
+			"│   ├── up -> .. (cycle)\n" +
+			"│   └── file.txt\n" +
+			"└── b\n" +
+			"    └── to-a\n" +
+			"        ├── up -> .. (cycle)\n" +
+			"        └── file.txt\n"
+		if tree != want {
+			t.Errorf("Tree() =\n%s\nwant:\n%s", tree, want)
+		}
+		wantExplained := []explained{
+			{path: "a/up", reason: SkipCycle},
+			{path: "b/to-a/up", reason: SkipCycle},
+		}
+		if len(got) != len(wantExplained) || got[0] != wantExplained[0] || got[1] != wantExplained[1] {
+			t.Errorf("explanations = %v, want %v", got, wantExplained)
+		}
+	})
+}
+
+func TestWalk_ConcurrentMatchesSequential(t *testing.T) {
+	root := mkLargeTree(t, 4, 3, 3)
+	if err := os.Symlink(root, filepath.Join(root, "dir_01", "dir_02", "loop")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	for _, follow := range []bool{false, true} {
+		opts := []TreeOption{WithConcurrency(1)}
+		if follow {
+			opts = append(opts, WithFollowSymlinks())
+		}
+		want, err := Tree(root, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, workers := range []int{0, 2, 16} {
+			for run := 0; run < 3; run++ {
+				got, err := Tree(root, append(opts, WithConcurrency(workers))...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("follow=%v concurrency=%d: tree differs from the sequential walk", follow, workers)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	root := mkLargeTree(b, 5, 4, 10)
+	for _, workers := range []int{1, 0} {
+		name := "sequential"
+		if workers == 0 {
+			name = "concurrent"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Walk(root, WithConcurrency(workers)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// mtimeSettle is how old the newest directory change must be before a tree
+// is cached. A change within the same mtime tick as the walk couldn't be
+// told apart from the cached state.
+const mtimeSettle = 2 * time.Second
+
+// treeCacheEntry is a rendered tree with the modification times of the
+// directories it was read from
+type treeCacheEntry struct {
+	Root string `json:"root"`
+	Tree string `json:"tree"`
+
+	// Dirs maps each walked directory, relative to Root, to its mtime in
+	// Unix nanoseconds. Adding, removing or renaming an entry changes its
+	// directory's mtime, so checking these is enough to trust Tree.
+	Dirs map[string]int64 `json:"dirs"`
+
+	// Ignore is the ignore file's mtime, 0 when there is none
+	Ignore int64 `json:"ignore"`
+
+	// Skipped lists the entries left out of Tree, replayed to Explain on a
+	// cache hit
+	Skipped []explainedEntry `json:"skipped,omitempty"`
+}
+
+// explainedEntry is one call to ExplainFunc
+type explainedEntry struct {
+	Path   string     `json:"path"`
+	Reason SkipReason `json:"reason"`
+	Detail string     `json:"detail,omitempty"`
+}
+
+// WithCache keeps rendered trees under dir/tree, reused while none of the
+// walked directories changed. "" turns the cache off.
+func WithCache(dir string) TreeOption {
+	return func(o *TreeOptions) {
+		o.CacheDir = dir
+	}
+}
+
+// cachedTree returns the cached tree for dirPath under o when no walked
+// directory changed since it was stored, and walks and stores it otherwise.
+// Checking costs one stat per directory rather than a full read. Skipped
+// entries are stored with the tree and explained again on a cache hit.
+// Cache failures only cost the speedup.
+func cachedTree(dirPath string, o TreeOptions, opts []TreeOption) (string, error) {
+	root, err := filepath.Abs(dirPath)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(o.CacheDir, "tree", treeCacheKey(root, o)+".json")
+	if entry, ok := readTreeCache(path); ok && entry.Root == root && entry.fresh(o) {
+		if o.Explain != nil {
+			for _, s := range entry.Skipped {
+				o.Explain(s.Path, s.Reason, s.Detail)
+			}
+		}
+		return entry.Tree, nil
+	}
+
+	var skipped []explainedEntry
+	explain := o.Explain
+	o.Explain = func(path string, reason SkipReason, detail string) {
+		skipped = append(skipped, explainedEntry{path, reason, detail})
+		if explain != nil {
+			explain(path, reason, detail)
+		}
+	}
+	node, err := Walk(root, append(opts[:len(opts):len(opts)], WithExplain(o.Explain))...)
+	if err != nil {
+		return "", err
+	}
+	tree, err := Render(node, o)
+	if err != nil {
+		return "", err
+	}
+	entry := treeCacheEntry{Root: root, Tree: tree, Dirs: map[string]int64{}, Ignore: ignoreMtime(root, o), Skipped: skipped}
+	if entry.record(node) {
+		writeTreeCache(path, entry)
+	}
+	return tree, nil
+}
+
+// treeCacheKey identifies root under the options that change the output
+func treeCacheKey(root string, o TreeOptions) string {
+	fingerprint := fmt.Sprintf("%s\x00%d\x00%d\x00%T\x00%v\x00%d\x00%s\x00%s\x00%v\x00%v",
+		root, o.MaxDepth, o.MaxEntries, o.Formatter, o.ShowCounts, o.MaxBytes,
+		o.IgnoreFile, strings.Join(o.SkipDirs, "/"), o.DirsOnly, o.FollowSymlinks)
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:16])
+}
+
+// record stores the mtime of every directory in node. It reports false when
+// one changed too recently for the cache to be trusted.
+func (e *treeCacheEntry) record(node *Node) bool {
+	settled := time.Now().Add(-mtimeSettle).UnixNano()
+	ok := true
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if !n.IsDir || n.cycle != "" {
+			return
+		}
+		info, err := os.Stat(filepath.Join(e.Root, n.path))
+		if err != nil || info.ModTime().UnixNano() > settled {
+			ok = false
+			return
+		}
+		e.Dirs[n.path] = info.ModTime().UnixNano()
+		for _, c := range n.Children {
+			visit(c)
+		}
+	}
+	visit(node)
+	return ok && e.Ignore <= settled
+}
+
+// fresh reports whether every recorded directory is unchanged
+func (e *treeCacheEntry) fresh(o TreeOptions) bool {
+	if ignoreMtime(e.Root, o) != e.Ignore {
+		return false
+	}
+	for rel, mtime := range e.Dirs {
+		info, err := os.Stat(filepath.Join(e.Root, rel))
+		if err != nil || info.ModTime().UnixNano() != mtime {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoreMtime returns the ignore file's mtime, or 0 when there is none
+func ignoreMtime(root string, o TreeOptions) int64 {
+	if o.IgnoreFile == "" {
+		return 0
+	}
+	name := o.IgnoreFile
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(root, name)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+func readTreeCache(path string) (treeCacheEntry, bool) {
+	var entry treeCacheEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, false
+	}
+	return entry, json.Unmarshal(data, &entry) == nil
+}
+
+// writeTreeCache stores entry atomically so a concurrent run never reads
+// half a file. Errors are ignored.
+func writeTreeCache(path string, entry treeCacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-tree-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
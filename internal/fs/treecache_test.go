@@ -0,0 +1,114 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ageDirs sets the mtime of root and every directory below it to an hour
+// ago, so a walk counts as settled and can be cached
+func ageDirs(t *testing.T, root string) time.Time {
+	t.Helper()
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, past, past)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return past
+}
+
+func TestTree_Cache(t *testing.T) {
+	root := mkTree(t, "Docs/", "Docs/a.txt", "Photos/")
+	past := ageDirs(t, root)
+	cacheDir := t.TempDir()
+
+	first, err := Tree(root, WithCache(cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new file whose folder mtime is put back is invisible: proof the
+	// second call is served from the cache
+	hidden := filepath.Join(root, "Docs", "hidden.txt")
+	if err := os.WriteFile(hidden, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(root, "Docs"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := Tree(root, WithCache(cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached != first {
+		t.Fatalf("cached tree = %q, want the first walk %q", cached, first)
+	}
+
+	// Different options are cached separately
+	if dirsOnly, _ := Tree(root, WithCache(cacheDir), WithDirsOnly()); strings.Contains(dirsOnly, "a.txt") {
+		t.Errorf("--dirs-only tree = %q, want no files", dirsOnly)
+	}
+
+	// A changed folder invalidates the cache
+	later := past.Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "Docs"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := Tree(root, WithCache(cacheDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rebuilt, "hidden.txt") {
+		t.Errorf("tree after a folder changed = %q, want it rebuilt", rebuilt)
+	}
+}
+
+func TestTree_CacheSkipsUnsettledTrees(t *testing.T) {
+	root := mkTree(t, "Docs/")
+	cacheDir := t.TempDir()
+
+	// Just created, so too recent to trust
+	if _, err := Tree(root, WithCache(cacheDir)); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(cacheDir, "tree"))
+	if len(entries) != 0 {
+		t.Errorf("cache has %d entries for a tree changed just now, want none", len(entries))
+	}
+}
+
+func TestTree_CacheReplaysSkipped(t *testing.T) {
+	root := mkTree(t, "Docs/", "Docs/a.txt", "node_modules/")
+	ageDirs(t, root)
+	cacheDir := t.TempDir()
+
+	var first, second []string
+	record := func(got *[]string) ExplainFunc {
+		return func(path string, reason SkipReason, detail string) {
+			*got = append(*got, path+": "+string(reason))
+		}
+	}
+	if _, err := Tree(root, WithCache(cacheDir), WithExplain(record(&first))); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(cacheDir, "tree")); len(entries) != 1 {
+		t.Fatalf("cache has %d entries, want 1", len(entries))
+	}
+	if _, err := Tree(root, WithCache(cacheDir), WithExplain(record(&second))); err != nil {
+		t.Fatal(err)
+	}
+	if len(first) == 0 {
+		t.Fatal("expected node_modules to be explained")
+	}
+	if strings.Join(second, "\n") != strings.Join(first, "\n") {
+		t.Errorf("explained on a cache hit = %q, want %q", second, first)
+	}
+}
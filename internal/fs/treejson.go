@@ -0,0 +1,66 @@
+package fs
+
+import "encoding/json"
+
+// JSONNode is one entry of the tree returned by TreeJSON and JSONFormatter,
+// and exported as tree.Node. Directories always carry a children array,
+// empty when there is nothing inside; files have none.
+type JSONNode struct {
+	Name     string     `json:"name"`
+	IsDir    bool       `json:"isDir"`
+	Children []JSONNode `json:"children,omitempty"`
+
+	// Truncated and Omitted mirror Node: contents cut by the depth limit and
+	// entries hidden by the entry limit
+	Truncated bool `json:"truncated,omitempty"`
+	Omitted   int  `json:"omitted,omitempty"`
+
+	// Files is the number of files directly inside, with ShowCounts
+	Files *int `json:"files,omitempty"`
+
+	// Error says why a directory could not be read, e.g. "permission denied"
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON keeps the children array of empty directories.
+func (n JSONNode) MarshalJSON() ([]byte, error) {
+	type plain JSONNode
+	if !n.IsDir {
+		return json.Marshal(plain(n))
+	}
+	children := n.Children
+	if children == nil {
+		children = []JSONNode{}
+	}
+	return json.Marshal(struct {
+		plain
+		Children []JSONNode `json:"children"`
+	}{plain(n), children})
+}
+
+// TreeJSON walks dirPath like Tree and returns it, root included, as compact
+// JSON made of JSONNode values in the same dirs-first alphabetical order.
+// Names pass through SafeName.
+func TreeJSON(dirPath string, opts ...TreeOption) ([]byte, error) {
+	o := newTreeOptions(opts)
+	root, err := Walk(dirPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(toJSONNode(Prune(root, o)))
+}
+
+func toJSONNode(n *Node) JSONNode {
+	j := JSONNode{
+		Name:      SafeName(n.Name),
+		IsDir:     n.IsDir,
+		Truncated: n.Truncated,
+		Omitted:   n.Omitted,
+		Files:     n.Files,
+		Error:     n.readErr,
+	}
+	for _, child := range n.Children {
+		j.Children = append(j.Children, toJSONNode(child))
+	}
+	return j
+}
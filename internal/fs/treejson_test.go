@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTreeJSON(t *testing.T) {
+	root := mkTree(t, "b.txt", "a/", "a/x.txt", "a/empty/", "c/")
+
+	data, err := TreeJSON(root)
+	if err != nil {
+		t.Fatalf("TreeJSON() unexpected error = %v", err)
+	}
+	if strings.Contains(string(data), "\n") {
+		t.Errorf("TreeJSON() should be compact, got %s", data)
+	}
+
+	var got JSONNode
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("TreeJSON() is not valid JSON: %v\n%s", err, data)
+	}
+	if !got.IsDir || len(got.Children) != 3 {
+		t.Fatalf("decoded root = %+v", got)
+	}
+
+	// Same dirs-first alphabetical order as Tree
+	var names []string
+	for _, c := range got.Children {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "a,c,b.txt" {
+		t.Errorf("top-level order = %v, want [a c b.txt]", names)
+	}
+	if a := got.Children[0]; len(a.Children) != 2 || a.Children[0].Name != "empty" || a.Children[1].Name != "x.txt" {
+		t.Errorf("children of a = %+v", a.Children)
+	}
+}
+
+func TestTreeJSON_EmptyDirectories(t *testing.T) {
+	root := mkTree(t, "empty/", "file.txt")
+
+	data, err := TreeJSON(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Children []map[string]json.RawMessage `json:"children"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Children) != 2 {
+		t.Fatalf("children = %s", data)
+	}
+	if got := string(raw.Children[0]["children"]); got != "[]" {
+		t.Errorf("empty directory children = %q, want []", got)
+	}
+	if _, ok := raw.Children[1]["children"]; ok {
+		t.Errorf("a file should have no children key: %s", data)
+	}
+}
+
+func TestTreeJSON_MatchesTree(t *testing.T) {
+	root := mkTree(t, "z/", "z/1.txt", "m.txt", "a/", "a/b/", "a/b/c.txt")
+
+	tree, err := Tree(root, WithFormatter(ASCIIFormatter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := TreeJSON(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got JSONNode
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// Redraw the decoded JSON and compare with the ASCII tree
+	var toNode func(j JSONNode) *Node
+	toNode = func(j JSONNode) *Node {
+		n := &Node{Name: j.Name, IsDir: j.IsDir}
+		for _, c := range j.Children {
+			n.Children = append(n.Children, toNode(c))
+		}
+		return n
+	}
+	redrawn, _ := ASCIIFormatter{}.Format(toNode(got))
+	if redrawn != tree {
+		t.Errorf("TreeJSON() and Tree() disagree:\n%s\nvs\n%s", redrawn, tree)
+	}
+}
@@ -0,0 +1,52 @@
+package httpx
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout bounds a whole request made with DefaultClient, including
+// reading the body. Interrupted update downloads resume on the next attempt.
+const DefaultTimeout = 2 * time.Minute
+
+// userAgent is sent with every request; main adds the version with SetVersion
+var userAgent = "sortpath"
+
+// SetVersion makes requests identify as sortpath/<version>
+func SetVersion(version string) {
+	userAgent = "sortpath/" + version
+}
+
+// UserAgent returns the User-Agent header sortpath sends
+func UserAgent() string {
+	return userAgent
+}
+
+// userAgentTransport adds the sortpath User-Agent to requests without one
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewClient returns a client sending the sortpath User-Agent through base, or
+// through DefaultTransport when base is nil so connections are pooled. A
+// positive timeout bounds whole requests; the transport always bounds each
+// phase. Clients are cheap: they share the transport and its connections.
+func NewClient(base http.RoundTripper, timeout time.Duration) *http.Client {
+	if base == nil {
+		base = DefaultTransport
+	}
+	return &http.Client{Transport: userAgentTransport{base: base}, Timeout: timeout}
+}
+
+// DefaultClient serves requests without settings of their own, such as the
+// updater's release checks and downloads
+var DefaultClient = NewClient(nil, DefaultTimeout)
@@ -0,0 +1,47 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClient_UserAgent(t *testing.T) {
+	old := userAgent
+	t.Cleanup(func() { userAgent = old })
+	SetVersion("1.2.3")
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+	}))
+	defer srv.Close()
+
+	if NewClient(nil, 0).Transport.(userAgentTransport).base != DefaultTransport {
+		t.Error("NewClient(nil) should share DefaultTransport")
+	}
+
+	// A transport without a proxy: ProxyFromEnvironment reads the
+	// environment only once, which would break the proxy test
+	client := NewClient(&http.Transport{}, DefaultTimeout)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("User-Agent", "custom")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(got) != 2 || got[0] != "sortpath/1.2.3" || got[1] != "custom" {
+		t.Errorf("User-Agent headers = %q, want [sortpath/1.2.3 custom]", got)
+	}
+	if req.Header.Get("User-Agent") != "custom" {
+		t.Error("the caller's request was modified")
+	}
+}
@@ -0,0 +1,55 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ParseFingerprint decodes a SHA-256 certificate fingerprint. Colons and
+// case are ignored, so both "AB:CD:..." (openssl) and "abcd..." are accepted.
+func ParseFingerprint(s string) ([]byte, error) {
+	cleaned := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	sum, err := hex.DecodeString(cleaned)
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 fingerprint '%s': expected 64 hex characters", s)
+	}
+	return sum, nil
+}
+
+// VerifyPinned returns a tls.Config.VerifyConnection callback accepting only a
+// leaf certificate whose SHA-256 fingerprint equals want
+func VerifyPinned(want []byte) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("pinned certificate check failed: server sent no certificate")
+		}
+		got := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		if !bytes.Equal(got[:], want) {
+			return fmt.Errorf("pinned certificate check failed: server certificate fingerprint %s does not match", hex.EncodeToString(got[:]))
+		}
+		return nil
+	}
+}
+
+// NewPinnedTransport returns a transport that trusts exactly one server
+// certificate. Chain verification is replaced by the fingerprint check, so
+// self-signed endpoints work while every other certificate is rejected,
+// including ones a system CA would accept.
+func NewPinnedTransport(fingerprint string) (*http.Transport, error) {
+	want, err := ParseFingerprint(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	tr := NewTransport()
+	tr.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true, // replaced by VerifyConnection below
+		VerifyConnection:   VerifyPinned(want),
+	}
+	return tr, nil
+}
@@ -0,0 +1,77 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseFingerprint(t *testing.T) {
+	valid := strings.Repeat("ab", 32)
+	colons := strings.ToUpper(strings.TrimSuffix(strings.Repeat("ab:", 32), ":"))
+
+	for _, s := range []string{valid, colons} {
+		if _, err := ParseFingerprint(s); err != nil {
+			t.Errorf("ParseFingerprint(%q) error = %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "abcd", strings.Repeat("zz", 32), valid + "ab"} {
+		if _, err := ParseFingerprint(s); err == nil {
+			t.Errorf("ParseFingerprint(%q) expected error", s)
+		}
+	}
+}
+
+func TestNewPinnedTransport(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // rejected handshakes are expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	matching := hex.EncodeToString(sum[:])
+	mismatching := strings.Repeat("00", 32)
+
+	tests := []struct {
+		name    string
+		pin     string
+		wantErr bool
+	}{
+		{name: "matching pin", pin: matching},
+		{name: "mismatching pin", pin: mismatching, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := NewPinnedTransport(tt.pin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Resolving the proxy would cache the environment for the proxy test
+			tr.Proxy = nil
+			client := &http.Client{Transport: tr}
+			resp, err := client.Get(srv.URL)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("expected pinned certificate mismatch error")
+				}
+				if !strings.Contains(err.Error(), "pinned certificate") {
+					t.Errorf("error = %v, want pinned certificate failure", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request with matching pin failed: %v", err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package httpx
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Transport timeouts. ResponseHeaderTimeout is generous because chat
+// completion endpoints only send headers once the whole answer is generated.
+const (
+	DialTimeout           = 10 * time.Second
+	TLSHandshakeTimeout   = 10 * time.Second
+	ExpectContinueTimeout = 1 * time.Second
+	ResponseHeaderTimeout = 90 * time.Second
+	IdleConnTimeout       = 90 * time.Second
+)
+
+// NewTransport returns the transport shared by sortpath's HTTP clients. It
+// honours proxy environment variables, attempts HTTP/2, and bounds every
+// phase of a request so a stalled server cannot hang the CLI.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   TLSHandshakeTimeout,
+		ExpectContinueTimeout: ExpectContinueTimeout,
+		ResponseHeaderTimeout: ResponseHeaderTimeout,
+		IdleConnTimeout:       IdleConnTimeout,
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   4,
+	}
+}
+
+// DefaultTransport is the process-wide transport so connections are pooled
+var DefaultTransport = NewTransport()
@@ -0,0 +1,48 @@
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewTransport_Settings(t *testing.T) {
+	tr := NewTransport()
+
+	if !tr.ForceAttemptHTTP2 {
+		t.Error("ForceAttemptHTTP2 = false, want true")
+	}
+	if tr.ExpectContinueTimeout != ExpectContinueTimeout {
+		t.Errorf("ExpectContinueTimeout = %v, want %v", tr.ExpectContinueTimeout, ExpectContinueTimeout)
+	}
+	if tr.ResponseHeaderTimeout != ResponseHeaderTimeout {
+		t.Errorf("ResponseHeaderTimeout = %v, want %v", tr.ResponseHeaderTimeout, ResponseHeaderTimeout)
+	}
+	if tr.TLSHandshakeTimeout != TLSHandshakeTimeout {
+		t.Errorf("TLSHandshakeTimeout = %v, want %v", tr.TLSHandshakeTimeout, TLSHandshakeTimeout)
+	}
+	if tr.IdleConnTimeout != IdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %v, want %v", tr.IdleConnTimeout, IdleConnTimeout)
+	}
+	if tr.ResponseHeaderTimeout <= 0 || tr.TLSHandshakeTimeout <= 0 {
+		t.Error("transport timeouts must be bounded")
+	}
+}
+
+func TestNewTransport_UsesProxyFromEnvironment(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	tr := NewTransport()
+	if tr.Proxy == nil {
+		t.Fatal("Proxy = nil, want http.ProxyFromEnvironment")
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.openai.com/v1/models", nil)
+	proxyURL, err := tr.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy() error = %v", err)
+	}
+	want, _ := url.Parse("http://proxy.example.com:3128")
+	if proxyURL == nil || proxyURL.String() != want.String() {
+		t.Errorf("Proxy() = %v, want %v", proxyURL, want)
+	}
+}
@@ -0,0 +1,140 @@
+// Package ui routes everything sortpath prints. Results go to stdout, so it
+// stays clean for piping; diagnostics, prompts and errors go to stderr.
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Options controls how output is shaped
+type Options struct {
+	// JSON marks results as JSON documents; callers emit them with ResultJSON
+	JSON bool
+
+	// PathOnly asks for bare folder paths, one per line, in place of the full
+	// text results
+	PathOnly bool
+
+	// Quiet suppresses diagnostics. Errors and prompts are still shown.
+	Quiet bool
+
+	// NoColor prints plain text, dropping the emoji status markers
+	NoColor bool
+}
+
+// Output separates results from everything else
+type Output struct {
+	opts   Options
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// New returns an Output writing results to stdout and the rest to stderr
+func New(stdout, stderr io.Writer, opts Options) *Output {
+	return &Output{opts: opts, stdout: stdout, stderr: stderr}
+}
+
+// Std writes to the process's stdout and stderr. A non-empty NO_COLOR
+// environment variable implies NoColor.
+func Std(opts Options) *Output {
+	if os.Getenv("NO_COLOR") != "" {
+		opts.NoColor = true
+	}
+	return New(os.Stdout, os.Stderr, opts)
+}
+
+// JSON reports whether results should be written with ResultJSON
+func (o *Output) JSON() bool {
+	return o.opts.JSON
+}
+
+// PathOnly reports whether text results should be bare paths
+func (o *Output) PathOnly() bool {
+	return o.opts.PathOnly
+}
+
+// Results is the stdout sink for the output the user asked for
+func (o *Output) Results() io.Writer {
+	return o.plain(o.stdout)
+}
+
+// Result writes formatted text to Results
+func (o *Output) Result(format string, args ...interface{}) {
+	fmt.Fprintf(o.Results(), format, args...)
+}
+
+// ResultJSON writes v to stdout as one line of JSON
+func (o *Output) ResultJSON(v interface{}) error {
+	return json.NewEncoder(o.stdout).Encode(v)
+}
+
+// Diagnostics is the stderr sink for progress and informational notices. It
+// discards everything when Quiet is set.
+func (o *Output) Diagnostics() io.Writer {
+	if o.opts.Quiet {
+		return io.Discard
+	}
+	return o.plain(o.stderr)
+}
+
+// Diagnostic writes formatted text to Diagnostics
+func (o *Output) Diagnostic(format string, args ...interface{}) {
+	fmt.Fprintf(o.Diagnostics(), format, args...)
+}
+
+// Errors is the stderr sink for errors, warnings that explain a failure,
+// and interactive prompts. Quiet doesn't apply.
+func (o *Output) Errors() io.Writer {
+	return o.plain(o.stderr)
+}
+
+// Error writes formatted text to Errors
+func (o *Output) Error(format string, args ...interface{}) {
+	fmt.Fprintf(o.Errors(), format, args...)
+}
+
+// Fail reports an error that ends the run. In JSON mode it is written to
+// stdout as {"error": "..."}, so consumers only read one stream; otherwise
+// it goes to Errors like Error.
+func (o *Output) Fail(format string, args ...interface{}) {
+	if !o.opts.JSON {
+		o.Error(format, args...)
+		return
+	}
+	msg := strings.TrimSpace(markers.Replace(fmt.Sprintf(format, args...)))
+	if err := o.ResultJSON(struct {
+		Error string `json:"error"`
+	}{msg}); err != nil {
+		o.Error(format, args...)
+	}
+}
+
+func (o *Output) plain(w io.Writer) io.Writer {
+	if !o.opts.NoColor {
+		return w
+	}
+	return plainWriter{w}
+}
+
+// markers are the decorations dropped by NoColor, each with its trailing space
+var markers = strings.NewReplacer(
+	"❌ ", "", "⚠️ ", "", "ℹ️ ", "", "✅ ", "", "💡 ", "",
+	"🔗 ", "", "📦 ", "", "🚀 ", "", "🔍 ", "", " ⬆️", "",
+)
+
+// plainWriter strips status markers. Callers write whole lines, so a marker
+// is never split across two writes.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, markers.Replace(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestOutput_Routing(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "default",
+			wantStdout: "/Documents/Finance\n",
+			wantStderr: "ℹ️ notice\n❌ failed\n",
+		},
+		{
+			name:       "quiet keeps errors",
+			opts:       Options{Quiet: true},
+			wantStdout: "/Documents/Finance\n",
+			wantStderr: "❌ failed\n",
+		},
+		{
+			name:       "no color",
+			opts:       Options{NoColor: true},
+			wantStdout: "/Documents/Finance\n",
+			wantStderr: "notice\nfailed\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			o := New(&stdout, &stderr, tt.opts)
+			o.Result("%s\n", "/Documents/Finance")
+			o.Diagnostic("ℹ️ notice\n")
+			o.Error("❌ failed\n")
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestOutput_ResultJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	o := New(&stdout, &stderr, Options{JSON: true})
+	if !o.JSON() {
+		t.Fatal("JSON() = false, want true")
+	}
+	if err := o.ResultJSON(map[string]string{"path": "/a"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "{\"path\":\"/a\"}\n" {
+		t.Errorf("stdout = %q", got)
+	}
+}
+
+func TestOutput_Fail(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	o := New(&stdout, &stderr, Options{})
+	o.Fail("❌ Config error: %s\n", "no model")
+	if stdout.Len() != 0 || stderr.String() != "❌ Config error: no model\n" {
+		t.Errorf("text mode: stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+
+	// In JSON mode both results and the error are JSON documents on stdout
+	stdout.Reset()
+	stderr.Reset()
+	o = New(&stdout, &stderr, Options{JSON: true})
+	o.ResultJSON(map[string]string{"path": "/a", "reason": "r"})
+	o.Fail("❌ Config error: %s\n", "no model")
+	if stderr.Len() != 0 {
+		t.Errorf("stderr = %q, want nothing", stderr.String())
+	}
+	dec := json.NewDecoder(&stdout)
+	var result struct{ Path, Reason string }
+	if err := dec.Decode(&result); err != nil || result.Path != "/a" {
+		t.Errorf("result line = %+v, %v", result, err)
+	}
+	var failure map[string]string
+	if err := dec.Decode(&failure); err != nil {
+		t.Fatalf("error line is not valid JSON: %v", err)
+	}
+	if len(failure) != 1 || failure["error"] != "Config error: no model" {
+		t.Errorf("error line = %v, want only error without the marker", failure)
+	}
+}
+
+func TestStd_NoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if !Std(Options{}).opts.NoColor {
+		t.Error("NO_COLOR in the environment should imply NoColor")
+	}
+}
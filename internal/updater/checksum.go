@@ -0,0 +1,76 @@
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
+)
+
+const (
+	// checksumSuffix is appended to a binary asset's name for its own digest
+	checksumSuffix = ".sha256"
+
+	// checksumsAsset ends the name of a release-wide list of digests in
+	// sha256sum format, such as sortpath_checksums.txt
+	checksumsAsset = "checksums.txt"
+)
+
+// releaseChecksum downloads the release's checksum asset and returns the
+// SHA-256 listed for the binary, lowercase hex
+func releaseChecksum(release *Release) (string, error) {
+	if release.ChecksumURL == "" {
+		return "", fmt.Errorf("release %s has no checksum asset; refusing to install unverified binary", release.Version)
+	}
+	resp, err := httpx.DefaultClient.Get(release.ChecksumURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksum: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("checksum download failed: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum: %w", err)
+	}
+	return parseChecksum(string(data), release.AssetName)
+}
+
+// parseChecksum finds the digest for asset in sha256sum output. A line naming
+// no file is a per-asset digest and applies to any asset.
+func parseChecksum(data, asset string) (string, error) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// sha256sum marks binary mode with a * before the name
+		if len(fields) == 1 || strings.TrimPrefix(fields[1], "*") == asset {
+			sum := strings.ToLower(fields[0])
+			if _, err := hex.DecodeString(sum); err != nil || len(sum) != 2*sha256.Size {
+				return "", fmt.Errorf("invalid SHA-256 checksum %q", fields[0])
+			}
+			return sum, nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", asset)
+}
+
+// fileSHA256 returns the SHA-256 of the file at path, lowercase hex
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
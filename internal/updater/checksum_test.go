@@ -0,0 +1,117 @@
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checksumLine formats data's digest the way sha256sum lists name
+func checksumLine(data []byte, name string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + "  " + name + "\n"
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", sha256.Size)
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{name: "checksums file", data: strings.Repeat("0", 64) + "  sortpath-darwin-arm64\n" + sum + "  sortpath-linux-amd64\n", want: sum},
+		{name: "binary mode marker", data: sum + " *sortpath-linux-amd64\n", want: sum},
+		{name: "per-asset file", data: strings.ToUpper(sum) + "\n", want: sum},
+		{name: "asset not listed", data: sum + "  sortpath-windows-amd64.exe\n", wantErr: "no checksum listed for sortpath-linux-amd64"},
+		{name: "not a digest", data: "deadbeef  sortpath-linux-amd64\n", wantErr: "invalid SHA-256 checksum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(tt.data, "sortpath-linux-amd64")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseChecksum() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseChecksum() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallBinary_Checksum(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho sortpath 9.9.9\n")
+
+	tests := []struct {
+		name      string
+		checksums string
+		noAsset   bool
+		skip      bool
+		wantErr   string
+	}{
+		{name: "matching checksum", checksums: checksumLine(binary, "sortpath-linux-amd64")},
+		{name: "mismatched checksum", checksums: checksumLine([]byte("other build"), "sortpath-linux-amd64"), wantErr: "checksum mismatch"},
+		{name: "binary not listed", checksums: checksumLine(binary, "sortpath-darwin-arm64"), wantErr: "no checksum listed"},
+		{name: "no checksum asset", noAsset: true, wantErr: "has no checksum asset"},
+		{name: "no checksum asset, skipped", noAsset: true, skip: true},
+		{name: "mismatch not skipped", checksums: checksumLine([]byte("other build"), "sortpath-linux-amd64"), skip: true, wantErr: "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/sortpath-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+				w.Write(binary)
+			})
+			mux.HandleFunc("/sortpath_checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.checksums))
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			release := &Release{Version: "9.9.9", DownloadURL: srv.URL + "/sortpath-linux-amd64", AssetName: "sortpath-linux-amd64"}
+			if !tt.noAsset {
+				release.ChecksumURL = srv.URL + "/sortpath_checksums.txt"
+			}
+
+			execPath := filepath.Join(t.TempDir(), "sortpath")
+			original := []byte("old binary")
+			if err := os.WriteFile(execPath, original, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := installBinary(release, execPath, UpdateOptions{SkipMissingChecksum: tt.skip})
+
+			got, _ := os.ReadFile(execPath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("installBinary() error = %v", err)
+				}
+				if string(got) != string(binary) {
+					t.Errorf("installed binary = %q, want %q", got, binary)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("installBinary() error = %v, want to contain %q", err, tt.wantErr)
+			}
+			if string(got) != string(original) {
+				t.Error("binary was replaced despite a failed checksum")
+			}
+			if _, err := os.Stat(execPath + ".tmp"); !os.IsNotExist(err) {
+				t.Error("rejected download should be removed")
+			}
+		})
+	}
+}
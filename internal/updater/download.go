@@ -0,0 +1,140 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
+)
+
+// maxDownloadAttempts bounds how often an interrupted download is retried
+// within one update
+const maxDownloadAttempts = 3
+
+// downloadFile fetches url into path. An interrupted download leaves the
+// partial file in place and is resumed with a Range request, both on the next
+// attempt and on the next `sortpath update`. Servers that ignore ranges, or
+// whose file changed since, answer with the full body and the download
+// starts over. Progress is reported on progress unless it is nil.
+func downloadFile(url, path string, progress io.Writer) error {
+	var err error
+	for attempt := 0; attempt < maxDownloadAttempts; attempt++ {
+		if err = downloadAttempt(url, path, progress); err == nil || !apperrors.IsRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// downloadAttempt makes one request, appending to path when the server
+// honours the resume range. Failures another attempt may fix are returned as
+// retryable errors (see apperrors.IsRetryable).
+func downloadAttempt(url, path string, progress io.Writer) error {
+	metaPath := path + ".resume"
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to download update: %w", err)
+	}
+
+	// Only resume when we can tell the server which version the partial
+	// file belongs to, so bytes from two releases are never spliced together
+	var offset int64
+	if validator := readResumeValidator(metaPath, url); validator != "" {
+		if info, statErr := os.Stat(path); statErr == nil && info.Size() > 0 {
+			offset = info.Size()
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", validator)
+		}
+	}
+
+	resp, err := httpx.DefaultClient.Do(req)
+	if err != nil {
+		return apperrors.NetworkError("failed to download update", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			os.Remove(path)
+			return apperrors.NetworkError("download resumed at an unexpected range: "+resp.Header.Get("Content-Range"), nil)
+		}
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+		writeResumeValidator(metaPath, url, resp.Header)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file doesn't match the server's; start over
+		os.Remove(path)
+		os.Remove(metaPath)
+		return apperrors.NetworkError(fmt.Sprintf("download failed: %d", resp.StatusCode), nil)
+	default:
+		return apperrors.APIError(fmt.Sprintf("download failed: %d", resp.StatusCode), nil).
+			WithStatus(resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(path, flags, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer f.Close()
+
+	var dst io.Writer = f
+	if progress != nil {
+		// A resumed download counts the bytes already on disk
+		done, total := int64(0), resp.ContentLength
+		if flags&os.O_APPEND != 0 {
+			done = offset
+			if total >= 0 {
+				total += offset
+			}
+		}
+		p := newProgressWriter(progress, done, total)
+		defer p.finish()
+		dst = io.MultiWriter(f, p)
+	}
+
+	if _, err := io.Copy(dst, resp.Body); err != nil {
+		return apperrors.NetworkError("failed to write update", err)
+	}
+	return f.Close()
+}
+
+// readResumeValidator returns the If-Range validator saved for url, if any
+func readResumeValidator(metaPath, url string) string {
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return ""
+	}
+	savedURL, validator, ok := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	if !ok || savedURL != url {
+		return ""
+	}
+	return validator
+}
+
+// writeResumeValidator records a strong ETag, or failing that Last-Modified,
+// for a fresh download. Weak ETags can't be used with If-Range.
+func writeResumeValidator(metaPath, url string, h http.Header) {
+	validator := h.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = h.Get("Last-Modified")
+	}
+	if validator == "" {
+		os.Remove(metaPath)
+		return
+	}
+	_ = os.WriteFile(metaPath, []byte(url+"\n"+validator+"\n"), 0644)
+}
+
+// removeDownload deletes a partial download and its resume metadata
+func removeDownload(path string) {
+	os.Remove(path)
+	os.Remove(path + ".resume")
+}
@@ -0,0 +1,109 @@
+package updater
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer serves payload, cutting the first response off halfway. With
+// ranges it behaves like a static file server; without, it ignores Range.
+type flakyServer struct {
+	payload []byte
+	ranges  bool
+
+	mu     sync.Mutex
+	ranged []string // Range header of each request
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	first := len(s.ranged) == 0
+	s.ranged = append(s.ranged, r.Header.Get("Range"))
+	s.mu.Unlock()
+
+	if first {
+		// Promise the whole file, send half, then drop the connection
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.payload)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(s.payload[:len(s.payload)/2])
+		if hj, ok := w.(http.Hijacker); ok {
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+		}
+		return
+	}
+	if !s.ranges {
+		w.WriteHeader(http.StatusOK)
+		w.Write(s.payload)
+		return
+	}
+	w.Header().Set("ETag", `"v1"`)
+	http.ServeContent(w, r, "sortpath", time.Time{}, bytes.NewReader(s.payload))
+}
+
+func TestDownloadFile_Resume(t *testing.T) {
+	payload := bytes.Repeat([]byte("sortpath-binary-"), 4096)
+
+	tests := []struct {
+		name      string
+		ranges    bool
+		wantRange string
+	}{
+		{name: "server supports ranges", ranges: true, wantRange: "bytes=" + strconv.Itoa(len(payload)/2) + "-"},
+		{name: "server ignores ranges", ranges: false, wantRange: "bytes=" + strconv.Itoa(len(payload)/2) + "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &flakyServer{payload: payload, ranges: tt.ranges}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			dest := filepath.Join(t.TempDir(), "sortpath.tmp")
+			if err := downloadFile(ts.URL, dest, nil); err != nil {
+				t.Fatalf("downloadFile() error = %v", err)
+			}
+
+			got, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("downloaded %d bytes, want the %d byte payload intact", len(got), len(payload))
+			}
+			if len(srv.ranged) != 2 {
+				t.Fatalf("server saw %d requests, want 2", len(srv.ranged))
+			}
+			if srv.ranged[1] != tt.wantRange {
+				t.Errorf("retry Range = %q, want %q", srv.ranged[1], tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestInstallBinary_ResumedDownloadIsVerified(t *testing.T) {
+	srv := &flakyServer{payload: []byte("resumed"), ranges: true}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	sums := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(checksumLine(srv.payload, "sortpath")))
+	}))
+	defer sums.Close()
+
+	execPath := filepath.Join(t.TempDir(), "sortpath")
+	release := &Release{Version: "9.9.9", DownloadURL: ts.URL, AssetName: "sortpath", ChecksumURL: sums.URL}
+	if err := installBinary(release, execPath, UpdateOptions{VerifySignature: true}); err == nil {
+		t.Fatal("expected signature verification to reject the resumed download")
+	}
+	if _, err := os.Stat(execPath + ".tmp"); !os.IsNotExist(err) {
+		t.Error("rejected download should be removed so the next update starts fresh")
+	}
+}
@@ -0,0 +1,139 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
+)
+
+// githubToken returns the token sent to the GitHub API, which raises the
+// anonymous rate limit; SORTPATH_GITHUB_TOKEN wins over GITHUB_TOKEN
+func githubToken() string {
+	if token := os.Getenv("SORTPATH_GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// newGitHubRequest builds a GitHub API request, authenticated when a token is
+// set. Only API requests carry the token, never asset downloads, which
+// redirect to other hosts.
+func newGitHubRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// rateLimitError explains a GitHub API response refused by rate limiting,
+// with when to retry if GitHub says, or returns nil for other responses
+func rateLimitError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	var retryAt time.Time
+	limited := resp.StatusCode == http.StatusTooManyRequests
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		// Secondary limits say how long to wait
+		retryAt, limited = time.Now().Add(time.Duration(secs)*time.Second), true
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		limited = true
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			retryAt = time.Unix(reset, 0)
+		}
+	}
+	if !limited {
+		return nil
+	}
+
+	msg := "GitHub API rate limited"
+	if wait := time.Until(retryAt).Round(time.Second); wait > 0 {
+		msg += fmt.Sprintf(", retry after %s (in %s)", retryAt.Format("15:04"), wait)
+	}
+	if resp.Request == nil || resp.Request.Header.Get("Authorization") == "" {
+		msg += "; set GITHUB_TOKEN or SORTPATH_GITHUB_TOKEN to raise the limit"
+	}
+	return errors.New(msg)
+}
+
+// recentTagCount is how many releases an unknown tag error suggests
+const recentTagCount = 5
+
+// unknownTagError reports that no release is tagged tag, listing the most
+// recent tags when GitHub returns them
+func unknownTagError(tag string) error {
+	tags, err := recentTags(recentTagCount)
+	if err != nil || len(tags) == 0 {
+		return fmt.Errorf("no release tagged %s", tag)
+	}
+	return fmt.Errorf("no release tagged %s; recent releases: %s", tag, strings.Join(tags, ", "))
+}
+
+// recentTags returns the tags of the n newest releases, newest first
+func recentTags(n int) ([]string, error) {
+	releases, err := listReleases(n)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(releases))
+	for _, r := range releases {
+		tags = append(tags, r.TagName)
+	}
+	return tags, nil
+}
+
+// newestReleaseScan is how many releases newestRelease looks through for one
+// that isn't a draft
+const newestReleaseScan = 10
+
+// newestRelease returns the most recently created published release,
+// prerelease or not
+func newestRelease() (*Release, error) {
+	releases, err := listReleases(newestReleaseScan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	for _, r := range releases {
+		if !r.Draft {
+			return r.forPlatform()
+		}
+	}
+	return nil, errors.New("no published releases found")
+}
+
+// listReleases returns up to n releases, newest first as GitHub sorts them
+func listReleases(n int) ([]githubRelease, error) {
+	req, err := newGitHubRequest(fmt.Sprintf("%s?per_page=%d", releasesURL, n))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpx.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		if err := rateLimitError(resp); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("GitHub API error: %d", resp.StatusCode)
+	}
+
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return releases, nil
+}
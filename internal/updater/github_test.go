@@ -0,0 +1,232 @@
+package updater
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubReleaseAPI points the release lookups at handler
+func stubReleaseAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	old := releasesURL
+	releasesURL = srv.URL + "/releases"
+	t.Cleanup(func() { releasesURL = old })
+}
+
+// platformAsset is the binary asset name CheckLatestRelease picks here
+func platformAsset() string {
+	asset := "sortpath-" + runtime.GOOS + "-" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	return asset
+}
+
+func TestCheckLatestRelease_Token(t *testing.T) {
+	asset := platformAsset()
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantAuth string
+	}{
+		{name: "anonymous", wantAuth: ""},
+		{name: "GITHUB_TOKEN", env: map[string]string{"GITHUB_TOKEN": "gh"}, wantAuth: "Bearer gh"},
+		{name: "SORTPATH_GITHUB_TOKEN wins", env: map[string]string{"GITHUB_TOKEN": "gh", "SORTPATH_GITHUB_TOKEN": "sp"}, wantAuth: "Bearer sp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", "")
+			t.Setenv("SORTPATH_GITHUB_TOKEN", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			var auth, agent string
+			stubReleaseAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				auth, agent = r.Header.Get("Authorization"), r.Header.Get("User-Agent")
+				fmt.Fprintf(w, `{"tag_name": "v1.2.0", "assets": [
+					{"name": "sortpath_checksums.txt", "browser_download_url": "https://example.com/sums"},
+					{"name": %q, "browser_download_url": "https://example.com/bin"}]}`, asset)
+			})
+
+			release, err := CheckLatestRelease()
+			if err != nil {
+				t.Fatalf("CheckLatestRelease() error = %v", err)
+			}
+			if release.Version != "1.2.0" || release.AssetName != asset || release.ChecksumURL != "https://example.com/sums" {
+				t.Errorf("release = %+v, want version 1.2.0, asset %s and the checksums file", release, asset)
+			}
+			if auth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", auth, tt.wantAuth)
+			}
+			if !strings.HasPrefix(agent, "sortpath") {
+				t.Errorf("User-Agent = %q, want sortpath/<version>", agent)
+			}
+		})
+	}
+}
+
+func TestCheckLatestRelease_RateLimited(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		headers map[string]string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "primary limit",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			want:    []string{"rate limited, retry after", "(in ", "GITHUB_TOKEN"},
+		},
+		{
+			name:    "primary limit with a token",
+			token:   "gh",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			want:    []string{"rate limited, retry after"},
+			notWant: []string{"GITHUB_TOKEN"},
+		},
+		{
+			name:    "secondary limit",
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{"Retry-After": "90"},
+			want:    []string{"rate limited, retry after", "(in 1m30s)"},
+		},
+		{
+			name:    "forbidden for another reason",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Remaining": "42"},
+			want:    []string{"GitHub API error: 403"},
+			notWant: []string{"rate limited"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", tt.token)
+			t.Setenv("SORTPATH_GITHUB_TOKEN", "")
+			stubReleaseAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+			})
+
+			_, err := CheckLatestRelease()
+			if err == nil {
+				t.Fatal("CheckLatestRelease() succeeded, want an error")
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("error = %q, want it to contain %q", err, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(err.Error(), s) {
+					t.Errorf("error = %q, should not contain %q", err, s)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchRelease_Tag(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("SORTPATH_GITHUB_TOKEN", "")
+	stubReleaseAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			fmt.Fprintf(w, `{"tag_name": "v1.2.0", "assets": [{"name": %q, "browser_download_url": "https://example.com/1.2.0"}]}`, platformAsset())
+		case "/releases/tags/v1.0.0":
+			fmt.Fprintf(w, `{"tag_name": "v1.0.0", "assets": [{"name": %q, "browser_download_url": "https://example.com/1.0.0"}]}`, platformAsset())
+		case "/releases":
+			if r.URL.Query().Get("per_page") != strconv.Itoa(recentTagCount) {
+				t.Errorf("per_page = %q, want %d", r.URL.Query().Get("per_page"), recentTagCount)
+			}
+			fmt.Fprint(w, `[{"tag_name": "v1.2.0"}, {"tag_name": "v1.1.0"}, {"tag_name": "v1.0.0"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tests := []struct {
+		tag     string
+		wantURL string
+		wantErr string
+	}{
+		{tag: LatestRelease, wantURL: "https://example.com/1.2.0"},
+		{tag: "v1.0.0", wantURL: "https://example.com/1.0.0"},
+		{tag: "1.0.0", wantURL: "https://example.com/1.0.0"},
+		{tag: "v0.9.0", wantErr: "no release tagged v0.9.0; recent releases: v1.2.0, v1.1.0, v1.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			release, err := FetchRelease(tt.tag)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("FetchRelease(%q) error = %v, want %q", tt.tag, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchRelease(%q) error = %v", tt.tag, err)
+			}
+			if release.DownloadURL != tt.wantURL {
+				t.Errorf("DownloadURL = %q, want %q", release.DownloadURL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestFetchRelease_Prerelease(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("SORTPATH_GITHUB_TOKEN", "")
+	release := func(tag string, prerelease, draft bool) string {
+		return fmt.Sprintf(`{"tag_name": %q, "prerelease": %v, "draft": %v, "assets": [{"name": %q, "browser_download_url": "https://example.com/%s"}]}`,
+			tag, prerelease, draft, platformAsset(), tag)
+	}
+	// The newest tag is a prerelease; GitHub's latest release skips it
+	stubReleaseAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			fmt.Fprint(w, release("v1.2.0", false, false))
+		case "/releases":
+			fmt.Fprintf(w, "[%s, %s, %s]", release("v1.4.0-rc.1", true, true), release("v1.3.0-beta.1", true, false), release("v1.2.0", false, false))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tests := []struct {
+		tag            string
+		wantVersion    string
+		wantPrerelease bool
+	}{
+		{tag: LatestRelease, wantVersion: "1.2.0"},
+		{tag: LatestPrerelease, wantVersion: "1.3.0-beta.1", wantPrerelease: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := FetchRelease(tt.tag)
+			if err != nil {
+				t.Fatalf("FetchRelease(%q) error = %v", tt.tag, err)
+			}
+			if got.Version != tt.wantVersion || got.Prerelease != tt.wantPrerelease {
+				t.Errorf("FetchRelease(%q) = %s (prerelease %v), want %s (prerelease %v)",
+					tt.tag, got.Version, got.Prerelease, tt.wantVersion, tt.wantPrerelease)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// managedPaths maps package-manager-controlled directories to the manager
+// that owns them. Paths are slash-separated; anywhere matches the fragment
+// inside the path rather than as a prefix.
+var managedPaths = []struct {
+	fragment string
+	anywhere bool
+	manager  string
+}{
+	{fragment: "/nix/store/", manager: "Nix"},
+	{fragment: "/opt/homebrew/", manager: "Homebrew"},
+	{fragment: "/home/linuxbrew/.linuxbrew/", manager: "Homebrew"},
+	{fragment: "/Cellar/", anywhere: true, manager: "Homebrew"},
+	{fragment: "/snap/", manager: "Snap"},
+	{fragment: "/usr/bin/", manager: "the system package manager"},
+	{fragment: "/bin/", manager: "the system package manager"},
+	{fragment: "/scoop/apps/", anywhere: true, manager: "Scoop"},
+}
+
+// InstallCheck describes how the running executable was installed, so a
+// self-update doesn't fight with a package manager
+type InstallCheck struct {
+	// Path is the file a self-update would replace
+	Path string
+
+	// Symlink is the link sortpath was started through, if any; updating
+	// replaces the file it points to, or the link itself
+	Symlink string
+
+	// ManagedBy names the package manager owning Path or Symlink
+	ManagedBy string
+
+	// Writable reports whether Path's directory accepts the new binary
+	Writable bool
+}
+
+// CheckCurrentInstall inspects the running executable
+func CheckCurrentInstall() (InstallCheck, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return InstallCheck{}, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	invoked := os.Args[0]
+	if !strings.ContainsRune(invoked, filepath.Separator) {
+		if found, err := exec.LookPath(invoked); err == nil {
+			invoked = found
+		}
+	}
+	return CheckInstall(execPath, invoked), nil
+}
+
+// CheckInstall inspects execPath, the executable a self-update replaces, and
+// invokedPath, how it was started (os.Args[0] resolved on PATH)
+func CheckInstall(execPath, invokedPath string) InstallCheck {
+	check := InstallCheck{Path: execPath}
+	for _, p := range []string{invokedPath, execPath} {
+		if p == "" {
+			continue
+		}
+		if info, err := os.Lstat(p); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			check.Symlink = p
+			break
+		}
+	}
+
+	candidates := []string{execPath}
+	if check.Symlink != "" {
+		candidates = append(candidates, check.Symlink)
+		if target, err := filepath.EvalSymlinks(check.Symlink); err == nil {
+			candidates = append(candidates, target)
+		}
+	}
+	for _, p := range candidates {
+		if manager := managedBy(p); manager != "" {
+			check.ManagedBy = manager
+			break
+		}
+	}
+
+	check.Writable = dirWritable(filepath.Dir(execPath))
+	return check
+}
+
+// NeedsConfirmation reports whether overwriting the install may clobber a
+// file another tool manages
+func (c InstallCheck) NeedsConfirmation() bool {
+	return c.Symlink != "" || c.ManagedBy != ""
+}
+
+// Warnings explains why updating this install is risky, one line each
+func (c InstallCheck) Warnings() []string {
+	var warnings []string
+	if c.ManagedBy != "" {
+		warnings = append(warnings, fmt.Sprintf("%s appears to be managed by %s; update it there instead, or the next upgrade may undo or conflict with this one", c.Path, c.ManagedBy))
+	}
+	if c.Symlink != "" {
+		warnings = append(warnings, fmt.Sprintf("sortpath was started through the symlink %s; updating replaces the file it points to", c.Symlink))
+	}
+	if !c.Writable {
+		warnings = append(warnings, fmt.Sprintf("%s is not writable; run the update with permission to write there", filepath.Dir(c.Path)))
+	}
+	return warnings
+}
+
+func managedBy(path string) string {
+	slashed := filepath.ToSlash(path)
+	for _, m := range managedPaths {
+		if (m.anywhere && strings.Contains(slashed, m.fragment)) || strings.HasPrefix(slashed, m.fragment) {
+			return m.manager
+		}
+	}
+	return ""
+}
+
+// dirWritable reports whether a file can be created in dir, which is what
+// replacing the executable by rename needs
+func dirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".sortpath-write-test-*")
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return true
+}
+
+// isRecordedInstall reports whether recorded names an executable file that
+// is execPath itself
+func isRecordedInstall(execPath, recorded string) bool {
+	if recorded == "" {
+		return false
+	}
+	info, err := os.Stat(recorded)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		return false
+	}
+	execInfo, err := os.Stat(execPath)
+	return err == nil && os.SameFile(info, execInfo)
+}
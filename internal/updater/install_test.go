@@ -0,0 +1,88 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckInstall_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "libexec", "sortpath")
+	os.MkdirAll(filepath.Dir(target), 0755)
+	if err := os.WriteFile(target, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "sortpath")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	// Linux reports the resolved target as the executable
+	check := CheckInstall(target, link)
+	if check.Symlink != link || !check.NeedsConfirmation() {
+		t.Errorf("CheckInstall() = %+v, want the symlink detected", check)
+	}
+	if !check.Writable {
+		t.Errorf("CheckInstall() reports %s unwritable", filepath.Dir(target))
+	}
+
+	// macOS may report the link itself
+	if check := CheckInstall(link, link); check.Symlink != link {
+		t.Errorf("CheckInstall(link) = %+v, want the symlink detected", check)
+	}
+
+	plain := CheckInstall(target, target)
+	if plain.Symlink != "" || plain.ManagedBy != "" || plain.NeedsConfirmation() || len(plain.Warnings()) != 0 {
+		t.Errorf("CheckInstall(plain file) = %+v, want a clean install", plain)
+	}
+}
+
+func TestCheckInstall_NotWritable(t *testing.T) {
+	// A directory that no longer exists can't receive the new binary
+	missing := filepath.Join(t.TempDir(), "gone", "sortpath")
+	check := CheckInstall(missing, missing)
+	if check.Writable {
+		t.Error("CheckInstall() reports a missing directory writable")
+	}
+	warnings := strings.Join(check.Warnings(), "\n")
+	if !strings.Contains(warnings, "is not writable") {
+		t.Errorf("Warnings() = %q", warnings)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits don't restrict root")
+	}
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "sortpath")
+	os.WriteFile(exe, []byte("binary"), 0755)
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+	if CheckInstall(exe, exe).Writable {
+		t.Error("CheckInstall() reports a read-only directory writable")
+	}
+}
+
+func TestManagedBy(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/opt/homebrew/bin/sortpath", want: "Homebrew"},
+		{path: "/usr/local/Cellar/sortpath/1.2.0/bin/sortpath", want: "Homebrew"},
+		{path: "/nix/store/abc123-sortpath-1.2.0/bin/sortpath", want: "Nix"},
+		{path: "/snap/bin/sortpath", want: "Snap"},
+		{path: "/usr/bin/sortpath", want: "the system package manager"},
+		{path: "/usr/local/bin/sortpath", want: ""},
+		{path: "/home/me/.local/bin/sortpath", want: ""},
+		{path: "/home/me/go/bin/sortpath", want: ""},
+	}
+	for _, tt := range tests {
+		if got := managedBy(tt.path); got != tt.want {
+			t.Errorf("managedBy(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
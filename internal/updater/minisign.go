@@ -0,0 +1,108 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// PublicKey is the pinned minisign public key used to verify release
+// signatures. Release builds embed it with
+// -ldflags "-X github.com/kacperkwapisz/sortpath/internal/updater.PublicKey=RW..."
+// and SORTPATH_MINISIGN_PUBKEY overrides it.
+var PublicKey = ""
+
+// minisignKey is a parsed minisign Ed25519 public key
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// configuredPublicKey returns the key release signatures are checked against
+func configuredPublicKey() (*minisignKey, error) {
+	raw := PublicKey
+	if env := os.Getenv("SORTPATH_MINISIGN_PUBKEY"); env != "" {
+		raw = env
+	}
+	if raw == "" {
+		return nil, fmt.Errorf("no minisign public key configured; set SORTPATH_MINISIGN_PUBKEY")
+	}
+	return parseMinisignPublicKey(raw)
+}
+
+// parseMinisignPublicKey accepts either the bare base64 key or the full
+// contents of a minisign .pub file
+func parseMinisignPublicKey(s string) (*minisignKey, error) {
+	lines := minisignLines(s)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty minisign public key")
+	}
+	encoded := lines[len(lines)-1]
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minisign public key encoding: %w", err)
+	}
+	if len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return nil, fmt.Errorf("invalid minisign public key: unexpected format")
+	}
+	k := &minisignKey{key: ed25519.PublicKey(b[10:])}
+	copy(k.id[:], b[2:10])
+	return k, nil
+}
+
+// verifyMinisign checks sigFile (the contents of a .minisig file) against
+// data. Both legacy ("Ed") and prehashed ("ED") signatures are supported, and
+// the trusted comment's global signature must also verify.
+func verifyMinisign(pub *minisignKey, data, sigFile []byte) error {
+	lines := minisignLines(string(sigFile))
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("invalid minisign signature file")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature encoding")
+	}
+	if !bytes.Equal(sig[2:10], pub.id[:]) {
+		return fmt.Errorf("signature was made with key %X, expected %X", sig[2:10], pub.id[:])
+	}
+
+	msg := data
+	switch string(sig[:2]) {
+	case "ED":
+		digest := blake2b.Sum512(data)
+		msg = digest[:]
+	case "Ed":
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+	if !ed25519.Verify(pub.key, msg, sig[10:]) {
+		return fmt.Errorf("signature verification failed: binary does not match signature")
+	}
+
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign trusted comment signature")
+	}
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(pub.key, append(append([]byte{}, sig[10:]...), trusted...), globalSig) {
+		return fmt.Errorf("signature verification failed: trusted comment has been tampered with")
+	}
+	return nil
+}
+
+// minisignLines splits s into trimmed, non-empty lines
+func minisignLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,126 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testSigner produces minisign-format keys and prehashed signatures
+type testSigner struct {
+	id   [8]byte
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSigner{pub: pub, priv: priv}
+	copy(s.id[:], "sortpath")
+	return s
+}
+
+func (s *testSigner) publicKey() string {
+	b := append([]byte("Ed"), s.id[:]...)
+	b = append(b, s.pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(b) + "\n"
+}
+
+func (s *testSigner) sign(data []byte) []byte {
+	digest := blake2b.Sum512(data)
+	sig := ed25519.Sign(s.priv, digest[:])
+	trusted := "timestamp:1700000000\tfile:sortpath-linux-amd64\thashed"
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), trusted...))
+
+	line := append([]byte("ED"), s.id[:]...)
+	line = append(line, sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(line) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestInstallBinary_SignatureVerification(t *testing.T) {
+	signer := newTestSigner(t)
+	t.Setenv("SORTPATH_MINISIGN_PUBKEY", signer.publicKey())
+
+	signed := []byte("#!/bin/sh\necho sortpath 9.9.9\n")
+	tampered := []byte("#!/bin/sh\necho pwned\n")
+
+	tests := []struct {
+		name       string
+		served     []byte
+		withSig    bool
+		wantErr    string
+		wantBinary []byte
+	}{
+		{name: "valid signature", served: signed, withSig: true, wantBinary: signed},
+		{name: "tampered binary", served: tampered, withSig: true, wantErr: "does not match signature"},
+		{name: "missing signature asset", served: signed, withSig: false, wantErr: "no .minisig signature asset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/sortpath-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+				w.Write(tt.served)
+			})
+			mux.HandleFunc("/sortpath-linux-amd64.minisig", func(w http.ResponseWriter, r *http.Request) {
+				w.Write(signer.sign(signed))
+			})
+			mux.HandleFunc("/sortpath_checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+				// The checksum matches, so only the signature can reject
+				w.Write([]byte(checksumLine(tt.served, "sortpath-linux-amd64")))
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			release := &Release{
+				Version:     "9.9.9",
+				DownloadURL: srv.URL + "/sortpath-linux-amd64",
+				AssetName:   "sortpath-linux-amd64",
+				ChecksumURL: srv.URL + "/sortpath_checksums.txt",
+			}
+			if tt.withSig {
+				release.SignatureURL = srv.URL + "/sortpath-linux-amd64.minisig"
+			}
+
+			execPath := filepath.Join(t.TempDir(), "sortpath")
+			original := []byte("old binary")
+			if err := os.WriteFile(execPath, original, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := installBinary(release, execPath, UpdateOptions{VerifySignature: true})
+
+			got, _ := os.ReadFile(execPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installBinary() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				if string(got) != string(original) {
+					t.Errorf("binary was replaced despite failed verification")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("installBinary() unexpected error = %v", err)
+			}
+			if string(got) != string(tt.wantBinary) {
+				t.Errorf("installed binary = %q, want %q", got, tt.wantBinary)
+			}
+		})
+	}
+}
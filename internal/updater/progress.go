@@ -0,0 +1,65 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/util"
+)
+
+// progressBarWidth is the number of cells in the download progress bar
+const progressBarWidth = 30
+
+// progressWriter counts downloaded bytes and redraws a one-line progress
+// report on out, with a bar when the total size is known
+type progressWriter struct {
+	out   io.Writer
+	done  int64
+	total int64 // -1 when the server sent no Content-Length
+
+	shown int64 // the last percentage, or kilobytes when total is unknown, drawn
+}
+
+// newProgressWriter reports a download that already has done of total bytes,
+// as when resuming; total is -1 when unknown
+func newProgressWriter(out io.Writer, done, total int64) *progressWriter {
+	p := &progressWriter{out: out, done: done, total: total, shown: -1}
+	p.draw()
+	return p
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	p.draw()
+	return len(b), nil
+}
+
+// draw redraws the line when the shown figure changed, so a fast download
+// doesn't flood the terminal
+func (p *progressWriter) draw() {
+	if p.total <= 0 {
+		if kb := p.done / 1024; kb != p.shown {
+			p.shown = kb
+			fmt.Fprintf(p.out, "\r📦 Downloading... %s", util.FormatSize(p.done))
+		}
+		return
+	}
+	pct := p.done * 100 / p.total
+	if pct > 100 {
+		pct = 100
+	}
+	if pct == p.shown {
+		return
+	}
+	p.shown = pct
+	filled := int(pct) * progressBarWidth / 100
+	fmt.Fprintf(p.out, "\r📦 Downloading [%s%s] %3d%% %s / %s",
+		strings.Repeat("#", filled), strings.Repeat(" ", progressBarWidth-filled),
+		pct, util.FormatSize(p.done), util.FormatSize(p.total))
+}
+
+// finish ends the progress line so later output starts on its own line
+func (p *progressWriter) finish() {
+	fmt.Fprintln(p.out)
+}
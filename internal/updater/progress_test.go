@@ -0,0 +1,64 @@
+package updater
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgressWriter(t *testing.T) {
+	tests := []struct {
+		name      string
+		done      int64
+		total     int64
+		write     int
+		want      []string
+		wantLines int // redraws, at most
+	}{
+		{name: "known size", total: 2048, write: 2048, want: []string{"  0%", "100%", "2.0 KB / 2.0 KB", "[" + strings.Repeat("#", progressBarWidth) + "]"}, wantLines: 101},
+		{name: "resumed", done: 1024, total: 2048, write: 1024, want: []string{" 50%", "100%"}, wantLines: 51},
+		{name: "unknown size", total: -1, write: 3 * 1024, want: []string{"Downloading... 3.0 KB"}, wantLines: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := newProgressWriter(&out, tt.done, tt.total)
+			for i := 0; i < tt.write; i++ {
+				p.Write([]byte{0})
+			}
+			p.finish()
+
+			got := out.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("progress output %q should contain %q", got, s)
+				}
+			}
+			if n := strings.Count(got, "\r"); n > tt.wantLines {
+				t.Errorf("progress redrawn %d times for %d writes, want at most %d", n, tt.write, tt.wantLines)
+			}
+			if !strings.HasSuffix(got, "\n") {
+				t.Error("finish should end the progress line")
+			}
+		})
+	}
+}
+
+func TestDownloadFile_Progress(t *testing.T) {
+	payload := bytes.Repeat([]byte("sortpath-binary-"), 4096)
+	srv := &flakyServer{payload: payload, ranges: true}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	var out bytes.Buffer
+	if err := downloadFile(ts.URL, filepath.Join(t.TempDir(), "sortpath.tmp"), &out); err != nil {
+		t.Fatalf("downloadFile() error = %v", err)
+	}
+	// The retry resumes halfway and finishes the same bar
+	last := out.String()[strings.LastIndex(out.String(), "\r"):]
+	if !strings.Contains(last, "100%") || !strings.Contains(last, "64.0 KB / 64.0 KB") {
+		t.Errorf("final progress line = %q, want 100%% of 64 KB", last)
+	}
+}
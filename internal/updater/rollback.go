@@ -0,0 +1,92 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// backupSuffix names the copy of the previous binary kept while an update is
+// smoke tested
+const backupSuffix = ".bak"
+
+// smokeTestTimeout bounds how long a freshly installed binary may take to
+// print its version
+const smokeTestTimeout = 10 * time.Second
+
+// smokeTest runs the binary at path with --version and checks that it
+// identifies itself as sortpath
+func smokeTest(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("`%s --version` did not finish within %s", path, smokeTestTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("`%s --version` failed: %v: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	if !strings.Contains(string(output), "sortpath") {
+		return fmt.Errorf("`%s --version` printed %q, not a sortpath version", path, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// backupBinary copies the binary at path to path+backupSuffix with its mode,
+// so the live binary is still replaced by a single atomic rename
+func backupBinary(path string) (string, error) {
+	backupPath := path + backupSuffix
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	dst, err := os.OpenFile(backupPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(backupPath)
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(backupPath)
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// applyWithRollback moves the verified binary at tmpPath over execPath and
+// smoke tests it. A binary that doesn't start is replaced by the backup of
+// the previous one, and the error says the update was rolled back.
+func applyWithRollback(release *Release, tmpPath, execPath string) error {
+	backupPath, err := backupBinary(execPath)
+	if err != nil {
+		return fmt.Errorf("failed to back up the current binary: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, execPath); err != nil {
+		os.Remove(backupPath)
+		return fmt.Errorf("failed to apply update: %w", err)
+	}
+
+	if err := smokeTest(execPath); err != nil {
+		if restoreErr := os.Rename(backupPath, execPath); restoreErr != nil {
+			return fmt.Errorf("version %s is broken (%v) and restoring the previous binary failed: %v; it is saved at %s",
+				release.Version, err, restoreErr, backupPath)
+		}
+		return fmt.Errorf("version %s failed its smoke test and was rolled back; you are still on the previous version: %w", release.Version, err)
+	}
+	os.Remove(backupPath)
+	return nil
+}
@@ -0,0 +1,76 @@
+package updater
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInstallBinary_RollsBackBrokenUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test binaries are shell scripts")
+	}
+
+	tests := []struct {
+		name    string
+		served  string
+		wantErr string
+	}{
+		{name: "working update", served: "#!/bin/sh\necho sortpath version 9.9.9\n"},
+		{name: "crashes", served: "#!/bin/sh\necho corrupt >&2\nexit 2\n", wantErr: "rolled back"},
+		{name: "not sortpath", served: "#!/bin/sh\necho hello\n", wantErr: "not a sortpath version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/sortpath-linux-amd64", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.served))
+			})
+			mux.HandleFunc("/sortpath_checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(checksumLine([]byte(tt.served), "sortpath-linux-amd64")))
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			execPath := filepath.Join(t.TempDir(), "sortpath")
+			original := "#!/bin/sh\necho sortpath version 1.0.0\n"
+			if err := os.WriteFile(execPath, []byte(original), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			release := &Release{
+				Version:     "9.9.9",
+				DownloadURL: srv.URL + "/sortpath-linux-amd64",
+				AssetName:   "sortpath-linux-amd64",
+				ChecksumURL: srv.URL + "/sortpath_checksums.txt",
+			}
+			err := installBinary(release, execPath, UpdateOptions{})
+
+			got, _ := os.ReadFile(execPath)
+			want := tt.served
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installBinary() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				want = original
+			} else if err != nil {
+				t.Fatalf("installBinary() error = %v", err)
+			}
+			if string(got) != want {
+				t.Errorf("binary after update = %q, want %q", got, want)
+			}
+			if info, err := os.Stat(execPath); err != nil || info.Mode().Perm()&0100 == 0 {
+				t.Errorf("binary should stay executable: %v", err)
+			}
+			for _, leftover := range []string{execPath + backupSuffix, execPath + ".tmp"} {
+				if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+					t.Errorf("%s should be removed", filepath.Base(leftover))
+				}
+			}
+		})
+	}
+}
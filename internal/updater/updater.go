@@ -4,29 +4,73 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
 )
 
 const (
     githubOwner = "kacperkwapisz"
     githubRepo  = "sortpath"
-    releaseURL  = "https://api.github.com/repos/%s/%s/releases/latest"
+)
+
+// releasesURL is the GitHub API endpoint for the repository's releases; tests replace it
+var releasesURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", githubOwner, githubRepo)
+
+// FetchRelease sentinels: LatestRelease is the newest stable release, as
+// GitHub's latest release skips prereleases; LatestPrerelease is the newest
+// release of any kind
+const (
+    LatestRelease    = "latest"
+    LatestPrerelease = "latest-prerelease"
 )
 
 type Release struct {
     Version     string
     DownloadURL string
     PublishedAt time.Time
+
+    // SignatureURL points at the detached minisign signature, if the release has one
+    SignatureURL string
+
+    // AssetName is the binary's file name, looked up in a checksums file
+    AssetName string
+
+    // ChecksumURL points at the binary's .sha256 file or the release's
+    // checksums file, if the release has one
+    ChecksumURL string
+
+    // Prerelease marks a release GitHub flags as not production-ready
+    Prerelease bool
+}
+
+// UpdateOptions controls how an update is downloaded and applied
+type UpdateOptions struct {
+    // VerifySignature refuses to install unless the binary's minisign
+    // signature verifies against the pinned public key
+    VerifySignature bool
+
+    // SkipMissingChecksum installs a release that publishes no checksum,
+    // such as one older than the first checksummed release, unverified. A
+    // release that has a checksum is still checked against it.
+    SkipMissingChecksum bool
+
+    // Progress receives a download progress line, redrawn with \r; nil
+    // downloads silently
+    Progress io.Writer
 }
 
 type githubRelease struct {
     TagName     string    `json:"tag_name"`
     PublishedAt time.Time `json:"published_at"`
+    Prerelease  bool      `json:"prerelease"`
+    Draft       bool      `json:"draft"`
     Assets      []struct {
         Name               string `json:"name"`
         BrowserDownloadURL string `json:"browser_download_url"`
@@ -65,19 +109,47 @@ func SetLastUpdateCheck(t time.Time) error {
 }
 
 func getCacheDir() string {
-    homeDir, _ := os.UserHomeDir()
-    return filepath.Join(homeDir, ".cache", "sortpath")
+    return config.CacheDir()
 }
 
+// CheckLatestRelease looks up the newest published release
 func CheckLatestRelease() (*Release, error) {
-	url := fmt.Sprintf(releaseURL, githubOwner, githubRepo)
-	resp, err := http.Get(url)
+	return FetchRelease(LatestRelease)
+}
+
+// FetchRelease looks up the release tagged tag, with or without its leading
+// v, or the newest one for LatestRelease or LatestPrerelease. An unknown tag
+// is an error listing recent releases.
+func FetchRelease(tag string) (*Release, error) {
+	if tag == LatestPrerelease {
+		return newestRelease()
+	}
+
+	url := releasesURL + "/latest"
+	if tag != LatestRelease {
+		if !strings.HasPrefix(tag, "v") {
+			tag = "v" + tag
+		}
+		url = releasesURL + "/tags/" + neturl.PathEscape(tag)
+	}
+
+	req, err := newGitHubRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpx.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch releases: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
+		if err := rateLimitError(resp); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == 404 && tag != LatestRelease {
+			return nil, unknownTagError(tag)
+		}
 		return nil, fmt.Errorf("GitHub API error: %d", resp.StatusCode)
 	}
 
@@ -85,17 +157,23 @@ func CheckLatestRelease() (*Release, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return release.forPlatform()
+}
 
+// forPlatform picks the binary for this platform and its signature and
+// checksum assets
+func (release githubRelease) forPlatform() (*Release, error) {
 	// Find appropriate asset for current platform
 	platform := runtime.GOOS + "-" + runtime.GOARCH
 	if runtime.GOOS == "windows" {
 		platform += ".exe"
 	}
 
-	var downloadURL string
+	var downloadURL, assetName string
 	for _, asset := range release.Assets {
-		if strings.Contains(asset.Name, platform) {
+		if strings.Contains(asset.Name, platform) && !strings.HasSuffix(asset.Name, signatureSuffix) && !strings.HasSuffix(asset.Name, checksumSuffix) {
 			downloadURL = asset.BrowserDownloadURL
+			assetName = asset.Name
 			break
 		}
 	}
@@ -104,61 +182,88 @@ func CheckLatestRelease() (*Release, error) {
 		return nil, fmt.Errorf("no suitable binary found for %s", platform)
 	}
 
+	var signatureURL, checksumURL string
+	for _, asset := range release.Assets {
+		switch {
+		case asset.Name == assetName+signatureSuffix:
+			signatureURL = asset.BrowserDownloadURL
+		case asset.Name == assetName+checksumSuffix:
+			// The binary's own digest beats the release-wide list
+			checksumURL = asset.BrowserDownloadURL
+		case strings.HasSuffix(asset.Name, checksumsAsset) && checksumURL == "":
+			checksumURL = asset.BrowserDownloadURL
+		}
+	}
+
 	return &Release{
-		Version:     strings.TrimPrefix(release.TagName, "v"),
-		DownloadURL: downloadURL,
-		PublishedAt: release.PublishedAt,
+		Version:      strings.TrimPrefix(release.TagName, "v"),
+		DownloadURL:  downloadURL,
+		PublishedAt:  release.PublishedAt,
+		SignatureURL: signatureURL,
+		AssetName:    assetName,
+		ChecksumURL:  checksumURL,
+		Prerelease:   release.Prerelease,
 	}, nil
 }
 
+// signatureSuffix is appended to a binary asset's name for its minisign signature
+const signatureSuffix = ".minisig"
+
 func UpdateBinary(release *Release) error {
+	return UpdateBinaryWithOptions(release, UpdateOptions{})
+}
+
+// UpdateBinaryWithOptions replaces the running executable with the release binary
+func UpdateBinaryWithOptions(release *Release, opts UpdateOptions) error {
 	// Get current executable path
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
+	return installBinary(release, execPath, opts)
+}
 
-	// Download new binary
-	resp, err := http.Get(release.DownloadURL)
-	if err != nil {
-		return fmt.Errorf("failed to download update: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("download failed: %d", resp.StatusCode)
-	}
-
-	// Create temporary file
+// installBinary downloads the release binary, verifies it, atomically moves
+// it over execPath and rolls back if it doesn't run
+func installBinary(release *Release, execPath string, opts UpdateOptions) error {
+	// Download new binary, resuming any partial download left by an earlier attempt
 	tmpPath := execPath + ".tmp"
-	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create temporary file: %w", err)
+	if err := downloadFile(release.DownloadURL, tmpPath, opts.Progress); err != nil {
+		return err
 	}
-	defer tmpFile.Close()
-	defer os.Remove(tmpPath) // Clean up on failure
 
-	// Copy new binary
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		return fmt.Errorf("failed to write update: %w", err)
+	// A resumed download is verified exactly like a fresh one; a bad file
+	// is discarded so the next update starts from scratch
+	checksum := ""
+	if release.ChecksumURL != "" || !opts.SkipMissingChecksum {
+		var err error
+		if checksum, err = releaseChecksum(release); err != nil {
+			removeDownload(tmpPath)
+			return fmt.Errorf("update verification failed: %w", err)
+		}
 	}
-	tmpFile.Close()
-
-	// Verify the binary is executable
-	if err := verifyBinary(tmpPath); err != nil {
+	if err := verifyBinary(tmpPath, checksum); err != nil {
+		removeDownload(tmpPath)
 		return fmt.Errorf("update verification failed: %w", err)
 	}
 
-	// Move temporary file to final location
-	if err := os.Rename(tmpPath, execPath); err != nil {
-		return fmt.Errorf("failed to apply update: %w", err)
+	if opts.VerifySignature {
+		if err := verifyReleaseSignature(release, tmpPath); err != nil {
+			removeDownload(tmpPath)
+			return fmt.Errorf("update verification failed: %w", err)
+		}
 	}
 
-	return nil
+	// Move the new binary into place, keeping the old one until it starts
+	err := applyWithRollback(release, tmpPath, execPath)
+	removeDownload(tmpPath)
+	return err
 }
 
-func verifyBinary(path string) error {
-	// Simple verification: check if file exists and is executable
+// verifyBinary checks that the downloaded binary at path is non-empty and
+// has the SHA-256 digest wantSHA256 (hex). An empty wantSHA256 skips the
+// digest check.
+func verifyBinary(path, wantSHA256 string) error {
 	info, err := os.Stat(path)
 	if err != nil {
 		return err
@@ -166,23 +271,75 @@ func verifyBinary(path string) error {
 	if info.Size() == 0 {
 		return fmt.Errorf("downloaded binary is empty")
 	}
+	if wantSHA256 == "" {
+		return nil
+	}
+	got, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash downloaded binary: %w", err)
+	}
+	if !strings.EqualFold(got, wantSHA256) {
+		return fmt.Errorf("checksum mismatch: downloaded binary has SHA-256 %s, release lists %s", got, wantSHA256)
+	}
 	return nil
 }
 
-// IsInstalled returns true if sortpath appears to be installed in a standard location
+// verifyReleaseSignature checks the downloaded binary at path against the
+// release's minisign signature
+func verifyReleaseSignature(release *Release, path string) error {
+	if release.SignatureURL == "" {
+		return fmt.Errorf("release %s has no %s signature asset; refusing to install unsigned binary", release.Version, signatureSuffix)
+	}
+	key, err := configuredPublicKey()
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpx.DefaultClient.Get(release.SignatureURL)
+	if err != nil {
+		return fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("signature download failed: %d", resp.StatusCode)
+	}
+	sig, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read downloaded binary: %w", err)
+	}
+	return verifyMinisign(key, data, sig)
+}
+
+// IsInstalled reports whether the running binary is an installed sortpath
+// (see IsInstalledAt)
 func IsInstalled() bool {
 	execPath, err := os.Executable()
 	if err != nil {
 		return false
 	}
-	
+	return IsInstalledAt(execPath)
+}
+
+// IsInstalledAt reports whether execPath is the binary `sortpath install`
+// recorded in the config file, provided that file is still executable, or
+// sits in a standard install location
+func IsInstalledAt(execPath string) bool {
+	if conf, err := config.Load(); err == nil && isRecordedInstall(execPath, conf.InstalledPath) {
+		return true
+	}
+
 	// Check if executable is in common installation directories
 	execDir := filepath.Dir(execPath)
 	commonPaths := []string{
 		"/usr/local/bin",
 		"/usr/bin",
-		filepath.Join(os.Getenv("HOME"), "bin"),
-		filepath.Join(os.Getenv("HOME"), ".local", "bin"),
+		filepath.Join(config.HomeDir(), "bin"),
+		filepath.Join(config.HomeDir(), ".local", "bin"),
 	}
 	
 	for _, path := range commonPaths {
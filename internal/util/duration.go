@@ -0,0 +1,75 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+// ParseDuration parses a human-friendly duration spec such as "90m", "24h",
+// "7d" or "2w". It accepts everything time.ParseDuration does plus the "d"
+// (24h) and "w" (7d) units, which may be combined with other units ("1w2d12h").
+// Negative durations are rejected. On failure a ValidationError naming field
+// (usually the flag or config key) is returned.
+func ParseDuration(spec, field string) (time.Duration, error) {
+	s := strings.TrimSpace(spec)
+	if s == "" {
+		return 0, invalidDuration(spec, field, "value is empty")
+	}
+	if strings.HasPrefix(s, "-") {
+		return 0, invalidDuration(spec, field, "duration must not be negative")
+	}
+	if s == "0" {
+		return 0, nil
+	}
+
+	// Expand day and week segments into hours so time.ParseDuration can
+	// handle the rest of the spec.
+	var expanded strings.Builder
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+			i++
+		}
+		j := i
+		for j < len(s) && !(s[j] == '.' || (s[j] >= '0' && s[j] <= '9')) {
+			j++
+		}
+		if i == 0 || j == i {
+			return 0, invalidDuration(spec, field, "expected a number followed by a unit")
+		}
+		num, unit := s[:i], s[i:j]
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, invalidDuration(spec, field, fmt.Sprintf("invalid number %q", num))
+			}
+			hours := n * 24
+			if unit == "w" {
+				hours *= 7
+			}
+			expanded.WriteString(strconv.FormatFloat(hours, 'f', -1, 64) + "h")
+		default:
+			expanded.WriteString(num + unit)
+		}
+		s = s[j:]
+	}
+
+	d, err := time.ParseDuration(expanded.String())
+	if err != nil {
+		return 0, invalidDuration(spec, field, "valid units are ns, us, ms, s, m, h, d, w")
+	}
+	return d, nil
+}
+
+func invalidDuration(spec, field, reason string) error {
+	msg := fmt.Sprintf("invalid duration '%s': %s (examples: 90m, 24h, 7d, 2w)", spec, reason)
+	if field != "" {
+		msg = fmt.Sprintf("invalid duration '%s' for %s: %s (examples: 90m, 24h, 7d, 2w)", spec, field, reason)
+	}
+	return apperrors.ValidationError(msg, field)
+}
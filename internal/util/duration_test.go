@@ -0,0 +1,59 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected time.Duration
+	}{
+		{name: "days", spec: "7d", expected: 7 * 24 * time.Hour},
+		{name: "weeks", spec: "2w", expected: 14 * 24 * time.Hour},
+		{name: "minutes", spec: "90m", expected: 90 * time.Minute},
+		{name: "hours", spec: "24h", expected: 24 * time.Hour},
+		{name: "combined", spec: "1w2d12h", expected: 9*24*time.Hour + 12*time.Hour},
+		{name: "fractional day", spec: "1.5d", expected: 36 * time.Hour},
+		{name: "zero", spec: "0", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuration(tt.spec, "--since")
+			if err != nil {
+				t.Fatalf("ParseDuration(%q) unexpected error = %v", tt.spec, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.spec, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDuration_Invalid(t *testing.T) {
+	specs := []string{"soon", "7x", "d", "", "-3d", "7d3"}
+
+	for _, spec := range specs {
+		t.Run(spec, func(t *testing.T) {
+			_, err := ParseDuration(spec, "--since")
+			if err == nil {
+				t.Fatalf("ParseDuration(%q) expected error but got none", spec)
+			}
+			if !apperrors.IsType(err, "VALIDATION_ERROR") {
+				t.Errorf("ParseDuration(%q) error type = %T, want VALIDATION_ERROR", spec, err)
+			}
+			if !strings.Contains(err.Error(), "--since") {
+				t.Errorf("ParseDuration(%q) error = %v, want it to name the flag", spec, err)
+			}
+			if field, ok := apperrors.GetContext(err, "field"); !ok || field != "--since" {
+				t.Errorf("ParseDuration(%q) field context = %v, want --since", spec, field)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+// sizeUnits maps the accepted size suffixes to their multipliers. Units are
+// binary, so "1K" is 1024 bytes.
+var sizeUnits = map[string]float64{
+	"":   1,
+	"b":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+}
+
+// ParseSize parses a byte count such as "4096", "512K", "100MB" or "1.5G".
+// On failure a ValidationError naming field is returned.
+func ParseSize(spec, field string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	i := 0
+	for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+		i++
+	}
+	mult, ok := sizeUnits[strings.TrimSpace(s[i:])]
+	if i == 0 || !ok {
+		return 0, invalidSize(spec, field)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, invalidSize(spec, field)
+	}
+	return int64(n * mult), nil
+}
+
+func invalidSize(spec, field string) error {
+	msg := fmt.Sprintf("invalid size '%s' for %s (examples: 4096, 512K, 100MB, 1G)", spec, field)
+	return apperrors.ValidationError(msg, field)
+}
+
+// FormatSize renders n bytes in a short human-readable form
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,37 @@
+package util
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		spec string
+		want int64
+	}{
+		{spec: "4096", want: 4096},
+		{spec: "512K", want: 512 << 10},
+		{spec: "100MB", want: 100 << 20},
+		{spec: "1.5g", want: 3 << 29},
+		{spec: " 10 b ", want: 10},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.spec, "--max-size")
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.spec, got, err, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "MB", "10TB", "-5", "1.2.3K"} {
+		if _, err := ParseSize(spec, "--max-size"); err == nil {
+			t.Errorf("ParseSize(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	if got := FormatSize(512); got != "512 B" {
+		t.Errorf("FormatSize(512) = %q", got)
+	}
+	if got := FormatSize(3 << 19); got != "1.5 MB" {
+		t.Errorf("FormatSize(1.5MB) = %q", got)
+	}
+}
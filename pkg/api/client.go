@@ -2,43 +2,202 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/kacperkwapisz/sortpath/internal/app"
 	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	"github.com/kacperkwapisz/sortpath/internal/httpx"
 )
 
+// clientFor returns a client whose requests give up after the configured
+// timeout. It reuses the shared transport, so connections are pooled between
+// calls, unless the config pins the server certificate.
+func clientFor(conf *config.Config) (*http.Client, error) {
+	var tr http.RoundTripper
+	if conf.PinnedCertSHA256 != "" {
+		pinned, err := httpx.NewPinnedTransport(conf.PinnedCertSHA256)
+		if err != nil {
+			return nil, err
+		}
+		tr = pinned
+	}
+	return httpx.NewClient(tr, conf.RequestTimeout()), nil
+}
+
+// requestError wraps a failed request as a NetworkError, naming the timeout
+// when the request ran out of time
+func requestError(ctx context.Context, conf *config.Config, err error) error {
+	if ctx.Err() != nil {
+		return apperrors.NetworkError("request cancelled", err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return apperrors.NetworkError(fmt.Sprintf("request timed out after %v; raise it with --timeout or SORTPATH_TIMEOUT", conf.RequestTimeout()), err).
+			WithContext("timeout", conf.Timeout)
+	}
+	return apperrors.NetworkError("network error", err)
+}
+
 type LLMResponse struct {
 	Path   string
 	Reason string
+
+	// Root is the archive label chosen in a multi-tree prompt; empty otherwise
+	Root string
+
+	// Alternatives are the runner-up suggestions, best first, when the
+	// prompt asked for more than one (ai.PromptOptions.Count)
+	Alternatives []Suggestion
+
+	// Unverified marks a Path that isn't in the folder tree (see
+	// cli.VerifyResponse)
+	Unverified bool
+
+	// Usage is the token usage reported by the provider, zero if it sent none
+	Usage Usage
 }
 
+// Suggestion is one ranked alternative to the recommended path
+type Suggestion struct {
+	Path   string
+	Reason string
+}
+
+// Usage counts the tokens consumed by one request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Total returns TotalTokens, or the sum of its parts for providers that omit it
+func (u Usage) Total() int {
+	if u.TotalTokens > 0 {
+		return u.TotalTokens
+	}
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// TruncateReason shortens Reason, and those of the alternatives, to at most
+// n characters, ending with an ellipsis when cut. A non-positive n leaves the
+// reasons untouched.
+func (r *LLMResponse) TruncateReason(n int) {
+	r.Reason = truncate(r.Reason, n)
+	for i := range r.Alternatives {
+		r.Alternatives[i].Reason = truncate(r.Alternatives[i].Reason, n)
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// maxQueryAttempts bounds how often a retryable API failure is retried
+const maxQueryAttempts = 3
+
+// retryDelay is the wait before the first retry, doubled for each further one
+var retryDelay = time.Second
+
+// Logger receives log lines as printf-style formats and arguments. Programs
+// embedding sortpath implement it to collect the client's and the
+// pipeline's logs; sortpath's own loggers satisfy it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// logger traces API retries; it logs nothing until SetLogger is called
+var logger Logger = app.NoopLogger{}
+
+// SetLogger routes the client's log lines to l; nil turns logging off
+func SetLogger(l Logger) {
+	if l == nil {
+		l = app.NoopLogger{}
+	}
+	logger = l
+}
+
+// QueryLLM is QueryLLMContext with a context that is never cancelled
 func QueryLLM(conf *config.Config, prompt string) (*LLMResponse, error) {
+	return QueryLLMContext(context.Background(), conf, prompt)
+}
+
+// QueryLLMContext asks the model for a recommendation, retrying failures that
+// apperrors.IsRetryable considers transient. Cancelling ctx aborts the
+// request in flight, or the wait before the next attempt, with an error
+// wrapping ctx.Err().
+func QueryLLMContext(ctx context.Context, conf *config.Config, prompt string) (*LLMResponse, error) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := queryOnce(ctx, conf, prompt)
+		if err == nil || attempt == maxQueryAttempts || !apperrors.IsRetryable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		logger.Info("attempt %d of %d failed, retrying in %v: %v", attempt, maxQueryAttempts, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, requestError(ctx, conf, ctx.Err())
+		}
+		delay *= 2
+	}
+}
+
+func queryOnce(ctx context.Context, conf *config.Config, prompt string) (*LLMResponse, error) {
 	reqBody := map[string]interface{}{
-		"model": conf.Model,
+		"model": conf.RequestModel(),
 		"messages": []map[string]string{
 			{"role": "system", "content": prompt},
 		},
 	}
+	applyRequestParams(reqBody, conf)
 	body, _ := json.Marshal(reqBody)
-	req, err := http.NewRequest("POST", conf.APIBase+"/chat/completions", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", CompletionsURL(conf), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+conf.APIKey)
+	setAuth(req, conf)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if conf.TraceID != "" {
+		header := conf.TraceHeader
+		if header == "" {
+			header = "traceparent"
+		}
+		req.Header.Set(header, traceHeaderValue(header, conf.TraceID))
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	client, err := clientFor(conf)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, requestError(ctx, conf, err)
+	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		b, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s", string(b))
+		return nil, responseError(resp)
+	}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
 	}
 	var apiResp struct {
 		Choices []struct {
@@ -46,38 +205,99 @@ func QueryLLM(conf *config.Config, prompt string) (*LLMResponse, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
+		return nil, decodeError(resp, err)
 	}
 	if len(apiResp.Choices) == 0 {
 		return nil, errors.New("no response from model")
 	}
-	// Parse XML output (simple, not robust)
-	content := apiResp.Choices[0].Message.Content
-	path, reason := parseXML(content)
-	return &LLMResponse{Path: path, Reason: reason}, nil
-}
-
-func parseXML(s string) (string, string) {
-	// Very basic XML extraction for <path> and <reason>
-	get := func(tag string) string {
-		start := fmt.Sprintf("<%s>", tag)
-		end := fmt.Sprintf("</%s>", tag)
-		i := len(start) + findIndex(s, start)
-		j := findIndex(s, end)
-		if i < len(start) || j < 0 {
-			return ""
+	recs, err := parseAnswer(apiResp.Choices[0].Message.Content, conf)
+	if err != nil {
+		return nil, err
+	}
+	best := recs[0]
+	result := &LLMResponse{Path: best.Path, Reason: best.Reason, Root: best.Root, Usage: apiResp.Usage}
+	for _, rec := range recs[1:] {
+		result.Alternatives = append(result.Alternatives, Suggestion{Path: rec.Path, Reason: rec.Reason})
+	}
+	return result, nil
+}
+
+// setAuth sends the API key as a bearer token. No header is sent without a
+// key or with --no-auth, so keyless local servers aren't sent "Bearer ".
+func setAuth(req *http.Request, conf *config.Config) {
+	if conf.APIKey != "" && !conf.NoAuth {
+		req.Header.Set("Authorization", "Bearer "+conf.APIKey)
+	}
+}
+
+// applyRequestParams adds the configured sampling parameters and response
+// format to body. Unset parameters are left out so the server's own defaults
+// apply.
+func applyRequestParams(body map[string]interface{}, conf *config.Config) {
+	if t, err := strconv.ParseFloat(conf.Temperature, 64); err == nil {
+		body["temperature"] = t
+	}
+	if n, err := strconv.Atoi(conf.MaxTokens); err == nil {
+		body["max_tokens"] = n
+	}
+	if conf.ResponseFormat == config.ResponseFormatJSON {
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+}
+
+// CompletionsURL returns the chat completions endpoint for conf. The path is
+// appended to the api-base path so query parameters on the base are kept.
+func CompletionsURL(conf *config.Config) string {
+	u, err := url.Parse(conf.APIBase)
+	if err != nil {
+		return conf.APIBase + "/chat/completions"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/chat/completions"
+	u.RawPath = ""
+	return u.String()
+}
+
+// secretQueryParams are query parameter names whose values are credentials
+var secretQueryParams = map[string]bool{
+	"api-key": true, "api_key": true, "apikey": true, "key": true,
+	"token": true, "access_token": true, "sig": true, "signature": true,
+	"secret": true, "password": true,
+}
+
+// RedactURL masks credentials in raw (userinfo passwords and secret query
+// parameters) so the URL can be shown to the user or logged
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for name := range q {
+			if secretQueryParams[strings.ToLower(name)] {
+				q.Set(name, "REDACTED")
+			}
 		}
-		return s[i:j]
+		u.RawQuery = q.Encode()
 	}
-	return get("path"), get("reason")
+	return u.String()
 }
 
-func findIndex(s, sub string) int {
-	idx := -1
-	if i := bytes.Index([]byte(s), []byte(sub)); i >= 0 {
-		idx = i
+// traceHeaderValue formats id for header. A bare trace ID sent as traceparent
+// gets a fresh span ID so the header is valid W3C trace context.
+func traceHeaderValue(header, id string) string {
+	if !strings.EqualFold(header, "traceparent") || strings.Count(id, "-") == 3 {
+		return id
+	}
+	span := make([]byte, 8)
+	if _, err := rand.Read(span); err != nil {
+		span = []byte{0, 0, 0, 0, 0, 0, 0, 1}
 	}
-	return idx
+	return fmt.Sprintf("00-%s-%s-01", id, hex.EncodeToString(span))
 }
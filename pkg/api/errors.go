@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+// Provider error codes recognised by apperrors.FormatUserError. OpenAI sends
+// the first and last verbatim; expired keys are detected from the message.
+const (
+	CodeInvalidAPIKey     = "invalid_api_key"
+	CodeExpiredAPIKey     = "expired_api_key"
+	CodeInsufficientQuota = "insufficient_quota"
+)
+
+// providerError matches the error envelope used by OpenAI-compatible APIs
+type providerError struct {
+	Error struct {
+		Message string      `json:"message"`
+		Type    string      `json:"type"`
+		Code    interface{} `json:"code"` // string for OpenAI, number for some proxies
+	} `json:"error"`
+}
+
+// parseAPIError turns a non-200 response into an AppError. The provider's
+// error code, normalised for auth and quota failures, is stored in the
+// "provider_code" context so FormatUserError can suggest a fix.
+func parseAPIError(status int, body []byte) error {
+	var pe providerError
+	message := strings.TrimSpace(string(body))
+	code := ""
+	if err := json.Unmarshal(body, &pe); err == nil && pe.Error.Message != "" {
+		message = pe.Error.Message
+		if pe.Error.Code != nil {
+			code = fmt.Sprint(pe.Error.Code)
+		}
+		if code == "" {
+			code = pe.Error.Type
+		}
+	}
+
+	appErr := apperrors.APIError(fmt.Sprintf("API error (%d): %s", status, message), nil).
+		WithStatus(status)
+	if normalized := classifyProviderCode(status, code, pe.Error.Type, message); normalized != "" {
+		appErr.WithContext("provider_code", normalized)
+		// An exhausted quota comes as a 429 but won't recover by retrying
+		if normalized == CodeInsufficientQuota {
+			appErr.WithRetryable(false)
+		}
+	}
+	return appErr
+}
+
+// classifyProviderCode maps provider-specific failures onto the Code*
+// constants, falling back to the raw code
+func classifyProviderCode(status int, code, errType, message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case code == CodeInsufficientQuota || errType == CodeInsufficientQuota:
+		return CodeInsufficientQuota
+	case (status == 401 || code == CodeInvalidAPIKey) && strings.Contains(lower, "expired"):
+		return CodeExpiredAPIKey
+	case status == 401 || code == CodeInvalidAPIKey || errType == "authentication_error":
+		return CodeInvalidAPIKey
+	}
+	return code
+}
+
+// responseError turns a non-200 response into an AppError, explaining HTML
+// pages (usually from a proxy in the way) rather than quoting their markup
+func responseError(resp *http.Response) error {
+	if err := checkContentType(resp); err != nil {
+		return err
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	return parseAPIError(resp.StatusCode, b)
+}
+
+// checkContentType fails when resp is an HTML page, which usually means a
+// corporate proxy, captive portal or login page answered instead of the API.
+// Other types are left to the JSON decoder: some local model servers send
+// JSON without a Content-Type, which then reads as text/plain.
+func checkContentType(resp *http.Response) error {
+	mt := mediaType(resp)
+	if mt != "text/html" && mt != "application/xhtml+xml" {
+		return nil
+	}
+
+	msg := fmt.Sprintf("API returned an HTML page (%d) instead of JSON; a proxy, firewall or login page probably intercepted the request. Check api-base and your proxy settings", resp.StatusCode)
+	snippet, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	if m := htmlTitle.FindSubmatch(snippet); m != nil {
+		if title := strings.Join(strings.Fields(string(m[1])), " "); title != "" {
+			msg += fmt.Sprintf(" (page title: %q)", title)
+		}
+	}
+	return apperrors.APIError(msg, nil).
+		WithStatus(resp.StatusCode).
+		WithContext("content_type", mt)
+}
+
+// decodeError explains a response body that isn't the expected JSON
+func decodeError(resp *http.Response, err error) error {
+	mt := mediaType(resp)
+	if mt == "" {
+		mt = "no content type"
+	}
+	return apperrors.APIError(fmt.Sprintf("cannot decode API response (%s): %v", mt, err), err).
+		WithContext("content_type", mt)
+}
+
+var htmlTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// mediaType returns the lower-case media type of resp's Content-Type, or ""
+// when the header is missing
+func mediaType(resp *http.Response) string {
+	ct := resp.Header.Get("Content-Type")
+	if ct == "" {
+		return ""
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(ct))
+	}
+	return mt
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+// noRetryDelay makes QueryLLM retry immediately
+func noRetryDelay(t *testing.T) {
+	t.Helper()
+	orig := retryDelay
+	retryDelay = 0
+	t.Cleanup(func() { retryDelay = orig })
+}
+
+func TestQueryLLM_ProviderErrors(t *testing.T) {
+	noRetryDelay(t)
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+		wantHint string
+	}{
+		{
+			name:     "invalid key",
+			status:   401,
+			body:     `{"error":{"message":"Incorrect API key provided: sk-abc***xyz.","type":"invalid_request_error","param":null,"code":"invalid_api_key"}}`,
+			wantCode: CodeInvalidAPIKey,
+			wantHint: "Re-set it with: sortpath config set api-key",
+		},
+		{
+			name:     "expired key",
+			status:   401,
+			body:     `{"error":{"message":"The API key has expired. Please generate a new key.","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			wantCode: CodeExpiredAPIKey,
+			wantHint: "has expired",
+		},
+		{
+			name:     "insufficient quota",
+			status:   429,
+			body:     `{"error":{"message":"You exceeded your current quota, please check your plan and billing details.","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`,
+			wantCode: CodeInsufficientQuota,
+			wantHint: "billing",
+		},
+		{
+			name:     "anthropic style auth error",
+			status:   401,
+			body:     `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			wantCode: CodeInvalidAPIKey,
+			wantHint: "Re-set it with",
+		},
+		{
+			name:     "plain text rate limit",
+			status:   429,
+			body:     "Too Many Requests",
+			wantCode: "",
+			wantHint: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := QueryLLM(&config.Config{APIBase: srv.URL, APIKey: "k", Model: "m"}, "prompt")
+			if !apperrors.IsType(err, "API_ERROR") {
+				t.Fatalf("QueryLLM() error = %v, want an API_ERROR", err)
+			}
+
+			code, _ := apperrors.GetContext(err, "provider_code")
+			if tt.wantCode == "" && code != nil || tt.wantCode != "" && code != tt.wantCode {
+				t.Errorf("provider_code = %v, want %q", code, tt.wantCode)
+			}
+
+			msg := apperrors.FormatUserError(err)
+			if tt.wantHint != "" && !strings.Contains(msg, tt.wantHint) {
+				t.Errorf("FormatUserError() = %q, want hint containing %q", msg, tt.wantHint)
+			}
+			if tt.wantHint == "" && strings.Contains(msg, "💡") {
+				t.Errorf("FormatUserError() = %q, want no hint", msg)
+			}
+		})
+	}
+}
+
+func TestQueryLLM_RetriesTransientErrors(t *testing.T) {
+	noRetryDelay(t)
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		body         string
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "server error then success", failures: 1, status: 503, wantRequests: 2},
+		{name: "rate limit gives up after max attempts", failures: 5, status: 429, wantRequests: maxQueryAttempts, wantErr: true},
+		{name: "unauthorized is not retried", failures: 5, status: 401, wantRequests: 1, wantErr: true},
+		{name: "exhausted quota is not retried", failures: 5, status: 429, body: `{"error":{"message":"You exceeded your current quota","code":"insufficient_quota"}}`, wantRequests: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tt.failures {
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, tt.body)
+					return
+				}
+				fmt.Fprint(w, `{"choices":[{"message":{"content":"<path>/a</path><reason>r</reason>"}}]}`)
+			}))
+			defer srv.Close()
+
+			_, err := QueryLLM(&config.Config{APIBase: srv.URL, APIKey: "k", Model: "m"}, "prompt")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryLLM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+const proxyLoginPage = `<!DOCTYPE html>
+<html><head><title>
+  Corporate Proxy – Sign In
+</title></head><body><form>...</form></body></html>`
+
+func TestQueryLLM_HTMLResponse(t *testing.T) {
+	noRetryDelay(t)
+	tests := []struct {
+		name         string
+		status       int
+		wantRequests int
+	}{
+		// A login page served with 200 won't go away by retrying
+		{name: "proxy login page", status: 200, wantRequests: 1},
+		{name: "gateway error page", status: 502, wantRequests: maxQueryAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if got := r.Header.Get("Accept"); got != "application/json" {
+					t.Errorf("Accept = %q, want application/json", got)
+				}
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, proxyLoginPage)
+			}))
+			defer srv.Close()
+
+			_, err := QueryLLM(&config.Config{APIBase: srv.URL, APIKey: "k", Model: "m"}, "prompt")
+			if !apperrors.IsType(err, "API_ERROR") {
+				t.Fatalf("QueryLLM() error = %v, want an API_ERROR", err)
+			}
+			msg := err.Error()
+			for _, want := range []string{"HTML page", "instead of JSON", "proxy", `page title: "Corporate Proxy – Sign In"`} {
+				if !strings.Contains(msg, want) {
+					t.Errorf("error %q does not mention %q", msg, want)
+				}
+			}
+			if strings.Contains(msg, "<form>") {
+				t.Errorf("error quotes the page markup: %q", msg)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestQueryLLM_UndecodableResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "service temporarily unavailable")
+	}))
+	defer srv.Close()
+
+	_, err := QueryLLM(&config.Config{APIBase: srv.URL, APIKey: "k", Model: "m"}, "prompt")
+	if err == nil || !strings.Contains(err.Error(), "cannot decode API response (text/plain)") {
+		t.Errorf("QueryLLM() error = %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/cache"
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// HealthTTL is how long a successful probe is trusted before probing again
+const HealthTTL = 30 * time.Second
+
+// now is the clock used for health cache expiry; tests replace it
+var now = time.Now
+
+var (
+	healthMu    sync.Mutex
+	healthCache = map[string]time.Time{}
+)
+
+// HealthCheck confirms the API endpoint in conf is reachable and accepts the
+// API key by listing models. A success is cached for HealthTTL in memory and
+// in the health folder of cache.Dir(), so commands run in quick
+// succession skip the probe. Failures are never cached, so a recovering
+// endpoint is retried.
+func HealthCheck(conf *config.Config) error {
+	key := healthKey(conf)
+	if healthy(key) {
+		return nil
+	}
+	if err := Probe(conf); err != nil {
+		return err
+	}
+	markHealthy(key, now())
+	return nil
+}
+
+// Probe lists models at the API endpoint in conf with the configured
+// credentials, bypassing the health cache. It fails like a completion
+// request would: an AppError carrying the HTTP status when the endpoint
+// answers with an error, or the transport error when it can't be reached.
+func Probe(conf *config.Config) error {
+	req, err := http.NewRequest("GET", modelsURL(conf), nil)
+	if err != nil {
+		return err
+	}
+	setAuth(req, conf)
+	req.Header.Set("Accept", "application/json")
+
+	client, err := clientFor(conf)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return requestError(context.Background(), conf, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return responseError(resp)
+	}
+	// A captive portal answers 200 with a login page
+	return checkContentType(resp)
+}
+
+// modelsURL returns the models endpoint for conf, keeping api-base query parameters
+func modelsURL(conf *config.Config) string {
+	u, err := url.Parse(conf.APIBase)
+	if err != nil {
+		return conf.APIBase + "/models"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/models"
+	u.RawPath = ""
+	return u.String()
+}
+
+// healthKey identifies an endpoint and credential pair without storing the key
+func healthKey(conf *config.Config) string {
+	sum := sha256.Sum256([]byte(conf.APIBase + "\x00" + conf.APIKey + "\x00" + conf.PinnedCertSHA256))
+	return hex.EncodeToString(sum[:16])
+}
+
+// healthy reports whether key had a successful probe within HealthTTL
+func healthy(key string) bool {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	last, ok := healthCache[key]
+	if !ok {
+		if data, err := os.ReadFile(healthCachePath(key)); err == nil {
+			if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data))); err == nil {
+				last, ok = t, true
+				healthCache[key] = t
+			}
+		}
+	}
+	if !ok {
+		return false
+	}
+	age := now().Sub(last)
+	return age >= 0 && age < HealthTTL
+}
+
+// markHealthy records a successful probe. The disk copy is best effort.
+func markHealthy(key string, t time.Time) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	healthCache[key] = t
+	path := healthCachePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+		_ = os.WriteFile(path, []byte(t.Format(time.RFC3339Nano)), 0600)
+	}
+}
+
+func healthCachePath(key string) string {
+	return filepath.Join(cache.Dir(), "health", key)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// stubHealth isolates the health cache and returns a settable clock
+func stubHealth(t *testing.T) *time.Time {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	origNow, origCache := now, healthCache
+	now = func() time.Time { return clock }
+	healthCache = map[string]time.Time{}
+	t.Cleanup(func() { now, healthCache = origNow, origCache })
+	return &clock
+}
+
+func TestHealthCheck_CachesSuccessForTTL(t *testing.T) {
+	clock := stubHealth(t)
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("probe path = %s, want /v1/models", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+	conf := &config.Config{APIBase: srv.URL + "/v1", APIKey: "sk-test"}
+
+	if err := HealthCheck(conf); err != nil {
+		t.Fatal(err)
+	}
+	*clock = clock.Add(HealthTTL - time.Second)
+	if err := HealthCheck(conf); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&probes); n != 1 {
+		t.Fatalf("probes within TTL = %d, want 1 (second served from cache)", n)
+	}
+
+	// A fresh process reads the disk cache
+	healthCache = map[string]time.Time{}
+	if err := HealthCheck(conf); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&probes); n != 1 {
+		t.Fatalf("probes after clearing memory cache = %d, want 1 (disk cache)", n)
+	}
+
+	*clock = clock.Add(2 * time.Second)
+	if err := HealthCheck(conf); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&probes); n != 2 {
+		t.Fatalf("probes after TTL = %d, want 2", n)
+	}
+}
+
+func TestHealthCheck_FailureNotCached(t *testing.T) {
+	stubHealth(t)
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&probes, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+	conf := &config.Config{APIBase: srv.URL, APIKey: "sk-test"}
+
+	if err := HealthCheck(conf); err == nil {
+		t.Fatal("expected the first probe to fail")
+	}
+	if err := HealthCheck(conf); err != nil {
+		t.Fatalf("recovered endpoint still failing: %v", err)
+	}
+	if n := atomic.LoadInt32(&probes); n != 2 {
+		t.Errorf("probes = %d, want 2 (failure must not be cached)", n)
+	}
+}
+
+func TestHealthCheck_KeyedByCredentials(t *testing.T) {
+	stubHealth(t)
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+	}))
+	defer srv.Close()
+
+	for _, key := range []string{"sk-one", "sk-two"} {
+		if err := HealthCheck(&config.Config{APIBase: srv.URL, APIKey: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&probes); n != 2 {
+		t.Errorf("probes = %d, want one per API key", n)
+	}
+}
+
+func TestHealthCheck_HTMLPageIsUnhealthy(t *testing.T) {
+	stubHealth(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Wi-Fi Login</title></head></html>`))
+	}))
+	defer srv.Close()
+
+	if err := HealthCheck(&config.Config{APIBase: srv.URL, APIKey: "sk-test"}); err == nil {
+		t.Fatal("HealthCheck() accepted a captive portal page")
+	}
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+// recommendation is one answer the prompts ask the model for:
+// <recommendation><root/><path/><reason/></recommendation> or the JSON
+// object with the same fields, where root only appears in multi-tree prompts
+type recommendation struct {
+	Root   string `json:"root"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// parseAnswer reads the model's answers, best first, in the configured
+// response format. A JSON answer that is missing or has no path falls back
+// to the XML extractor, for providers that ignore the requested format and
+// reply in prose.
+func parseAnswer(content string, conf *config.Config) ([]recommendation, error) {
+	if conf.ResponseFormat == config.ResponseFormatJSON {
+		if recs := parseJSONRecommendations(content); len(recs) > 0 {
+			return recs, nil
+		}
+	}
+	return parseRecommendations(content, conf.StrictXML)
+}
+
+// parseJSONRecommendations decodes the outermost {...} in content, so a
+// fenced or introduced object still parses. The object is one answer, or
+// holds several in a "recommendations" array. Answers without a path are
+// dropped.
+func parseJSONRecommendations(content string) []recommendation {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil
+	}
+	var answer struct {
+		recommendation
+		Recommendations []recommendation `json:"recommendations"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &answer); err != nil {
+		return nil
+	}
+	recs := answer.Recommendations
+	if len(recs) == 0 {
+		recs = []recommendation{answer.recommendation}
+	}
+	var found []recommendation
+	for _, rec := range recs {
+		rec = recommendation{
+			Root:   strings.TrimSpace(rec.Root),
+			Path:   strings.TrimSpace(rec.Path),
+			Reason: strings.TrimSpace(rec.Reason),
+		}
+		if rec.Path != "" {
+			found = append(found, rec)
+		}
+	}
+	return found
+}
+
+// parseRecommendations extracts the model's answers from content, best
+// first. Prose around them, attributes, whitespace, CDATA and markup nested
+// in a field are tolerated. Each <recommendation> element is one answer;
+// without any, bare tags form a single answer. strict requires complete
+// elements. Answers without a <path> are dropped, and content with none at
+// all is a retryable API error carrying the output as "raw_response", since
+// the next sample may be well-formed.
+func parseRecommendations(content string, strict bool) ([]recommendation, error) {
+	recs, complete := scanRecommendations(content)
+	var found []recommendation
+	for _, rec := range recs {
+		if rec.Path != "" {
+			found = append(found, rec)
+		}
+	}
+	problem := ""
+	switch {
+	case strict && !complete:
+		problem = "no complete <recommendation> element"
+	case len(found) == 0 && complete:
+		problem = "no <path> in <recommendation>"
+	case len(found) == 0:
+		problem = "no <path> in the answer"
+	default:
+		return found, nil
+	}
+	return nil, apperrors.APIError("model returned malformed output: "+problem, nil).
+		WithContext("raw_response", content).
+		WithRetryable(true)
+}
+
+// scanRecommendations reads the first <root>, <path> and <reason> of every
+// <recommendation> element in content, in order. complete reports that at
+// least one element was found and all were closed. Bare tags outside any
+// element only count when there is no element. Decoding is lenient
+// (unescaped & and HTML entities are accepted) and stops at the first syntax
+// error, keeping the fields read so far.
+func scanRecommendations(content string) (recs []recommendation, complete bool) {
+	start := answerStart(content)
+	if start < 0 {
+		return nil, false
+	}
+	d := xml.NewDecoder(strings.NewReader(content[start:]))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var bare, cur recommendation
+	inside, seen := false, false
+scan:
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if name == "recommendation" {
+				if !inside {
+					cur, inside, seen = recommendation{}, true, true
+				}
+				continue
+			}
+			target := &bare
+			if inside {
+				target = &cur
+			}
+			field := target.field(name)
+			if field == nil {
+				continue
+			}
+			text, err := elementText(d)
+			if *field == "" {
+				*field = text
+			}
+			if err != nil {
+				break scan
+			}
+		case xml.EndElement:
+			if inside && strings.EqualFold(t.Name.Local, "recommendation") {
+				recs = append(recs, cur)
+				inside = false
+			}
+		}
+	}
+	if inside {
+		// Keep what an unclosed element held
+		recs = append(recs, cur)
+	}
+	if !seen && bare != (recommendation{}) {
+		recs = append(recs, bare)
+	}
+	return recs, seen && !inside
+}
+
+// field returns the field an answer tag fills, or nil for other tags
+func (r *recommendation) field(tag string) *string {
+	switch tag {
+	case "root":
+		return &r.Root
+	case "path":
+		return &r.Path
+	case "reason":
+		return &r.Reason
+	}
+	return nil
+}
+
+// answerStart returns the offset of the first tag of the answer in content,
+// so a stray < in the prose before it can't stop the decoder, or -1
+func answerStart(content string) int {
+	if i := strings.Index(content, "<recommendation"); i >= 0 {
+		return i
+	}
+	start := -1
+	for _, tag := range []string{"<root", "<path", "<reason"} {
+		if i := strings.Index(content, tag); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	return start
+}
+
+// elementText reads the character data up to the end of the element just
+// started, including text in nested markup, with surrounding space trimmed
+func elementText(d *xml.Decoder) (string, error) {
+	var b strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return strings.TrimSpace(b.String()), err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			b.Write(t)
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
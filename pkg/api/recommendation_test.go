@@ -0,0 +1,107 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+func TestParseRecommendation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    recommendation
+	}{
+		{
+			name:    "plain",
+			content: "<recommendation><path>/Docs/Tax</path><reason>Tax form</reason></recommendation>",
+			want:    recommendation{Path: "/Docs/Tax", Reason: "Tax form"},
+		},
+		{
+			name:    "attributes",
+			content: `<recommendation confidence="high"><path type="dir">/Docs/Tax</path><reason lang="en">Tax form</reason></recommendation>`,
+			want:    recommendation{Path: "/Docs/Tax", Reason: "Tax form"},
+		},
+		{
+			name:    "extra whitespace",
+			content: "<recommendation>\n  <path>\n    /Docs/Tax\n  </path>\n  <reason> Tax form </reason>\n</recommendation>\n",
+			want:    recommendation{Path: "/Docs/Tax", Reason: "Tax form"},
+		},
+		{
+			name:    "cdata",
+			content: "<recommendation><path><![CDATA[/R&D/<Drafts>]]></path><reason><![CDATA[Notes & drafts]]></reason></recommendation>",
+			want:    recommendation{Path: "/R&D/<Drafts>", Reason: "Notes & drafts"},
+		},
+		{
+			name:    "surrounding prose",
+			content: "Sure! If a < b then <path>/wrong</path> won't do.\n<recommendation><path>/Docs/Tax</path><reason>r</reason></recommendation>\nHope that helps.",
+			want:    recommendation{Path: "/Docs/Tax", Reason: "r"},
+		},
+		{
+			name:    "nested markup",
+			content: "<response><recommendation><root>work</root><path><b>/Docs</b>/Tax</path><reason>r</reason></recommendation></response>",
+			want:    recommendation{Root: "work", Path: "/Docs/Tax", Reason: "r"},
+		},
+		{
+			name:    "unescaped ampersand and entities",
+			content: "<recommendation><path>/R&D/Notes</path><reason>R&amp;D&nbsp;notes</reason></recommendation>",
+			want:    recommendation{Path: "/R&D/Notes", Reason: "R&D\u00a0notes"},
+		},
+		{
+			name:    "bare tags",
+			content: "<path>/a</path><reason>r</reason>",
+			want:    recommendation{Path: "/a", Reason: "r"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRecommendations(tt.content, false)
+			if err != nil {
+				t.Fatalf("parseRecommendations() error = %v", err)
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("parseRecommendations() = %+v, want [%+v]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecommendation_NoPath(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"It belongs in Documents.",
+		"<recommendation><reason>unsure</reason></recommendation>",
+		"<recommendation><path>  </path></recommendation>",
+	} {
+		_, err := parseRecommendations(content, false)
+		if !apperrors.IsType(err, "API_ERROR") {
+			t.Errorf("parseRecommendations(%q) error = %v, want an API_ERROR", content, err)
+			continue
+		}
+		if raw, _ := apperrors.GetContext(err, "raw_response"); raw != content {
+			t.Errorf("raw_response = %v, want the model output", raw)
+		}
+	}
+}
+
+func TestParseRecommendations_Ranked(t *testing.T) {
+	want := []recommendation{
+		{Path: "/Docs/Tax/2025", Reason: "tax return"},
+		{Path: "/Finance/Receipts", Reason: "receipt-like"},
+	}
+
+	xmlContent := "Two options:\n" +
+		"<recommendation><path>/Docs/Tax/2025</path><reason>tax return</reason></recommendation>\n" +
+		"<recommendation><reason>no path</reason></recommendation>\n" +
+		"<recommendation><path>/Finance/Receipts</path><reason>receipt-like</reason></recommendation>"
+	got, err := parseRecommendations(xmlContent, true)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRecommendations() = %+v, %v; want %+v", got, err, want)
+	}
+
+	jsonContent := `{"recommendations": [{"path": "/Docs/Tax/2025", "reason": "tax return"}, {"path": "/Finance/Receipts", "reason": "receipt-like"}]}`
+	if got := parseJSONRecommendations(jsonContent); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONRecommendations() = %+v, want %+v", got, want)
+	}
+}
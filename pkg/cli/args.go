@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
 	"github.com/kacperkwapisz/sortpath/internal/updater"
 )
 
@@ -23,48 +25,271 @@ func ParseArgs(args []string) (config.CLIOptions, string) {
     fs.StringVar(&opts.APIKey, "api-key", "", "OpenAI-compatible API key")
     fs.StringVar(&opts.APIBase, "api-base", "", "API base URL")
     fs.StringVar(&opts.Model, "model", "", "Model name")
-    fs.StringVar(&opts.TreePath, "tree", "", "Path to folder tree file")
+    var trees stringList
+    fs.Var(&trees, "tree", "Path to folder tree file; repeat as LABEL=PATH to choose between archives")
     fs.StringVar(&opts.LogLevel, "log-level", "", "Log level (debug, info, error)")
-    fs.SetOutput(os.Stderr)
+    fs.StringVar(&opts.LogFormat, "log-format", "", "Log line format (text, json)")
+    fs.StringVar(&opts.LogFile, "log-file", "", "Also append log lines to this file")
+    fs.BoolVar(&opts.LogFileOnly, "log-file-only", false, "Write log lines to the --log-file only, not the console")
+    fs.StringVar(&opts.ConfigPath, "config", "", "Config file to use instead of "+config.DefaultConfigPath())
+    fs.StringVar(&opts.Profile, "profile", "", "Use the named profile from the config file")
+    fs.StringVar(&opts.Provider, "provider", "", "Provider whose default request parameters apply (openai, anthropic)")
+    fs.StringVar(&opts.Temperature, "temperature", "", "Sampling temperature sent with the request (0-2)")
+    fs.StringVar(&opts.MaxTokens, "max-tokens", "", "Maximum tokens the model may generate")
+    fs.StringVar(&opts.Timeout, "timeout", "", "Give up on an API request after this long, e.g. 30s (default 60s)")
+    fs.StringVar(&opts.OnMissingTree, "on-missing-tree", "", "What to do when the tree path doesn't exist (error, create, cwd)")
+    fs.BoolVar(&opts.ExplainTree, "explain-tree", false, "Print the tree and why entries were skipped, then exit")
+    fs.IntVar(&opts.ContextWindow, "context-window", 0, "Shrink the tree so the prompt fits in N tokens")
+    fs.StringVar(&opts.TreeDepth, "tree-depth", "", "Only walk N levels of the tree (0 = top-level entries only)")
+    fs.StringVar(&opts.TreeMaxBytes, "tree-max-bytes", "", "Cut the tree at this size, e.g. 64K (default 64KB, 0 = unlimited)")
+    fs.BoolVar(&opts.DirsOnly, "dirs-only", false, "List only folders in the tree, no files")
+    fs.BoolVar(&opts.NoCache, "no-cache", false, "Rebuild the folder tree instead of reusing the cached one")
+    fs.BoolVar(&opts.NoDefaultIgnores, "no-default-ignores", false, "Also walk node_modules, .git, vendor, dist, build and .cache")
+    fs.BoolVar(&opts.LargeTree, "large-tree", false, "Pick a top-level folder first, then sort within it (two API calls)")
+    fs.BoolVar(&opts.NoTree, "no-tree", false, "Classify into a generic category without reading the folder tree")
+    fs.StringVar(&opts.TraceID, "trace-id", "", "Trace ID sent with the API request and added to log lines")
+    fs.StringVar(&opts.TraceHeader, "trace-header", "", "Request header carrying the trace ID (default traceparent)")
+    var rules stringList
+    fs.Var(&rules, "rule", "Add a rule to the prompt's instructions; repeat for several (replaces config key extra-rules)")
+    fs.StringVar(&opts.PromptTemplate, "prompt-template", "", "Build the prompt from this text/template file instead of the built-in one")
+    minimal := fs.Bool("minimal-prompt", false, "Send a bare prompt for models fine-tuned for sortpath")
+    fs.StringVar(&opts.ResponseFormat, "response-format", "", "Ask the model to answer in xml (default) or json")
+    fs.BoolVar(&opts.ShowURL, "show-url", false, "Print the request URL before calling the API")
+    fs.BoolVar(&opts.ShowUsage, "show-usage", false, "Print the tokens each query used")
+    fs.Float64Var(&opts.PriceInput, "price-input", 0, "Price per 1,000 prompt tokens, for a cost estimate with --show-usage")
+    fs.Float64Var(&opts.PriceOutput, "price-output", 0, "Price per 1,000 completion tokens, for a cost estimate with --show-usage")
+    fs.StringVar(&opts.FromFile, "from-file", "", "Describe this file automatically from its name, type and size")
+    fs.IntVar(&opts.PreviewBytes, "preview-bytes", 0, "With --from-file, include the first N bytes of a text file (max 4096)")
+    fs.StringVar(&opts.Move, "move", "", "Move this file into the recommended folder")
+    fs.StringVar(&opts.Copy, "copy", "", "Copy this file into the recommended folder")
+    fs.BoolVar(&opts.DryRun, "dry-run", false, "With --move or --copy, print what would happen without touching files")
+    fs.StringVar(&opts.RecordDataset, "record-dataset", "", "Append each recommendation to FILE as a JSONL fine-tuning example")
+    fs.BoolVar(&opts.DatasetTreeRef, "dataset-tree-ref", false, "With --record-dataset, store each tree once by hash instead of inline")
+    fs.BoolVar(&opts.Batch, "batch", false, "Sort each argument (file path or description) separately; - reads items from stdin")
+    fs.BoolVar(&opts.Stdin, "stdin", false, "Read descriptions from stdin, one per line (automatic when stdin is piped)")
+    fs.IntVar(&opts.Concurrency, "concurrency", 1, "With --batch, query up to N items at once")
+    fs.IntVar(&opts.Budget, "budget", 0, "With --batch, stop once cumulative token usage exceeds N tokens (0 = unlimited)")
+    fs.BoolVar(&opts.NoAuth, "no-auth", false, "Send no API key, for local servers that don't need one")
+    fs.BoolVar(&opts.StrictXML, "strict-xml", false, "Require a complete <recommendation> element in the model's answer")
+    fs.BoolVar(&opts.Strict, "strict", false, "Fail when the recommended path is not in the folder tree")
+    fs.BoolVar(&opts.Pick, "pick", false, "Choose the folder yourself from a filterable list of the tree")
+    fs.BoolVar(&opts.Pick, "select-interactive", false, "Same as --pick")
+    fs.BoolVar(&opts.JSON, "json", false, "Print each result as a JSON object on stdout")
+    fs.BoolVar(&opts.JSONSchema, "json-schema", false, "Print the JSON Schema of the --json output and exit")
+    fs.BoolVar(&opts.Quiet, "quiet", false, "Hide notices; only results and errors are printed")
+    fs.StringVar(&opts.Format, "format", "", "Print results as full (default), path or json")
+    script := fs.Bool("q", false, "Print only the recommended path, without notices (--quiet --format path)")
+    fs.BoolVar(&opts.NoColor, "no-color", false, "Plain output without emoji markers (also NO_COLOR)")
+    fs.StringVar(&opts.Color, "color", "", "Color log lines by level: auto (default), always or never")
+    fs.IntVar(&opts.MaxReasonLength, "max-reason-length", 0, "Truncate the reason to N characters (0 = unlimited)")
+    fs.IntVar(&opts.Count, "count", 1, "Ask for N ranked recommendations")
+    fs.SetOutput(out.Errors())
 
-    // Find first non-flag arg as description
-    descIdx := 0
-    for i, arg := range args {
-        if !strings.HasPrefix(arg, "-") {
-            descIdx = i
-            break
+    // Flags stop at the first non-flag arg; everything after is the description
+    _ = fs.Parse(args)
+    if len(trees) > 0 {
+        // A lone labeled tree is just its path
+        opts.TreePath = trees[0]
+        if roots, err := ParseTreeRoots(trees[:1]); err == nil {
+            opts.TreePath = roots[0].Path
         }
     }
-    flagArgs := args[:descIdx]
-    desc := strings.Join(args[descIdx:], " ")
-
-    _ = fs.Parse(flagArgs)
+    if len(trees) > 1 {
+        opts.Trees = trees
+    }
+    if *script {
+        opts.Quiet = true
+        if opts.Format == "" && !opts.JSON {
+            opts.Format = FormatPath
+        }
+    }
+    if len(rules) > 0 {
+        opts.ExtraRules = strings.Join(rules, "\n")
+    }
+    if *minimal {
+        opts.PromptStyle = config.PromptStyleMinimal
+    }
+    if opts.Batch {
+        opts.BatchInputs = fs.Args()
+    }
+    desc := strings.Join(fs.Args(), " ")
     return opts, desc
 }
 
+// TakeConfigFlag removes a leading --config PATH (or --config=PATH) from args
+// and makes PATH the config file for the rest of the run, so the flag works
+// ahead of subcommands: sortpath --config client.yaml config list
+func TakeConfigFlag(args []string) ([]string, error) {
+    if len(args) == 0 {
+        return args, nil
+    }
+    var path string
+    switch {
+    case strings.HasPrefix(args[0], "--config="):
+        path = strings.TrimPrefix(args[0], "--config=")
+        args = args[1:]
+    case args[0] == "--config":
+        if len(args) < 2 {
+            return nil, errors.New("--config needs a file path")
+        }
+        path = args[1]
+        args = args[2:]
+    default:
+        return args, nil
+    }
+    if path == "" {
+        return nil, errors.New("--config needs a file path")
+    }
+    config.SetConfigPath(path)
+    return args, nil
+}
+
 func PrintHelp(version string) {
-    fmt.Printf(`sortpath: AI-powered folder recommendation CLI
+    out.Result(`sortpath: AI-powered folder recommendation CLI
 Version: %s
 
 Usage:
   sortpath [flags] "file description"
-  sortpath config set|get|remove|list [key] [value]
+  sortpath --pick [--tree DIR]
+  sortpath [--config FILE] config set|get|remove|list [key] [value]
   sortpath install [--path /usr/local/bin] [--force]
-    sortpath update [--check-only]
+  sortpath prompt-test [--prompt-template FILE] [--tree DIR] "file description"
+  sortpath cache prune [--max-age DUR] [--max-size BYTES] | cache clear
+    sortpath update [--check-only] [--channel stable|beta] [--version vX.Y.Z] [--quiet]
 
 Flags:
-  --api-key    OpenAI-compatible API key
+  --api-key    OpenAI-compatible API key (not needed for localhost)
+  --no-auth    Send no API key, for a keyless server that isn't on localhost
   --api-base   API base URL (e.g. https://api.openai.com/v1)
   --model      Model name (e.g. gpt-3.5-turbo)
-  --tree       Path to folder tree file
+  --tree       Path to folder tree file; repeat (optionally as LABEL=PATH)
+               to let the model choose between several archives
   --log-level  Log level (debug, info, error)
+  --log-format FORMAT  Log lines as text (default) or json, one object per
+                  line (config key log-format, env SORTPATH_LOG_FORMAT)
+  --log-file FILE  Also append log lines to FILE, created owner-only with its
+                  directories (config key log-file, env SORTPATH_LOG_FILE)
+  --log-file-only  Write log lines to the --log-file only, not the console
+  --config FILE  Use FILE instead of %s
+                 (env SORTPATH_CONFIG); put it before a subcommand to apply there
+  --profile NAME  Use the named profile's settings (env SORTPATH_PROFILE;
+                  config key profile sets the default)
+  --provider NAME  Apply the provider's default parameters: openai (default), anthropic
+  --temperature T  Sampling temperature (0-2); overrides the provider default
+  --max-tokens N   Maximum tokens to generate; overrides the provider default
+  --timeout DURATION  Give up on an API request after DURATION, e.g. 30s
+                  (default 60s; config key timeout)
+  --on-missing-tree POLICY  When the tree path doesn't exist: error (default), create, cwd
+  --explain-tree  Print the folder tree and why entries were skipped (no API call)
+  --max-reason-length N  Truncate the reason to N characters (default unlimited)
+  --count N      Ask for N ranked recommendations, printed numbered (default 1)
+  --context-window N  Shrink the tree until the prompt fits in N tokens
+  --tree-depth N  Only walk N levels below the tree root; deeper folders are
+                  marked … (0 = top-level entries only; config key tree-depth)
+  --tree-max-bytes SIZE  Cut the tree to fit SIZE bytes; folders cut short end
+                  with "(N more)" (default 64KB, 0 = unlimited; config key
+                  tree-max-bytes)
+  --dirs-only    List only folders in the tree; files are left out
+  --no-cache     Rebuild the tree instead of reusing the copy cached while
+                  no folder in it has changed
+  --no-default-ignores  Also walk node_modules, .git, vendor, dist, build
+                  and .cache, which are skipped by default
+  --large-tree   For huge trees: pick a top-level folder first, then sort within it
+  --no-tree      Classify into a generic category without reading the folder tree
+  --trace-id ID  Trace ID for the API request and logs (env SORTPATH_TRACE_ID)
+  --trace-header NAME  Header carrying the trace ID (default traceparent)
+  --rule TEXT    Add TEXT as a rule in the prompt's instructions; repeat for
+                 several. Replaces the extra-rules config key for the run
+  --prompt-template FILE  Build the prompt from FILE, a text/template using
+                    {{.Tree}}, {{.Description}}, {{.Date}}, {{.Time}}
+                    (config key prompt-template)
+  --minimal-prompt  Send only the tree, description and a one-line instruction
+                    (for fine-tuned models; config key prompt-style)
+  --response-format FORMAT  Ask for the answer as xml (default) or json, using
+                    the provider's JSON mode (config key response-format)
+  --from-file PATH  Describe PATH from its name, type and size
+  --preview-bytes N  With --from-file, include up to N bytes of text content
+                     (secrets redacted, binary files skipped, max 4096)
+  --move FILE    Move FILE into the recommended folder under the tree,
+                 creating it if needed; a taken name gets a suffix like " (1)"
+  --copy FILE    Like --move, but copy FILE and leave it in place
+  --dry-run      With --move or --copy, only print the plan, TAB-separated:
+                 MKDIR<TAB>dir when the folder would be created, then
+                 MOVE|COPY<TAB>src<TAB>dst
+  --record-dataset FILE  Append each recommendation to FILE as JSONL
+                         (description, tree, path, reason; secrets redacted)
+  --dataset-tree-ref  With --record-dataset, store trees in FILE.trees by hash
+  --batch        Sort every argument on its own, reading the folder tree once;
+                 a file path is described like --from-file and - reads one
+                 item per line from stdin. Prints input<TAB>path per item;
+                 a failed item is reported on stderr and the rest still run,
+                 ending with a non-zero exit code
+  --stdin        Read descriptions or file paths from stdin, one per line,
+                 printing input<TAB>path for each; used automatically when
+                 input is piped and no description is given
+  --concurrency N  With --batch, query up to N items at once; output keeps
+                 the input order (default 1)
+  --budget N     With --batch, stop once the run has used more than N tokens
+                 (exit code 3)
+  --strict-xml   Only accept a path inside a complete <recommendation>; an
+                 answer without any path is always an error (retried)
+  --strict       Fail (exit code 7) when the recommended path is not in the
+                 folder tree, instead of marking it [unverified]
+  --pick         Choose the folder from a filterable list of the tree, with
+                 the model's suggestion as the default; without a description
+                 the model isn't asked (alias --select-interactive)
+  --json         Print results as JSON lines, and a fatal error as
+                 {"error": "..."}; notices always go to stderr
+  --json-schema  Print the JSON Schema describing --json results, then exit
+  --quiet        Hide notices; only results and errors are printed
+  --format FORMAT  Print results as full (path and reason, default), path
+                 (the bare path, one per line) or json (same as --json)
+  -q             For scripts: only the recommended path on stdout, no
+                 notices (--quiet --format path)
+  --no-color     Plain output without emoji markers (or set NO_COLOR)
+  --color WHEN   Color log lines by level (errors red, debug dim): auto
+                 (default; only on a terminal without NO_COLOR or
+                 --no-color), always or never. Log files stay plain
+  --show-url     Print the request URL (secrets masked) before calling the API
+  --show-usage   Print the tokens each query used (also logged at debug level)
+  --price-input PRICE  Price per 1,000 prompt tokens; adds a cost estimate
+  --price-output PRICE  Price per 1,000 completion tokens
+  --reset-install-prompt  Ask again about installing to PATH after a "no"
   -v, --version  Show version
 
-Config subcommands:
+Exit codes:
+  0  Success
+  1  Other failure, including invalid flags
+  2  Configuration error (missing API key, invalid setting)
+  3  --budget exceeded
+  4  API error (rejected key, rate limit, malformed answer)
+  5  Network error (connection failed, timeout)
+  6  File system error (tree, --from-file, --move/--copy, --log-file)
+  7  Invalid value
+  8  Install error
+
+Config subcommands (add --profile NAME to work on a profile):
+  config init           Set up api-key, api-base, model and tree-path step by step
   config set <key> <value>
-  config get <key>
+  config set --use-keychain api-key <value>
+                        Keep the API key in the macOS Keychain or the Secret
+                        Service (secret-tool) on Linux instead of the file
+  config get <key> [--effective]
   config remove <key>
-  config list
+  config list [--show-secrets]
+                        Print every setting; the api-key is masked unless
+                        --show-secrets is given
+  config list-profiles  List the profiles, marking the default
+  config diff [--json]  Show only the settings that differ from the defaults
+  config edit           Open the config file in $VISUAL or $EDITOR; an invalid
+                        result can be edited again and is never saved
+  config export [--redact]
+                        Print the config file as YAML; --redact masks API keys
+  config import [--force] <file>
+                        Check and save every setting in file; --force replaces
+                        an existing api-key
+  config validate       Show every effective value with its source and list every
+                        problem, without calling the API (exit code 2 if invalid)
 
 Install:
   install           Install the current binary to a PATH directory (default /usr/local/bin)
@@ -72,65 +297,213 @@ Install:
     --path PATH     Destination directory (must be on your PATH)
     --force         Overwrite existing binary if present
 
+Prompt test:
+  prompt-test       Render a prompt template against your tree without calling the API
+  Options:
+    --prompt-template FILE  Template using {{.Tree}}, {{.Description}}, {{.Date}}, {{.Time}}
+    --tree DIR              Folder to build the tree from
+
+Doctor:
+  doctor            Check the config, API endpoint and key, tree, environment and install
+  Options:
+    --profile NAME  Config profile to check
+    --tree DIR      Folder to check instead of the configured tree
+    --no-auth       Send no API key, for a keyless server that isn't on localhost
+
+Cache:
+  cache prune       Remove cached entries (%s)
+  Options:
+    --max-age DUR     Remove entries older than DUR (e.g. 7d, 12h)
+    --max-size BYTES  Remove the oldest entries until the rest fit (e.g. 100MB)
+  cache clear       Remove every cached entry
+
 Update:
     update            Update to the latest version from GitHub
     Options:
     --check-only    Only check for updates, don't install
-`, version)
+    --verify-signature  Require a valid minisign signature before installing
+    --force         Update even if a package manager or symlink owns the install
+    --version TAG   Install this release (e.g. v1.2.0) instead of the latest, even an older one
+    --quiet         Don't show download progress (hidden anyway when not on a terminal)
+    --channel NAME  stable (default) or beta, which includes prereleases
+                    (config key update-channel, env SORTPATH_UPDATE_CHANNEL)
+    --insecure-skip-checksum  Install a release that publishes no checksum,
+                    e.g. a rollback to one older than the first checksummed
+                    release, without verifying the download
+`, version, config.DefaultConfigPath(), config.CacheDir())
 }
 
 func HandleConfigCommand(args []string) {
+    var profile string
+    args, err := TakeConfigFlag(args)
+    if err == nil {
+        args, profile, err = takeProfileFlag(args)
+    }
+    if err != nil {
+        out.Error("❌ %v\n", err)
+        os.Exit(1)
+    }
     if len(args) < 1 {
         PrintHelp("dev")
         return
     }
+    opts := config.CLIOptions{Profile: profile}
     switch args[0] {
     case "set":
+        args, useKeychain := takeUseKeychainFlag(args)
         if len(args) != 3 {
-            fmt.Println("Usage: sortpath config set <key> <value>")
+            out.Error("Usage: sortpath config set [--profile NAME] [--use-keychain] <key> <value>\n")
             return
         }
-        err := setConfigValue(args[1], args[2])
+        set := setProfileValue
+        if useKeychain {
+            set = setKeychainValue
+        }
+        err := set(profile, args[1], args[2])
         if err != nil {
-            fmt.Fprintf(os.Stderr, "❌ Config set error: %v\n", err)
+            out.Error("❌ Config set error: %v\n", err)
             os.Exit(1)
         }
     case "get":
-        if len(args) != 2 {
-            fmt.Println("Usage: sortpath config get <key>")
+        key, effective := parseGetArgs(args[1:])
+        if key == "" {
+            out.Error("Usage: sortpath config get [--profile NAME] <key> [--effective]\n")
             return
         }
-        val, err := getConfigValue(args[1])
+        get := getConfigValue
+        switch {
+        case effective:
+            get = func(key string) (string, error) { return effectiveConfigValue(opts, key) }
+        case profile != "":
+            get = func(key string) (string, error) { return getProfileValue(profile, key) }
+        }
+        val, err := get(key)
         if err != nil {
-            fmt.Fprintf(os.Stderr, "❌ Config get error: %v\n", err)
+            out.Error("❌ Config get error: %v\n", err)
             os.Exit(1)
         }
-        fmt.Println(val)
+        out.Result("%s\n", val)
     case "remove":
         if len(args) != 2 {
-            fmt.Println("Usage: sortpath config remove <key>")
+            out.Error("Usage: sortpath config remove [--profile NAME] <key>\n")
             return
         }
-        err := removeConfigValue(args[1])
+        err := removeProfileValue(profile, args[1])
         if err != nil {
-            fmt.Fprintf(os.Stderr, "❌ Config remove error: %v\n", err)
+            out.Error("❌ Config remove error: %v\n", err)
             os.Exit(1)
         }
     case "list":
+        showSecrets := len(args) == 2 && (args[1] == "--show-secrets" || args[1] == "-show-secrets")
+        if len(args) > 1 && !showSecrets {
+            out.Error("Usage: sortpath config list [--profile NAME] [--show-secrets]\n")
+            return
+        }
         conf, err := config.Load()
         if err != nil {
-            fmt.Fprintf(os.Stderr, "❌ Config list error: %v\n", err)
+            out.Error("❌ Config list error: %v\n", err)
             os.Exit(1)
         }
-        configMap := map[string]string{
-            "api-key":   config.RedactSensitiveValue("api-key", conf.APIKey),
-            "api-base":  conf.APIBase,
-            "model":     conf.Model,
-            "tree-path": conf.TreePath,
-            "log-level": conf.LogLevel,
+        key, _ := config.DefaultSecretStore.GetSecret(config.ProfileSecretName(profile))
+        if profile != "" {
+            p, ok := conf.Profiles[profile]
+            if !ok {
+                out.Error("❌ Config list error: unknown profile '%s'\n", profile)
+                os.Exit(1)
+            }
+            p.APIKey = key
+            writeConfigList(out.Results(), &p, showSecrets)
+            return
+        }
+        conf.APIKey = key
+        writeConfigList(out.Results(), conf, showSecrets)
+    case "init":
+        if len(args) != 1 {
+            out.Error("Usage: sortpath config init [--profile NAME]\n")
+            return
         }
-        for k, v := range configMap {
-            fmt.Printf("%s: %s\n", k, v)
+        if err := initConfig(profile); err != nil {
+            out.Error("❌ %v\n", err)
+            os.Exit(1)
+        }
+    case "list-profiles":
+        conf, err := config.Load()
+        if err != nil {
+            out.Error("❌ Config list error: %v\n", err)
+            os.Exit(1)
+        }
+        writeProfileList(out.Results(), conf)
+    case "validate":
+        if len(args) != 1 {
+            out.Error("Usage: sortpath config validate\n")
+            return
+        }
+        conf, sources, err := validateConfig(opts)
+        writeConfigSources(out.Results(), sources)
+        if err != nil {
+            problems := config.Problems(err)
+            noun := "problems"
+            if len(problems) == 1 {
+                noun = "problem"
+            }
+            out.Error("\n%d config %s:\n", len(problems), noun)
+            for _, p := range problems {
+                out.Error("%s\n", apperrors.FormatUserError(problemError(conf, p)))
+            }
+            os.Exit(apperrors.ExitConfig)
+        }
+        out.Result("\n✅ Configuration is valid\n")
+    case "edit":
+        if len(args) != 1 {
+            out.Error("Usage: sortpath config edit\n")
+            return
+        }
+        if err := editConfig(); err != nil {
+            out.Error("❌ Config edit error: %v\n", err)
+            os.Exit(apperrors.ExitConfig)
+        }
+    case "export":
+        redact := len(args) == 2 && (args[1] == "--redact" || args[1] == "-redact")
+        if len(args) > 1 && !redact {
+            out.Error("Usage: sortpath config export [--redact]\n")
+            return
+        }
+        if err := exportConfig(out.Results(), redact); err != nil {
+            out.Error("❌ Config export error: %v\n", err)
+            os.Exit(1)
+        }
+    case "import":
+        path, force := parseImportArgs(args[1:])
+        if path == "" {
+            out.Error("Usage: sortpath config import [--force] <file>\n")
+            return
+        }
+        res, err := importConfig(path, force)
+        if err != nil {
+            out.Error("❌ Config import error: %v\n", err)
+            os.Exit(apperrors.ExitConfig)
+        }
+        noun := "settings"
+        if res.Imported == 1 {
+            noun = "setting"
+        }
+        out.Result("✅ Imported %d %s from %s\n", res.Imported, noun, path)
+        if res.SkippedKeys > 0 {
+            out.Result("The file's api-key is redacted and was not imported. Set it with: sortpath config set api-key YOUR_KEY\n")
+        }
+    case "diff":
+        asJSON := len(args) == 2 && (args[1] == "--json" || args[1] == "-json")
+        if len(args) > 1 && !asJSON {
+            out.Error("Usage: sortpath config diff [--json]\n")
+            return
+        }
+        diffs, err := configDiff(opts)
+        if err == nil {
+            err = writeConfigDiff(out.Results(), diffs, asJSON)
+        }
+        if err != nil {
+            out.Error("❌ Config diff error: %v\n", err)
+            os.Exit(1)
         }
     default:
         PrintHelp("dev")
@@ -143,19 +516,19 @@ func HandleInstallCommand(args []string) {
     fs := flag.NewFlagSet("install", flag.ContinueOnError)
     fs.StringVar(&destDir, "path", "/usr/local/bin", "Destination directory (must be on PATH)")
     fs.BoolVar(&force, "force", false, "Overwrite existing binary if present")
-    fs.SetOutput(os.Stderr)
+    fs.SetOutput(out.Errors())
     _ = fs.Parse(args)
 
     srcPath, err := os.Executable()
     if err != nil {
-        fmt.Fprintf(os.Stderr, "❌ Cannot determine current executable path: %v\n", err)
+        out.Error("❌ Cannot determine current executable path: %v\n", err)
         os.Exit(1)
     }
 
     destPath := filepath.Join(destDir, "sortpath")
     if !force {
         if _, err := os.Stat(destPath); err == nil {
-            fmt.Fprintf(os.Stderr, "⚠️ Destination already has sortpath: %s (use --force to overwrite)\n", destPath)
+            out.Error("⚠️ Destination already has sortpath: %s (use --force to overwrite)\n", destPath)
             os.Exit(1)
         }
     }
@@ -165,83 +538,156 @@ func HandleInstallCommand(args []string) {
         if errors.Is(err, os.ErrPermission) || strings.Contains(strings.ToLower(err.Error()), "permission denied") {
             fallbackDir := userBinFallbackDir()
             if fallbackDir == "" {
-                fmt.Fprintf(os.Stderr, "Install failed: %v\n", err)
-                fmt.Fprintf(os.Stderr, "Try: sudo cp %q %q\n", srcPath, destPath)
+                out.Error("Install failed: %v\n", err)
+                out.Error("Try: sudo cp %q %q\n", srcPath, destPath)
                 os.Exit(1)
             }
             _ = os.MkdirAll(fallbackDir, 0755)
             userDest := filepath.Join(fallbackDir, "sortpath")
             if err2 := copyFile(srcPath, userDest); err2 != nil {
-                fmt.Fprintf(os.Stderr, "Install failed: %v\n", err)
-                fmt.Fprintf(os.Stderr, "Also failed to install to %s: %v\n", userDest, err2)
-                fmt.Fprintf(os.Stderr, "Try: sudo cp %q %q\n", srcPath, destPath)
+                out.Error("Install failed: %v\n", err)
+                out.Error("Also failed to install to %s: %v\n", userDest, err2)
+                out.Error("Try: sudo cp %q %q\n", srcPath, destPath)
                 os.Exit(1)
             }
             _ = os.Chmod(userDest, 0755)
+            recordInstall(userDest)
 
             // Ensure PATH contains fallbackDir; if not, attempt to add to shell profile
             if !pathContainsDir(fallbackDir) {
                 profilePath, added, addErr := addDirToShellPATH(fallbackDir)
                 if addErr == nil && added {
-                    fmt.Printf("Installed sortpath to %s and added it to PATH in %s. Restart your shell or run: source %s\n", userDest, profilePath, profilePath)
+                    out.Result("Installed sortpath to %s and added it to PATH in %s. Restart your shell or run: source %s\n", userDest, profilePath, profilePath)
                 } else {
-                    fmt.Printf("Installed sortpath to %s. Add it to your PATH by adding this to your shell profile:\n\n    export PATH=\"%s:$PATH\"\n\nThen restart your terminal.\n", userDest, fallbackDir)
+                    out.Result("Installed sortpath to %s. Add it to your PATH by adding this to your shell profile:\n\n    export PATH=\"%s:$PATH\"\n\nThen restart your terminal.\n", userDest, fallbackDir)
                 }
             } else {
-                fmt.Printf("✅ Installed sortpath to %s\n", userDest)
+                out.Result("✅ Installed sortpath to %s\n", userDest)
             }
             return
         }
-        fmt.Fprintf(os.Stderr, "Install failed: %v\n", err)
-        fmt.Fprintf(os.Stderr, "Try: sudo cp %q %q\n", srcPath, destPath)
+        out.Error("Install failed: %v\n", err)
+        out.Error("Try: sudo cp %q %q\n", srcPath, destPath)
         os.Exit(1)
     }
     // Make executable
     _ = os.Chmod(destPath, 0755)
+    recordInstall(destPath)
 
     // Installation complete
-    fmt.Printf("✅ Installed sortpath to %s\n", destPath)
+    out.Result("✅ Installed sortpath to %s\n", destPath)
+}
+
+// recordInstall saves where sortpath was installed, so `sortpath update`
+// recognizes that binary as its own
+func recordInstall(path string) {
+    err := config.Update(func(c *config.Config) error {
+        c.InstalledPath = path
+        return nil
+    })
+    if err != nil {
+        out.Diagnostic("⚠️ Could not record the install location: %v\n", err)
+    }
 }
 
 func HandleUpdateCommand(args []string, currentVersion string) {
-    var checkOnly bool
+    var checkOnly, verifySignature, force, quiet, skipChecksum bool
+    var tag, channel string
     fs := flag.NewFlagSet("update", flag.ContinueOnError)
     fs.BoolVar(&checkOnly, "check-only", false, "Only check for updates, don't install")
-    fs.SetOutput(os.Stderr)
+    fs.BoolVar(&verifySignature, "verify-signature", false, "Require a valid minisign signature before installing")
+    fs.BoolVar(&force, "force", false, "Update even if a package manager or symlink appears to own the install")
+    fs.StringVar(&tag, "version", "", "Install this release (e.g. v1.2.0) instead of the latest, even an older one")
+    fs.BoolVar(&quiet, "quiet", false, "Don't show download progress")
+    fs.StringVar(&channel, "channel", "", "Release channel: stable (default) or beta, which includes prereleases")
+    fs.BoolVar(&skipChecksum, "insecure-skip-checksum", false, "Install a release that publishes no checksum without verifying it")
+    fs.SetOutput(out.Errors())
     _ = fs.Parse(args)
 
-    release, err := updater.CheckLatestRelease()
+    pinned := tag != "" && tag != updater.LatestRelease
+    if pinned && channel != "" {
+        out.Error("❌ --version and --channel cannot be combined\n")
+        os.Exit(1)
+    }
+    if !pinned {
+        // --channel beats SORTPATH_UPDATE_CHANNEL and the update-channel key
+        channel = config.ResolveConfigUnvalidated(config.CLIOptions{UpdateChannel: channel}).UpdateChannel
+        if err := config.ValidateUpdateChannel(channel); err != nil {
+            out.Error("❌ %v\n", err)
+            os.Exit(1)
+        }
+        tag = channelTag(channel)
+    }
+    release, err := updater.FetchRelease(tag)
     if err != nil {
-        fmt.Fprintf(os.Stderr, "❌ Failed to check for updates: %v\n", err)
+        out.Error("❌ Failed to check for updates: %v\n", err)
         os.Exit(1)
     }
 
     if release.Version == currentVersion {
-        fmt.Printf("✅ You are already running the latest version: %s\n", currentVersion)
+        if pinned {
+            out.Result("✅ You are already running version %s\n", currentVersion)
+        } else {
+            out.Result("✅ You are already running the latest version: %s\n", currentVersion)
+        }
         return
     }
 
-    header, instruction := updater.FormatUpdateNotification(release.Version, currentVersion, false)
-    fmt.Println(header)
+    if pinned {
+        // A pinned version may be older: it is a rollback, not an update
+        out.Result("📌 Version %s found (current: %s)\n", release.Version, currentVersion)
+        if checkOnly {
+            out.Result("Run 'sortpath update --version %s' to install it\n", release.Version)
+            return
+        }
+    } else {
+        header, instruction := updater.FormatUpdateNotification(release.Version, currentVersion, false)
+        out.Result("%s\n", header)
+        if release.Prerelease {
+            out.Diagnostic("⚠️ %s is a prerelease from the beta channel\n", release.Version)
+        }
 
-    if checkOnly {
-        fmt.Println(instruction)
-        return
+        if checkOnly {
+            out.Result("%s\n", instruction)
+            return
+        }
     }
 
     if !updater.IsInstalled() {
-        fmt.Fprintf(os.Stderr, "❌ Error: sortpath was not installed via the install command.\n")
-        fmt.Fprintf(os.Stderr, "Please reinstall manually or run 'sortpath install' first.\n")
+        out.Error("❌ Error: sortpath was not installed via the install command.\n")
+        out.Error("Please reinstall manually or run 'sortpath install' first.\n")
         os.Exit(1)
     }
 
-    fmt.Printf("📦 Downloading and installing version %s...\n", release.Version)
-    if err := updater.UpdateBinary(release); err != nil {
-        fmt.Fprintf(os.Stderr, "❌ Failed to install update: %v\n", err)
+    check, err := updater.CheckCurrentInstall()
+    if err == nil {
+        err = confirmUpdate(check, force)
+    }
+    if err != nil {
+        out.Error("❌ %v\n", err)
         os.Exit(1)
     }
 
-    fmt.Printf("✅ Successfully updated to version %s!\n", release.Version)
+    if release.ChecksumURL == "" {
+        // Releases from before checksums were published can't be verified;
+        // rolling back to one takes an explicit, loudly warned opt-in
+        if !skipChecksum {
+            out.Error("❌ Release %s publishes no checksum, so the download can't be verified.\n", release.Version)
+            out.Error("Rerun with --insecure-skip-checksum to install it anyway.\n")
+            os.Exit(1)
+        }
+        out.Error("⚠️ WARNING: release %s publishes no checksum. Installing it WITHOUT verifying the download.\n", release.Version)
+    }
+
+    out.Diagnostic("📦 Downloading and installing version %s...\n", release.Version)
+    opts := updateOptions(verifySignature, quiet)
+    opts.SkipMissingChecksum = skipChecksum
+    if err := updater.UpdateBinaryWithOptions(release, opts); err != nil {
+        out.Error("❌ Failed to install update: %v\n", err)
+        os.Exit(1)
+    }
+
+    out.Result("✅ Successfully updated to version %s!\n", release.Version)
 }
 
 func copyFile(src, dst string) error {
@@ -267,11 +713,7 @@ func copyFile(src, dst string) error {
 }
 
 func userHomeDir() string {
-    h, err := os.UserHomeDir()
-    if err != nil {
-        return os.Getenv("HOME")
-    }
-    return h
+    return config.HomeDir()
 }
 
 func userBinFallbackDir() string {
@@ -298,93 +740,332 @@ func pathContainsDir(dir string) bool {
 }
 
 func setConfigValue(key, value string) error {
+    return setProfileValue("", key, value)
+}
+
+// setProfileValue validates and stores value under key, in the named profile
+// when profile is set and at the top level otherwise
+func setProfileValue(profile, key, value string) error {
+    sanitizedValue, err := checkConfigValue(key, value)
+    if err != nil {
+        return err
+    }
+    if key == config.APIKeySecret {
+        // Secrets live in the secret store, not necessarily the config file
+        return setSecret(config.ProfileSecretName(profile), sanitizedValue)
+    }
+
+    // Load-modify-save under the config lock so concurrent writers don't clobber each other
+    return config.Update(func(c *config.Config) error {
+        if profile != "" {
+            return c.SetProfileValue(profile, key, sanitizedValue)
+        }
+        return c.SetValue(key, sanitizedValue)
+    })
+}
+
+// takeUseKeychainFlag removes --use-keychain from config set arguments
+func takeUseKeychainFlag(args []string) ([]string, bool) {
+    rest := make([]string, 0, len(args))
+    found := false
+    for _, a := range args {
+        if a == "--use-keychain" || a == "-use-keychain" {
+            found = true
+            continue
+        }
+        rest = append(rest, a)
+    }
+    return rest, found
+}
+
+// setKeychainValue stores the api-key, the top-level one or the named
+// profile's, in the OS keychain, leaving a sentinel in the config file.
+// Without a usable keychain it warns and stores the key in the config file
+// as usual.
+func setKeychainValue(profile, key, value string) error {
+    if key != config.APIKeySecret {
+        return fmt.Errorf("--use-keychain only applies to the api-key")
+    }
+    store, ok := config.DefaultSecretStore.(*config.FileSecretStore)
+    if !ok {
+        return setProfileValue(profile, key, value)
+    }
+    sanitizedValue, err := checkConfigValue(key, value)
+    if err != nil {
+        return err
+    }
+    name := config.ProfileSecretName(profile)
+    err = store.SetSecretInKeychain(name, sanitizedValue)
+    if !errors.Is(err, config.ErrKeychainUnavailable) {
+        return err
+    }
+    fmt.Fprintf(notices, "⚠️ No keychain is available; storing the api-key in the config file instead\n")
+    return store.SetSecretInFile(name, sanitizedValue)
+}
+
+// setSecret stores the named secret in the secret store. When it is kept in
+// a keychain that is no longer available, it warns like setKeychainValue
+// and stores the secret in the config file instead.
+func setSecret(name, value string) error {
+    err := config.DefaultSecretStore.SetSecret(name, value)
+    store, ok := config.DefaultSecretStore.(*config.FileSecretStore)
+    if !ok || !errors.Is(err, config.ErrKeychainUnavailable) {
+        return err
+    }
+    fmt.Fprintf(notices, "⚠️ No keychain is available; storing the api-key in the config file instead\n")
+    return store.SetSecretInFile(name, value)
+}
+
+// deleteSecret removes the named secret from the secret store. When it is
+// kept in a keychain that is no longer available, it warns that the keychain
+// item stays and removes the secret from the config file only.
+func deleteSecret(name string) error {
+    err := config.DefaultSecretStore.DeleteSecret(name)
+    store, ok := config.DefaultSecretStore.(*config.FileSecretStore)
+    if !ok || !errors.Is(err, config.ErrKeychainUnavailable) {
+        return err
+    }
+    fmt.Fprintf(notices, "⚠️ No keychain is available; removing the api-key from the config file, but its keychain item stays\n")
+    return store.SetSecretInFile(name, "")
+}
+
+// checkConfigValue validates value for key the way `config set` does and
+// returns it sanitized
+func checkConfigValue(key, value string) (string, error) {
     // Validate the config key first
     if err := config.ValidateConfigKey(key); err != nil {
-        return err
+        return "", err
     }
 
     // Sanitize the value
     sanitizedValue, err := config.SanitizeConfigValue(key, value)
     if err != nil {
-        return err
+        return "", err
     }
 
-    c, _ := config.Load()
-    
-    // Set the sanitized value
+    // Validate the sanitized value
     switch key {
     case "api-key":
         if sanitizedValue == "" {
-            return fmt.Errorf("API key cannot be empty")
+            return "", fmt.Errorf("API key cannot be empty")
         }
-        c.APIKey = sanitizedValue
     case "api-base":
         if sanitizedValue == "" {
-            return fmt.Errorf("API base URL cannot be empty")
+            return "", fmt.Errorf("API base URL cannot be empty")
         }
         // Additional URL validation
         if _, err := url.Parse(sanitizedValue); err != nil {
-            return fmt.Errorf("invalid API base URL '%s': %v. Use format: https://api.openai.com/v1", sanitizedValue, err)
+            return "", fmt.Errorf("invalid API base URL '%s': %v. Use format: https://api.openai.com/v1", sanitizedValue, err)
         }
-        c.APIBase = sanitizedValue
     case "model":
         if sanitizedValue == "" {
-            return fmt.Errorf("model cannot be empty")
+            return "", fmt.Errorf("model cannot be empty")
         }
-        c.Model = sanitizedValue
     case "tree-path":
         if sanitizedValue != "" && sanitizedValue != "." {
             // Validate path exists and is readable
             if _, err := os.Stat(sanitizedValue); err != nil {
                 if os.IsNotExist(err) {
-                    return fmt.Errorf("tree path '%s' does not exist. Use an existing directory path", sanitizedValue)
+                    return "", fmt.Errorf("tree path '%s' does not exist. Use an existing directory path", sanitizedValue)
                 }
-                return fmt.Errorf("cannot access tree path '%s': %v", sanitizedValue, err)
+                return "", fmt.Errorf("cannot access tree path '%s': %v", sanitizedValue, err)
             }
         }
-        c.TreePath = sanitizedValue
-    case "log-level":
-        c.LogLevel = sanitizedValue
     }
-    
-    return config.Save(c)
+    return sanitizedValue, nil
+}
+
+// configValues is a set of settings keyed by config.ConfigKeys: the
+// top-level config or a profile
+type configValues interface {
+    Value(key string) (string, error)
+}
+
+// writeConfigList prints every config key in config.ConfigKeys order with
+// values aligned in a single column. Sensitive values are redacted unless
+// showSecrets is set.
+func writeConfigList(w io.Writer, c configValues, showSecrets bool) {
+    width := 0
+    for _, k := range config.ConfigKeys {
+        if len(k) > width {
+            width = len(k)
+        }
+    }
+    for _, k := range config.ConfigKeys {
+        v, _ := c.Value(k)
+        // Multi-line values (extra-rules) stay on one line, as config set accepts them
+        v = strings.ReplaceAll(v, "\n", `\n`)
+        if !showSecrets {
+            v = config.RedactSensitiveValue(k, v)
+        }
+        line := fmt.Sprintf("%-*s %s", width+1, k+":", v)
+        fmt.Fprintln(w, strings.TrimRight(line, " "))
+    }
 }
 
 func getConfigValue(key string) (string, error) {
+    if key == config.APIKeySecret {
+        return config.DefaultSecretStore.GetSecret(config.APIKeySecret)
+    }
     c, _ := config.Load()
-    switch key {
-    case "api-key":
-        return c.APIKey, nil
-    case "api-base":
-        return c.APIBase, nil
-    case "model":
-        return c.Model, nil
-    case "tree-path":
-        return c.TreePath, nil
-    case "log-level":
-        return c.LogLevel, nil
-    default:
-        return "", fmt.Errorf("unknown config key: %s", key)
+    return c.Value(key)
+}
+
+// getProfileValue returns key as stored in the named profile, without
+// falling back to the top-level value
+func getProfileValue(profile, key string) (string, error) {
+    if err := config.ValidateConfigKey(key); err != nil {
+        return "", err
     }
+    c, err := config.Load()
+    if err != nil {
+        return "", err
+    }
+    p, ok := c.Profiles[profile]
+    if !ok {
+        return "", fmt.Errorf("unknown profile '%s'", profile)
+    }
+    if key == config.APIKeySecret {
+        return config.DefaultSecretStore.GetSecret(config.ProfileSecretName(profile))
+    }
+    return p.Value(key)
+}
+
+// parseGetArgs extracts the key and --effective flag from `config get` args,
+// accepting the flag on either side of the key
+func parseGetArgs(args []string) (key string, effective bool) {
+    var keys []string
+    for _, a := range args {
+        if a == "--effective" || a == "-effective" {
+            effective = true
+            continue
+        }
+        keys = append(keys, a)
+    }
+    if len(keys) != 1 {
+        return "", effective
+    }
+    return keys[0], effective
+}
+
+// effectiveConfigValue returns the value a run would use for key after
+// CLI > ENV > file > defaults resolution, annotated with its source. The
+// api-key is redacted.
+func effectiveConfigValue(opts config.CLIOptions, key string) (string, error) {
+    if err := config.ValidateConfigKey(key); err != nil {
+        return "", err
+    }
+    // Validation errors don't matter here; sources are reported regardless
+    _, sources, err := config.ResolveConfigWithSources(opts, config.NewFileLoader(), config.DefaultSecretStore)
+    for _, s := range sources {
+        if s.Key == key {
+            return fmt.Sprintf("%s (source: %s)", s.Value, s.Source), nil
+        }
+    }
+    if err != nil {
+        return "", err
+    }
+    return "", fmt.Errorf("unknown config key: %s", key)
+}
+
+// validateConfig checks the effective configuration without calling the
+// API and reports every problem at once as a *config.MultiError, along
+// with the config and where each value came from
+func validateConfig(opts config.CLIOptions) (*config.Config, []config.FieldSource, error) {
+    conf, sources, problems := config.CheckConfig(opts)
+    if len(problems) == 0 {
+        return conf, sources, nil
+    }
+    return conf, sources, &config.MultiError{Errors: problems}
+}
+
+// writeConfigSources prints each resolved value, secrets redacted, with the
+// layer it came from
+func writeConfigSources(w io.Writer, sources []config.FieldSource) {
+    width := 0
+    for _, s := range sources {
+        if len(s.Key) > width {
+            width = len(s.Key)
+        }
+    }
+    for _, s := range sources {
+        fmt.Fprintf(w, "%-*s %s (source: %s)\n", width+1, s.Key+":", s.Value, s.Source)
+    }
+}
+
+// problemError turns a config problem into an AppError, so FormatUserError
+// can add a suggestion: tree path problems are file system errors on the
+// tree, the rest config errors
+func problemError(conf *config.Config, problem error) error {
+    var fieldErr *config.FieldError
+    if !errors.As(problem, &fieldErr) {
+        return apperrors.ConfigError(problem.Error(), nil)
+    }
+    msg := fieldErr.Key + ": " + fieldErr.Error()
+    if fieldErr.Key == "tree-path" {
+        return apperrors.FSError(msg, conf.TreePath, nil)
+    }
+    return apperrors.ConfigError(msg, nil)
+}
+
+// configDiff resolves the effective config like a run would and returns the
+// keys that differ from the defaults. The api-key is redacted.
+func configDiff(opts config.CLIOptions) ([]config.FieldDiff, error) {
+    // Validation errors don't matter here; sources are reported regardless
+    _, sources, _ := config.ResolveConfigWithSources(opts, config.NewFileLoader(), config.DefaultSecretStore)
+    if sources == nil {
+        return nil, fmt.Errorf("could not resolve configuration")
+    }
+    return config.DiffFromDefaults(sources), nil
+}
+
+// writeConfigDiff prints diffs as aligned text, or as a JSON array when asJSON is set
+func writeConfigDiff(w io.Writer, diffs []config.FieldDiff, asJSON bool) error {
+    if asJSON {
+        if diffs == nil {
+            diffs = []config.FieldDiff{}
+        }
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(diffs)
+    }
+    if len(diffs) == 0 {
+        _, err := fmt.Fprintln(w, "All settings match the defaults")
+        return err
+    }
+    width := 0
+    for _, d := range diffs {
+        if len(d.Key) > width {
+            width = len(d.Key)
+        }
+    }
+    for _, d := range diffs {
+        if _, err := fmt.Fprintf(w, "%-*s %s (default: %s, source: %s)\n", width+1, d.Key+":", d.Value, d.Default, d.Source); err != nil {
+            return err
+        }
+    }
+    return nil
 }
 
 func removeConfigValue(key string) error {
-    c, _ := config.Load()
-    switch key {
-    case "api-key":
-        c.APIKey = ""
-    case "api-base":
-        c.APIBase = ""
-    case "model":
-        c.Model = ""
-    case "tree-path":
-        c.TreePath = ""
-    case "log-level":
-        c.LogLevel = ""
-    default:
-        return fmt.Errorf("unknown config key: %s", key)
+    return removeProfileValue("", key)
+}
+
+// removeProfileValue clears key in the named profile, or at the top level
+// when profile is empty
+func removeProfileValue(profile, key string) error {
+    if key == config.APIKeySecret {
+        return deleteSecret(config.ProfileSecretName(profile))
+    }
+    if err := config.ValidateConfigKey(key); err != nil {
+        return err
     }
-    return config.Save(c)
+    return config.Update(func(c *config.Config) error {
+        if profile != "" {
+            return c.SetProfileValue(profile, key, "")
+        }
+        return c.SetValue(key, "")
+    })
 }
 
 func addDirToShellPATH(dir string) (profilePath string, added bool, err error) {
@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	"github.com/kacperkwapisz/sortpath/internal/ui"
 )
 
 func TestSetConfigValue_Validation(t *testing.T) {
@@ -213,6 +219,121 @@ func TestGetConfigValue(t *testing.T) {
 	}
 }
 
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantModel   string
+		wantExplain bool
+		wantDesc    string
+	}{
+		{
+			name:     "description only",
+			args:     []string{"quarterly", "report"},
+			wantDesc: "quarterly report",
+		},
+		{
+			name:      "flag with separate value",
+			args:      []string{"--model", "gpt-4", "Invoice PDF"},
+			wantModel: "gpt-4",
+			wantDesc:  "Invoice PDF",
+		},
+		{
+			name:        "bool flag without description",
+			args:        []string{"--explain-tree"},
+			wantExplain: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, desc := ParseArgs(tt.args)
+			if opts.Model != tt.wantModel {
+				t.Errorf("ParseArgs() Model = %q, want %q", opts.Model, tt.wantModel)
+			}
+			if opts.ExplainTree != tt.wantExplain {
+				t.Errorf("ParseArgs() ExplainTree = %v, want %v", opts.ExplainTree, tt.wantExplain)
+			}
+			if desc != tt.wantDesc {
+				t.Errorf("ParseArgs() desc = %q, want %q", desc, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestParseArgs_ScriptFlag(t *testing.T) {
+	opts, desc := ParseArgs([]string{"-q", "Berlin trip"})
+	if !opts.Quiet || opts.Format != FormatPath || desc != "Berlin trip" {
+		t.Errorf("-q gave Quiet=%v Format=%q desc=%q", opts.Quiet, opts.Format, desc)
+	}
+
+	// An explicit format or --json wins over -q's path format
+	if opts, _ := ParseArgs([]string{"-q", "--format", "full", "x"}); opts.Format != FormatFull {
+		t.Errorf("-q --format full gave Format=%q", opts.Format)
+	}
+	if opts, _ := ParseArgs([]string{"-q", "--json", "x"}); opts.Format != "" || !opts.JSON {
+		t.Errorf("-q --json gave Format=%q JSON=%v", opts.Format, opts.JSON)
+	}
+}
+
+func TestWriteConfigList_Ordered(t *testing.T) {
+	c := &config.Config{
+		APIKey:   "sk-test-1234567890",
+		APIBase:  "https://api.openai.com/v1",
+		Model:    "gpt-4",
+		TreePath: "/data/archive",
+		LogLevel: "debug",
+	}
+
+	want := "api-key:            sk-t...7890\n" +
+		"api-base:           https://api.openai.com/v1\n" +
+		"model:              gpt-4\n" +
+		"tree-path:          /data/archive\n" +
+		"log-level:          debug\n" +
+		"on-missing-tree:\n" +
+		"prompt-style:\n" +
+		"response-format:\n" +
+		"pinned-cert-sha256:\n" +
+		"provider:\n" +
+		"temperature:\n" +
+		"max-tokens:\n" +
+		"timeout:\n" +
+		"tree-depth:\n" +
+		"tree-max-bytes:\n" +
+		"profile:\n" +
+		"update-channel:\n" +
+		"prompt-template:\n" +
+		"extra-rules:\n" +
+		"log-format:\n" +
+		"log-file:\n"
+
+	// Run several times to catch any map-order dependence
+	for i := 0; i < 5; i++ {
+		var buf bytes.Buffer
+		writeConfigList(&buf, c, false)
+		if got := buf.String(); got != want {
+			t.Fatalf("writeConfigList() =\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func TestWriteConfigList_ShowSecrets(t *testing.T) {
+	const key = "sk-secret-1234567890abcdef"
+	c := &config.Config{APIKey: key, Model: "gpt-4"}
+
+	var masked bytes.Buffer
+	writeConfigList(&masked, c, false)
+	if strings.Contains(masked.String(), key) || !strings.Contains(masked.String(), "api-key:            sk-s...cdef\n") {
+		t.Errorf("writeConfigList() without --show-secrets =\n%s\nwant the api-key masked", masked.String())
+	}
+
+	var shown bytes.Buffer
+	writeConfigList(&shown, c, true)
+	if !strings.Contains(shown.String(), "api-key:            "+key+"\n") {
+		t.Errorf("writeConfigList() with --show-secrets =\n%s\nwant the full api-key", shown.String())
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || 
@@ -225,4 +346,267 @@ func contains(s, substr string) bool {
 			}
 			return false
 		}())))
-}
\ No newline at end of file
+}
+
+func TestEffectiveConfigValue_EnvOverridesFile(t *testing.T) {
+	home := isolateConfig(t)
+
+	loader := &config.FileLoader{ConfigPath: filepath.Join(home, ".config", "sortpath", "config.yaml")}
+	if err := loader.Save(&config.Config{APIKey: "sk-file-1234567890", Model: "file-model", TreePath: home}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OPENAI_MODEL", "env-model")
+
+	fileValue, err := getConfigValue("model")
+	if err != nil || fileValue != "file-model" {
+		t.Fatalf("getConfigValue() = %q, %v; want the file value", fileValue, err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "model", want: "env-model (source: env)"},
+		{key: "api-key", want: "sk-f...7890 (source: file)"},
+		{key: "log-level", want: "info (source: default)"},
+	}
+	for _, tt := range tests {
+		got, err := effectiveConfigValue(config.CLIOptions{}, tt.key)
+		if err != nil {
+			t.Fatalf("effectiveConfigValue(%q) error = %v", tt.key, err)
+		}
+		if got != tt.want {
+			t.Errorf("effectiveConfigValue(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	if _, err := effectiveConfigValue(config.CLIOptions{}, "unknown-key"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestParseGetArgs(t *testing.T) {
+	for _, args := range [][]string{{"model", "--effective"}, {"--effective", "model"}} {
+		key, effective := parseGetArgs(args)
+		if key != "model" || !effective {
+			t.Errorf("parseGetArgs(%v) = %q, %v", args, key, effective)
+		}
+	}
+	if key, _ := parseGetArgs([]string{"a", "b"}); key != "" {
+		t.Errorf("parseGetArgs() with two keys = %q, want empty", key)
+	}
+}
+
+func TestConfigDiff_OnlyNonDefaultFields(t *testing.T) {
+	home := isolateConfig(t)
+
+	loader := &config.FileLoader{ConfigPath: filepath.Join(home, ".config", "sortpath", "config.yaml")}
+	// log-level is set explicitly but to its default, so it must not show up
+	if err := loader.Save(&config.Config{APIKey: "sk-file-1234567890", LogLevel: "info", TreePath: "."}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OPENAI_MODEL", "gpt-4o")
+
+	diffs, err := configDiff(config.CLIOptions{})
+	if err != nil {
+		t.Fatalf("configDiff() error = %v", err)
+	}
+	want := []config.FieldDiff{
+		{Key: "api-key", Value: "sk-f...7890", Default: "(unset)", Source: "file"},
+		{Key: "model", Value: "gpt-4o", Default: "gpt-3.5-turbo", Source: "env"},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Fatalf("configDiff() = %+v, want %+v", diffs, want)
+	}
+
+	var text bytes.Buffer
+	if err := writeConfigDiff(&text, diffs, false); err != nil {
+		t.Fatal(err)
+	}
+	wantText := "api-key: sk-f...7890 (default: (unset), source: file)\n" +
+		"model:   gpt-4o (default: gpt-3.5-turbo, source: env)\n"
+	if got := text.String(); got != wantText {
+		t.Errorf("text diff =\n%s\nwant:\n%s", got, wantText)
+	}
+
+	var out bytes.Buffer
+	if err := writeConfigDiff(&out, diffs, true); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "1234567890") {
+		t.Errorf("JSON diff leaks the api-key: %s", out.String())
+	}
+	var decoded []config.FieldDiff
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON diff does not decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("JSON diff = %+v, want %+v", decoded, want)
+	}
+}
+
+func TestWriteConfigDiff_Empty(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeConfigDiff(&out, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "[]" {
+		t.Errorf("empty JSON diff = %q, want []", got)
+	}
+}
+
+func TestValidateConfig_ListsEveryProblem(t *testing.T) {
+	home := isolateConfig(t)
+	t.Setenv("OPENAI_API_BASE", "api.example.com")
+	t.Setenv("SORTPATH_LOG_LEVEL", "chatty")
+	t.Setenv("SORTPATH_FOLDER_TREE", home)
+
+	_, _, err := validateConfig(config.CLIOptions{})
+	if err == nil {
+		t.Fatal("validateConfig() = nil, want the config problems")
+	}
+	for _, want := range []string{"api-key: API key is required", "api-base: API base URL must use http or https", "log-level: invalid log level 'chatty'"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateConfig() missing %q:\n%v", want, err)
+		}
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-test-1234567890")
+	t.Setenv("OPENAI_API_BASE", "https://api.example.com/v1")
+	t.Setenv("SORTPATH_LOG_LEVEL", "")
+	if _, _, err := validateConfig(config.CLIOptions{}); err != nil {
+		t.Errorf("validateConfig() = %v for a valid config", err)
+	}
+}
+
+func TestValidateConfig_SourcesAndTree(t *testing.T) {
+	home := isolateConfig(t)
+	t.Setenv("OPENAI_API_KEY", "sk-test-1234567890")
+	notDir := filepath.Join(home, "tree.txt")
+	if err := os.WriteFile(notDir, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(home, ".config", "sortpath", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("model: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, sources, err := validateConfig(config.CLIOptions{TreePath: notDir})
+	problems := config.Problems(err)
+	if len(problems) != 2 {
+		t.Fatalf("validateConfig() problems = %v, want the YAML and tree path ones", problems)
+	}
+	if !strings.Contains(problems[0].Error(), "not valid YAML") {
+		t.Errorf("first problem = %v, want the invalid config file", problems[0])
+	}
+	treeErr := problemError(conf, problems[1])
+	if !apperrors.IsType(treeErr, "FS_ERROR") || !strings.Contains(treeErr.Error(), "tree-path: tree path '"+notDir+"' is not a directory") {
+		t.Errorf("tree problem = %v, want an FS_ERROR about the tree path", treeErr)
+	}
+
+	var buf bytes.Buffer
+	writeConfigSources(&buf, sources)
+	for _, want := range []string{"sk-t...7890 (source: env)", notDir + " (source: cli)", "model:", "(source: default)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("sources missing %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "sk-test-1234567890") {
+		t.Errorf("sources show the API key:\n%s", buf.String())
+	}
+}
+
+func TestTakeConfigFlag(t *testing.T) {
+	t.Cleanup(func() { config.SetConfigPath("") })
+	t.Setenv("SORTPATH_CONFIG", "")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantArgs []string
+		wantPath string
+		wantErr  bool
+	}{
+		{name: "separate value", args: []string{"--config", "a.yaml", "config", "list"}, wantArgs: []string{"config", "list"}, wantPath: "a.yaml"},
+		{name: "equals form", args: []string{"--config=b.yaml", "config", "list"}, wantArgs: []string{"config", "list"}, wantPath: "b.yaml"},
+		{name: "not leading", args: []string{"config", "list"}, wantArgs: []string{"config", "list"}},
+		{name: "missing value", args: []string{"--config"}, wantErr: true},
+		{name: "empty value", args: []string{"--config=", "list"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SetConfigPath("")
+			args, err := TakeConfigFlag(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TakeConfigFlag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if strings.Join(args, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("TakeConfigFlag() args = %q, want %q", args, tt.wantArgs)
+			}
+			if tt.wantPath != "" && config.ConfigPath() != tt.wantPath {
+				t.Errorf("ConfigPath() = %q, want %q", config.ConfigPath(), tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestHandleConfigCommand_ConfigFlag(t *testing.T) {
+	isolateConfig(t)
+	t.Cleanup(func() { config.SetConfigPath("") })
+	path := filepath.Join(t.TempDir(), "client.yaml")
+
+	HandleConfigCommand([]string{"--config", path, "set", "model", "client-model"})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("config set with --config did not write %s: %v", path, err)
+	}
+	if !strings.Contains(string(data), "client-model") {
+		t.Errorf("%s = %q, want the model set", path, data)
+	}
+}
+
+func TestHandleConfigCommand_Profiles(t *testing.T) {
+	isolateConfig(t)
+	t.Setenv("SORTPATH_PROFILE", "")
+	stdout, _ := useOutput(t, ui.Options{})
+
+	HandleConfigCommand([]string{"set", "--profile", "work", "model", "work-model"})
+	HandleConfigCommand([]string{"set", "--profile=personal", "api-key", "sk-personal-123456"})
+	HandleConfigCommand([]string{"set", "profile", "work"})
+
+	conf, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Profiles["work"].Model != "work-model" || conf.Profiles["personal"].APIKey != "sk-personal-123456" {
+		t.Fatalf("profiles = %+v", conf.Profiles)
+	}
+	if conf.Model != "" {
+		t.Errorf("setting a profile value changed the top level: model = %q", conf.Model)
+	}
+
+	stdout.Reset()
+	HandleConfigCommand([]string{"list-profiles"})
+	if got, want := stdout.String(), "personal\nwork (default)\n"; got != want {
+		t.Errorf("list-profiles = %q, want %q", got, want)
+	}
+
+	stdout.Reset()
+	HandleConfigCommand([]string{"get", "--profile", "work", "model"})
+	if got := stdout.String(); got != "work-model\n" {
+		t.Errorf("get --profile = %q, want work-model", got)
+	}
+
+	HandleConfigCommand([]string{"remove", "--profile", "work", "model"})
+	conf, _ = config.Load()
+	if _, ok := conf.Profiles["work"]; ok {
+		t.Errorf("removing the last value should drop the profile: %+v", conf.Profiles)
+	}
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "sync"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+    treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// ExitBudgetExceeded is the exit code when --budget stopped a run early
+const ExitBudgetExceeded = 3
+
+// QueryFunc recommends a folder for one description
+type QueryFunc func(desc string) (*api.LLMResponse, error)
+
+// BatchSummary reports how far a batch got
+type BatchSummary struct {
+    Total     int
+    Completed int
+    Failed    int
+    Tokens    int
+
+    // BudgetExceeded is set when items were left unprocessed because the
+    // token budget ran out
+    BudgetExceeded bool
+}
+
+// RunBatch queries each description in order, passing every result to emit
+// as soon as it arrives. With a positive budget, processing stops once the
+// cumulative token usage exceeds it; results already emitted are kept.
+//
+// When fail is nil, an error from query stops the batch and is returned with
+// the summary so far. Otherwise each failed item is passed to fail and the
+// batch goes on; the first error is returned once every item is done.
+func RunBatch(descs []string, budget int, query QueryFunc, emit func(desc string, resp *api.LLMResponse), fail func(desc string, err error)) (BatchSummary, error) {
+    return RunBatchConcurrent(descs, budget, 1, query, emit, fail)
+}
+
+// batchSlot holds one item's result until it is its turn to be emitted
+type batchSlot struct {
+    resp *api.LLMResponse
+    err  error
+    done chan struct{}
+}
+
+// RunBatchConcurrent is RunBatch with up to concurrency items queried or
+// waiting to be emitted at once. Results and failures are still passed on in
+// input order. Once the budget runs out, or a query fails with a nil fail, no
+// new queries start; those already running finish and their results are
+// dropped.
+func RunBatchConcurrent(descs []string, budget, concurrency int, query QueryFunc, emit func(desc string, resp *api.LLMResponse), fail func(desc string, err error)) (BatchSummary, error) {
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    summary := BatchSummary{Total: len(descs)}
+    slots := make([]batchSlot, len(descs))
+    for i := range slots {
+        slots[i].done = make(chan struct{})
+    }
+
+    // A token is taken per item launched and returned once it is emitted, so
+    // with concurrency 1 each query waits for the previous budget check
+    tokens := make(chan struct{}, concurrency)
+    var mu sync.Mutex
+    stopped := false
+    var running sync.WaitGroup
+    launched := make(chan struct{})
+    go func() {
+        defer close(launched)
+        for i, desc := range descs {
+            tokens <- struct{}{}
+            mu.Lock()
+            stop := stopped
+            mu.Unlock()
+            if stop {
+                return
+            }
+            running.Add(1)
+            go func(slot *batchSlot, desc string) {
+                defer running.Done()
+                slot.resp, slot.err = query(desc)
+                close(slot.done)
+            }(&slots[i], desc)
+        }
+    }()
+
+    var err error
+    for i, desc := range descs {
+        slot := &slots[i]
+        <-slot.done
+        failed := slot.err != nil
+        if failed {
+            logger.Error("item %d of %d failed: %v", i+1, len(descs), slot.err)
+            summary.Failed++
+            if err == nil {
+                err = slot.err
+            }
+            if fail != nil {
+                fail(desc, slot.err)
+            }
+        } else {
+            emit(desc, slot.resp)
+            summary.Completed++
+            summary.Tokens += slot.resp.Usage.Total()
+            logger.Debug("item %d of %d done (%d tokens, %d so far)", i+1, len(descs), slot.resp.Usage.Total(), summary.Tokens)
+        }
+        overBudget := !failed && budget > 0 && summary.Tokens > budget
+        if overBudget {
+            summary.BudgetExceeded = summary.Completed+summary.Failed < summary.Total
+        }
+        stop := (failed && fail == nil) || overBudget
+        if stop {
+            mu.Lock()
+            stopped = true
+            mu.Unlock()
+        }
+        <-tokens
+        if stop {
+            break
+        }
+    }
+    <-launched
+    running.Wait()
+    return summary, err
+}
+
+// ReadBatchInputs returns the items of a --batch run: each argument, with
+// "-" replaced by the non-blank lines read from stdin
+func ReadBatchInputs(args []string, stdin io.Reader) ([]string, error) {
+    var inputs []string
+    for _, arg := range args {
+        if arg != "-" {
+            inputs = append(inputs, arg)
+            continue
+        }
+        scanner := bufio.NewScanner(stdin)
+        for scanner.Scan() {
+            if line := strings.TrimSpace(scanner.Text()); line != "" {
+                inputs = append(inputs, line)
+            }
+        }
+        if err := scanner.Err(); err != nil {
+            return nil, fmt.Errorf("cannot read batch items from stdin: %w", err)
+        }
+    }
+    return inputs, nil
+}
+
+// UseStdin switches opts to a batch over stdin lines when --stdin is set, or
+// when stdin is piped and nothing else says what to sort. An explicit --stdin
+// adds stdin to the --batch items, if any.
+func UseStdin(opts *config.CLIOptions, desc string, piped bool) error {
+    if !opts.Stdin {
+        // Piped input only stands in for a missing description
+        if !piped || opts.Batch || desc != "" || opts.FromFile != "" || opts.Pick || opts.Move != "" || opts.Copy != "" {
+            return nil
+        }
+    } else if opts.Move != "" || opts.Copy != "" || opts.Pick || opts.FromFile != "" {
+        return fmt.Errorf("--stdin cannot be combined with --move, --copy, --pick or --from-file")
+    } else if !opts.Batch && desc != "" {
+        return fmt.Errorf("--stdin reads the descriptions from stdin; pass other items with --batch")
+    }
+    opts.Batch = true
+    opts.BatchInputs = append(opts.BatchInputs, "-")
+    return nil
+}
+
+// DescribeBatchInput turns a --batch item into a description: an existing
+// file is described like --from-file, anything else is taken as typed
+func DescribeBatchInput(input string, previewBytes int) (string, error) {
+    if info, err := os.Stat(input); err != nil || !info.Mode().IsRegular() {
+        return input, nil
+    }
+    return treefs.DescribeFile(input, previewBytes)
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/app"
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// usageClient answers every query with a fixed token usage
+func usageClient(tokens int, calls *int) QueryFunc {
+	return func(desc string) (*api.LLMResponse, error) {
+		*calls++
+		return &api.LLMResponse{Path: "/" + desc, Usage: api.Usage{PromptTokens: tokens - 10, CompletionTokens: 10}}, nil
+	}
+}
+
+func TestRunBatch_Budget(t *testing.T) {
+	descs := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name          string
+		budget        int
+		wantCompleted int
+		wantExceeded  bool
+	}{
+		{name: "no budget", budget: 0, wantCompleted: 5},
+		{name: "stops after crossing the budget", budget: 250, wantCompleted: 3, wantExceeded: true},
+		{name: "exact budget is not exceeded", budget: 500, wantCompleted: 5},
+		{name: "last item crossing the budget", budget: 450, wantCompleted: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var emitted []string
+			summary, err := RunBatch(descs, tt.budget, usageClient(100, &calls), func(desc string, resp *api.LLMResponse) {
+				emitted = append(emitted, resp.Path)
+			}, nil)
+			if err != nil {
+				t.Fatalf("RunBatch() error = %v", err)
+			}
+			if summary.Completed != tt.wantCompleted || calls != tt.wantCompleted || len(emitted) != tt.wantCompleted {
+				t.Errorf("completed %d, queried %d, emitted %d; want %d", summary.Completed, calls, len(emitted), tt.wantCompleted)
+			}
+			if summary.BudgetExceeded != tt.wantExceeded {
+				t.Errorf("BudgetExceeded = %v, want %v", summary.BudgetExceeded, tt.wantExceeded)
+			}
+			if summary.Tokens != 100*tt.wantCompleted {
+				t.Errorf("Tokens = %d, want %d", summary.Tokens, 100*tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestRunBatch_QueryError(t *testing.T) {
+	calls := 0
+	query := func(desc string) (*api.LLMResponse, error) {
+		calls++
+		if desc == "b" {
+			return nil, errors.New("boom")
+		}
+		return &api.LLMResponse{Path: fmt.Sprintf("/%s", desc)}, nil
+	}
+
+	summary, err := RunBatch([]string{"a", "b", "c"}, 0, query, func(string, *api.LLMResponse) {}, nil)
+	if err == nil || summary.Completed != 1 || calls != 2 {
+		t.Errorf("RunBatch() = %+v, %v after %d calls; want to stop at the failing item", summary, err, calls)
+	}
+
+	// With fail set, the failing item is reported and the rest still run
+	calls = 0
+	var emitted, failed []string
+	summary, err = RunBatch([]string{"a", "b", "c"}, 0, query, func(desc string, resp *api.LLMResponse) {
+		emitted = append(emitted, desc)
+	}, func(desc string, err error) {
+		failed = append(failed, desc+": "+err.Error())
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("RunBatch() error = %v, want the failure returned at the end", err)
+	}
+	if summary.Completed != 2 || summary.Failed != 1 || calls != 3 {
+		t.Errorf("RunBatch() = %+v after %d calls; want every item processed", summary, calls)
+	}
+	if !reflect.DeepEqual(emitted, []string{"a", "c"}) || !reflect.DeepEqual(failed, []string{"b: boom"}) {
+		t.Errorf("emitted %v, failed %v; want a and c emitted and b reported", emitted, failed)
+	}
+}
+
+func TestRunBatch_InjectedLogger(t *testing.T) {
+	var logs bytes.Buffer
+	SetLogger(app.NewLoggerWithOutput(app.LogLevelDebug, &logs, &logs))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	calls := 0
+	if _, err := RunBatch([]string{"a", "b"}, 0, usageClient(100, &calls), func(string, *api.LLMResponse) {}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "item 2 of 2 done (100 tokens, 200 so far)") {
+		t.Errorf("logs = %q", logs.String())
+	}
+
+	// The default logger discards everything
+	SetLogger(nil)
+	if _, ok := logger.(app.NoopLogger); !ok {
+		t.Errorf("SetLogger(nil) installed %T, want app.NoopLogger", logger)
+	}
+}
+
+func TestRunBatchConcurrent_KeepsOrderAndBound(t *testing.T) {
+	descs := []string{"a", "b", "c", "d", "e", "f"}
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	query := func(desc string) (*api.LLMResponse, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		// Earlier items answer last, so completion order is reversed
+		time.Sleep(time.Duration(len(descs)-strings.Index("abcdef", desc)) * 5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return &api.LLMResponse{Path: "/" + desc}, nil
+	}
+
+	var emitted []string
+	summary, err := RunBatchConcurrent(descs, 0, 3, query, func(desc string, resp *api.LLMResponse) {
+		emitted = append(emitted, resp.Path)
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/a", "/b", "/c", "/d", "/e", "/f"}; !reflect.DeepEqual(emitted, want) {
+		t.Errorf("emitted %v, want %v", emitted, want)
+	}
+	if summary.Completed != 6 {
+		t.Errorf("Completed = %d, want 6", summary.Completed)
+	}
+	if peak > 3 || peak < 2 {
+		t.Errorf("peak concurrency = %d, want 2-3", peak)
+	}
+}
+
+func TestRunBatchConcurrent_StopsLaunching(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	query := func(desc string) (*api.LLMResponse, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if desc == "b" {
+			return nil, errors.New("boom")
+		}
+		return &api.LLMResponse{Path: "/" + desc}, nil
+	}
+
+	descs := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	summary, err := RunBatchConcurrent(descs, 0, 2, query, func(string, *api.LLMResponse) {}, nil)
+	if err == nil || summary.Completed != 1 {
+		t.Fatalf("RunBatchConcurrent() = %+v, %v; want to stop at the failing item", summary, err)
+	}
+	// Only "c" can have started while "b" was in flight
+	if calls > 3 {
+		t.Errorf("queried %d items, want at most 3", calls)
+	}
+}
+
+func TestReadBatchInputs(t *testing.T) {
+	stdin := strings.NewReader("invoice.pdf\n\n  Berlin trip photos  \n")
+	got, err := ReadBatchInputs([]string{"first", "-", "last"}, stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first", "invoice.pdf", "Berlin trip photos", "last"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBatchInputs() = %q, want %q", got, want)
+	}
+}
+
+func TestDescribeBatchInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("meeting notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := DescribeBatchInput(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc == path || !strings.Contains(desc, "notes.txt") {
+		t.Errorf("file described as %q, want a generated description", desc)
+	}
+
+	typed := "Q3 financial reports"
+	if desc, err := DescribeBatchInput(typed, 0); err != nil || desc != typed {
+		t.Errorf("DescribeBatchInput(%q) = %q, %v; want it unchanged", typed, desc, err)
+	}
+}
+
+func TestUseStdin(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      config.CLIOptions
+		desc      string
+		piped     bool
+		wantBatch []string
+		wantErr   bool
+	}{
+		{name: "terminal", opts: config.CLIOptions{}},
+		{name: "piped without description", piped: true, wantBatch: []string{"-"}},
+		{name: "description beats piped input", desc: "invoice", piped: true},
+		{name: "from-file beats piped input", opts: config.CLIOptions{FromFile: "a.pdf"}, piped: true},
+		{name: "batch beats piped input", opts: config.CLIOptions{Batch: true, BatchInputs: []string{"a"}}, piped: true, wantBatch: []string{"a"}},
+		{name: "explicit flag", opts: config.CLIOptions{Stdin: true}, wantBatch: []string{"-"}},
+		{name: "flag adds to batch", opts: config.CLIOptions{Stdin: true, Batch: true, BatchInputs: []string{"a"}}, wantBatch: []string{"a", "-"}},
+		{name: "flag with description", opts: config.CLIOptions{Stdin: true}, desc: "invoice", wantErr: true},
+		{name: "flag with move", opts: config.CLIOptions{Stdin: true, Move: "a.jpg"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := UseStdin(&opts, tt.desc, tt.piped)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseStdin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if opts.Batch != (tt.wantBatch != nil) || !reflect.DeepEqual(opts.BatchInputs, tt.wantBatch) {
+				t.Errorf("Batch = %v, BatchInputs = %q; want %q", opts.Batch, opts.BatchInputs, tt.wantBatch)
+			}
+		})
+	}
+}
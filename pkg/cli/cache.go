@@ -0,0 +1,84 @@
+package cli
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "os"
+
+    "github.com/kacperkwapisz/sortpath/internal/cache"
+    "github.com/kacperkwapisz/sortpath/internal/util"
+)
+
+// cacheDir is the directory cache commands act on; tests replace it
+var cacheDir = cache.Dir
+
+// HandleCacheCommand runs `cache prune` and `cache clear`
+func HandleCacheCommand(args []string) {
+    if len(args) < 1 {
+        out.Error("Usage: sortpath cache prune [--max-age DUR] [--max-size BYTES] | cache clear\n")
+        os.Exit(1)
+    }
+    var res cache.Result
+    var err error
+    switch args[0] {
+    case "prune":
+        var opts cache.PruneOptions
+        if opts, err = parsePruneArgs(args[1:]); err == nil {
+            res, err = cache.Prune(cacheDir(), opts)
+        }
+    case "clear":
+        if len(args) != 1 {
+            out.Error("Usage: sortpath cache clear\n")
+            os.Exit(1)
+        }
+        res, err = cache.Clear(cacheDir())
+    default:
+        out.Error("Unknown cache command: %s\n", args[0])
+        os.Exit(1)
+    }
+    if err != nil {
+        out.Error("❌ Cache %s error: %v\n", args[0], err)
+        os.Exit(1)
+    }
+    writeCacheResult(out.Results(), res)
+}
+
+// parsePruneArgs reads the prune limits. At least one is required, so a bare
+// `cache prune` never deletes anything by surprise.
+func parsePruneArgs(args []string) (cache.PruneOptions, error) {
+    var maxAge, maxSize string
+    fs := flag.NewFlagSet("cache prune", flag.ContinueOnError)
+    fs.StringVar(&maxAge, "max-age", "", "Remove entries older than this (e.g. 7d)")
+    fs.StringVar(&maxSize, "max-size", "", "Trim the oldest entries until the cache fits (e.g. 100MB)")
+    fs.SetOutput(out.Errors())
+    if err := fs.Parse(args); err != nil {
+        return cache.PruneOptions{}, err
+    }
+
+    var opts cache.PruneOptions
+    var err error
+    if maxAge == "" && maxSize == "" {
+        return opts, fmt.Errorf("give --max-age, --max-size or both (use 'cache clear' to remove everything)")
+    }
+    if maxAge != "" {
+        if opts.MaxAge, err = util.ParseDuration(maxAge, "--max-age"); err != nil {
+            return opts, err
+        }
+    }
+    if maxSize != "" {
+        if opts.MaxSize, err = util.ParseSize(maxSize, "--max-size"); err != nil {
+            return opts, err
+        }
+    }
+    return opts, nil
+}
+
+func writeCacheResult(w io.Writer, res cache.Result) {
+    if res.Removed == 0 {
+        fmt.Fprintf(w, "✅ Nothing to remove (%d entries, %s)\n", res.Kept, util.FormatSize(res.KeptSize))
+        return
+    }
+    fmt.Fprintf(w, "✅ Removed %d entries, freed %s (%d entries, %s left)\n",
+        res.Removed, util.FormatSize(res.Freed), res.Kept, util.FormatSize(res.KeptSize))
+}
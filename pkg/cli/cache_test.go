@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/internal/ui"
+)
+
+func TestParsePruneArgs(t *testing.T) {
+	useOutput(t, ui.Options{})
+
+	opts, err := parsePruneArgs([]string{"--max-age", "7d", "--max-size", "100MB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.MaxAge != 7*24*time.Hour || opts.MaxSize != 100<<20 {
+		t.Errorf("opts = %+v", opts)
+	}
+
+	for _, args := range [][]string{nil, {"--max-age", "soon"}, {"--max-size", "lots"}} {
+		if _, err := parsePruneArgs(args); err == nil {
+			t.Errorf("parsePruneArgs(%q) expected an error", args)
+		}
+	}
+}
+
+func TestHandleCacheCommand_Prune(t *testing.T) {
+	stdout, _ := useOutput(t, ui.Options{})
+	root := t.TempDir()
+	origDir := cacheDir
+	cacheDir = func() string { return root }
+	t.Cleanup(func() { cacheDir = origDir })
+
+	old := filepath.Join(root, "health", "old")
+	os.MkdirAll(filepath.Dir(old), 0700)
+	os.WriteFile(old, make([]byte, 2048), 0600)
+	mtime := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(old, mtime, mtime)
+
+	HandleCacheCommand([]string{"prune", "--max-age", "1d"})
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expired entry was not removed")
+	}
+	if got := stdout.String(); !strings.Contains(got, "Removed 1 entries, freed 2.0 KB") {
+		t.Errorf("output = %q", got)
+	}
+}
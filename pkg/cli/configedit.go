@@ -0,0 +1,140 @@
+package cli
+
+import (
+    "bufio"
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "runtime"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+    "gopkg.in/yaml.v3"
+)
+
+// ErrEditNotInteractive is returned by config edit when nobody can use an editor
+var ErrEditNotInteractive = errors.New("config edit needs an interactive terminal. Set values with 'sortpath config set <key> <value>' or 'sortpath config import FILE'")
+
+// runEditor opens path in editor, which may carry arguments (e.g.
+// "code --wait"), on the current terminal; tests replace it
+var runEditor = func(editor, path string) error {
+    fields := strings.Fields(editor)
+    cmd := exec.Command(fields[0], append(fields[1:], path)...)
+    cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+    return cmd.Run()
+}
+
+// editorCommand returns $VISUAL, then $EDITOR, then the platform's editor
+func editorCommand() string {
+    for _, name := range []string{"VISUAL", "EDITOR"} {
+        if e := strings.TrimSpace(os.Getenv(name)); e != "" {
+            return e
+        }
+    }
+    if runtime.GOOS == "windows" {
+        return "notepad"
+    }
+    return "vi"
+}
+
+// editConfig opens a copy of the config file in the user's editor, creating
+// the file with the defaults first if it doesn't exist. The edited copy is
+// saved over the config file only when it is valid YAML whose values pass
+// the checks config set makes; otherwise the problem is shown and the user
+// can edit again or discard the changes.
+func editConfig() error {
+    if !interactive() {
+        return ErrEditNotInteractive
+    }
+
+    path := config.ConfigPath()
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        data, err = defaultConfigYAML()
+        if err == nil {
+            err = config.DefaultSecureFileOps.AtomicWrite(path, data)
+        }
+    }
+    if err != nil {
+        return err
+    }
+
+    tmp, err := os.CreateTemp(filepath.Dir(path), "config-edit-*.yaml")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    _, err = tmp.Write(data)
+    if closeErr := tmp.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return err
+    }
+
+    editor := editorCommand()
+    reader := bufio.NewReader(promptInput)
+    for {
+        if err := runEditor(editor, tmp.Name()); err != nil {
+            return fmt.Errorf("editor %s failed: %w", editor, err)
+        }
+        edited, err := os.ReadFile(tmp.Name())
+        if err != nil {
+            return err
+        }
+        if bytes.Equal(edited, data) {
+            fmt.Fprintln(promptOutput, "No changes")
+            return nil
+        }
+        problem := checkConfigYAML(edited)
+        if problem == nil {
+            return config.DefaultSecureFileOps.AtomicWrite(path, edited)
+        }
+
+        fmt.Fprintf(promptOutput, "❌ %v\nEdit again? [Y/n]: ", problem)
+        answer, readErr := reader.ReadString('\n')
+        answer = strings.TrimSpace(strings.ToLower(answer))
+        if (readErr == io.EOF && answer == "") || (answer != "" && answer != "y" && answer != "yes") {
+            return fmt.Errorf("changes discarded: %w", problem)
+        }
+    }
+}
+
+// defaultConfigYAML is a new config file listing every key's default
+func defaultConfigYAML() ([]byte, error) {
+    var c config.Config
+    for _, key := range config.ConfigKeys {
+        if err := c.SetValue(key, config.DefaultValue(key)); err != nil {
+            return nil, err
+        }
+    }
+    return yaml.Marshal(&c)
+}
+
+// checkConfigYAML reports the first problem with an edited config file:
+// invalid YAML, an unknown field or a value config set would refuse
+func checkConfigYAML(data []byte) error {
+    var c config.Config
+    dec := yaml.NewDecoder(bytes.NewReader(data))
+    dec.KnownFields(true)
+    if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+        return fmt.Errorf("invalid YAML: %w", err)
+    }
+    if _, err := importValues(&c, "", true, &importResult{}); err != nil {
+        return err
+    }
+    for _, name := range c.ProfileNames() {
+        if err := config.ValidateProfileName(name); err != nil {
+            return err
+        }
+        p := c.Profiles[name]
+        if _, err := importValues(&p, "", true, &importResult{}); err != nil {
+            return fmt.Errorf("profile %s: %w", name, err)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// stubEditor replaces the editor with edits, applied one per run
+func stubEditor(t *testing.T, edits ...string) *int {
+	t.Helper()
+	orig := runEditor
+	runs := 0
+	runEditor = func(editor, path string) error {
+		if runs >= len(edits) {
+			t.Fatalf("editor opened %d times, want %d", runs+1, len(edits))
+		}
+		runs++
+		return os.WriteFile(path, []byte(edits[runs-1]), 0600)
+	}
+	t.Cleanup(func() { runEditor = orig })
+	return &runs
+}
+
+func TestEditConfig_CreatesAndSaves(t *testing.T) {
+	isolateConfig(t)
+	stubTerminal(t, true, "")
+	stubEditor(t, "api_key: sk-edited-1234567890\nmodel: gpt-4o\n")
+
+	if err := editConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := getConfigValue("model"); got != "gpt-4o" {
+		t.Errorf("model = %q, want gpt-4o", got)
+	}
+	info, err := os.Stat(config.ConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config file mode = %o, want 600", perm)
+	}
+}
+
+func TestEditConfig_InvalidReopens(t *testing.T) {
+	isolateConfig(t)
+	if err := setConfigValue("model", "gpt-4"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Broken YAML, then an invalid value, then a fix
+	prompts := stubTerminal(t, true, "y\n\n")
+	runs := stubEditor(t, "model: [gpt-4o\n", "model: gpt-4o\nlog_level: verbose\n", "model: gpt-4o\nlog_level: debug\n")
+	if err := editConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if *runs != 3 {
+		t.Errorf("editor runs = %d, want 3", *runs)
+	}
+	if !strings.Contains(prompts.String(), "invalid YAML") || !strings.Contains(prompts.String(), "log-level") {
+		t.Errorf("prompts = %q, want both problems reported", prompts.String())
+	}
+	if got, _ := getConfigValue("log-level"); got != "debug" {
+		t.Errorf("log-level = %q, want debug", got)
+	}
+}
+
+func TestEditConfig_DiscardInvalid(t *testing.T) {
+	isolateConfig(t)
+	if err := setConfigValue("model", "gpt-4"); err != nil {
+		t.Fatal(err)
+	}
+	stubTerminal(t, true, "n\n")
+	stubEditor(t, "model: gpt-4o\nunknown_key: x\n")
+
+	if err := editConfig(); err == nil || !strings.Contains(err.Error(), "changes discarded") {
+		t.Fatalf("editConfig() = %v, want the changes discarded", err)
+	}
+	if got, _ := getConfigValue("model"); got != "gpt-4" {
+		t.Errorf("model = %q, want the original gpt-4", got)
+	}
+}
+
+func TestEditConfig_NonInteractive(t *testing.T) {
+	isolateConfig(t)
+	stubTerminal(t, false, "")
+	stubEditor(t)
+	if err := editConfig(); !errors.Is(err, ErrEditNotInteractive) {
+		t.Fatalf("editConfig() = %v, want ErrEditNotInteractive", err)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano -w")
+	if got := editorCommand(); got != "nano -w" {
+		t.Errorf("editorCommand() = %q, want $EDITOR", got)
+	}
+	t.Setenv("VISUAL", "code --wait")
+	if got := editorCommand(); got != "code --wait" {
+		t.Errorf("editorCommand() = %q, want $VISUAL first", got)
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// initKeys are the settings config init asks for, in order
+var initKeys = []string{"api-key", "api-base", "model", "tree-path"}
+
+// ErrNotInteractive is returned by config init when nobody can answer
+var ErrNotInteractive = errors.New("config init needs an interactive terminal. Set values with 'sortpath config set <key> <value>' or the OPENAI_API_KEY, OPENAI_API_BASE, OPENAI_MODEL and SORTPATH_FOLDER_TREE environment variables")
+
+// initConfig asks for each of initKeys, offering the current value (or the
+// default) in brackets, and saves the answers to the config file, or to the
+// named profile when profile is set. Invalid answers are asked again.
+func initConfig(profile string) error {
+    if !interactive() {
+        return ErrNotInteractive
+    }
+
+    conf, err := config.Load()
+    if err != nil {
+        return err
+    }
+    key, _ := config.DefaultSecretStore.GetSecret(config.ProfileSecretName(profile))
+    var current configValues = conf
+    if profile != "" {
+        p := conf.Profiles[profile]
+        p.APIKey = key
+        current = &p
+    } else {
+        conf.APIKey = key
+    }
+
+    reader := bufio.NewReader(promptInput)
+    answers := map[string]string{}
+    for _, key := range initKeys {
+        def := initDefault(current, key)
+        for {
+            if def != "" {
+                fmt.Fprintf(promptOutput, "%s [%s]: ", key, config.RedactSensitiveValue(key, def))
+            } else {
+                fmt.Fprintf(promptOutput, "%s: ", key)
+            }
+            line, readErr := reader.ReadString('\n')
+            value := strings.TrimSpace(line)
+            if readErr == io.EOF && value == "" {
+                return errors.New("config init cancelled; nothing was saved")
+            }
+            if value == "" {
+                value = def
+            }
+            sanitized, err := checkConfigValue(key, value)
+            if err != nil {
+                fmt.Fprintf(promptOutput, "❌ %v\n", err)
+                continue
+            }
+            answers[key] = sanitized
+            break
+        }
+    }
+
+    // Secrets live in the secret store, not necessarily the config file
+    if err := setSecret(config.ProfileSecretName(profile), answers["api-key"]); err != nil {
+        return err
+    }
+    err = config.Update(func(c *config.Config) error {
+        for _, key := range initKeys {
+            if key == config.APIKeySecret {
+                continue
+            }
+            var err error
+            if profile != "" {
+                err = c.SetProfileValue(profile, key, answers[key])
+            } else {
+                err = c.SetValue(key, answers[key])
+            }
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    fmt.Fprintf(promptOutput, "✅ Saved to %s\n", config.ConfigPath())
+    return nil
+}
+
+// initDefault is the value offered for key: what is configured now, or the
+// built-in default (the current directory for tree-path)
+func initDefault(c configValues, key string) string {
+    if v, _ := c.Value(key); v != "" {
+        return v
+    }
+    switch key {
+    case "tree-path":
+        if wd, err := os.Getwd(); err == nil {
+            return wd
+        }
+        return ""
+    case "api-key":
+        return ""
+    }
+    return config.DefaultValue(key)
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+func TestInitConfig_Interactive(t *testing.T) {
+	home := isolateConfig(t)
+	// api-key, then defaults for api-base and model, a bad tree path, then a good one
+	out := stubTerminal(t, true, "sk-init-1234567890\n\n\n/does/not/exist\n"+home+"\n")
+
+	if err := initConfig(""); err != nil {
+		t.Fatalf("initConfig() error = %v", err)
+	}
+
+	prompts := out.String()
+	for _, want := range []string{"api-key: ", "api-base [https://api.openai.com/v1]: ", "model [gpt-3.5-turbo]: ", "does not exist", "✅ Saved to"} {
+		if !strings.Contains(prompts, want) {
+			t.Errorf("prompts missing %q:\n%s", want, prompts)
+		}
+	}
+
+	conf, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.APIBase != "https://api.openai.com/v1" || conf.Model != "gpt-3.5-turbo" || conf.TreePath != home {
+		t.Errorf("saved config = %+v", conf)
+	}
+	if key, _ := config.DefaultSecretStore.GetSecret(config.APIKeySecret); key != "sk-init-1234567890" {
+		t.Errorf("saved api key = %q", key)
+	}
+
+	// Running again offers the saved values, with the key redacted
+	out = stubTerminal(t, true, "\n\ngpt-4o\n\n")
+	if err := initConfig(""); err != nil {
+		t.Fatalf("second initConfig() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "api-key [sk-i...7890]: ") {
+		t.Errorf("expected the current key to be offered redacted:\n%s", out.String())
+	}
+	conf, _ = config.Load()
+	if conf.Model != "gpt-4o" || conf.TreePath != home {
+		t.Errorf("second run saved %+v", conf)
+	}
+}
+
+func TestInitConfig_NonInteractive(t *testing.T) {
+	isolateConfig(t)
+	stubTerminal(t, false, "")
+
+	err := initConfig("")
+	if !errors.Is(err, ErrNotInteractive) {
+		t.Fatalf("initConfig() error = %v, want ErrNotInteractive", err)
+	}
+	if !strings.Contains(err.Error(), "config set") {
+		t.Errorf("error should point at config set: %v", err)
+	}
+}
+
+func TestInitConfig_Cancelled(t *testing.T) {
+	isolateConfig(t)
+	stubTerminal(t, true, "sk-init-1234567890\n")
+
+	if err := initConfig(""); err == nil {
+		t.Fatal("expected running out of input to cancel")
+	}
+	if key, _ := config.DefaultSecretStore.GetSecret(config.APIKeySecret); key != "" {
+		t.Errorf("a cancelled init saved the key %q", key)
+	}
+}
@@ -0,0 +1,253 @@
+package cli
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "regexp"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+    "gopkg.in/yaml.v3"
+)
+
+// redactedKey matches an API key as RedactSensitiveValue shows it
+var redactedKey = regexp.MustCompile(`^(\*\*\*|.{4}\.\.\..{4})$`)
+
+// exportConfig writes the config file's settings as YAML, the stored API keys
+// included. redact masks the API keys, the top-level one and the profiles',
+// so the result can be shared. The machine-specific install path is left out.
+func exportConfig(w io.Writer, redact bool) error {
+    conf, err := config.Load()
+    if err != nil {
+        return err
+    }
+    if key, err := config.DefaultSecretStore.GetSecret(config.APIKeySecret); err == nil {
+        conf.APIKey = key
+    }
+    for name, p := range conf.Profiles {
+        if key, err := config.DefaultSecretStore.GetSecret(config.ProfileSecretName(name)); err == nil {
+            p.APIKey = key
+        }
+        if redact {
+            p.APIKey = redactAPIKey(p.APIKey)
+        }
+        conf.Profiles[name] = p
+    }
+    conf.InstalledPath = ""
+    if redact {
+        conf.APIKey = redactAPIKey(conf.APIKey)
+    }
+
+    data, err := yaml.Marshal(conf)
+    if err != nil {
+        return err
+    }
+    _, err = w.Write(data)
+    return err
+}
+
+func redactAPIKey(key string) string {
+    if key == "" {
+        return ""
+    }
+    return config.RedactSensitiveValue(config.APIKeySecret, key)
+}
+
+// parseImportArgs splits config import arguments into the file and --force
+func parseImportArgs(args []string) (path string, force bool) {
+    var paths []string
+    for _, a := range args {
+        if a == "--force" || a == "-force" {
+            force = true
+            continue
+        }
+        paths = append(paths, a)
+    }
+    if len(paths) != 1 {
+        return "", force
+    }
+    return paths[0], force
+}
+
+// importResult describes what importConfig saved
+type importResult struct {
+    Imported int
+    // SkippedKeys counts redacted API keys that were left alone
+    SkippedKeys int
+}
+
+// importConfig reads a file written by exportConfig, checks every value the
+// way config set does and saves them over the current settings. Nothing is
+// saved when a value is invalid. Replacing a different API key needs force;
+// redacted API keys are skipped.
+func importConfig(path string, force bool) (importResult, error) {
+    var res importResult
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return res, err
+    }
+    var in config.Config
+    dec := yaml.NewDecoder(bytes.NewReader(data))
+    dec.KnownFields(true)
+    if err := dec.Decode(&in); err != nil {
+        if errors.Is(err, io.EOF) {
+            return res, fmt.Errorf("%s is empty", path)
+        }
+        return res, fmt.Errorf("cannot read %s: %w", path, err)
+    }
+
+    if _, err := config.Load(); err != nil {
+        return res, err
+    }
+    currentKey, _ := config.DefaultSecretStore.GetSecret(config.APIKeySecret)
+
+    values, err := importValues(&in, currentKey, force, &res)
+    if err != nil {
+        return res, err
+    }
+    // API keys go through the secret store, keyed by profile ("" for the top level)
+    keys := map[string]string{}
+    if key, ok := values[config.APIKeySecret]; ok {
+        keys[""] = key
+        delete(values, config.APIKeySecret)
+    }
+    profiles := map[string]map[string]string{}
+    for _, name := range in.ProfileNames() {
+        if err := config.ValidateProfileName(name); err != nil {
+            return res, err
+        }
+        p := in.Profiles[name]
+        existingKey, _ := config.DefaultSecretStore.GetSecret(config.ProfileSecretName(name))
+        pv, err := importValues(&p, existingKey, force, &res)
+        if err != nil {
+            return res, fmt.Errorf("profile %s: %w", name, err)
+        }
+        if key, ok := pv[config.APIKeySecret]; ok {
+            keys[name] = key
+            delete(pv, config.APIKeySecret)
+        }
+        profiles[name] = pv
+    }
+    aliases, err := importAliases(in.ModelAliases)
+    if err != nil {
+        return res, err
+    }
+    environments, err := importEnvironments(in.Environments)
+    if err != nil {
+        return res, err
+    }
+
+    err = config.Update(func(c *config.Config) error {
+        for _, key := range config.ConfigKeys {
+            if v, ok := values[key]; ok {
+                if err := c.SetValue(key, v); err != nil {
+                    return err
+                }
+            }
+        }
+        for name, pv := range profiles {
+            for _, key := range config.ConfigKeys {
+                if v, ok := pv[key]; ok {
+                    if err := c.SetProfileValue(name, key, v); err != nil {
+                        return err
+                    }
+                }
+            }
+        }
+        for alias, model := range aliases {
+            if c.ModelAliases == nil {
+                c.ModelAliases = map[string]string{}
+            }
+            c.ModelAliases[alias] = model
+        }
+        for env, p := range environments {
+            if c.Environments == nil {
+                c.Environments = map[string]config.EnvProfile{}
+            }
+            c.Environments[env] = p
+        }
+        return nil
+    })
+    for profile, key := range keys {
+        if err != nil {
+            break
+        }
+        err = setSecret(config.ProfileSecretName(profile), key)
+    }
+    if err != nil {
+        return res, err
+    }
+    res.Imported = len(values) + len(keys) + len(aliases) + len(environments)
+    for _, pv := range profiles {
+        res.Imported += len(pv)
+    }
+    return res, nil
+}
+
+// importValues checks each ConfigKeys value set in in and returns them
+// sanitized. A redacted API key is skipped, and one that would replace a
+// different currentKey is refused without force.
+func importValues(in configValues, currentKey string, force bool, res *importResult) (map[string]string, error) {
+    values := map[string]string{}
+    for _, key := range config.ConfigKeys {
+        v, _ := in.Value(key)
+        if v == "" {
+            continue
+        }
+        if key == config.APIKeySecret {
+            if redactedKey.MatchString(v) {
+                res.SkippedKeys++
+                continue
+            }
+            if currentKey != "" && currentKey != v && !force {
+                return nil, fmt.Errorf("an api-key is already set; pass --force to replace it")
+            }
+        }
+        sanitized, err := checkConfigValue(key, v)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", key, err)
+        }
+        values[key] = sanitized
+    }
+    return values, nil
+}
+
+// importAliases checks each model alias and the model it names the way
+// config set checks a model, and returns them sanitized
+func importAliases(in map[string]string) (map[string]string, error) {
+    aliases := map[string]string{}
+    for alias, target := range in {
+        name, err := checkConfigValue("model", alias)
+        if err != nil {
+            return nil, fmt.Errorf("model alias %s: %w", alias, err)
+        }
+        model, err := checkConfigValue("model", target)
+        if err != nil {
+            return nil, fmt.Errorf("model alias %s: %w", alias, err)
+        }
+        aliases[name] = model
+    }
+    return aliases, nil
+}
+
+// importEnvironments checks each environment profile's name and log level,
+// and returns them with the log level sanitized
+func importEnvironments(in map[string]config.EnvProfile) (map[string]config.EnvProfile, error) {
+    environments := map[string]config.EnvProfile{}
+    for env, p := range in {
+        if config.ValidateProfileName(env) != nil {
+            return nil, fmt.Errorf("invalid environment name '%s'. Use letters, digits, '-' and '_'", env)
+        }
+        if p.LogLevel != "" {
+            level, err := checkConfigValue("log-level", p.LogLevel)
+            if err != nil {
+                return nil, fmt.Errorf("environment %s: log-level: %w", env, err)
+            }
+            p.LogLevel = level
+        }
+        environments[env] = p
+    }
+    return environments, nil
+}
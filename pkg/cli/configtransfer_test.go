@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+func TestConfigExportImport_RoundTrip(t *testing.T) {
+	home := isolateConfig(t)
+	const key = "sk-roundtrip-1234567890"
+	for k, v := range map[string]string{"api-key": key, "model": "gpt-4o", "tree-path": home, "extra-rules": "- Keep receipts together"} {
+		if err := setConfigValue(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := setProfileValue("work", "model", "gpt-4o-mini"); err != nil {
+		t.Fatal(err)
+	}
+
+	var exported bytes.Buffer
+	if err := exportConfig(&exported, false); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "sortpath.yaml")
+	if err := os.WriteFile(file, exported.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh machine
+	t.Setenv("HOME", t.TempDir())
+	res, err := importConfig(file, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 5 || res.SkippedKeys != 0 {
+		t.Errorf("importConfig() = %+v, want 5 imported and none skipped", res)
+	}
+	var again bytes.Buffer
+	if err := exportConfig(&again, false); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != exported.String() {
+		t.Errorf("re-exported config differs:\n%s\nwant:\n%s", again.String(), exported.String())
+	}
+}
+
+func TestConfigExportImport_Redact(t *testing.T) {
+	isolateConfig(t)
+	const key = "sk-original-1234567890"
+	if err := setConfigValue("api-key", key); err != nil {
+		t.Fatal(err)
+	}
+	if err := setProfileValue("work", "api-key", "sk-work-key-0987654321"); err != nil {
+		t.Fatal(err)
+	}
+
+	var redacted bytes.Buffer
+	if err := exportConfig(&redacted, true); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(redacted.String(), key) || strings.Contains(redacted.String(), "sk-work-key-0987654321") {
+		t.Fatalf("redacted export leaks an API key:\n%s", redacted.String())
+	}
+	if !strings.Contains(redacted.String(), config.RedactSensitiveValue("api-key", key)) {
+		t.Errorf("redacted export = %q, want the masked key", redacted.String())
+	}
+
+	// Importing the redacted file leaves the real keys alone
+	file := filepath.Join(t.TempDir(), "shared.yaml")
+	if err := os.WriteFile(file, redacted.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	res, err := importConfig(file, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SkippedKeys != 2 {
+		t.Errorf("SkippedKeys = %d, want 2", res.SkippedKeys)
+	}
+	if got, _ := getConfigValue("api-key"); got != key {
+		t.Errorf("api-key = %q after importing a redacted file, want it unchanged", got)
+	}
+}
+
+func TestConfigImport_APIKeyNeedsForce(t *testing.T) {
+	isolateConfig(t)
+	if err := setConfigValue("api-key", "sk-existing-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "other.yaml")
+	if err := os.WriteFile(file, []byte("api_key: sk-imported-1234567890\nmodel: gpt-4o\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := importConfig(file, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("importConfig() error = %v, want a refusal mentioning --force", err)
+	}
+	if got, _ := getConfigValue("model"); got == "gpt-4o" {
+		t.Error("model was saved although the import was refused")
+	}
+
+	if _, err := importConfig(file, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := getConfigValue("api-key"); got != "sk-imported-1234567890" {
+		t.Errorf("api-key = %q after --force, want the imported key", got)
+	}
+}
+
+func TestConfigImport_InvalidValue(t *testing.T) {
+	isolateConfig(t)
+	file := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(file, []byte("model: gpt-4o\nlog_level: verbose\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := importConfig(file, false); err == nil || !strings.Contains(err.Error(), "log-level") {
+		t.Fatalf("importConfig() error = %v, want the invalid log-level named", err)
+	}
+	if got, _ := getConfigValue("model"); got == "gpt-4o" {
+		t.Error("model was saved although another value was invalid")
+	}
+}
+
+func TestConfigImport_InvalidAliasOrEnvironment(t *testing.T) {
+	isolateConfig(t)
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "alias target", yaml: "model_aliases:\n  fast: \"gpt 4o; rm\"\n", want: "model alias fast"},
+		{name: "environment log level", yaml: "environments:\n  ci:\n    log_level: verbose\n", want: "environment ci: log-level"},
+		{name: "environment name", yaml: "environments:\n  \"c i\":\n    log_level: error\n", want: "invalid environment name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "bad.yaml")
+			if err := os.WriteFile(file, []byte("model: gpt-4o\n"+tt.yaml), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := importConfig(file, false); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("importConfig() error = %v, want %q", err, tt.want)
+			}
+			if got, _ := getConfigValue("model"); got == "gpt-4o" {
+				t.Error("model was saved although another value was invalid")
+			}
+		})
+	}
+
+	file := filepath.Join(t.TempDir(), "good.yaml")
+	good := "model_aliases:\n  fast: gpt-4o-mini\nenvironments:\n  ci:\n    log_level: ERROR\n"
+	if err := os.WriteFile(file, []byte(good), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := importConfig(file, false); err != nil || res.Imported != 2 {
+		t.Fatalf("importConfig() = %+v, %v; want 2 imported", res, err)
+	}
+	c, _ := config.Load()
+	if c.ModelAliases["fast"] != "gpt-4o-mini" || c.Environments["ci"].LogLevel != "error" {
+		t.Errorf("imported aliases %v and environments %v, want fast and a lowercase ci log level", c.ModelAliases, c.Environments)
+	}
+}
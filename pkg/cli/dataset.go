@@ -0,0 +1,100 @@
+package cli
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+
+    treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// DatasetRecord is one description→path example appended by --record-dataset
+type DatasetRecord struct {
+    Timestamp   time.Time `json:"timestamp"`
+    Description string    `json:"description"`
+
+    // Tree is the tree sent to the model. With tree references it is left
+    // empty and TreeRef names the file under <dataset>.trees holding it.
+    Tree    string `json:"tree,omitempty"`
+    TreeRef string `json:"tree_ref,omitempty"`
+
+    Model  string `json:"model"`
+    Path   string `json:"path"`
+    Reason string `json:"reason"`
+}
+
+// DatasetWriter appends successful recommendations to a JSONL file. Secrets
+// in every text field are redacted before anything is written.
+type DatasetWriter struct {
+    Path string
+
+    // TreeByRef stores each distinct tree once, named by its SHA-256, instead
+    // of inlining it in every line
+    TreeByRef bool
+
+    now func() time.Time
+}
+
+// Record appends one line for a recommendation of desc against tree
+func (w *DatasetWriter) Record(desc, tree, model string, resp *api.LLMResponse) error {
+    now := time.Now
+    if w.now != nil {
+        now = w.now
+    }
+    rec := DatasetRecord{
+        Timestamp:   now().UTC(),
+        Description: treefs.RedactSecrets(desc),
+        Model:       model,
+        Path:        treefs.RedactSecrets(resp.Path),
+        Reason:      treefs.RedactSecrets(resp.Reason),
+    }
+
+    tree = treefs.RedactSecrets(tree)
+    if w.TreeByRef && tree != "" {
+        ref, err := w.storeTree(tree)
+        if err != nil {
+            return err
+        }
+        rec.TreeRef = ref
+    } else {
+        rec.Tree = tree
+    }
+
+    line, err := json.Marshal(rec)
+    if err != nil {
+        return err
+    }
+    f, err := os.OpenFile(w.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+    if err != nil {
+        return fmt.Errorf("cannot open dataset file: %w", err)
+    }
+    if _, err := f.Write(append(line, '\n')); err != nil {
+        f.Close()
+        return fmt.Errorf("cannot write dataset file: %w", err)
+    }
+    return f.Close()
+}
+
+// storeTree writes tree to the dataset's tree directory unless an identical
+// tree is already there, and returns its reference
+func (w *DatasetWriter) storeTree(tree string) (string, error) {
+    sum := sha256.Sum256([]byte(tree))
+    ref := "sha256-" + hex.EncodeToString(sum[:]) + ".txt"
+    dir := w.Path + ".trees"
+    path := filepath.Join(dir, ref)
+    if _, err := os.Stat(path); err == nil {
+        return ref, nil
+    }
+    if err := os.MkdirAll(dir, 0700); err != nil {
+        return "", fmt.Errorf("cannot create dataset tree directory: %w", err)
+    }
+    if err := os.WriteFile(path, []byte(tree), 0600); err != nil {
+        return "", fmt.Errorf("cannot write dataset tree: %w", err)
+    }
+    return ref, nil
+}
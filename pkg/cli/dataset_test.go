@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+func readDataset(t *testing.T, path string) []DatasetRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []DatasetRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec DatasetRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v\n%s", len(records)+1, err, sc.Text())
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestDatasetWriter_AppendsJSONLAndRedacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	w := &DatasetWriter{Path: path, now: func() time.Time { return ts }}
+
+	secret := "sk-abcdefghijklmnopqrstuvwx"
+	tree := "Documents\n├── 01_PROJECTS\n└── token=" + secret + "\n"
+	resp := &api.LLMResponse{Path: "/Documents/01_PROJECTS", Reason: "contains api_key: hunter22"}
+	if err := w.Record("invoice with key "+secret, tree, "gpt-4", resp); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Record("second item", "", "gpt-4", &api.LLMResponse{Path: "/Other", Reason: "r"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, leaked := range []string{secret, "hunter22"} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("dataset leaked %q:\n%s", leaked, data)
+		}
+	}
+
+	records := readDataset(t, path)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	first := records[0]
+	if !first.Timestamp.Equal(ts) || first.Model != "gpt-4" || first.Path != "/Documents/01_PROJECTS" {
+		t.Errorf("unexpected record: %+v", first)
+	}
+	if !strings.Contains(first.Tree, "01_PROJECTS") || !strings.Contains(first.Tree, "[REDACTED]") {
+		t.Errorf("tree not kept with secrets redacted: %q", first.Tree)
+	}
+	if records[1].Tree != "" || records[1].TreeRef != "" {
+		t.Errorf("empty tree recorded as %+v", records[1])
+	}
+}
+
+func TestDatasetWriter_TreeByRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	w := &DatasetWriter{Path: path, TreeByRef: true}
+
+	tree := "Documents\n└── Finance\n"
+	for _, desc := range []string{"a", "b"} {
+		if err := w.Record(desc, tree, "m", &api.LLMResponse{Path: "/Documents/Finance"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := readDataset(t, path)
+	if len(records) != 2 || records[0].Tree != "" || records[0].TreeRef == "" || records[0].TreeRef != records[1].TreeRef {
+		t.Fatalf("records = %+v, want one shared tree reference", records)
+	}
+	stored, err := os.ReadFile(filepath.Join(path+".trees", records[0].TreeRef))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != tree {
+		t.Errorf("stored tree = %q, want %q", stored, tree)
+	}
+	if entries, _ := os.ReadDir(path + ".trees"); len(entries) != 1 {
+		t.Errorf("tree directory has %d files, want 1", len(entries))
+	}
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+    apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+    treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// doctorCheck is the outcome of one sortpath doctor check. Err is nil when
+// the check passed; Hint says how to fix a failure.
+type doctorCheck struct {
+    Name   string
+    Detail string
+    Hint   string
+    Err    error
+}
+
+// HandleDoctorCommand checks the config, the API endpoint, the tree, the
+// environment and the install, printing ✅ or ❌ per check. It exits with
+// the exit code of the first failed check.
+func HandleDoctorCommand(args []string) {
+    var profile, treePath string
+    var noAuth bool
+    fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+    fs.StringVar(&profile, "profile", "", "Config profile to check")
+    fs.StringVar(&treePath, "tree", "", "Folder to check instead of the configured tree")
+    fs.BoolVar(&noAuth, "no-auth", false, "Check a keyless server that isn't on localhost, sending no API key")
+    fs.SetOutput(out.Errors())
+    if err := fs.Parse(args); err != nil {
+        // Parse already printed the problem and usage; -h is not an error
+        if errors.Is(err, flag.ErrHelp) {
+            return
+        }
+        os.Exit(apperrors.ExitFailure)
+    }
+    if fs.NArg() > 0 {
+        out.Error("Usage: sortpath doctor [--profile NAME] [--tree DIR] [--no-auth]\n")
+        os.Exit(1)
+    }
+
+    checks := runDoctor(config.CLIOptions{Profile: profile, TreePath: treePath, NoAuth: noAuth})
+    if err := writeDoctorReport(out.Results(), checks); err != nil {
+        os.Exit(apperrors.ExitCode(err))
+    }
+}
+
+// runDoctor runs every check against the effective configuration for opts
+func runDoctor(opts config.CLIOptions) []doctorCheck {
+    conf, _, problems := config.CheckConfig(opts)
+    var treeProblem error
+    var others []error
+    for _, p := range problems {
+        var fieldErr *config.FieldError
+        if errors.As(p, &fieldErr) && fieldErr.Key == "tree-path" {
+            treeProblem = p
+            continue
+        }
+        others = append(others, p)
+    }
+
+    checks := []doctorCheck{
+        configCheck(conf, others),
+        apiCheck(conf),
+        treeCheck(conf, treeProblem),
+        environmentCheck(),
+        installCheck(),
+    }
+    for i := range checks {
+        checks[i].Detail = redactDoctor(conf, checks[i].Detail)
+    }
+    return checks
+}
+
+// writeDoctorReport prints checks with their hints and a summary, returning
+// the error of the first failed check
+func writeDoctorReport(w io.Writer, checks []doctorCheck) error {
+    var first error
+    failed := 0
+    for _, c := range checks {
+        mark := "✅"
+        if c.Err != nil {
+            mark = "❌"
+            failed++
+            if first == nil {
+                first = c.Err
+            }
+        }
+        fmt.Fprintf(w, "%s %-12s %s\n", mark, c.Name+":", c.Detail)
+        if c.Err != nil && c.Hint != "" {
+            fmt.Fprintf(w, "   💡 %s\n", c.Hint)
+        }
+    }
+    if failed == 0 {
+        fmt.Fprintln(w, "\nAll checks passed")
+    } else {
+        fmt.Fprintf(w, "\n%d of %d checks failed\n", failed, len(checks))
+    }
+    return first
+}
+
+// configCheck reports config problems other than the tree, which has its
+// own check
+func configCheck(conf *config.Config, problems []error) doctorCheck {
+    check := doctorCheck{Name: "Config", Detail: "valid"}
+    if len(problems) == 0 {
+        return check
+    }
+    msgs := make([]string, len(problems))
+    for i, p := range problems {
+        msgs[i] = p.Error()
+    }
+    check.Detail = strings.Join(msgs, "; ")
+    check.Hint = "Run 'sortpath config validate' to see where each value comes from"
+    check.Err = problemError(conf, problems[0])
+    return check
+}
+
+// apiCheck lists models at the API endpoint, telling an unreachable endpoint
+// apart from a rejected API key
+func apiCheck(conf *config.Config) doctorCheck {
+    base := api.RedactURL(conf.APIBase)
+    check := doctorCheck{Name: "API"}
+    if conf.APIKey == "" && conf.RequiresAPIKey() {
+        check.Detail = "no API key configured"
+        check.Hint = "Set your API key with: sortpath config set api-key YOUR_KEY"
+        check.Err = apperrors.ConfigError("API key is required", nil)
+        return check
+    }
+
+    err := api.Probe(conf)
+    switch {
+    case err == nil:
+        check.Detail = fmt.Sprintf("%s is reachable and accepts the API key", base)
+        if conf.NoAuth || conf.APIKey == "" {
+            check.Detail = fmt.Sprintf("%s is reachable (no auth)", base)
+        }
+    case apperrors.IsType(err, "NETWORK_ERROR"):
+        check.Detail = fmt.Sprintf("cannot reach %s: %v", base, networkCause(err))
+        check.Hint = "Check your network connection, proxy settings and api-base"
+    case isAuthFailure(err):
+        check.Detail = fmt.Sprintf("%s rejected the API key: %v", base, err)
+        check.Hint = "Check your API key with: sortpath config get api-key"
+    default:
+        check.Detail = fmt.Sprintf("%s answered with an error: %v", base, err)
+        check.Hint = "Check that api-base points at an OpenAI-compatible API (usually ending in /v1)"
+    }
+    check.Err = err
+    return check
+}
+
+// isAuthFailure reports whether err is the API refusing the credentials
+func isAuthFailure(err error) bool {
+    status, _ := apperrors.GetContext(err, "status")
+    return status == 401 || status == 403
+}
+
+// networkCause strips the request URL from a transport error, since it may
+// carry credentials in its query string
+func networkCause(err error) error {
+    var urlErr *url.Error
+    if errors.As(err, &urlErr) {
+        return urlErr.Err
+    }
+    return err
+}
+
+// treeCheck reports whether the tree is a folder sortpath can read, naming
+// any folders inside it that are left out of the tree because they can't be
+// read. Those don't fail the check: the rest of the tree is still used.
+func treeCheck(conf *config.Config, problem error) doctorCheck {
+    check := doctorCheck{Name: "Tree", Detail: fmt.Sprintf("%s is a readable folder", conf.TreePath)}
+    if problem != nil {
+        check.Detail = problem.Error()
+        check.Hint = "Set the folder with: sortpath config set tree-path DIR, or pass --tree DIR"
+        check.Err = problemError(conf, problem)
+        return check
+    }
+    root, err := treefs.Walk(conf.TreePath, TreeOptions(conf)...)
+    if err != nil {
+        check.Detail = fmt.Sprintf("cannot read %s: %v", conf.TreePath, err)
+        check.Err = apperrors.FSError(check.Detail, conf.TreePath, nil)
+        return check
+    }
+    if unreadable := treefs.Unreadable(root); len(unreadable) > 0 {
+        noun, verb := "folders", "are"
+        if len(unreadable) == 1 {
+            noun, verb = "folder", "is"
+        }
+        check.Detail += fmt.Sprintf(", but %d unreadable %s in it %s left out of the tree: %s", len(unreadable), noun, verb, summarizePaths(unreadable, 3))
+    }
+    return check
+}
+
+// summarizePaths joins the first max paths, noting how many more there are
+func summarizePaths(paths []string, max int) string {
+    if len(paths) <= max {
+        return strings.Join(paths, ", ")
+    }
+    return fmt.Sprintf("%s and %d more", strings.Join(paths[:max], ", "), len(paths)-max)
+}
+
+// environmentCheck reports the detected environment; it never fails
+func environmentCheck() doctorCheck {
+    return doctorCheck{Name: "Environment", Detail: config.DefaultEnvironmentDetector.GetEnvironmentType()}
+}
+
+// installCheck reports whether the running binary's folder is on PATH
+func installCheck() doctorCheck {
+    check := doctorCheck{Name: "Install"}
+    execPath, err := os.Executable()
+    if err != nil {
+        check.Detail = fmt.Sprintf("cannot locate the sortpath binary: %v", err)
+        check.Err = apperrors.InstallError("cannot locate the sortpath binary", err)
+        return check
+    }
+    dir := filepath.Dir(execPath)
+    if pathContainsDir(dir) {
+        check.Detail = fmt.Sprintf("%s is on PATH", dir)
+        return check
+    }
+    check.Detail = fmt.Sprintf("%s is not on PATH", dir)
+    check.Hint = "Run 'sortpath install' to copy it to /usr/local/bin, or add the folder to PATH"
+    check.Err = apperrors.InstallError(check.Detail, nil)
+    return check
+}
+
+// redactDoctor masks the API key and other credentials in s
+func redactDoctor(conf *config.Config, s string) string {
+    if conf.APIKey != "" {
+        s = strings.ReplaceAll(s, conf.APIKey, config.RedactSensitiveValue("api-key", conf.APIKey))
+    }
+    return treefs.RedactSecrets(s)
+}
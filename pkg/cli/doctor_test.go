@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+)
+
+func TestRunDoctor(t *testing.T) {
+	home := isolateConfig(t)
+	const key = "sk-doctor-secret-1234567890"
+	validKey := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !validKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"Incorrect API key provided: ` + key + `","code":"invalid_api_key"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("OPENAI_API_KEY", key)
+	t.Setenv("OPENAI_API_BASE", srv.URL+"/v1")
+
+	var buf bytes.Buffer
+	err := writeDoctorReport(&buf, runDoctor(config.CLIOptions{TreePath: home}))
+	for _, want := range []string{"✅ Config:", "✅ API:", "✅ Tree:", "✅ Environment:"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+	if err != nil && !apperrors.IsType(err, "INSTALL_ERROR") {
+		t.Errorf("writeDoctorReport() = %v, want only the install check to fail", err)
+	}
+
+	validKey = false
+	buf.Reset()
+	err = writeDoctorReport(&buf, runDoctor(config.CLIOptions{TreePath: home + "/missing"}))
+	if apperrors.ExitCode(err) != apperrors.ExitAPI {
+		t.Errorf("exit code = %d, want %d for a rejected key", apperrors.ExitCode(err), apperrors.ExitAPI)
+	}
+	report := buf.String()
+	for _, want := range []string{"❌ API:", "rejected the API key", "💡 Check your API key", "❌ Tree:"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, key) {
+		t.Errorf("report leaks the API key:\n%s", report)
+	}
+}
+
+func TestDoctorAPICheck_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	base := srv.URL + "/v1?api-key=hunter2"
+	srv.Close()
+
+	check := apiCheck(&config.Config{APIBase: base, APIKey: "sk-x", Timeout: "2s"})
+	if !apperrors.IsType(check.Err, "NETWORK_ERROR") {
+		t.Fatalf("apiCheck() error = %v, want a NETWORK_ERROR", check.Err)
+	}
+	if !strings.HasPrefix(check.Detail, "cannot reach") || strings.Contains(check.Detail, "hunter2") {
+		t.Errorf("detail = %q, want an unreachable message without the query secret", check.Detail)
+	}
+}
+
+func TestDoctorAPICheck_Keyless(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+
+	// A server on this machine needs no key, elsewhere one is required
+	check := apiCheck(&config.Config{APIBase: srv.URL + "/v1", Timeout: "2s"})
+	if check.Err != nil || !strings.Contains(check.Detail, "(no auth)") {
+		t.Errorf("apiCheck() = %q, %v; want a keyless local server accepted", check.Detail, check.Err)
+	}
+	check = apiCheck(&config.Config{APIBase: "https://llm.example.com/v1"})
+	if check.Detail != "no API key configured" {
+		t.Errorf("apiCheck() = %q, want a missing key reported for a remote server", check.Detail)
+	}
+	if len(auth) != 1 || auth[0] != "" {
+		t.Errorf("Authorization headers = %q, want one request without a key", auth)
+	}
+}
+
+func TestDoctorTreeCheck_Unreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks are bypassed when running as root")
+	}
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "locked")
+	if err := os.Mkdir(locked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	check := treeCheck(&config.Config{TreePath: dir}, nil)
+	if check.Err != nil {
+		t.Fatalf("treeCheck() failed: %v", check.Err)
+	}
+	if !strings.Contains(check.Detail, "1 unreadable folder in it is left out of the tree: locked") {
+		t.Errorf("Detail = %q, want the unreadable folder named", check.Detail)
+	}
+}
+
+func TestSummarizePaths(t *testing.T) {
+	if got := summarizePaths([]string{"a", "b"}, 3); got != "a, b" {
+		t.Errorf("summarizePaths() = %q, want a, b", got)
+	}
+	if got := summarizePaths([]string{"a", "b", "c", "d", "e"}, 3); got != "a, b, c and 2 more" {
+		t.Errorf("summarizePaths() = %q, want a, b, c and 2 more", got)
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	"github.com/kacperkwapisz/sortpath/internal/ui"
+	"github.com/kacperkwapisz/sortpath/internal/updater"
+)
+
+func TestHandleInstallCommand_RecordsPath(t *testing.T) {
+	isolateConfig(t)
+	stdout, _ := useOutput(t, ui.Options{})
+	dir := t.TempDir()
+
+	HandleInstallCommand([]string{"--path", dir})
+
+	dest := filepath.Join(dir, "sortpath")
+	if !strings.Contains(stdout.String(), "Installed sortpath to "+dest) {
+		t.Fatalf("install output = %q", stdout)
+	}
+	conf, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.InstalledPath != dest {
+		t.Fatalf("InstalledPath = %q, want %q", conf.InstalledPath, dest)
+	}
+
+	// update trusts the recorded binary, but not other copies of it
+	if !updater.IsInstalledAt(dest) {
+		t.Error("IsInstalledAt(installed binary) = false, want true")
+	}
+	other := filepath.Join(t.TempDir(), "sortpath")
+	if err := copyFile(dest, other); err != nil {
+		t.Fatal(err)
+	}
+	if updater.IsInstalledAt(other) {
+		t.Error("IsInstalledAt(a copy elsewhere) = true, want false")
+	}
+
+	// A recorded binary that lost its execute bit or was deleted no longer counts
+	if err := os.Chmod(dest, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if updater.IsInstalledAt(dest) {
+		t.Error("IsInstalledAt(non-executable binary) = true, want false")
+	}
+	if err := os.Remove(dest); err != nil {
+		t.Fatal(err)
+	}
+	if updater.IsInstalledAt(dest) {
+		t.Error("IsInstalledAt(deleted binary) = true, want false")
+	}
+}
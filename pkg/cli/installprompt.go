@@ -0,0 +1,73 @@
+package cli
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// promptOutput receives interactive prompts, on stderr so stdout only ever
+// carries results; tests replace it
+var promptOutput io.Writer = os.Stderr
+
+// installDeclinedPath is the marker recording that the user said no to the
+// install prompt
+func installDeclinedPath() string {
+    return filepath.Join(config.CacheDir(), "install-declined")
+}
+
+// MaybePromptInstall offers to install sortpath when it runs from a directory
+// that isn't on PATH. The prompt is skipped in non-interactive environments,
+// and a "no" is remembered until ResetInstallPrompt clears it.
+func MaybePromptInstall() {
+    execPath, err := os.Executable()
+    if err != nil {
+        return
+    }
+    if !shouldPromptInstall(filepath.Dir(execPath)) {
+        return
+    }
+    if askInstall() {
+        HandleInstallCommand([]string{})
+    }
+}
+
+// shouldPromptInstall reports whether the install prompt applies to execDir
+func shouldPromptInstall(execDir string) bool {
+    if !interactive() || pathContainsDir(execDir) {
+        return false
+    }
+    _, err := os.Stat(installDeclinedPath())
+    return os.IsNotExist(err)
+}
+
+// askInstall asks the question, recording a decline so it isn't asked again
+func askInstall() bool {
+    fmt.Fprint(promptOutput, "📦 Install sortpath to /usr/local/bin so you can run it from anywhere? [Y/n]: ")
+    answer, _ := bufio.NewReader(promptInput).ReadString('\n')
+    answer = strings.TrimSpace(strings.ToLower(answer))
+    if answer == "" || answer == "y" || answer == "yes" {
+        return true
+    }
+
+    marker := installDeclinedPath()
+    if err := os.MkdirAll(filepath.Dir(marker), 0755); err == nil {
+        _ = os.WriteFile(marker, []byte(time.Now().Format(time.RFC3339)+"\n"), 0644)
+    }
+    fmt.Fprintln(promptOutput, "OK, not asking again. Run 'sortpath --reset-install-prompt' to be asked next time.")
+    return false
+}
+
+// ResetInstallPrompt forgets a declined install prompt
+func ResetInstallPrompt() error {
+    if err := os.Remove(installDeclinedPath()); err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInstallPrompt_DeclineIsRemembered(t *testing.T) {
+	isolateConfig(t)
+	t.Setenv("PATH", "/usr/bin")
+	execDir := t.TempDir() // a build dir, not on PATH
+
+	stubTerminal(t, true, "n\n")
+	var out bytes.Buffer
+	origOutput := promptOutput
+	promptOutput = &out
+	defer func() { promptOutput = origOutput }()
+
+	if !shouldPromptInstall(execDir) {
+		t.Fatal("prompt should be shown before any decision")
+	}
+	if askInstall() {
+		t.Fatal("answer 'n' should decline the install")
+	}
+	if shouldPromptInstall(execDir) {
+		t.Error("prompt should be suppressed after a recorded decline")
+	}
+	if !strings.Contains(out.String(), "--reset-install-prompt") {
+		t.Errorf("decline message should mention the reset flag:\n%s", out.String())
+	}
+
+	if err := ResetInstallPrompt(); err != nil {
+		t.Fatalf("ResetInstallPrompt() error = %v", err)
+	}
+	if !shouldPromptInstall(execDir) {
+		t.Error("prompt should be shown again after reset")
+	}
+
+	// Resetting with nothing recorded is not an error
+	if err := ResetInstallPrompt(); err != nil {
+		t.Errorf("second ResetInstallPrompt() error = %v", err)
+	}
+}
+
+func TestInstallPrompt_SkippedWhenNonInteractiveOrOnPath(t *testing.T) {
+	isolateConfig(t)
+	execDir := t.TempDir()
+
+	stubTerminal(t, false, "")
+	t.Setenv("PATH", "/usr/bin")
+	if shouldPromptInstall(execDir) {
+		t.Error("non-interactive runs should never prompt")
+	}
+
+	stubTerminal(t, true, "")
+	t.Setenv("PATH", "/usr/bin:"+execDir)
+	if shouldPromptInstall(execDir) {
+		t.Error("a binary already on PATH should not prompt")
+	}
+}
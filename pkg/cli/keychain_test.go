@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// noKeychain behaves like a system without a keychain
+type noKeychain struct{}
+
+func (noKeychain) Get(string) (string, error) { return "", config.ErrKeychainUnavailable }
+func (noKeychain) Set(string, string) error   { return config.ErrKeychainUnavailable }
+func (noKeychain) Delete(string) error        { return config.ErrKeychainUnavailable }
+
+func TestSetKeychainValue_Fallback(t *testing.T) {
+	isolateConfig(t)
+	notes := stubTerminal(t, false, "")
+	orig := config.DefaultKeychain
+	config.DefaultKeychain = noKeychain{}
+	t.Cleanup(func() { config.DefaultKeychain = orig })
+
+	if err := setKeychainValue("", "api-key", "sk-fallback-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(notes.String(), "No keychain is available") {
+		t.Errorf("notices = %q, want the fallback explained", notes.String())
+	}
+	if got, _ := getConfigValue("api-key"); got != "sk-fallback-1234567890" {
+		t.Errorf("api-key = %q, want it stored in the config file", got)
+	}
+	if err := setKeychainValue("work", "api-key", "sk-work-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := getProfileValue("work", "api-key"); got != "sk-work-1234567890" {
+		t.Errorf("work api-key = %q, want it stored in the profile", got)
+	}
+	if err := setKeychainValue("", "model", "gpt-4o"); err == nil {
+		t.Error("expected --use-keychain with another key to be refused")
+	}
+}
+
+func TestSetSecret_KeychainGone(t *testing.T) {
+	isolateConfig(t)
+	notes := stubTerminal(t, false, "")
+	if err := config.Update(func(c *config.Config) error {
+		c.APIKey = config.KeychainSentinel
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	orig := config.DefaultKeychain
+	config.DefaultKeychain = noKeychain{}
+	t.Cleanup(func() { config.DefaultKeychain = orig })
+
+	if err := setConfigValue("api-key", "sk-plain-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(notes.String(), "storing the api-key in the config file instead") {
+		t.Errorf("notices = %q, want the fallback explained", notes.String())
+	}
+	if got, _ := getConfigValue("api-key"); got != "sk-plain-1234567890" {
+		t.Errorf("api-key = %q, want it stored in the config file", got)
+	}
+
+	if err := config.Update(func(c *config.Config) error {
+		c.APIKey = config.KeychainSentinel
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	notes.Reset()
+	if err := removeConfigValue("api-key"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(notes.String(), "keychain item stays") {
+		t.Errorf("notices = %q, want the leftover keychain item mentioned", notes.String())
+	}
+	if c, _ := config.Load(); c.APIKey != "" {
+		t.Errorf("APIKey = %q, want it removed from the config file", c.APIKey)
+	}
+}
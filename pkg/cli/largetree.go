@@ -0,0 +1,101 @@
+package cli
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/ai"
+    treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// Limits for the branch overview shown in the first stage of --large-tree
+const (
+    branchOverviewDepth   = 1
+    branchOverviewEntries = 8
+)
+
+// PromptFunc sends a finished prompt to the model
+type PromptFunc func(prompt string) (*api.LLMResponse, error)
+
+// RecommendLargeTree sorts desc into a tree too large for one prompt in two
+// calls: the model first picks a top-level branch from a shallow overview,
+// then recommends a path from the full tree of that branch only. The final
+// path is always inside the chosen branch and usage covers both calls.
+// treeOpts bound the walk, e.g. with treefs.WithMaxDepth.
+func RecommendLargeTree(treePath, desc string, promptOpts ai.PromptOptions, query PromptFunc, treeOpts ...treefs.TreeOption) (*api.LLMResponse, error) {
+    root, err := walkTree(treePath, treeOpts...)
+    if err != nil {
+        return nil, treeError(treePath, err)
+    }
+
+    var branches []*treefs.Node
+    for _, child := range root.Children {
+        if child.IsDir {
+            branches = append(branches, child)
+        }
+    }
+    if len(branches) == 0 {
+        return nil, fmt.Errorf("--large-tree needs top-level folders in %s", treePath)
+    }
+
+    // Map: pick the branch from a cheap overview
+    overview, err := treefs.Render(root, treefs.TreeOptions{MaxDepth: branchOverviewDepth, MaxEntries: branchOverviewEntries})
+    if err != nil {
+        return nil, err
+    }
+    pick, err := query(ai.BuildBranchPrompt(overview, desc, promptOpts))
+    if err != nil {
+        return nil, err
+    }
+    branch := matchBranch(branches, pick.Path)
+    if branch == nil {
+        return nil, fmt.Errorf("model chose %q, which is not a top-level folder", pick.Path)
+    }
+    fmt.Fprintf(notices, "ℹ️ Large tree: searching in /%s\n", treefs.SafeName(branch.Name))
+    logger.Debug("large tree: model chose branch %q of %d", branch.Name, len(branches))
+
+    // Reduce: full prompt over the chosen branch only, kept under its name
+    // so the model answers with paths from the tree root
+    subtree, err := treefs.Render(&treefs.Node{Name: root.Name, IsDir: true, Children: []*treefs.Node{branch}}, treefs.TreeOptions{MaxDepth: -1})
+    if err != nil {
+        return nil, err
+    }
+    resp, err := query(ai.BuildPromptWithOptions(subtree, desc, promptOpts))
+    if err != nil {
+        return nil, err
+    }
+
+    resp.Path = withinBranch(treefs.SafeName(branch.Name), resp.Path)
+    resp.Usage.PromptTokens += pick.Usage.PromptTokens
+    resp.Usage.CompletionTokens += pick.Usage.CompletionTokens
+    resp.Usage.TotalTokens += pick.Usage.TotalTokens
+    return resp, nil
+}
+
+// matchBranch finds the branch the model named, ignoring slashes and case
+func matchBranch(branches []*treefs.Node, answer string) *treefs.Node {
+    name := strings.Trim(strings.TrimSpace(answer), "/")
+    if i := strings.Index(name, "/"); i >= 0 {
+        name = name[:i]
+    }
+    for _, b := range branches {
+        if strings.EqualFold(treefs.SafeName(b.Name), name) {
+            return b
+        }
+    }
+    return nil
+}
+
+// withinBranch makes sure path starts at branch, prefixing it when the model
+// answered relative to the branch
+func withinBranch(branch, path string) string {
+    trimmed := strings.Trim(strings.TrimSpace(path), "/")
+    if trimmed == branch || strings.HasPrefix(trimmed, branch+"/") {
+        return "/" + trimmed
+    }
+    if trimmed == "" {
+        return "/" + branch
+    }
+    return "/" + branch + "/" + trimmed
+}
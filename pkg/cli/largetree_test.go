@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+func largeTreeFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{
+		"01_PROJECTS/2025/BrandX/Deliverables",
+		"03_PHOTOS/2025/Berlin_Trip",
+		"07_RESOURCES/Mockups/Clothing",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// scriptedModel answers each call with the next response and records prompts
+type scriptedModel struct {
+	responses []*api.LLMResponse
+	prompts   []string
+}
+
+func (m *scriptedModel) query(prompt string) (*api.LLMResponse, error) {
+	m.prompts = append(m.prompts, prompt)
+	resp := m.responses[len(m.prompts)-1]
+	return resp, nil
+}
+
+func TestRecommendLargeTree_TwoStages(t *testing.T) {
+	root := largeTreeFixture(t)
+	orig := notices
+	notices = &bytes.Buffer{}
+	defer func() { notices = orig }()
+
+	model := &scriptedModel{responses: []*api.LLMResponse{
+		{Path: "/07_RESOURCES", Reason: "mockups are resources", Usage: api.Usage{TotalTokens: 50}},
+		{Path: "/07_RESOURCES/Mockups/Clothing", Reason: "clothing mockup", Usage: api.Usage{TotalTokens: 300}},
+	}}
+
+	resp, err := RecommendLargeTree(root, "Clothing mockup, PSD file", ai.PromptOptions{}, model.query)
+	if err != nil {
+		t.Fatalf("RecommendLargeTree() error = %v", err)
+	}
+	if len(model.prompts) != 2 {
+		t.Fatalf("model called %d times, want 2", len(model.prompts))
+	}
+
+	// Stage 1 sees every branch but not the deep contents
+	for _, branch := range []string{"01_PROJECTS", "03_PHOTOS", "07_RESOURCES"} {
+		if !strings.Contains(model.prompts[0], branch) {
+			t.Errorf("branch prompt missing %s", branch)
+		}
+	}
+	if strings.Contains(model.prompts[0], "Deliverables") {
+		t.Error("branch prompt should not include deep entries")
+	}
+
+	// Stage 2 sees only the selected branch, in full
+	if !strings.Contains(model.prompts[1], "Clothing") || strings.Contains(model.prompts[1], "── 01_PROJECTS") {
+		t.Errorf("final prompt should contain only the 07_RESOURCES branch:\n%s", model.prompts[1])
+	}
+
+	if resp.Path != "/07_RESOURCES/Mockups/Clothing" {
+		t.Errorf("Path = %q, want the path inside the selected branch", resp.Path)
+	}
+	if resp.Usage.Total() != 350 {
+		t.Errorf("Usage = %d tokens, want both calls counted (350)", resp.Usage.Total())
+	}
+}
+
+func TestRecommendLargeTree_PathKeptInsideBranch(t *testing.T) {
+	root := largeTreeFixture(t)
+	orig := notices
+	notices = &bytes.Buffer{}
+	defer func() { notices = orig }()
+
+	// The model answers relative to the branch in the second stage
+	model := &scriptedModel{responses: []*api.LLMResponse{
+		{Path: "03_photos"},
+		{Path: "/2025/Berlin_Trip"},
+	}}
+	resp, err := RecommendLargeTree(root, "Berlin trip photos", ai.PromptOptions{}, model.query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Path != "/03_PHOTOS/2025/Berlin_Trip" {
+		t.Errorf("Path = %q, want it prefixed with the selected branch", resp.Path)
+	}
+
+	model = &scriptedModel{responses: []*api.LLMResponse{{Path: "/99_UNKNOWN"}}}
+	if _, err := RecommendLargeTree(root, "x", ai.PromptOptions{}, model.query); err == nil {
+		t.Error("expected an error when the model picks a branch that doesn't exist")
+	}
+}
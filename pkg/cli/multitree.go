@@ -0,0 +1,82 @@
+package cli
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/ai"
+    treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// TreeRoot is one labeled archive given with a repeated --tree flag
+type TreeRoot struct {
+    Label string
+    Path  string
+}
+
+// stringList collects the values of a repeated flag such as --tree
+type stringList []string
+
+func (l *stringList) String() string {
+    return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(v string) error {
+    *l = append(*l, v)
+    return nil
+}
+
+// ParseTreeRoots labels --tree values. A value is either LABEL=PATH or a
+// plain path labeled with its base name. Labels must be unique.
+func ParseTreeRoots(values []string) ([]TreeRoot, error) {
+    roots := make([]TreeRoot, 0, len(values))
+    seen := map[string]bool{}
+    for _, v := range values {
+        root := TreeRoot{Path: v}
+        if label, path, ok := strings.Cut(v, "="); ok && label != "" && !strings.ContainsAny(label, `/\`) {
+            root = TreeRoot{Label: label, Path: path}
+        }
+        if root.Path == "" {
+            return nil, fmt.Errorf("tree %q has no path", v)
+        }
+        if root.Label == "" {
+            root.Label = filepath.Base(filepath.Clean(root.Path))
+        }
+        key := strings.ToLower(root.Label)
+        if seen[key] {
+            return nil, fmt.Errorf("duplicate tree label %q; name the trees with --tree LABEL=PATH", root.Label)
+        }
+        seen[key] = true
+        roots = append(roots, root)
+    }
+    return roots, nil
+}
+
+// BuildMultiTreePrompt walks every root under treeOpts and presents them side
+// by side, so the model picks the archive as well as the folder within it
+func BuildMultiTreePrompt(roots []TreeRoot, desc string, promptOpts ai.PromptOptions, treeOpts ...treefs.TreeOption) (string, error) {
+    trees := make([]ai.LabeledTree, 0, len(roots))
+    for _, root := range roots {
+        tree, err := buildTree(root.Path, treeOpts...)
+        if err != nil {
+            return "", treeError(root.Path, fmt.Errorf("tree %s: %w", root.Label, err))
+        }
+        trees = append(trees, ai.LabeledTree{Label: root.Label, Tree: tree})
+    }
+    return ai.BuildMultiTreePrompt(trees, desc, promptOpts), nil
+}
+
+// ResolveRoot finds the root named in a multi-tree response, normalizing
+// resp.Root to its exact label
+func ResolveRoot(roots []TreeRoot, resp *api.LLMResponse) (TreeRoot, error) {
+    chosen := strings.TrimSpace(resp.Root)
+    for _, root := range roots {
+        if strings.EqualFold(root.Label, chosen) {
+            resp.Root = root.Label
+            return root, nil
+        }
+    }
+    return TreeRoot{}, fmt.Errorf("model chose unknown tree %q", chosen)
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+func TestParseTreeRoots(t *testing.T) {
+	roots, err := ParseTreeRoots([]string{"work=/archive/Work", "/home/me/Personal", "./x=y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TreeRoot{
+		{Label: "work", Path: "/archive/Work"},
+		{Label: "Personal", Path: "/home/me/Personal"},
+		{Label: "x=y", Path: "./x=y"},
+	}
+	if !reflect.DeepEqual(roots, want) {
+		t.Errorf("ParseTreeRoots() = %+v, want %+v", roots, want)
+	}
+
+	if _, err := ParseTreeRoots([]string{"/a/Archive", "/b/archive"}); err == nil || !strings.Contains(err.Error(), "LABEL=PATH") {
+		t.Errorf("duplicate labels error = %v, want a hint to name them", err)
+	}
+}
+
+func TestParseArgs_RepeatedTree(t *testing.T) {
+	opts, _ := ParseArgs([]string{"--tree", "work=/w", "--tree", "/p", "desc"})
+	if opts.TreePath != "/w" || !reflect.DeepEqual(opts.Trees, []string{"work=/w", "/p"}) {
+		t.Errorf("TreePath = %q, Trees = %v", opts.TreePath, opts.Trees)
+	}
+
+	opts, _ = ParseArgs([]string{"--tree", "work=/w", "desc"})
+	if opts.TreePath != "/w" || opts.Trees != nil {
+		t.Errorf("single labeled tree: TreePath = %q, Trees = %v", opts.TreePath, opts.Trees)
+	}
+}
+
+func TestMultiTree_RecommendsRootAndPath(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"work/Clients/BrandX", "work/Invoices", "personal/Photos/2025", "personal/Taxes"} {
+		if err := os.MkdirAll(filepath.Join(base, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	roots, err := ParseTreeRoots([]string{
+		"Work=" + filepath.Join(base, "work"),
+		"Personal=" + filepath.Join(base, "personal"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prompt, err := BuildMultiTreePrompt(roots, "Holiday photos from Lisbon", ai.PromptOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<tree root="Work">`, `<tree root="Personal">`, "BrandX", "Photos", "<root></root>"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+
+	resp := &api.LLMResponse{Root: " personal ", Path: "/Photos/2025/Lisbon", Reason: "personal photos"}
+	root, err := ResolveRoot(roots, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Path != filepath.Join(base, "personal") || resp.Root != "Personal" || resp.Path != "/Photos/2025/Lisbon" {
+		t.Errorf("resolved root = %+v, response = %+v", root, resp)
+	}
+
+	if _, err := ResolveRoot(roots, &api.LLMResponse{Root: "Archive"}); err == nil {
+		t.Error("expected an error for a root that wasn't offered")
+	}
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/app"
+    "github.com/kacperkwapisz/sortpath/internal/config"
+    "github.com/kacperkwapisz/sortpath/internal/ui"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// out routes everything the cli package prints; main replaces it once the
+// output flags are parsed
+var out = ui.Std(ui.Options{})
+
+// SetOutput routes cli output, including notices and prompts, through o
+func SetOutput(o *ui.Output) {
+    out = o
+    notices = o.Diagnostics()
+    promptOutput = o.Errors()
+}
+
+// Result formats for --format
+const (
+    FormatFull = "full"
+    FormatPath = "path"
+    FormatJSON = "json"
+)
+
+// Log color modes for --color
+const (
+    ColorAuto   = "auto"
+    ColorAlways = "always"
+    ColorNever  = "never"
+)
+
+// stderrIsTerminal reports whether log lines reach a terminal; tests replace it
+var stderrIsTerminal = func() bool {
+    fi, err := os.Stderr.Stat()
+    return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// LogColor reports whether console log lines should be colored. auto colors
+// a terminal unless NO_COLOR or --no-color is set.
+func LogColor(opts config.CLIOptions) bool {
+    switch opts.Color {
+    case ColorAlways:
+        return true
+    case ColorNever:
+        return false
+    }
+    return !opts.NoColor && os.Getenv("NO_COLOR") == "" && stderrIsTerminal()
+}
+
+// OutputOptions derives the output options for a run from the parsed flags,
+// folding --format into them
+func OutputOptions(opts config.CLIOptions) (ui.Options, error) {
+    uiOpts := ui.Options{JSON: opts.JSON, Quiet: opts.Quiet, NoColor: opts.NoColor}
+    switch opts.Color {
+    case "", ColorAuto, ColorAlways, ColorNever:
+    default:
+        return uiOpts, fmt.Errorf("unknown --color %q; use auto, always or never", opts.Color)
+    }
+    switch opts.Format {
+    case "":
+    case FormatFull, FormatPath:
+        if opts.JSON {
+            return uiOpts, fmt.Errorf("--json cannot be combined with --format %s", opts.Format)
+        }
+        uiOpts.PathOnly = opts.Format == FormatPath
+    case FormatJSON:
+        uiOpts.JSON = true
+    default:
+        return uiOpts, fmt.Errorf("unknown --format %q; use full, path or json", opts.Format)
+    }
+    return uiOpts, nil
+}
+
+// logger traces the recommendation pipeline; it logs nothing until an
+// embedder or main injects one with SetLogger
+var logger api.Logger = app.NoopLogger{}
+
+// SetLogger routes the pipeline's log lines to l; nil turns logging off
+func SetLogger(l api.Logger) {
+    if l == nil {
+        l = app.NoopLogger{}
+    }
+    logger = l
+}
+
+// Result is the JSON form of one recommendation. The doc tags describe the
+// fields in the --json-schema output.
+type Result struct {
+    Description string `json:"description" doc:"The file description the recommendation is for"`
+    Root        string `json:"root,omitempty" doc:"Label of the chosen archive when several --tree roots were given"`
+    Path        string `json:"path" doc:"Recommended folder path, starting at the top of the tree; empty if the model gave none"`
+    Reason      string `json:"reason" doc:"Brief justification from the model"`
+    Unverified  bool   `json:"unverified,omitempty" doc:"Set when the path is not in the folder tree and may have been made up by the model"`
+
+    Alternatives []Alternative `json:"alternatives,omitempty" doc:"Runner-up folders, best first, when --count asked for more than one"`
+
+    Destination string `json:"destination,omitempty" doc:"Where --move or --copy put the file, or would put it with --dry-run"`
+    DryRun      bool   `json:"dry_run,omitempty" doc:"Set when --dry-run only planned the move or copy"`
+    CreatesDir  bool   `json:"creates_folder,omitempty" doc:"Set when the destination folder did not exist yet"`
+}
+
+// Alternative is the JSON form of one runner-up suggestion
+type Alternative struct {
+    Path   string `json:"path" doc:"Alternative folder path"`
+    Reason string `json:"reason" doc:"Brief justification from the model"`
+}
+
+// WriteResult prints a recommendation to stdout: the path and reason as
+// text, numbered when there are alternatives, or one JSON object per line in
+// JSON mode
+func WriteResult(desc string, resp *api.LLMResponse) error {
+    return WritePlacedResult(desc, resp, nil)
+}
+
+// WritePlacedResult prints a recommendation like WriteResult, followed by
+// where the file was moved or copied when placed is not nil
+func WritePlacedResult(desc string, resp *api.LLMResponse, placed *Placement) error {
+    if out.JSON() {
+        result := Result{Description: desc, Root: resp.Root, Path: resp.Path, Reason: resp.Reason, Unverified: resp.Unverified}
+        for _, alt := range resp.Alternatives {
+            result.Alternatives = append(result.Alternatives, Alternative{Path: alt.Path, Reason: alt.Reason})
+        }
+        if placed != nil {
+            result.Destination, result.DryRun, result.CreatesDir = placed.Path, placed.DryRun, placed.CreatesDir
+        }
+        return out.ResultJSON(result)
+    }
+    if out.PathOnly() {
+        // Bare paths for scripts; a --dry-run plan is still printed below
+        out.Result("%s\n", resp.Path)
+        for _, alt := range resp.Alternatives {
+            out.Result("%s\n", alt.Path)
+        }
+        if placed != nil && placed.DryRun {
+            writePlan(placed)
+        }
+        return nil
+    }
+    if len(resp.Alternatives) > 0 {
+        writeRanked(resp)
+    } else {
+        out.Result("%s\n", markedPath(resp))
+        if resp.Root != "" {
+            out.Result("Root: %s\n", resp.Root)
+        }
+        out.Result("Reason: %s\n", resp.Reason)
+    }
+    if placed != nil && placed.DryRun {
+        writePlan(placed)
+    } else if placed != nil {
+        verb := "Moved"
+        if placed.Copied {
+            verb = "Copied"
+        }
+        out.Result("%s to: %s\n", verb, placed.Path)
+    }
+    return nil
+}
+
+// WriteBatchResult prints one --batch result: "input<TAB>path" as text,
+// with a third column naming the root when several trees were given, just
+// the path with --format path, or the JSON object WriteResult prints with
+// input as the description
+func WriteBatchResult(input string, resp *api.LLMResponse) error {
+    if out.JSON() {
+        return WriteResult(input, resp)
+    }
+    if out.PathOnly() {
+        out.Result("%s\n", resp.Path)
+        return nil
+    }
+    // Keep one line and two columns per item whatever the input holds
+    input = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(input)
+    if resp.Root != "" {
+        out.Result("%s\t%s\t%s\n", input, resp.Path, resp.Root)
+        return nil
+    }
+    out.Result("%s\t%s\n", input, resp.Path)
+    return nil
+}
+
+// writePlan prints a --dry-run plan one operation per line, for scripts. The
+// fields are TAB-separated so paths with spaces still split: "MKDIR<TAB>dir"
+// when the folder would be created, then "MOVE<TAB>src<TAB>dst" or
+// "COPY<TAB>src<TAB>dst"
+func writePlan(p *Placement) {
+    if p.CreatesDir {
+        out.Result("MKDIR\t%s\n", filepath.Dir(p.Path))
+    }
+    op := "MOVE"
+    if p.Copied {
+        op = "COPY"
+    }
+    out.Result("%s\t%s\t%s\n", op, p.Source, p.Path)
+}
+
+// markedPath is resp.Path followed by UnverifiedMark when it isn't in the tree
+func markedPath(resp *api.LLMResponse) string {
+    if resp.Unverified {
+        return resp.Path + " " + UnverifiedMark
+    }
+    return resp.Path
+}
+
+// writeRanked prints the recommendation and its alternatives as a numbered
+// list, best first
+func writeRanked(resp *api.LLMResponse) {
+    out.Result("1. %s\n   Reason: %s\n", markedPath(resp), resp.Reason)
+    for i, alt := range resp.Alternatives {
+        out.Result("%d. %s\n   Reason: %s\n", i+2, alt.Path, alt.Reason)
+    }
+}
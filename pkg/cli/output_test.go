@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	"github.com/kacperkwapisz/sortpath/internal/ui"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// useOutput routes cli output to buffers for the duration of the test
+func useOutput(t *testing.T, opts ui.Options) (stdout, stderr *bytes.Buffer) {
+	t.Helper()
+	origOut, origNotices, origPrompts := out, notices, promptOutput
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	SetOutput(ui.New(stdout, stderr, opts))
+	t.Cleanup(func() { out, notices, promptOutput = origOut, origNotices, origPrompts })
+	return stdout, stderr
+}
+
+func TestJSONOutput_StdoutOnlyHasResults(t *testing.T) {
+	stdout, stderr := useOutput(t, ui.Options{JSON: true})
+	root := largeTreeFixture(t)
+
+	model := &scriptedModel{responses: []*api.LLMResponse{
+		{Path: "/07_RESOURCES"},
+		{Path: "/07_RESOURCES/Mockups/Clothing", Reason: "clothing mockup"},
+	}}
+	desc := "Clothing mockup, PSD file"
+	resp, err := RecommendLargeTree(root, desc, ai.PromptOptions{}, model.query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteResult(desc, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("stdout has %d lines, want only the JSON result:\n%s", len(lines), stdout.String())
+	}
+	var got Result
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout.String())
+	}
+	want := Result{Description: desc, Path: "/07_RESOURCES/Mockups/Clothing", Reason: "clothing mockup"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("result = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(stderr.String(), "Large tree: searching in /07_RESOURCES") {
+		t.Errorf("notice missing from stderr: %q", stderr.String())
+	}
+}
+
+func TestQuietOutput_HidesNoticesButNotPrompts(t *testing.T) {
+	_, stderr := useOutput(t, ui.Options{Quiet: true})
+	isolateConfig(t)
+	origInput, origInteractive := promptInput, interactive
+	promptInput = strings.NewReader("n\n")
+	interactive = func() bool { return true }
+	t.Cleanup(func() { promptInput, interactive = origInput, origInteractive })
+
+	root := largeTreeFixture(t)
+	model := &scriptedModel{responses: []*api.LLMResponse{{Path: "/03_PHOTOS"}, {Path: "/03_PHOTOS/2025"}}}
+	if _, err := RecommendLargeTree(root, "photo", ai.PromptOptions{}, model.query); err != nil {
+		t.Fatal(err)
+	}
+	askInstall()
+
+	if strings.Contains(stderr.String(), "Large tree") {
+		t.Errorf("--quiet printed a notice: %q", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Install sortpath") {
+		t.Errorf("--quiet hid the install prompt: %q", stderr.String())
+	}
+}
+
+func TestWriteResult_Ranked(t *testing.T) {
+	resp := &api.LLMResponse{
+		Path:   "/Docs/Tax/2025",
+		Reason: "tax return",
+		Alternatives: []api.Suggestion{
+			{Path: "/Finance/Receipts", Reason: "receipt-like"},
+		},
+	}
+
+	stdout, _ := useOutput(t, ui.Options{})
+	if err := WriteResult("tax pdf", resp); err != nil {
+		t.Fatal(err)
+	}
+	want := "1. /Docs/Tax/2025\n   Reason: tax return\n2. /Finance/Receipts\n   Reason: receipt-like\n"
+	if stdout.String() != want {
+		t.Errorf("text output =\n%s\nwant:\n%s", stdout.String(), want)
+	}
+
+	stdout, _ = useOutput(t, ui.Options{JSON: true})
+	if err := WriteResult("tax pdf", resp); err != nil {
+		t.Fatal(err)
+	}
+	var got Result
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != resp.Path || len(got.Alternatives) != 1 || got.Alternatives[0].Path != "/Finance/Receipts" {
+		t.Errorf("JSON result = %+v", got)
+	}
+
+	// A single recommendation keeps the plain two-line output
+	stdout, _ = useOutput(t, ui.Options{})
+	WriteResult("tax pdf", &api.LLMResponse{Path: "/Docs/Tax/2025", Reason: "tax return"})
+	if stdout.String() != "/Docs/Tax/2025\nReason: tax return\n" {
+		t.Errorf("single output = %q", stdout.String())
+	}
+}
+
+func TestWriteBatchResult(t *testing.T) {
+	stdout, _ := useOutput(t, ui.Options{})
+	WriteBatchResult("scan.pdf", &api.LLMResponse{Path: "/Docs/Scans", Reason: "scanned document"})
+	WriteBatchResult("tab\there", &api.LLMResponse{Root: "Work", Path: "/Misc"})
+	want := "scan.pdf\t/Docs/Scans\ntab here\t/Misc\tWork\n"
+	if stdout.String() != want {
+		t.Errorf("text output = %q, want %q", stdout.String(), want)
+	}
+
+	stdout, _ = useOutput(t, ui.Options{JSON: true})
+	WriteBatchResult("scan.pdf", &api.LLMResponse{Path: "/Docs/Scans", Reason: "scanned document"})
+	var got Result
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Description != "scan.pdf" || got.Path != "/Docs/Scans" {
+		t.Errorf("JSON result = %+v", got)
+	}
+}
+
+func TestOutputOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    config.CLIOptions
+		want    ui.Options
+		wantErr bool
+	}{
+		{name: "default", want: ui.Options{}},
+		{name: "full", opts: config.CLIOptions{Format: FormatFull}, want: ui.Options{}},
+		{name: "path", opts: config.CLIOptions{Format: FormatPath, Quiet: true}, want: ui.Options{PathOnly: true, Quiet: true}},
+		{name: "json format", opts: config.CLIOptions{Format: FormatJSON}, want: ui.Options{JSON: true}},
+		{name: "json flag", opts: config.CLIOptions{JSON: true}, want: ui.Options{JSON: true}},
+		{name: "json flag with path", opts: config.CLIOptions{JSON: true, Format: FormatPath}, wantErr: true},
+		{name: "unknown", opts: config.CLIOptions{Format: "yaml"}, wantErr: true},
+		{name: "color", opts: config.CLIOptions{Color: ColorAlways}, want: ui.Options{}},
+		{name: "unknown color", opts: config.CLIOptions{Color: "sometimes"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OutputOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OutputOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("OutputOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    config.CLIOptions
+		tty     bool
+		noColor string
+		want    bool
+	}{
+		{name: "auto on a terminal", tty: true, want: true},
+		{name: "auto when piped", tty: false, want: false},
+		{name: "auto with NO_COLOR", tty: true, noColor: "1", want: false},
+		{name: "auto with --no-color", opts: config.CLIOptions{NoColor: true}, tty: true, want: false},
+		{name: "always when piped", opts: config.CLIOptions{Color: ColorAlways}, want: true},
+		{name: "never on a terminal", opts: config.CLIOptions{Color: ColorNever}, tty: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := stderrIsTerminal
+			t.Cleanup(func() { stderrIsTerminal = orig })
+			stderrIsTerminal = func() bool { return tt.tty }
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			if got := LogColor(tt.opts); got != tt.want {
+				t.Errorf("LogColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteResult_PathOnly(t *testing.T) {
+	stdout, _ := useOutput(t, ui.Options{PathOnly: true})
+	WriteResult("tax pdf", &api.LLMResponse{
+		Path:         "/Docs/Tax/2025",
+		Reason:       "tax return",
+		Alternatives: []api.Suggestion{{Path: "/Finance/Receipts", Reason: "receipt-like"}},
+	})
+	WriteBatchResult("scan.pdf", &api.LLMResponse{Path: "/Docs/Scans", Reason: "scanned document"})
+	if want := "/Docs/Tax/2025\n/Finance/Receipts\n/Docs/Scans\n"; stdout.String() != want {
+		t.Errorf("path-only output = %q, want %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+
+    treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+)
+
+// pickLimit is how many matches the picker lists at once
+const pickLimit = 15
+
+// PickedReason replaces the model's reason when the user picks another folder
+const PickedReason = "Picked manually"
+
+// ErrPickCancelled is returned when the picker is left without a choice
+var ErrPickCancelled = errors.New("no folder picked")
+
+// PickFromTree lets the user choose a folder of the tree at treePath, walked
+// with treeOpts so it matches the tree the model saw. The model's
+// suggestion, if any, is offered as the default. Picking needs a terminal,
+// so it fails in non-interactive environments.
+func PickFromTree(treePath, suggestion string, treeOpts ...treefs.TreeOption) (string, error) {
+    if err := CheckPick(); err != nil {
+        return "", err
+    }
+    root, err := treefs.Walk(treePath, treeOpts...)
+    if err != nil {
+        return "", fmt.Errorf("Folder tree error: %w", err)
+    }
+    dirs := treefs.Directories(root)
+    if len(dirs) == 0 {
+        return "", fmt.Errorf("no folders to pick from in %s", treePath)
+    }
+    return PickDirectory(dirs, suggestion)
+}
+
+// CheckPick reports whether the picker can run, so callers can fail before
+// querying the model
+func CheckPick() error {
+    if !interactive() {
+        return errors.New("--pick needs an interactive terminal")
+    }
+    return nil
+}
+
+// PickDirectory runs an incremental search over dirs: each line typed
+// replaces the filter, a number picks that match, and an empty line accepts
+// the suggestion (or the only match left). q or end of input cancels.
+func PickDirectory(dirs []string, suggestion string) (string, error) {
+    reader := bufio.NewReader(promptInput)
+    filter := ""
+    for {
+        matches := MatchDirs(dirs, filter)
+        writeMatches(matches, filter, suggestion)
+
+        fmt.Fprint(promptOutput, "Filter, number or Enter (q to cancel): ")
+        line, err := reader.ReadString('\n')
+        input := strings.TrimSpace(line)
+        if err != nil && input == "" {
+            return "", ErrPickCancelled
+        }
+
+        switch {
+        case input == "q":
+            return "", ErrPickCancelled
+        case input == "":
+            if filter == "" && suggestion != "" {
+                return suggestion, nil
+            }
+            if len(matches) == 1 {
+                return matches[0], nil
+            }
+        default:
+            if n, convErr := strconv.Atoi(input); convErr == nil {
+                if n >= 1 && n <= len(matches) && n <= pickLimit {
+                    return matches[n-1], nil
+                }
+                fmt.Fprintf(promptOutput, "❌ No match numbered %d\n", n)
+                continue
+            }
+            filter = input
+        }
+    }
+}
+
+func writeMatches(matches []string, filter, suggestion string) {
+    if filter == "" && suggestion != "" {
+        fmt.Fprintf(promptOutput, "Suggested: %s (press Enter to accept)\n", suggestion)
+    }
+    if len(matches) == 0 {
+        fmt.Fprintf(promptOutput, "No folders match %q\n", filter)
+        return
+    }
+    for i, dir := range matches {
+        if i == pickLimit {
+            fmt.Fprintf(promptOutput, "  … %d more; type to narrow the list\n", len(matches)-pickLimit)
+            break
+        }
+        fmt.Fprintf(promptOutput, "%3d  %s\n", i+1, dir)
+    }
+}
+
+// MatchDirs returns the dirs matching query, best first. Every
+// space-separated term must appear in order, case-insensitively, though not
+// necessarily contiguously ("inv 24" matches "/Work/Invoices/2024"). Tighter
+// matches rank higher; ties keep tree order.
+func MatchDirs(dirs []string, query string) []string {
+    terms := strings.Fields(strings.ToLower(query))
+    type scored struct {
+        dir   string
+        score int
+    }
+    var found []scored
+    for _, dir := range dirs {
+        if score, ok := fuzzyScore(strings.ToLower(dir), terms); ok {
+            found = append(found, scored{dir, score})
+        }
+    }
+    sort.SliceStable(found, func(i, j int) bool { return found[i].score < found[j].score })
+
+    matches := make([]string, len(found))
+    for i, f := range found {
+        matches[i] = f.dir
+    }
+    return matches
+}
+
+// fuzzyScore matches each term as a subsequence of s, counting the
+// characters skipped inside each match; 0 means every term is a substring
+func fuzzyScore(s string, terms []string) (int, bool) {
+    score := 0
+    for _, term := range terms {
+        if strings.Contains(s, term) {
+            continue
+        }
+        start, pos := -1, 0
+        for _, r := range term {
+            i := strings.IndexRune(s[pos:], r)
+            if i < 0 {
+                return 0, false
+            }
+            if start < 0 {
+                start = pos + i
+            }
+            pos += i + len(string(r))
+        }
+        score += pos - start - len(term)
+    }
+    return score, true
+}
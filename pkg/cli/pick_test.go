@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+)
+
+var pickDirs = []string{
+	"/Personal",
+	"/Personal/Taxes",
+	"/Personal/Taxes/2024",
+	"/Work",
+	"/Work/Invoices",
+	"/Work/Invoices/2024",
+	"/Work/Invoices/Archive",
+}
+
+func TestMatchDirs(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: pickDirs},
+		{query: "INVOICES", want: []string{"/Work/Invoices", "/Work/Invoices/2024", "/Work/Invoices/Archive"}},
+		{query: "inv 24", want: []string{"/Work/Invoices/2024"}},
+		// Substring matches rank above scattered ones
+		{query: "tax", want: []string{"/Personal/Taxes", "/Personal/Taxes/2024"}},
+		{query: "wia", want: []string{"/Work/Invoices/Archive"}},
+		{query: "2024", want: []string{"/Personal/Taxes/2024", "/Work/Invoices/2024"}},
+		{query: "nothing", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := MatchDirs(pickDirs, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchDirs(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchDirs_TighterMatchFirst(t *testing.T) {
+	dirs := []string{"/Projects/Notes", "/Photos"}
+	if got := MatchDirs(dirs, "pho"); got[0] != "/Photos" {
+		t.Errorf("MatchDirs() = %q, want /Photos first", got)
+	}
+}
+
+func TestPickDirectory(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		suggestion string
+		want       string
+		wantErr    error
+	}{
+		{name: "accept suggestion", input: "\n", suggestion: "/Work", want: "/Work"},
+		{name: "pick by number", input: "2\n", want: "/Personal/Taxes"},
+		{name: "filter then number", input: "inv\n3\n", want: "/Work/Invoices/Archive"},
+		{name: "refine to a single match", input: "inv\narch\n\n", want: "/Work/Invoices/Archive"},
+		{name: "out of range number", input: "99\n1\n", want: "/Personal"},
+		{name: "cancel", input: "q\n", wantErr: ErrPickCancelled},
+		{name: "end of input", input: "tax\n", wantErr: ErrPickCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubTerminal(t, true, tt.input)
+			got, err := PickDirectory(pickDirs, tt.suggestion)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("PickDirectory() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPickFromTree(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "Work", "Invoices"), 0755)
+
+	out := stubTerminal(t, true, "invo\n\n")
+	got, err := PickFromTree(root, "/Work")
+	if err != nil || got != "/Work/Invoices" {
+		t.Errorf("PickFromTree() = %q, %v", got, err)
+	}
+	if !strings.Contains(out.String(), "Suggested: /Work") {
+		t.Errorf("prompt did not offer the suggestion:\n%s", out)
+	}
+
+	// The picker walks with the query's tree options
+	out = stubTerminal(t, true, "\n")
+	if got, err := PickFromTree(root, "/Work", treefs.WithMaxDepth(0)); err != nil || got != "/Work" {
+		t.Errorf("PickFromTree() with depth 0 = %q, %v, want the suggestion", got, err)
+	}
+	if strings.Contains(out.String(), "Invoices") {
+		t.Errorf("picker listed a folder below the depth limit:\n%s", out)
+	}
+
+	stubTerminal(t, false, "")
+	if _, err := PickFromTree(root, ""); err == nil || !strings.Contains(err.Error(), "interactive") {
+		t.Errorf("PickFromTree() in non-interactive mode: err = %v", err)
+	}
+}
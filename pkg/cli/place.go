@@ -0,0 +1,180 @@
+package cli
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// maxNameSuffix bounds the numeric suffixes tried when the destination name
+// is taken
+const maxNameSuffix = 1000
+
+// Placement is where --move or --copy puts the described file: planned, or
+// done once ApplyPlacement has run
+type Placement struct {
+    Source string
+    Path   string
+    Copied bool
+
+    // CreatesDir is set when the destination folder doesn't exist yet
+    CreatesDir bool
+
+    // DryRun marks a plan that was only printed (--dry-run)
+    DryRun bool
+}
+
+// CheckPlaceSource reports a --move or --copy source that isn't a regular
+// file, so callers can fail before querying the model
+func CheckPlaceSource(src string) error {
+    info, err := os.Stat(src)
+    if err != nil {
+        return fmt.Errorf("cannot use %s: %w", src, err)
+    }
+    if !info.Mode().IsRegular() {
+        return fmt.Errorf("cannot use %s: not a regular file", src)
+    }
+    return nil
+}
+
+// DestinationDir resolves a recommended folder, given from the top of the
+// tree as in "/Photos/2025", to a directory under root. Paths that would leave
+// root are rejected.
+func DestinationDir(root, recommended string) (string, error) {
+    rel := strings.TrimLeft(filepath.FromSlash(strings.TrimSpace(recommended)), `/\`)
+    if rel == "" {
+        return "", errors.New("the recommendation names no folder")
+    }
+    clean, err := config.SanitizePath(rel)
+    if err != nil {
+        return "", err
+    }
+    if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" {
+        return "", fmt.Errorf("recommended folder %s is not inside the tree", recommended)
+    }
+    return filepath.Join(root, clean), nil
+}
+
+// PlaceFile moves src into the recommended folder under root, or copies it
+// when keepSource is set, creating the folder if needed. An existing file is never
+// overwritten: the new one gets a numeric suffix, as in "photo (1).jpg".
+func PlaceFile(src, root, recommended string, keepSource bool) (*Placement, error) {
+    plan, err := PlanPlacement(src, root, recommended, keepSource)
+    if err != nil {
+        return nil, err
+    }
+    if err := ApplyPlacement(plan); err != nil {
+        return nil, err
+    }
+    return plan, nil
+}
+
+// PlanPlacement works out where PlaceFile would put src without changing
+// anything, so invalid destinations are caught by --dry-run too
+func PlanPlacement(src, root, recommended string, keepSource bool) (*Placement, error) {
+    dir, err := DestinationDir(root, recommended)
+    if err != nil {
+        return nil, err
+    }
+    createsDir := false
+    if info, err := os.Stat(dir); os.IsNotExist(err) {
+        createsDir = true
+    } else if err != nil {
+        return nil, err
+    } else if !info.IsDir() {
+        return nil, fmt.Errorf("%s is not a folder", dir)
+    }
+    dest, err := freeName(dir, filepath.Base(src))
+    if err != nil {
+        return nil, err
+    }
+    return &Placement{Source: src, Path: dest, Copied: keepSource, CreatesDir: createsDir}, nil
+}
+
+// ApplyPlacement creates the destination folder and moves or copies the
+// file. The destination is claimed atomically; when another file took the
+// planned name in the meantime, the next free suffix is used and p.Path
+// updated.
+func ApplyPlacement(p *Placement) error {
+    dir := filepath.Dir(p.Path)
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return fmt.Errorf("cannot create folder: %w", err)
+    }
+    place := moveFile
+    if p.Copied {
+        place = copyNew
+    }
+    for i := 0; ; i++ {
+        err := place(p.Source, p.Path)
+        if !errors.Is(err, fs.ErrExist) || i == maxNameSuffix {
+            return err
+        }
+        if p.Path, err = freeName(dir, filepath.Base(p.Source)); err != nil {
+            return err
+        }
+    }
+}
+
+// freeName returns a path in dir for name that no file uses yet
+func freeName(dir, name string) (string, error) {
+    ext := filepath.Ext(name)
+    base := strings.TrimSuffix(name, ext)
+    candidate := filepath.Join(dir, name)
+    for i := 1; i <= maxNameSuffix; i++ {
+        if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+            return candidate, nil
+        }
+        candidate = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+    }
+    return "", fmt.Errorf("%s already exists in %s, as do %d numbered copies", name, dir, maxNameSuffix)
+}
+
+// moveFile moves src to dest, failing with fs.ErrExist rather than
+// replacing a file at dest. It links dest to src and removes src, copying
+// instead when they are on different filesystems or links aren't supported;
+// a rename would overwrite a file created at dest after freeName checked.
+func moveFile(src, dest string) error {
+    if err := os.Link(src, dest); err != nil {
+        if errors.Is(err, fs.ErrExist) {
+            return err
+        }
+        if err := copyNew(src, dest); err != nil {
+            return err
+        }
+    }
+    return os.Remove(src)
+}
+
+// copyNew copies src to dest with src's permissions, failing if dest exists
+func copyNew(src, dest string) error {
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+    info, err := in.Stat()
+    if err != nil {
+        return err
+    }
+
+    outFile, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(outFile, in); err != nil {
+        outFile.Close()
+        os.Remove(dest)
+        return err
+    }
+    if err := outFile.Close(); err != nil {
+        os.Remove(dest)
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/ui"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+func TestDestinationDir(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		recommended string
+		want        string
+		wantErr     string
+	}{
+		{recommended: "/03_PHOTOS/2025/Berlin_Trip", want: filepath.Join(root, "03_PHOTOS", "2025", "Berlin_Trip")},
+		{recommended: "Work/Invoices/", want: filepath.Join(root, "Work", "Invoices")},
+		{recommended: "/Work/../../etc", wantErr: "directory traversal"},
+		{recommended: "  ", wantErr: "names no folder"},
+		{recommended: "/", wantErr: "names no folder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recommended, func(t *testing.T) {
+			got, err := DestinationDir(root, tt.recommended)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DestinationDir(%q) error = %v, want %q", tt.recommended, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DestinationDir(%q) error = %v", tt.recommended, err)
+			}
+			if got != tt.want {
+				t.Errorf("DestinationDir(%q) = %q, want %q", tt.recommended, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceFile(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		name := "move"
+		if keep {
+			name = "copy"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			src := filepath.Join(t.TempDir(), "photo.jpg")
+			if err := os.WriteFile(src, []byte("new"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			// The folder exists already and holds a photo of the same name
+			existing := filepath.Join(root, "Photos", "photo.jpg")
+			if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			placed, err := PlaceFile(src, root, "/Photos", keep)
+			if err != nil {
+				t.Fatalf("PlaceFile() error = %v", err)
+			}
+			want := filepath.Join(root, "Photos", "photo (1).jpg")
+			if placed.Path != want || placed.Copied != keep {
+				t.Errorf("PlaceFile() = %+v, want %s (copied %v)", placed, want, keep)
+			}
+			if data, _ := os.ReadFile(existing); string(data) != "old" {
+				t.Error("the existing file was overwritten")
+			}
+			if data, _ := os.ReadFile(want); string(data) != "new" {
+				t.Errorf("placed file holds %q, want %q", data, "new")
+			}
+			if _, err := os.Stat(src); os.IsNotExist(err) == keep {
+				t.Errorf("source exists = %v after %s", !os.IsNotExist(err), name)
+			}
+		})
+	}
+}
+
+func TestApplyPlacement_NameTakenAfterPlanning(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(src, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanPlacement(src, root, "/Photos", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another process takes the planned name before the move
+	if err := os.MkdirAll(filepath.Dir(plan.Path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(plan.Path, []byte("racer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	taken := plan.Path
+
+	if err := ApplyPlacement(plan); err != nil {
+		t.Fatalf("ApplyPlacement() error = %v", err)
+	}
+	if data, _ := os.ReadFile(taken); string(data) != "racer" {
+		t.Errorf("the file created after planning was overwritten: %q", data)
+	}
+	if want := filepath.Join(root, "Photos", "photo (1).jpg"); plan.Path != want {
+		t.Errorf("Path = %s, want %s", plan.Path, want)
+	}
+	if data, _ := os.ReadFile(plan.Path); string(data) != "new" {
+		t.Errorf("placed file holds %q, want %q", data, "new")
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("the source is still there after the move")
+	}
+}
+
+func TestPlaceFile_CreatesFolder(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(src, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	placed, err := PlaceFile(src, root, "/Work/2025/Notes", false)
+	if err != nil {
+		t.Fatalf("PlaceFile() error = %v", err)
+	}
+	if want := filepath.Join(root, "Work", "2025", "Notes", "notes.txt"); placed.Path != want {
+		t.Errorf("PlaceFile() = %s, want %s", placed.Path, want)
+	}
+}
+
+func TestCheckPlaceSource(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPlaceSource(file); err != nil {
+		t.Errorf("CheckPlaceSource(file) error = %v", err)
+	}
+	if err := CheckPlaceSource(dir); err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Errorf("CheckPlaceSource(dir) error = %v", err)
+	}
+	if err := CheckPlaceSource(filepath.Join(dir, "missing")); err == nil {
+		t.Error("CheckPlaceSource(missing) succeeded")
+	}
+}
+
+func TestWritePlacedResult(t *testing.T) {
+	resp := &api.LLMResponse{Path: "/Photos", Reason: "a photo"}
+
+	stdout, _ := useOutput(t, ui.Options{})
+	WritePlacedResult("photo", resp, &Placement{Path: "/tree/Photos/photo.jpg"})
+	WritePlacedResult("photo", resp, &Placement{Path: "/tree/Photos/photo (1).jpg", Copied: true})
+	want := "/Photos\nReason: a photo\nMoved to: /tree/Photos/photo.jpg\n" +
+		"/Photos\nReason: a photo\nCopied to: /tree/Photos/photo (1).jpg\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("text output = %q, want %q", got, want)
+	}
+
+	stdout, _ = useOutput(t, ui.Options{JSON: true})
+	WritePlacedResult("photo", resp, &Placement{Path: "/tree/Photos/photo.jpg"})
+	if got := stdout.String(); !strings.Contains(got, `"destination":"/tree/Photos/photo.jpg"`) {
+		t.Errorf("JSON output = %s, want a destination", got)
+	}
+}
+
+func TestPlanPlacement_DryRun(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(src, []byte("photo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanPlacement(src, root, "/Photos/2025", false)
+	if err != nil {
+		t.Fatalf("PlanPlacement() error = %v", err)
+	}
+	dest := filepath.Join(root, "Photos", "2025", "photo.jpg")
+	if plan.Path != dest || !plan.CreatesDir {
+		t.Errorf("plan = %+v, want %s in a new folder", plan, dest)
+	}
+	if _, err := os.Stat(filepath.Join(root, "Photos")); !os.IsNotExist(err) {
+		t.Error("planning created the folder")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Error("planning moved the source")
+	}
+
+	plan.DryRun = true
+	stdout, _ := useOutput(t, ui.Options{})
+	WritePlacedResult("photo", &api.LLMResponse{Path: "/Photos/2025", Reason: "a photo"}, plan)
+	want := "/Photos/2025\nReason: a photo\n" +
+		"MKDIR\t" + filepath.Dir(dest) + "\n" +
+		"MOVE\t" + src + "\t" + dest + "\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("dry-run output = %q, want %q", got, want)
+	}
+
+	// Sanitization still applies
+	if _, err := PlanPlacement(src, root, "/../outside", true); err == nil {
+		t.Error("PlanPlacement() accepted a destination outside the tree")
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// takeProfileFlag removes --profile NAME (or --profile=NAME) from config
+// subcommand args, wherever it appears
+func takeProfileFlag(args []string) ([]string, string, error) {
+    var rest []string
+    profile := ""
+    for i := 0; i < len(args); i++ {
+        a := args[i]
+        switch {
+        case strings.HasPrefix(a, "--profile="):
+            profile = strings.TrimPrefix(a, "--profile=")
+        case a == "--profile":
+            if i+1 >= len(args) {
+                return nil, "", errors.New("--profile needs a profile name")
+            }
+            i++
+            profile = args[i]
+        default:
+            rest = append(rest, a)
+            continue
+        }
+        if err := config.ValidateProfileName(profile); err != nil {
+            return nil, "", err
+        }
+    }
+    return rest, profile, nil
+}
+
+// writeProfileList prints the profiles in the config file, one per line,
+// marking the default
+func writeProfileList(w io.Writer, c *config.Config) {
+    names := c.ProfileNames()
+    if len(names) == 0 {
+        fmt.Fprintln(w, "No profiles. Create one with: sortpath config set --profile NAME <key> <value>")
+        return
+    }
+    for _, name := range names {
+        if name == c.Profile {
+            fmt.Fprintf(w, "%s (default)\n", name)
+            continue
+        }
+        fmt.Fprintln(w, name)
+    }
+}
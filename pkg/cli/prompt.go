@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+)
+
+// HandlePromptTestCommand renders a prompt template against a real tree and
+// prints the result without calling the API
+func HandlePromptTestCommand(args []string) {
+    var templatePath, treePath string
+    fs := flag.NewFlagSet("prompt-test", flag.ContinueOnError)
+    fs.StringVar(&templatePath, "prompt-template", "", "Prompt template file to render (default: built-in prompt)")
+    fs.StringVar(&treePath, "tree", "", "Folder to build the tree from")
+    fs.SetOutput(out.Errors())
+    if err := fs.Parse(args); err != nil {
+        // Parse already printed the problem and usage; -h is not an error
+        if errors.Is(err, flag.ErrHelp) {
+            return
+        }
+        os.Exit(apperrors.ExitFailure)
+    }
+
+    desc := strings.Join(fs.Args(), " ")
+    if desc == "" {
+        out.Error("Usage: sortpath prompt-test [--prompt-template FILE] [--tree DIR] \"description\"\n")
+        os.Exit(1)
+    }
+
+    prompt, err := renderPromptTest(templatePath, treePath, desc)
+    if err != nil {
+        out.Error("❌ Prompt template error: %v\n", err)
+        os.Exit(1)
+    }
+    out.Result("%s", prompt)
+}
+
+// renderPromptTest builds the tree for treePath (or the configured tree) and
+// renders templatePath, the configured prompt template when templatePath is
+// empty, or the built-in prompt when neither is set
+func renderPromptTest(templatePath, treePath, desc string) (string, error) {
+    conf := config.ResolveConfigUnvalidated(config.CLIOptions{TreePath: treePath, PromptTemplate: templatePath})
+    tree, err := treefs.Tree(conf.TreePath)
+    if err != nil {
+        return "", fmt.Errorf("folder tree error: %w", err)
+    }
+
+    templatePath = conf.PromptTemplate
+    if templatePath == "" {
+        return ai.BuildPrompt(tree, desc), nil
+    }
+    tmpl, err := ai.LoadTemplate(templatePath)
+    if err != nil {
+        return "", err
+    }
+    return ai.RenderTemplate(tmpl, ai.NewPromptData(tree, desc))
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderPromptTest(t *testing.T) {
+	treeDir := t.TempDir()
+	os.Mkdir(filepath.Join(treeDir, "Invoices"), 0755)
+
+	tmplPath := filepath.Join(t.TempDir(), "prompt.tmpl")
+	os.WriteFile(tmplPath, []byte("{{.Tree}}=> {{.Description}}"), 0644)
+
+	got, err := renderPromptTest(tmplPath, treeDir, "ACME invoice")
+	if err != nil {
+		t.Fatalf("renderPromptTest() unexpected error = %v", err)
+	}
+	want := "└── Invoices\n=> ACME invoice"
+	if got != want {
+		t.Errorf("renderPromptTest() = %q, want %q", got, want)
+	}
+
+	badPath := filepath.Join(t.TempDir(), "bad.tmpl")
+	os.WriteFile(badPath, []byte("{{.Nope}}"), 0644)
+	if _, err := renderPromptTest(badPath, treeDir, "ACME invoice"); err == nil || !contains(err.Error(), "can't evaluate field Nope") {
+		t.Errorf("renderPromptTest() error = %v, want undefined field error", err)
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/internal/cache"
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+)
+
+// notices receives informational messages that aren't part of the result
+var notices io.Writer = os.Stderr
+
+// buildTree walks the folder tree; tests replace it to observe filesystem access
+var buildTree = treefs.Tree
+
+// walkTree reads the folder tree into nodes for callers that render it
+// themselves; tests replace it to observe filesystem access
+var walkTree = treefs.Walk
+
+// CacheTrees makes later walks of a tree path reuse the first result, so a
+// batch walks each tree once however many items it sorts. The tree options
+// must not change between queries, as is the case within one run.
+// --context-window and --large-tree still render the walked tree for each
+// description.
+func CacheTrees() {
+    build := buildTree
+    var mu sync.Mutex
+    type built struct {
+        tree string
+        err  error
+    }
+    trees := map[string]built{}
+    buildTree = func(path string, opts ...treefs.TreeOption) (string, error) {
+        mu.Lock()
+        defer mu.Unlock()
+        b, ok := trees[path]
+        if !ok {
+            b.tree, b.err = build(path, opts...)
+            trees[path] = b
+        }
+        return b.tree, b.err
+    }
+
+    walk := walkTree
+    type walked struct {
+        root *treefs.Node
+        err  error
+    }
+    roots := map[string]walked{}
+    walkTree = func(path string, opts ...treefs.TreeOption) (*treefs.Node, error) {
+        mu.Lock()
+        defer mu.Unlock()
+        w, ok := roots[path]
+        if !ok {
+            w.root, w.err = walk(path, opts...)
+            roots[path] = w
+        }
+        return w.root, w.err
+    }
+}
+
+// BuildQueryPrompt assembles the prompt for desc. With NoTree set the folder
+// tree is never walked and a generic category prompt is used instead.
+func BuildQueryPrompt(opts config.CLIOptions, conf *config.Config, desc string) (string, error) {
+    prompt, _, err := BuildQueryPromptWithTree(opts, conf, desc)
+    return prompt, err
+}
+
+// BuildQueryPromptWithTree is BuildQueryPrompt that also returns the rendered
+// tree included in the prompt (empty with NoTree)
+func BuildQueryPromptWithTree(opts config.CLIOptions, conf *config.Config, desc string) (prompt, tree string, err error) {
+    promptOpts := QueryPromptOptions(opts, conf)
+    if opts.NoTree {
+        return ai.BuildDescribePrompt(desc, promptOpts), "", nil
+    }
+
+    treeOpts := TreeOptions(conf)
+    if opts.ContextWindow > 0 {
+        tree, err = fitTree(opts.ContextWindow, conf, desc, promptOpts, treeOpts...)
+    } else if tree, err = buildTree(conf.TreePath, treeOpts...); err != nil {
+        err = treeError(conf.TreePath, err)
+    }
+    if err != nil {
+        return "", "", err
+    }
+    prompt, err = treePrompt(conf, tree, desc, promptOpts)
+    if err != nil {
+        return "", "", err
+    }
+    return prompt, tree, nil
+}
+
+// treePrompt renders the prompt for tree and desc from the configured prompt
+// template, or the built-in prompt when there is none
+func treePrompt(conf *config.Config, tree, desc string, promptOpts ai.PromptOptions) (string, error) {
+    if conf.PromptTemplate == "" {
+        return ai.BuildPromptWithOptions(tree, desc, promptOpts), nil
+    }
+    tmpl, err := ai.LoadTemplate(conf.PromptTemplate)
+    if err == nil {
+        var prompt string
+        if prompt, err = ai.RenderTemplate(tmpl, ai.NewPromptData(tree, desc)); err == nil {
+            return prompt, nil
+        }
+    }
+    return "", apperrors.ConfigError(fmt.Sprintf("Prompt template error: %v", err), err)
+}
+
+// treeError reports a failed walk of the tree at path as a file system error
+func treeError(path string, err error) error {
+    return apperrors.FSError(fmt.Sprintf("Folder tree error: %v", err), path, err)
+}
+
+// TreeOptions returns the tree limits configured for a run. Unreadable
+// directories are logged; a later WithExplain replaces that.
+func TreeOptions(conf *config.Config) []treefs.TreeOption {
+    opts := []treefs.TreeOption{treefs.WithExplain(logUnreadable)}
+    if depth := conf.MaxTreeDepth(); depth >= 0 {
+        opts = append(opts, treefs.WithMaxDepth(depth))
+    }
+    if n := conf.MaxTreeBytes(); n > 0 {
+        opts = append(opts, treefs.WithMaxBytes(n))
+    }
+    if conf.NoDefaultIgnores {
+        opts = append(opts, treefs.WithSkipDirs())
+    }
+    if conf.DirsOnly {
+        opts = append(opts, treefs.WithDirsOnly())
+    }
+    if !conf.NoCache {
+        opts = append(opts, treefs.WithCache(cache.Dir()))
+    }
+    return opts
+}
+
+// logUnreadable logs directories left out of the tree because they couldn't be read
+func logUnreadable(path string, reason treefs.SkipReason, detail string) {
+    if reason == treefs.SkipUnreadable {
+        logger.Info("skipped unreadable directory %s: %s", treefs.SafeName(path), detail)
+    }
+}
+
+// QueryPromptOptions derives the prompt options for a run
+func QueryPromptOptions(opts config.CLIOptions, conf *config.Config) ai.PromptOptions {
+    return ai.PromptOptions{
+        MaxReasonLength: opts.MaxReasonLength,
+        Minimal:         conf.PromptStyle == config.PromptStyleMinimal,
+        JSON:            conf.ResponseFormat == config.ResponseFormatJSON,
+        Count:           opts.Count,
+        Rules:           conf.Rules(),
+    }
+}
+
+// fitTree shrinks the tree at conf.TreePath until the whole prompt plus a
+// response budget fits in contextWindow tokens, reporting the limits it applied
+func fitTree(contextWindow int, conf *config.Config, desc string, promptOpts ai.PromptOptions, treeOpts ...treefs.TreeOption) (string, error) {
+    budget := contextWindow - ai.ResponseTokenBudget
+    empty, err := treePrompt(conf, "", desc, promptOpts)
+    if err != nil {
+        return "", err
+    }
+    overhead := ai.EstimateTokens(empty)
+    if overhead >= budget {
+        msg := fmt.Sprintf("context window of %d tokens is too small: the prompt without a tree needs about %d plus %d for the response", contextWindow, overhead, ai.ResponseTokenBudget)
+        return "", apperrors.ValidationError(msg, "context-window")
+    }
+
+    fits := func(tree string) bool {
+        return overhead+ai.EstimateTokens(tree) <= budget
+    }
+    root, err := walkTree(conf.TreePath, treeOpts...)
+    if err != nil {
+        return "", treeError(conf.TreePath, err)
+    }
+    tree, limits, err := treefs.FitNode(root, fits, treeOpts...)
+    if err != nil {
+        return "", apperrors.ValidationError(fmt.Sprintf("cannot fit folder tree into %d tokens: %v", contextWindow, err), "context-window")
+    }
+    if len(limits) > 0 {
+        fmt.Fprintf(notices, "ℹ️ Fitted tree to %d-token context window: %s\n", contextWindow, strings.Join(limits, ", "))
+    }
+    return tree, nil
+}
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/ai"
+	"github.com/kacperkwapisz/sortpath/internal/cache"
+	"github.com/kacperkwapisz/sortpath/internal/config"
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+)
+
+func TestBuildQueryPrompt_NoTree(t *testing.T) {
+	walks := 0
+	orig := buildTree
+	buildTree = func(dir string, opts ...treefs.TreeOption) (string, error) {
+		walks++
+		return "├── Docs\n", nil
+	}
+	defer func() { buildTree = orig }()
+
+	conf := &config.Config{TreePath: t.TempDir()}
+
+	prompt, err := BuildQueryPrompt(config.CLIOptions{NoTree: true}, conf, "holiday photo")
+	if err != nil {
+		t.Fatalf("BuildQueryPrompt() error = %v", err)
+	}
+	if walks != 0 {
+		t.Errorf("tree walked %d times with --no-tree, want 0", walks)
+	}
+	if strings.Contains(prompt, "<context>") {
+		t.Error("--no-tree prompt should omit the <context> section")
+	}
+
+	prompt, err = BuildQueryPrompt(config.CLIOptions{}, conf, "holiday photo")
+	if err != nil {
+		t.Fatalf("BuildQueryPrompt() error = %v", err)
+	}
+	if walks != 1 {
+		t.Errorf("tree walked %d times without --no-tree, want 1", walks)
+	}
+	if !strings.Contains(prompt, "├── Docs") {
+		t.Error("default prompt should contain the folder tree")
+	}
+}
+
+func TestCacheTrees(t *testing.T) {
+	walks := map[string]int{}
+	orig, origWalk := buildTree, walkTree
+	buildTree = func(dir string, opts ...treefs.TreeOption) (string, error) {
+		walks[dir]++
+		return "├── " + dir + "\n", nil
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	walkTree = func(path string, opts ...treefs.TreeOption) (*treefs.Node, error) {
+		walks[path]++
+		return treefs.Walk(path, opts...)
+	}
+	defer func() { buildTree, walkTree = orig, origWalk }()
+	CacheTrees()
+
+	conf := &config.Config{TreePath: "Archive"}
+	for _, desc := range []string{"invoice", "holiday photo", "tax return"} {
+		prompt, err := BuildQueryPrompt(config.CLIOptions{}, conf, desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(prompt, "├── Archive") || !strings.Contains(prompt, desc) {
+			t.Errorf("prompt for %q is missing the tree or description", desc)
+		}
+	}
+	roots := []TreeRoot{{Label: "Work", Path: "Work"}, {Label: "Archive", Path: "Archive"}}
+	if _, err := BuildMultiTreePrompt(roots, "contract", ai.PromptOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if walks["Archive"] != 1 || walks["Work"] != 1 {
+		t.Errorf("walks = %v, want each tree walked once", walks)
+	}
+
+	// --context-window renders the cached walk for each description
+	for _, desc := range []string{"invoice", "holiday photo"} {
+		prompt, err := BuildQueryPrompt(config.CLIOptions{ContextWindow: 100000}, &config.Config{TreePath: dir}, desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(prompt, "Docs") {
+			t.Errorf("fitted prompt for %q is missing the tree", desc)
+		}
+	}
+	if walks[dir] != 1 {
+		t.Errorf("walked %s %d times with --context-window, want once", dir, walks[dir])
+	}
+}
+
+func TestBuildQueryPrompt_Template(t *testing.T) {
+	orig := buildTree
+	buildTree = func(dir string, opts ...treefs.TreeOption) (string, error) {
+		return "├── Docs\n", nil
+	}
+	defer func() { buildTree = orig }()
+
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("Tree:\n{{.Tree}}File: {{.Description}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.Config{TreePath: t.TempDir(), PromptTemplate: path}
+
+	prompt, err := BuildQueryPrompt(config.CLIOptions{}, conf, "tax return")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Tree:\n├── Docs\nFile: tax return"; prompt != want {
+		t.Errorf("prompt = %q, want %q", prompt, want)
+	}
+}
+
+func TestBuildQueryPrompt_ContextWindow(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 40; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("Project_%02d", i))
+		for j := 0; j < 10; j++ {
+			os.MkdirAll(filepath.Join(dir, fmt.Sprintf("Deliverable_%02d", j), "Drafts"), 0755)
+		}
+	}
+
+	var out bytes.Buffer
+	notices = &out
+	defer func() { notices = os.Stderr }()
+
+	const window = 3000
+	prompt, err := BuildQueryPrompt(config.CLIOptions{ContextWindow: window}, &config.Config{TreePath: root}, "client brief")
+	if err != nil {
+		t.Fatalf("BuildQueryPrompt() error = %v", err)
+	}
+	if got := ai.EstimateTokens(prompt); got > window-ai.ResponseTokenBudget {
+		t.Errorf("estimated prompt = %d tokens, want at most %d", got, window-ai.ResponseTokenBudget)
+	}
+	if !strings.Contains(out.String(), "Fitted tree") {
+		t.Errorf("expected applied limits to be reported, got %q", out.String())
+	}
+
+	if _, err := BuildQueryPrompt(config.CLIOptions{ContextWindow: 300}, &config.Config{TreePath: root}, "client brief"); err == nil {
+		t.Error("expected an error when the window can't hold the base prompt")
+	}
+}
+
+func TestBuildQueryPrompt_ErrorKinds(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "Docs"), 0755)
+
+	missing := filepath.Join(t.TempDir(), "missing.tmpl")
+	_, err := BuildQueryPrompt(config.CLIOptions{}, &config.Config{TreePath: root, PromptTemplate: missing}, "x")
+	if got := apperrors.ExitCode(err); got != apperrors.ExitConfig {
+		t.Errorf("template error exit code = %d, want %d (%v)", got, apperrors.ExitConfig, err)
+	}
+
+	_, err = BuildQueryPrompt(config.CLIOptions{ContextWindow: 10}, &config.Config{TreePath: root}, "x")
+	if got := apperrors.ExitCode(err); got != apperrors.ExitValidation {
+		t.Errorf("context window error exit code = %d, want %d (%v)", got, apperrors.ExitValidation, err)
+	}
+
+	walkErr := errors.New("permission denied")
+	orig := buildTree
+	buildTree = func(dir string, opts ...treefs.TreeOption) (string, error) {
+		return "", walkErr
+	}
+	defer func() { buildTree = orig }()
+
+	_, err = BuildQueryPrompt(config.CLIOptions{}, &config.Config{TreePath: root}, "x")
+	if got := apperrors.ExitCode(err); got != apperrors.ExitFS {
+		t.Errorf("tree error exit code = %d, want %d (%v)", got, apperrors.ExitFS, err)
+	}
+	if !errors.Is(err, walkErr) {
+		t.Errorf("tree error %v does not wrap the walk error", err)
+	}
+}
+
+func TestBuildQueryPrompt_TreeDepth(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "Media", "Movies", "1999", "Matrix"), 0755)
+
+	tests := []struct {
+		depth   string
+		want    []string
+		notWant []string
+	}{
+		{depth: "", want: []string{"Movies", "1999", "Matrix"}},
+		{depth: "0", want: []string{"Media", "…"}, notWant: []string{"Movies"}},
+		{depth: "1", want: []string{"Movies", "…"}, notWant: []string{"1999"}},
+	}
+	for _, tt := range tests {
+		t.Run("depth "+tt.depth, func(t *testing.T) {
+			conf := &config.Config{TreePath: root, TreeDepth: tt.depth}
+			_, tree, err := BuildQueryPromptWithTree(config.CLIOptions{}, conf, "film")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(tree, w) {
+					t.Errorf("tree missing %q:\n%s", w, tree)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(tree, w) {
+					t.Errorf("tree should stop before %q:\n%s", w, tree)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildQueryPrompt_NoDefaultIgnores(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "Code", "node_modules", "left-pad"), 0755)
+
+	_, tree, err := BuildQueryPromptWithTree(config.CLIOptions{}, &config.Config{TreePath: root}, "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(tree, "node_modules") {
+		t.Errorf("node_modules should be skipped by default:\n%s", tree)
+	}
+
+	_, tree, err = BuildQueryPromptWithTree(config.CLIOptions{}, &config.Config{TreePath: root, NoDefaultIgnores: true}, "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(tree, "left-pad") {
+		t.Errorf("NoDefaultIgnores should walk node_modules:\n%s", tree)
+	}
+}
+
+func TestBuildQueryPrompt_DirsOnly(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "Invoices", "2024"), 0755)
+	os.WriteFile(filepath.Join(root, "Invoices", "2024", "march.pdf"), nil, 0644)
+
+	_, tree, err := BuildQueryPromptWithTree(config.CLIOptions{}, &config.Config{TreePath: root, DirsOnly: true}, "invoice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(tree, "2024") || strings.Contains(tree, "march.pdf") {
+		t.Errorf("DirsOnly tree should list folders only:\n%s", tree)
+	}
+}
+
+func TestTreeOptions_Cache(t *testing.T) {
+	isolateConfig(t)
+	cacheDirOf := func(conf *config.Config) string {
+		var o treefs.TreeOptions
+		for _, opt := range TreeOptions(conf) {
+			opt(&o)
+		}
+		return o.CacheDir
+	}
+	if dir := cacheDirOf(&config.Config{}); dir != cache.Dir() {
+		t.Errorf("CacheDir = %q, want %q", dir, cache.Dir())
+	}
+	if dir := cacheDirOf(&config.Config{NoCache: true}); dir != "" {
+		t.Errorf("CacheDir with --no-cache = %q, want none", dir)
+	}
+}
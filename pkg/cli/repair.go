@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// promptInput and interactive are replaced in tests to simulate a terminal
+var (
+	promptInput io.Reader = os.Stdin
+	interactive           = config.DefaultEnvironmentDetector.ShouldPromptUser
+)
+
+// ResolveConfigWithRepair resolves configuration like config.ResolveConfig.
+// When validation fails on a single key and the terminal is interactive, the
+// user is asked for a replacement, which is saved like `config set` before
+// resolving again. Non-interactive runs fail fast with the original error.
+func ResolveConfigWithRepair(opts config.CLIOptions) (*config.Config, []config.FieldSource, error) {
+	reader := bufio.NewReader(promptInput)
+	repaired := map[string]bool{}
+
+	for {
+		conf, sources, err := config.ResolveConfigWithSources(opts, config.NewFileLoader(), config.DefaultSecretStore)
+		var fieldErr *config.FieldError
+		// A key that is still invalid after saving is overridden by a flag or
+		// environment variable, which prompting again can't fix
+		if err == nil || !interactive() || !errors.As(err, &fieldErr) || repaired[fieldErr.Key] {
+			return conf, sources, err
+		}
+
+		fmt.Fprintf(promptOutput, "⚠️ %v\n", err)
+		for !repaired[fieldErr.Key] {
+			fmt.Fprintf(promptOutput, "Enter %s (leave empty to abort): ", fieldErr.Key)
+			line, _ := reader.ReadString('\n')
+			value := strings.TrimSpace(line)
+			if value == "" {
+				return nil, sources, err
+			}
+			if setErr := setConfigValue(fieldErr.Key, value); setErr != nil {
+				fmt.Fprintf(promptOutput, "❌ %v\n", setErr)
+				continue
+			}
+			fmt.Fprintf(promptOutput, "✅ Saved %s\n", fieldErr.Key)
+			repaired[fieldErr.Key] = true
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/config"
+)
+
+// stubTerminal replaces the interactivity check and stdin for one test
+func stubTerminal(t *testing.T, isInteractive bool, input string) *bytes.Buffer {
+	t.Helper()
+	origInput, origInteractive, origNotices, origPrompts := promptInput, interactive, notices, promptOutput
+	var out bytes.Buffer
+	promptInput = strings.NewReader(input)
+	interactive = func() bool { return isInteractive }
+	notices = &out
+	promptOutput = &out
+	t.Cleanup(func() {
+		promptInput, interactive, notices, promptOutput = origInput, origInteractive, origNotices, origPrompts
+	})
+	return &out
+}
+
+func isolateConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"OPENAI_API_KEY", "OPENAI_API_BASE", "OPENAI_MODEL", "SORTPATH_FOLDER_TREE", "SORTPATH_LOG_LEVEL", "SORTPATH_CONFIG", "SORTPATH_PROFILE", "XDG_CONFIG_HOME", "XDG_CACHE_HOME"} {
+		t.Setenv(name, "")
+	}
+	// Keep CI and container profiles from changing resolved values
+	t.Setenv("SORTPATH_ENVIRONMENT", "interactive")
+	return home
+}
+
+func TestResolveConfigWithRepair_Interactive(t *testing.T) {
+	home := isolateConfig(t)
+	// An empty answer aborts with the original error
+	stubTerminal(t, true, "\n")
+	if _, _, err := ResolveConfigWithRepair(config.CLIOptions{TreePath: home}); err == nil {
+		t.Fatal("expected an empty answer to abort the repair")
+	}
+
+	out := stubTerminal(t, true, "sk-repaired-1234567890\n")
+	conf, _, err := ResolveConfigWithRepair(config.CLIOptions{TreePath: home})
+	if err != nil {
+		t.Fatalf("ResolveConfigWithRepair() error = %v\n%s", err, out)
+	}
+	if conf.APIKey != "sk-repaired-1234567890" {
+		t.Errorf("APIKey = %q, want the repaired key", conf.APIKey)
+	}
+	if !strings.Contains(out.String(), "Enter api-key") || !strings.Contains(out.String(), "Saved api-key") {
+		t.Errorf("unexpected prompt output:\n%s", out)
+	}
+
+	// The repaired value was persisted for the next run
+	if got, _ := config.DefaultSecretStore.GetSecret(config.APIKeySecret); got != "sk-repaired-1234567890" {
+		t.Errorf("stored api-key = %q, want the repaired key", got)
+	}
+}
+
+func TestResolveConfigWithRepair_NonInteractive(t *testing.T) {
+	home := isolateConfig(t)
+	out := stubTerminal(t, false, "sk-never-read\n")
+
+	_, _, err := ResolveConfigWithRepair(config.CLIOptions{TreePath: home})
+	if err == nil || !strings.Contains(err.Error(), "API key is required") {
+		t.Fatalf("error = %v, want the missing API key error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("non-interactive run prompted:\n%s", out)
+	}
+}
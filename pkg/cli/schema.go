@@ -0,0 +1,79 @@
+package cli
+
+import (
+    "encoding/json"
+    "io"
+    "reflect"
+    "strings"
+)
+
+// ResultSchema returns a JSON Schema for one line of --json output. It is
+// generated from Result's json and doc tags, so the schema can't drift from
+// what WriteResult prints: fields without omitempty are required, and no
+// other properties are allowed.
+func ResultSchema() map[string]interface{} {
+    schema := structSchema(reflect.TypeOf(Result{}))
+    schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+    schema["title"] = "sortpath recommendation"
+    return schema
+}
+
+// WriteResultSchema prints ResultSchema as indented JSON
+func WriteResultSchema(w io.Writer) error {
+    data, err := json.MarshalIndent(ResultSchema(), "", "  ")
+    if err != nil {
+        return err
+    }
+    _, err = w.Write(append(data, '\n'))
+    return err
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+    properties := map[string]interface{}{}
+    required := []string{}
+    for i := 0; i < t.NumField(); i++ {
+        field := t.Field(i)
+        name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+        if name == "-" || !field.IsExported() {
+            continue
+        }
+        if name == "" {
+            name = field.Name
+        }
+        prop := typeSchema(field.Type)
+        if doc := field.Tag.Get("doc"); doc != "" {
+            prop["description"] = doc
+        }
+        properties[name] = prop
+        if !strings.Contains(opts, "omitempty") {
+            required = append(required, name)
+        }
+    }
+    return map[string]interface{}{
+        "type":                 "object",
+        "properties":           properties,
+        "required":             required,
+        "additionalProperties": false,
+    }
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+    switch t.Kind() {
+    case reflect.Ptr:
+        return typeSchema(t.Elem())
+    case reflect.String:
+        return map[string]interface{}{"type": "string"}
+    case reflect.Bool:
+        return map[string]interface{}{"type": "boolean"}
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return map[string]interface{}{"type": "integer"}
+    case reflect.Float32, reflect.Float64:
+        return map[string]interface{}{"type": "number"}
+    case reflect.Slice, reflect.Array:
+        return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+    case reflect.Struct:
+        return structSchema(t)
+    }
+    return map[string]interface{}{}
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/ui"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// validate checks value against the subset of JSON Schema ResultSchema uses
+func validate(schema map[string]interface{}, value interface{}, path string) []string {
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{path + ": not an object"}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required %q", path, name))
+			}
+		}
+		for name, v := range obj {
+			prop, ok := props[name].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					problems = append(problems, fmt.Sprintf("%s: unexpected property %q", path, name))
+				}
+				continue
+			}
+			problems = append(problems, validate(prop, v, path+"."+name)...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, path+": not a string")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{path + ": not an array"}
+		}
+		for i, item := range items {
+			problems = append(problems, validate(schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+// emittedSchema returns the schema as printed by --json-schema
+func emittedSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteResultSchema(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	return schema
+}
+
+func TestResultSchema_ValidatesJSONOutput(t *testing.T) {
+	schema := emittedSchema(t)
+	stdout, _ := useOutput(t, ui.Options{JSON: true})
+
+	WriteResult("invoice.pdf", &api.LLMResponse{Path: "/Work/Invoices", Reason: "an invoice"})
+	WriteResult("holiday.jpg", &api.LLMResponse{Root: "personal", Path: "/Photos", Reason: "a photo"})
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output = %q, want two JSON lines", stdout.String())
+	}
+	for _, line := range lines {
+		var value interface{}
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			t.Fatal(err)
+		}
+		if problems := validate(schema, value, "$"); len(problems) > 0 {
+			t.Errorf("%s does not match the schema: %v", line, problems)
+		}
+	}
+
+	// The validator itself must reject output that breaks the contract
+	bad := map[string]interface{}{"path": 7, "extra": true}
+	if problems := validate(schema, bad, "$"); len(problems) != 4 {
+		t.Errorf("validate(bad) = %v, want missing description and reason, wrong path type and an extra field", problems)
+	}
+}
+
+func TestResultSchema_FollowsStructTags(t *testing.T) {
+	schema := emittedSchema(t)
+	props := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"description", "root", "path", "reason"} {
+		prop, ok := props[name].(map[string]interface{})
+		if !ok || prop["type"] != "string" || prop["description"] == "" {
+			t.Errorf("property %q = %v", name, props[name])
+		}
+	}
+	required := fmt.Sprint(schema["required"])
+	if required != "[description path reason]" {
+		t.Errorf("required = %s; root is omitempty and must stay optional", required)
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/kacperkwapisz/sortpath/internal/config"
+    "github.com/kacperkwapisz/sortpath/internal/updater"
+)
+
+// showProgress reports whether the update download may draw a progress bar:
+// stderr must be a terminal and the run interactive. Tests replace it.
+var showProgress = func() bool {
+    fi, err := os.Stderr.Stat()
+    return err == nil && fi.Mode()&os.ModeCharDevice != 0 && !config.DefaultEnvironmentDetector.IsNonInteractive()
+}
+
+// channelTag is the FetchRelease sentinel for the newest release on an
+// update channel; anything but beta is stable
+func channelTag(channel string) string {
+    if channel == config.UpdateChannelBeta {
+        return updater.LatestPrerelease
+    }
+    return updater.LatestRelease
+}
+
+// LatestRelease looks up the newest release on the update channel
+func LatestRelease(channel string) (*updater.Release, error) {
+    return updater.FetchRelease(channelTag(channel))
+}
+
+// updateOptions builds the install options for `sortpath update`, drawing
+// download progress on diagnostics unless quiet or not on a terminal
+func updateOptions(verifySignature, quiet bool) updater.UpdateOptions {
+    opts := updater.UpdateOptions{VerifySignature: verifySignature}
+    if !quiet && showProgress() {
+        opts.Progress = out.Diagnostics()
+    }
+    return opts
+}
+
+// confirmUpdate decides whether a self-update may overwrite the install in
+// check. An unwritable install always fails. One that a package manager or
+// symlink appears to own needs force, or a "yes" on an interactive terminal.
+func confirmUpdate(check updater.InstallCheck, force bool) error {
+    if !check.Writable {
+        return fmt.Errorf("cannot update: %s", strings.Join(check.Warnings(), "; "))
+    }
+    if !check.NeedsConfirmation() || force {
+        return nil
+    }
+
+    for _, warning := range check.Warnings() {
+        fmt.Fprintf(promptOutput, "⚠️ %s\n", warning)
+    }
+    if !interactive() {
+        return errors.New("not overwriting an install another tool may manage; rerun with --force to update anyway")
+    }
+    fmt.Fprint(promptOutput, "Overwrite it anyway? [y/N]: ")
+    answer, _ := bufio.NewReader(promptInput).ReadString('\n')
+    answer = strings.TrimSpace(strings.ToLower(answer))
+    if answer == "y" || answer == "yes" {
+        return nil
+    }
+    return errors.New("update cancelled")
+}
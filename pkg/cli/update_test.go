@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/internal/updater"
+)
+
+func TestConfirmUpdate(t *testing.T) {
+	clean := updater.InstallCheck{Path: "/usr/local/bin/sortpath", Writable: true}
+	managed := updater.InstallCheck{Path: "/opt/homebrew/bin/sortpath", ManagedBy: "Homebrew", Writable: true}
+	readOnly := updater.InstallCheck{Path: "/usr/local/bin/sortpath"}
+
+	tests := []struct {
+		name        string
+		check       updater.InstallCheck
+		force       bool
+		interactive bool
+		input       string
+		wantErr     string
+	}{
+		{name: "clean install", check: clean},
+		{name: "managed with --force", check: managed, force: true},
+		{name: "managed, confirmed", check: managed, interactive: true, input: "y\n"},
+		{name: "managed, declined", check: managed, interactive: true, input: "\n", wantErr: "update cancelled"},
+		{name: "managed, non-interactive", check: managed, wantErr: "--force"},
+		{name: "not writable even with --force", check: readOnly, force: true, wantErr: "not writable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := stubTerminal(t, tt.interactive, tt.input)
+			err := confirmUpdate(tt.check, tt.force)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("confirmUpdate() = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("confirmUpdate() = %v, want error containing %q", err, tt.wantErr)
+			}
+			if tt.check.NeedsConfirmation() && !tt.force && !strings.Contains(out.String(), "managed by Homebrew") {
+				t.Errorf("warning not shown:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestUpdateOptions_Progress(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		quiet    bool
+		want     bool
+	}{
+		{name: "terminal", terminal: true, want: true},
+		{name: "--quiet", terminal: true, quiet: true, want: false},
+		{name: "not a terminal", terminal: false, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := showProgress
+			showProgress = func() bool { return tt.terminal }
+			t.Cleanup(func() { showProgress = orig })
+
+			opts := updateOptions(true, tt.quiet)
+			if got := opts.Progress != nil; got != tt.want {
+				t.Errorf("progress shown = %v, want %v", got, tt.want)
+			}
+			if !opts.VerifySignature {
+				t.Error("VerifySignature was dropped")
+			}
+		})
+	}
+}
+
+func TestChannelTag(t *testing.T) {
+	tests := map[string]string{
+		"":       updater.LatestRelease,
+		"stable": updater.LatestRelease,
+		"beta":   updater.LatestPrerelease,
+	}
+	for channel, want := range tests {
+		if got := channelTag(channel); got != want {
+			t.Errorf("channelTag(%q) = %q, want %q", channel, got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// Prices are the per-1,000-token prices used to estimate the cost of a query
+// (--price-input, --price-output); zero means unknown
+type Prices struct {
+    Input  float64
+    Output float64
+}
+
+// FormatUsage describes the tokens a query used. A cost estimate is added
+// when prices are set and the provider split prompt and completion tokens.
+// Providers that omit usage are reported as such rather than as zero.
+func FormatUsage(u api.Usage, p Prices) string {
+    if u.Total() == 0 {
+        return "not reported by the provider"
+    }
+    if u.PromptTokens+u.CompletionTokens == 0 {
+        return fmt.Sprintf("%d tokens", u.Total())
+    }
+    s := fmt.Sprintf("%d tokens (%d prompt, %d completion)", u.Total(), u.PromptTokens, u.CompletionTokens)
+    if p.Input > 0 || p.Output > 0 {
+        cost := float64(u.PromptTokens)/1000*p.Input + float64(u.CompletionTokens)/1000*p.Output
+        s += fmt.Sprintf(", about $%.4f", cost)
+    }
+    return s
+}
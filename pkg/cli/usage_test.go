@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+func TestFormatUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		usage  api.Usage
+		prices Prices
+		want   string
+	}{
+		{name: "omitted", usage: api.Usage{}, want: "not reported by the provider"},
+		{name: "total only", usage: api.Usage{TotalTokens: 900}, prices: Prices{Input: 1}, want: "900 tokens"},
+		{name: "split", usage: api.Usage{PromptTokens: 1200, CompletionTokens: 80, TotalTokens: 1280}, want: "1280 tokens (1200 prompt, 80 completion)"},
+		{name: "no total", usage: api.Usage{PromptTokens: 1200, CompletionTokens: 80}, want: "1280 tokens (1200 prompt, 80 completion)"},
+		{
+			name:   "with prices",
+			usage:  api.Usage{PromptTokens: 2000, CompletionTokens: 500, TotalTokens: 2500},
+			prices: Prices{Input: 0.0005, Output: 0.0015},
+			want:   "2500 tokens (2000 prompt, 500 completion), about $0.0018",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatUsage(tt.usage, tt.prices); got != tt.want {
+				t.Errorf("FormatUsage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
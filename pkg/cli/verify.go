@@ -0,0 +1,52 @@
+package cli
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+    "github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+// UnverifiedMark follows a recommended path that isn't in the tree
+const UnverifiedMark = "[unverified]"
+
+// VerifyPath checks a recommended folder against the tree at root. A path
+// that leaves the tree is a ValidationError. verified reports that the
+// folder exists, or would be a new folder directly inside an existing one;
+// anything deeper was most likely made up by the model.
+func VerifyPath(root, recommended string) (verified bool, err error) {
+    if strings.Trim(strings.TrimSpace(recommended), `/\`) == "" {
+        // The top of the tree itself
+        return true, nil
+    }
+    dir, err := DestinationDir(root, recommended)
+    if err != nil {
+        return false, apperrors.ValidationError(fmt.Sprintf("recommended path %s is not inside the tree: %v", recommended, err), "path")
+    }
+    if info, err := os.Stat(dir); err == nil {
+        return info.IsDir(), nil
+    }
+    info, err := os.Stat(filepath.Dir(dir))
+    return err == nil && info.IsDir(), nil
+}
+
+// VerifyResponse checks resp.Path against the tree at root, marking it
+// Unverified when it isn't there. With strict, an unverified path is a
+// ValidationError too.
+func VerifyResponse(root string, resp *api.LLMResponse, strict bool) error {
+    verified, err := VerifyPath(root, resp.Path)
+    if err != nil {
+        return err
+    }
+    resp.Unverified = !verified
+    if !verified {
+        logger.Debug("recommended path %s is not in the tree at %s", resp.Path, root)
+        if strict {
+            return apperrors.ValidationError(fmt.Sprintf("recommended path %s is not in the tree (--strict)", resp.Path), "path")
+        }
+    }
+    return nil
+}
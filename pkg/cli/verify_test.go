@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apperrors "github.com/kacperkwapisz/sortpath/internal/errors"
+	"github.com/kacperkwapisz/sortpath/internal/ui"
+	"github.com/kacperkwapisz/sortpath/pkg/api"
+)
+
+func TestVerifyPath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "Docs", "Tax"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Docs", "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		verified bool
+		wantErr  bool
+	}{
+		{name: "existing folder", path: "/Docs/Tax", verified: true},
+		{name: "without leading slash", path: "Docs/Tax/", verified: true},
+		{name: "new folder in an existing one", path: "/Docs/Tax/2026", verified: true},
+		{name: "tree root", path: "/", verified: true},
+		{name: "hallucinated path", path: "/Finance/Invoices/2026", verified: false},
+		{name: "file instead of folder", path: "/Docs/notes.txt", verified: false},
+		{name: "traversal", path: "/Docs/../../etc", wantErr: true},
+		{name: "parent of the tree", path: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verified, err := VerifyPath(root, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if tt.wantErr && !apperrors.IsType(err, "VALIDATION_ERROR") {
+				t.Errorf("VerifyPath(%q) error = %v, want a VALIDATION_ERROR", tt.path, err)
+			}
+			if verified != tt.verified {
+				t.Errorf("VerifyPath(%q) = %v, want %v", tt.path, verified, tt.verified)
+			}
+		})
+	}
+}
+
+func TestVerifyResponse(t *testing.T) {
+	root := t.TempDir()
+
+	resp := &api.LLMResponse{Path: "/Made/Up/Folder"}
+	if err := VerifyResponse(root, resp, false); err != nil || !resp.Unverified {
+		t.Errorf("VerifyResponse() = %v, Unverified %v; want nil, true", err, resp.Unverified)
+	}
+	if err := VerifyResponse(root, resp, true); !apperrors.IsType(err, "VALIDATION_ERROR") {
+		t.Errorf("strict VerifyResponse() = %v, want a VALIDATION_ERROR", err)
+	}
+
+	stdout, _ := useOutput(t, ui.Options{})
+	WriteResult("scan", &api.LLMResponse{Path: "/Made/Up", Reason: "r", Unverified: true})
+	if !strings.HasPrefix(stdout.String(), "/Made/Up [unverified]\n") {
+		t.Errorf("output = %q, want the path marked unverified", stdout.String())
+	}
+}
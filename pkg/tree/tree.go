@@ -0,0 +1,65 @@
+// Package tree walks folder trees the way sortpath does, for Go programs
+// that embed it.
+package tree
+
+import (
+	treefs "github.com/kacperkwapisz/sortpath/internal/fs"
+)
+
+// Node is one entry of a tree returned by JSON: its name, whether it is a
+// directory and, for directories, its children. It is the schema sortpath
+// uses for every JSON tree, --explain-tree --json included.
+type Node = treefs.JSONNode
+
+// Option configures how a tree is walked.
+type Option = treefs.TreeOption
+
+// JSON walks dirPath and returns it, root included, as compact JSON made of
+// Node values. Directories come first, then files, each alphabetically, and
+// an empty directory has an empty children array.
+func JSON(dirPath string, opts ...Option) ([]byte, error) {
+	return treefs.TreeJSON(dirPath, opts...)
+}
+
+// Text walks dirPath and returns the indented tree sortpath sends to the
+// model. It comes from the same traversal as JSON.
+func Text(dirPath string, opts ...Option) (string, error) {
+	return treefs.Tree(dirPath, opts...)
+}
+
+// WithMaxDepth limits how deep the tree is walked; 0 lists only the
+// top-level entries.
+func WithMaxDepth(n int) Option {
+	return treefs.WithMaxDepth(n)
+}
+
+// WithMaxEntries limits how many entries are listed per directory.
+func WithMaxEntries(n int) Option {
+	return treefs.WithMaxEntries(n)
+}
+
+// WithCounts adds how many files each directory holds.
+func WithCounts() Option {
+	return treefs.WithCounts()
+}
+
+// WithDirsOnly lists directories only.
+func WithDirsOnly() Option {
+	return treefs.WithDirsOnly()
+}
+
+// WithFollowSymlinks descends into symlinked directories.
+func WithFollowSymlinks() Option {
+	return treefs.WithFollowSymlinks()
+}
+
+// WithIgnoreFile reads ignore patterns from name instead of .sortpathignore.
+func WithIgnoreFile(name string) Option {
+	return treefs.WithIgnoreFile(name)
+}
+
+// WithSkipDirs replaces the built-in list of skipped directories
+// (node_modules, .git, ...). With no names nothing is skipped.
+func WithSkipDirs(names ...string) Option {
+	return treefs.WithSkipDirs(names...)
+}
@@ -0,0 +1,42 @@
+package tree
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"Photos/2025", "Empty"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := JSON(root, WithMaxDepth(1))
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var got Node
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("JSON() is not valid JSON: %v\n%s", err, data)
+	}
+	if len(got.Children) != 3 {
+		t.Fatalf("root children = %+v, want Empty, Photos and notes.txt", got.Children)
+	}
+	empty, photos, notes := got.Children[0], got.Children[1], got.Children[2]
+	if empty.Name != "Empty" || !empty.IsDir || len(empty.Children) != 0 {
+		t.Errorf("first child = %+v, want the empty directory", empty)
+	}
+	if photos.Name != "Photos" || len(photos.Children) != 1 || photos.Children[0].Name != "2025" {
+		t.Errorf("second child = %+v, want Photos holding 2025", photos)
+	}
+	if notes.Name != "notes.txt" || notes.IsDir {
+		t.Errorf("last child = %+v, want the file", notes)
+	}
+}
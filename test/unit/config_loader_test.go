@@ -3,6 +3,7 @@ package unit
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/kacperkwapisz/sortpath/internal/config"
@@ -22,7 +23,7 @@ func TestFileLoader_LoadNonExistentFile(t *testing.T) {
 
 	// Should return empty config
 	expected := &config.Config{}
-	if *cfg != *expected {
+	if !reflect.DeepEqual(cfg, expected) {
 		t.Errorf("Expected empty config, got: %+v", cfg)
 	}
 }
@@ -59,7 +60,7 @@ log_level: debug
 		LogLevel: "debug",
 	}
 
-	if *cfg != *expected {
+	if !reflect.DeepEqual(cfg, expected) {
 		t.Errorf("Expected config %+v, got %+v", expected, cfg)
 	}
 }
@@ -133,7 +134,7 @@ func TestFileLoader_Save(t *testing.T) {
 		t.Errorf("Failed to load saved config: %v", err)
 	}
 
-	if *loadedConfig != *cfg {
+	if !reflect.DeepEqual(loadedConfig, cfg) {
 		t.Errorf("Loaded config %+v doesn't match saved config %+v", loadedConfig, cfg)
 	}
 }
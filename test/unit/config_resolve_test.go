@@ -116,6 +116,7 @@ log_level: info
 
 func TestResolveConfig_Defaults(t *testing.T) {
 	// Test that defaults are used when no other values are provided
+	t.Setenv("SORTPATH_ENVIRONMENT", "interactive") // no CI/container profile
 	opts := config.CLIOptions{
 		APIKey: "required-key", // API key is required, so provide it
 	}